@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+// 可验证凭证锚定合约：平台登记员按批次写入凭证颁发/撤销记录的Merkle根，
+// 任何人都可以用包含证明在链上核对某条记录是否属于已锚定的批次。
+// Merkle树采用有序对哈希：parent = keccak256(min(a,b) ++ max(a,b))。
+contract CredentialAnchor {
+    // 平台登记员
+    address public registrar;
+    // 已锚定的批次数量，批次ID从1开始
+    uint256 public batchCount;
+    // Merkle根 => 锚定时间
+    mapping(bytes32 => uint256) public anchoredAt;
+    // 批次ID => Merkle根
+    mapping(uint256 => bytes32) public batchRoots;
+
+    event RootAnchored(uint256 indexed batchId, bytes32 indexed root, uint256 leafCount);
+
+    event RegistrarChanged(address registrar);
+
+    constructor() {
+        registrar = msg.sender;
+    }
+
+    function setRegistrar(address newRegistrar) external {
+        require(msg.sender == registrar, "not_registrar");
+        registrar = newRegistrar;
+        emit RegistrarChanged(newRegistrar);
+    }
+
+    function anchorRoot(bytes32 root, uint256 leafCount) external returns (uint256 batchId) {
+        require(msg.sender == registrar, "not_registrar");
+        require(root != bytes32(0), "empty_root");
+        require(anchoredAt[root] == 0, "already_anchored");
+
+        batchCount++;
+        batchId = batchCount;
+        anchoredAt[root] = block.timestamp;
+        batchRoots[batchId] = root;
+        emit RootAnchored(batchId, root, leafCount);
+    }
+
+    function verifyInclusion(bytes32[] calldata proof, bytes32 root, bytes32 leaf) external view returns (bool) {
+        if (anchoredAt[root] == 0) {
+            return false;
+        }
+        bytes32 computed = leaf;
+        for (uint256 i = 0; i < proof.length; i++) {
+            bytes32 sibling = proof[i];
+            if (computed < sibling) {
+                computed = keccak256(abi.encodePacked(computed, sibling));
+            } else {
+                computed = keccak256(abi.encodePacked(sibling, computed));
+            }
+        }
+        return computed == root;
+    }
+}
@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+// ERC-1056风格的DID注册表，事件与did:ethr解析器兼容。
+// 平台登记员（部署者）可代未声明自主管理的身份写入变更，身份所有者调用setSelfManaged后只能由所有者本人变更。
+contract EthereumDIDRegistry {
+    mapping(address => address) public owners;
+    mapping(address => mapping(bytes32 => mapping(address => uint256))) public delegates;
+    mapping(address => uint256) public changed;
+    mapping(address => uint256) public nonce;
+
+    // 平台登记员
+    address public registrar;
+    // 已声明自主管理、登记员不能再代为变更的身份
+    mapping(address => bool) public selfManaged;
+
+    event DIDOwnerChanged(address indexed identity, address owner, uint256 previousChange);
+
+    event DIDDelegateChanged(
+        address indexed identity,
+        bytes32 delegateType,
+        address delegate,
+        uint256 validTo,
+        uint256 previousChange
+    );
+
+    event DIDAttributeChanged(
+        address indexed identity,
+        bytes32 name,
+        bytes value,
+        uint256 validTo,
+        uint256 previousChange
+    );
+
+    event SelfManagementChanged(address indexed identity, bool selfManaged);
+
+    event RegistrarChanged(address registrar);
+
+    modifier onlyOwner(address identity, address actor) {
+        require(
+            actor == identityOwner(identity) || (actor == registrar && !selfManaged[identity]),
+            "bad_actor"
+        );
+        _;
+    }
+
+    constructor() {
+        registrar = msg.sender;
+    }
+
+    function identityOwner(address identity) public view returns (address) {
+        address owner = owners[identity];
+        if (owner != address(0)) {
+            return owner;
+        }
+        return identity;
+    }
+
+    function validDelegate(address identity, bytes32 delegateType, address delegate) public view returns (bool) {
+        uint256 validity = delegates[identity][keccak256(abi.encode(delegateType))][delegate];
+        return validity > block.timestamp;
+    }
+
+    function setRegistrar(address newRegistrar) external {
+        require(msg.sender == registrar, "not_registrar");
+        registrar = newRegistrar;
+        emit RegistrarChanged(newRegistrar);
+    }
+
+    // 身份所有者声明是否自主管理（自主管理后登记员不能代为变更）
+    function setSelfManaged(address identity, bool value) external {
+        require(msg.sender == identityOwner(identity), "bad_actor");
+        selfManaged[identity] = value;
+        emit SelfManagementChanged(identity, value);
+    }
+
+    function checkSignature(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 hash) internal returns (address) {
+        address signer = ecrecover(hash, sigV, sigR, sigS);
+        require(signer == identityOwner(identity), "bad_signature");
+        nonce[signer]++;
+        return signer;
+    }
+
+    function changeOwner(address identity, address actor, address newOwner) internal onlyOwner(identity, actor) {
+        owners[identity] = newOwner;
+        emit DIDOwnerChanged(identity, newOwner, changed[identity]);
+        changed[identity] = block.number;
+    }
+
+    function changeOwner(address identity, address newOwner) public {
+        changeOwner(identity, msg.sender, newOwner);
+    }
+
+    function changeOwnerSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, address newOwner) public {
+        bytes32 hash = keccak256(
+            abi.encodePacked(bytes1(0x19), bytes1(0), this, nonce[identityOwner(identity)], identity, "changeOwner", newOwner)
+        );
+        changeOwner(identity, checkSignature(identity, sigV, sigR, sigS, hash), newOwner);
+    }
+
+    function addDelegate(address identity, address actor, bytes32 delegateType, address delegate, uint256 validity) internal onlyOwner(identity, actor) {
+        delegates[identity][keccak256(abi.encode(delegateType))][delegate] = block.timestamp + validity;
+        emit DIDDelegateChanged(identity, delegateType, delegate, block.timestamp + validity, changed[identity]);
+        changed[identity] = block.number;
+    }
+
+    function addDelegate(address identity, bytes32 delegateType, address delegate, uint256 validity) public {
+        addDelegate(identity, msg.sender, delegateType, delegate, validity);
+    }
+
+    function addDelegateSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 delegateType, address delegate, uint256 validity) public {
+        bytes32 hash = keccak256(
+            abi.encodePacked(bytes1(0x19), bytes1(0), this, nonce[identityOwner(identity)], identity, "addDelegate", delegateType, delegate, validity)
+        );
+        addDelegate(identity, checkSignature(identity, sigV, sigR, sigS, hash), delegateType, delegate, validity);
+    }
+
+    function revokeDelegate(address identity, address actor, bytes32 delegateType, address delegate) internal onlyOwner(identity, actor) {
+        delegates[identity][keccak256(abi.encode(delegateType))][delegate] = block.timestamp;
+        emit DIDDelegateChanged(identity, delegateType, delegate, block.timestamp, changed[identity]);
+        changed[identity] = block.number;
+    }
+
+    function revokeDelegate(address identity, bytes32 delegateType, address delegate) public {
+        revokeDelegate(identity, msg.sender, delegateType, delegate);
+    }
+
+    function revokeDelegateSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 delegateType, address delegate) public {
+        bytes32 hash = keccak256(
+            abi.encodePacked(bytes1(0x19), bytes1(0), this, nonce[identityOwner(identity)], identity, "revokeDelegate", delegateType, delegate)
+        );
+        revokeDelegate(identity, checkSignature(identity, sigV, sigR, sigS, hash), delegateType, delegate);
+    }
+
+    function setAttribute(address identity, address actor, bytes32 name, bytes memory value, uint256 validity) internal onlyOwner(identity, actor) {
+        emit DIDAttributeChanged(identity, name, value, block.timestamp + validity, changed[identity]);
+        changed[identity] = block.number;
+    }
+
+    function setAttribute(address identity, bytes32 name, bytes memory value, uint256 validity) public {
+        setAttribute(identity, msg.sender, name, value, validity);
+    }
+
+    function setAttributeSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 name, bytes memory value, uint256 validity) public {
+        bytes32 hash = keccak256(
+            abi.encodePacked(bytes1(0x19), bytes1(0), this, nonce[identityOwner(identity)], identity, "setAttribute", name, value, validity)
+        );
+        setAttribute(identity, checkSignature(identity, sigV, sigR, sigS, hash), name, value, validity);
+    }
+
+    function revokeAttribute(address identity, address actor, bytes32 name, bytes memory value) internal onlyOwner(identity, actor) {
+        emit DIDAttributeChanged(identity, name, value, 0, changed[identity]);
+        changed[identity] = block.number;
+    }
+
+    function revokeAttribute(address identity, bytes32 name, bytes memory value) public {
+        revokeAttribute(identity, msg.sender, name, value);
+    }
+
+    function revokeAttributeSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 name, bytes memory value) public {
+        bytes32 hash = keccak256(
+            abi.encodePacked(bytes1(0x19), bytes1(0), this, nonce[identityOwner(identity)], identity, "revokeAttribute", name, value)
+        );
+        revokeAttribute(identity, checkSignature(identity, sigV, sigR, sigS, hash), name, value);
+    }
+}
@@ -6,9 +6,18 @@ import "@openzeppelin/contracts/token/ERC721/extensions/ERC721Enumerable.sol";
 import "@openzeppelin/contracts/token/ERC721/extensions/ERC721URIStorage.sol";
 import "@openzeppelin/contracts/access/Ownable.sol";
 import "@openzeppelin/contracts/utils/Strings.sol";
+import "@openzeppelin/contracts/utils/Nonces.sol";
+import "@openzeppelin/contracts/utils/cryptography/EIP712.sol";
+import "@openzeppelin/contracts/utils/cryptography/ECDSA.sol";
 //ipfs://
-contract MainNFT is ERC721, ERC721Enumerable, ERC721URIStorage, Ownable {
+contract MainNFT is ERC721, ERC721Enumerable, ERC721URIStorage, Ownable, EIP712, Nonces {
     uint256 public MAX_AMOUNT = 10000;
+
+    // EIP-712类型：用户签名后由平台代为提交，合约按签名确认操作者
+    bytes32 public constant MINT_REQUEST_TYPEHASH =
+        keccak256("MintRequest(address to,string uri,uint256 nonce,uint256 deadline)");
+    bytes32 public constant CREATE_CHILD_REQUEST_TYPEHASH =
+        keccak256("CreateChildRequest(address owner,uint256 parentTokenId,address receiver,string uri,uint256 nonce,uint256 deadline)");
  
     uint256 private _nextTokenId;
     string private _baseTokenURI;
@@ -18,15 +27,30 @@ contract MainNFT is ERC721, ERC721Enumerable, ERC721URIStorage, Ownable {
     
     event ChildNFTCreated(uint256 indexed tokenId, address indexed receiver);
 
-    constructor() ERC721("MainABE", "MABE") Ownable(msg.sender) {}
+    constructor() ERC721("MainABE", "MABE") Ownable(msg.sender) EIP712("MainABE", "1") {}
     
     // 设置子NFT合约地址
     function setChildNFTContract(address _childNFTContract) external onlyOwner {
         childNFTContract = _childNFTContract;
     }
 
-    // 铸造主NFT - 接受URI参数
-    function mintTo(address to,string memory uri) public payable {
+    // 铸造主NFT - 接受URI参数，只有合约所有者（平台）可以直接指定接收者
+    function mintTo(address to,string memory uri) public payable onlyOwner {
+        _mintWithURI(to, uri);
+    }
+
+    // 按用户签名的MintRequest铸造主NFT，任何人都可以代为提交
+    function mintWithSignature(address to, string calldata uri, uint256 deadline, bytes calldata signature) external {
+        require(block.timestamp <= deadline, "Signature expired");
+        bytes32 structHash = keccak256(
+            abi.encode(MINT_REQUEST_TYPEHASH, to, keccak256(bytes(uri)), _useNonce(to), deadline)
+        );
+        require(ECDSA.recover(_hashTypedDataV4(structHash), signature) == to, "Invalid signature");
+
+        _mintWithURI(to, uri);
+    }
+
+    function _mintWithURI(address to, string memory uri) internal {
         require(totalSupply() < MAX_AMOUNT, "NFT is sold out!");
         
         uint256 tokenId = _nextTokenId;
@@ -69,7 +93,7 @@ contract MainNFT is ERC721, ERC721Enumerable, ERC721URIStorage, Ownable {
     }
     
     // 使用HTTP网关URL铸造NFT
-    function mintWithHttpGateway(address to,string memory ipfsURI) public payable {
+    function mintWithHttpGateway(address to,string memory ipfsURI) public payable onlyOwner {
         require(totalSupply() < MAX_AMOUNT, "NFT is sold out!");
         
         uint256 tokenId = _nextTokenId;
@@ -84,10 +108,52 @@ contract MainNFT is ERC721, ERC721Enumerable, ERC721URIStorage, Ownable {
         // 使用HTTP网关URL
         _setTokenURI(tokenId, httpGatewayURI);
     }
-    // 通过主NFT为指定地址铸造子NFT并设置自定义URI
-    function createChildNFTWithURI(address to,uint256 tokenId, address receiver, string memory uri) public {
+    // 平台按发行规则自动批准申请时创建子NFT。只有合约所有者（平台）可以调用，creator固定为父NFT的当前持有者，
+    // 调用方不能指定代表谁操作。这是平台密钥特意保留的唯一例外：持有者审批的申请和持有者自己发起的创建都使用createChildWithSignature
+    function createAutoApprovedChild(uint256 tokenId, address receiver, string memory uri) public onlyOwner {
         require(_exists(tokenId), "Token does not exist");
-        require(ownerOf(tokenId) == to, "You must own the token");
+
+        _createChild(tokenId, receiver, ownerOf(tokenId), uri);
+    }
+
+    // 按父NFT持有者签名的CreateChildRequest铸造子NFT，任何人都可以代为提交
+    function createChildWithSignature(
+        address parentOwner,
+        uint256 parentTokenId,
+        address receiver,
+        string calldata uri,
+        uint256 deadline,
+        bytes calldata signature
+    ) external {
+        require(block.timestamp <= deadline, "Signature expired");
+        require(_exists(parentTokenId), "Token does not exist");
+        require(ownerOf(parentTokenId) == parentOwner, "You must own the token");
+        bytes32 digest = _hashTypedDataV4(_createChildStructHash(parentOwner, parentTokenId, receiver, uri, deadline));
+        require(ECDSA.recover(digest, signature) == parentOwner, "Invalid signature");
+
+        _createChild(parentTokenId, receiver, parentOwner, uri);
+    }
+
+    function _createChildStructHash(
+        address parentOwner,
+        uint256 parentTokenId,
+        address receiver,
+        string calldata uri,
+        uint256 deadline
+    ) internal returns (bytes32) {
+        return keccak256(abi.encode(
+            CREATE_CHILD_REQUEST_TYPEHASH,
+            parentOwner,
+            parentTokenId,
+            receiver,
+            keccak256(bytes(uri)),
+            _useNonce(parentOwner),
+            deadline
+        ));
+    }
+
+    // 调用子合约铸造子NFT，creator记录为父NFT持有者
+    function _createChild(uint256 tokenId, address receiver, address creator, string memory uri) internal {
         require(childNFTContract != address(0), "Child NFT contract not set");
         
         // 调用子合约的带URI的铸造函数
@@ -96,7 +162,7 @@ contract MainNFT is ERC721, ERC721Enumerable, ERC721URIStorage, Ownable {
                 "mintChildNFTWithURI(uint256,address,address,string)", 
                 tokenId, 
                 receiver, 
-                msg.sender,
+                creator,
                 uri
             )
         );
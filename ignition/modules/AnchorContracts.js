@@ -0,0 +1,11 @@
+const { buildModule } = require("@nomicfoundation/hardhat-ignition/modules");
+
+module.exports = buildModule("AnchorContractsModule", (m) => {
+    // 部署ERC-1056风格的DID注册表，部署账户成为登记员
+    const didRegistry = m.contract("EthereumDIDRegistry", []);
+
+    // 部署凭证Merkle根锚定合约
+    const credentialAnchor = m.contract("CredentialAnchor", []);
+
+    return { didRegistry, credentialAnchor };
+});
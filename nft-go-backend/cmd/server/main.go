@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
 	"path/filepath"
 	"runtime"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 
@@ -22,14 +24,20 @@ func main() {
 		log.Fatalf("加载配置失败: %v", err)
 	}
 
-	// 初始化数据库（包含ABE表的迁移）
-	err = models.InitDB(cfg.GetDSN())
+	// 初始化数据库（包含ABE表的迁移），升级前的记录归入默认部署
+	deployment := cfg.DefaultDeployment()
+	err = models.InitDB(cfg.GetDSN(), models.LegacyDeployment{
+		Name:          deployment.Name,
+		ChainID:       deployment.ChainID,
+		MainContract:  common.HexToAddress(deployment.MainNFTAddress).Hex(),
+		ChildContract: common.HexToAddress(deployment.ChildNFTAddress).Hex(),
+	})
 	if err != nil {
 		log.Fatalf("初始化数据库失败: %v", err)
 	}
 
-	// 初始化区块链客户端
-	client, err := blockchain.NewEthClient(cfg)
+	// 为每个部署初始化区块链客户端
+	registry, err := blockchain.NewRegistry(cfg)
 	if err != nil {
 		log.Fatalf("初始化以太坊客户端失败: %v", err)
 	}
@@ -66,19 +74,45 @@ func main() {
 	r.LoadHTMLGlob(templatesPath)
 
 	// 初始化API处理程序（现在包含ABE功能）
-	router := api.NewRouter(client)
+	router := api.NewRouter(registry)
 
 	// 设置路由
 	router.SetupRoutes(r)
 
-	// 启动事件监听（非阻塞）
-	go client.ListenToEvents()
+	// 跟踪平台账户在每条链上的待打包交易，超时加价重发并记录最终状态
+	for _, txManager := range registry.TxManagers {
+		go txManager.Run(context.Background(), time.Duration(cfg.TxPollInterval)*time.Second)
+	}
+
+	for _, collection := range router.Collections {
+		// 确认账本中待打包的交易，并写入NFT记录和申请状态
+		go collection.TxLedgerService.Run(context.Background(), time.Duration(cfg.TxPollInterval)*time.Second)
+
+		// 同步合约事件到数据库（非阻塞），订阅断开时自动重连
+		if collection.IndexerSupervisor != nil {
+			go collection.IndexerSupervisor.Run(context.Background())
+		}
+
+		// 定期将超过有效期的待审批子NFT申请标记为过期
+		go collection.ChildRequestService.RunExpiry(context.Background(), time.Duration(cfg.ChildRequestExpiryInterval)*time.Second)
+		// 继续确认批准时未及时上链的子NFT创建交易
+		go collection.ChildMintService.Run(context.Background(), time.Duration(cfg.ChildMintReceiptTimeout)*time.Second)
+	}
+
+	// 启用链上锚定时定期批量锚定凭证记录，并按提交顺序把DID变更写入注册表
+	if router.AnchorService != nil {
+		go router.AnchorService.Run(context.Background(), time.Duration(cfg.AnchorBatchInterval)*time.Second)
+		go router.AnchorService.RunDIDOperations(context.Background())
+	}
+
+	// 投递子NFT申请事件Webhook并按退避策略重试
+	go router.WebhookService.Run(context.Background(), time.Duration(cfg.WebhookPollInterval)*time.Second)
 
 	// 启动服务器
 	port := ":" + cfg.Port
 	log.Printf("NFT+ABE+DID/VC集成服务器启动在 %s 端口", port)
 	log.Println("可用的API端点:")
-	log.Println("  - NFT相关: /api/nft/*")
+	log.Println("  - NFT相关: /api/nft/*（?collection=或?chainId=选择部署，见/api/collections）")
 	log.Println("  - ABE相关: /api/abe/*")
 	log.Println("  - DID相关: /api/did/*")
 	log.Println("  - VC相关: /api/vc/*")
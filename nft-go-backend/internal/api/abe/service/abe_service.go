@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"log"
 	"math/big"
 	"strings"
 	"time"
@@ -12,7 +13,9 @@ import (
 	"github.com/fentec-project/gofe/abe"
 	"gorm.io/gorm"
 
+	did_vc "github.com/ABE/nft/nft-go-backend/internal/api/did_vc/service"
 	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
 )
 
 // ABEService ABE服务结构体
@@ -857,17 +860,45 @@ func (s *ABEService) GenerateUserKeyAuto(userAttributes []string) (*models.ABEUs
 	return s.KeyGenABE(systemKey.ID, 1, userAttributes) // 使用默认用户ID 1
 }
 
-// extractAttributesFromMap 从map中提取VC属性的辅助函数
-func extractAttributesFromMap(data map[string]interface{}, vcAttributes map[string]string) {
-	// 支持的属性列表
-	supportedAttributes := []string{
-		"wallet", "name", "department", "hospital", "title",
-		"licenseNumber", "specialty", "did", "specialties",
+// platformAttributes 由平台注入、不属于任何凭证模式的属性
+var platformAttributes = []string{"wallet", "did"}
+
+// policyAttributeNames 返回策略可引用的属性名：平台属性加上所有已注册凭证模式中定义的属性
+func (s *ABEService) policyAttributeNames() []string {
+	names := append([]string{}, platformAttributes...)
+	seen := make(map[string]bool)
+	for _, name := range names {
+		seen[name] = true
+	}
+
+	var schemas []models.CredentialSchema
+	if err := s.DB.Find(&schemas).Error; err != nil {
+		log.Printf("查询凭证模式失败: %v", err)
+		return names
+	}
+	for _, schema := range schemas {
+		parsed, err := util.ParseJSONSchema(schema.SchemaJSON)
+		if err != nil {
+			continue
+		}
+		for _, name := range parsed.AttributeNames() {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
 	}
+	return names
+}
 
-	for _, attr := range supportedAttributes {
-		if value, ok := data[attr].(string); ok {
+// extractAttributesFromMap 从map中提取VC属性的辅助函数
+func extractAttributesFromMap(data map[string]interface{}, attributeNames []string, vcAttributes map[string]string) {
+	for _, attr := range attributeNames {
+		switch value := data[attr].(type) {
+		case string:
 			vcAttributes[attr] = value
+		case float64, bool:
+			vcAttributes[attr] = fmt.Sprint(value)
 		}
 	}
 }
@@ -884,15 +915,16 @@ func (s *ABEService) VerifyVCAgainstPolicy(vcContent string, policy string) (boo
 
 	// 提取VC中的属性
 	vcAttributes := make(map[string]string)
+	attributeNames := s.policyAttributeNames()
 
 	// 方法1：尝试从credentialSubject中提取（标准VC格式）
 	if credentialSubject, ok := vcData["credentialSubject"].(map[string]interface{}); ok {
 		fmt.Printf("使用标准VC格式提取属性\n")
-		extractAttributesFromMap(credentialSubject, vcAttributes)
+		extractAttributesFromMap(credentialSubject, attributeNames, vcAttributes)
 	} else {
 		// 方法2：直接从根级别提取属性（当前VC格式）
 		fmt.Printf("使用直接格式提取属性\n")
-		extractAttributesFromMap(vcData, vcAttributes)
+		extractAttributesFromMap(vcData, attributeNames, vcAttributes)
 	}
 
 	fmt.Printf("提取的VC属性: %+v\n", vcAttributes)
@@ -1068,23 +1100,9 @@ func (s *ABEService) buildDetailedFailureReason(failedConditions []map[string]in
 	return strings.Join(reasons, "；")
 }
 
-// getAttributeNameCN 获取属性的中文名称
+// getAttributeNameCN 获取属性的中文名称（来自角色模板）
 func (s *ABEService) getAttributeNameCN(attribute string) string {
-	attributeNames := map[string]string{
-		"department":    "科室",
-		"hospital":      "医院",
-		"title":         "职称",
-		"specialty":     "专长",
-		"name":          "姓名",
-		"licenseNumber": "执业编号",
-		"wallet":        "钱包地址",
-		"did":           "DID",
-	}
-
-	if cnName, exists := attributeNames[attribute]; exists {
-		return cnName
-	}
-	return attribute
+	return did_vc.AttributeLabel(attribute)
 }
 
 // splitPolicy 安全地分割策略字符串，考虑括号嵌套
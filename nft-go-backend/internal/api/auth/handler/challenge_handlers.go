@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ABE/nft/nft-go-backend/internal/api/auth/service"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// ChallengeHandlers 操作挑战处理程序
+type ChallengeHandlers struct {
+	Service *service.ChallengeService
+}
+
+// NewChallengeHandlers 创建新的操作挑战处理程序
+func NewChallengeHandlers(challengeService *service.ChallengeService) *ChallengeHandlers {
+	return &ChallengeHandlers{
+		Service: challengeService,
+	}
+}
+
+// CreateChallengeHandler 为签名授权的写操作下发一次性挑战
+func (h *ChallengeHandlers) CreateChallengeHandler(c *gin.Context) {
+	var req models.ActionChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	challenge, err := h.Service.Issue(req.Address, req.Action, req.Params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "申请操作挑战失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, challenge)
+}
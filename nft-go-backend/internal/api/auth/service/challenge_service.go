@@ -0,0 +1,201 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+)
+
+// ErrActionUnauthorized 操作签名无效或签名者无权执行该操作
+var ErrActionUnauthorized = errors.New("操作未授权")
+
+// 操作名称和参数名的格式
+var (
+	actionNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]{0,63}$`)
+	paramNamePattern  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]{0,63}$`)
+)
+
+// ChallengeService 下发和消费签名授权写操作的一次性挑战。
+// 待签名消息由操作名称、按名称排序的参数、nonce和过期时间组成，提交操作时服务端按请求内容重新构造消息后验证签名，
+// 签名只对该操作和这组参数有效，nonce只能使用一次
+type ChallengeService struct {
+	DB             *gorm.DB
+	TTL            time.Duration
+	AdminAddresses []string // 平台管理员钱包地址
+}
+
+// NewChallengeService 创建新的操作挑战服务
+func NewChallengeService(db *gorm.DB, ttl time.Duration, adminAddresses []string) *ChallengeService {
+	return &ChallengeService{
+		DB:             db,
+		TTL:            ttl,
+		AdminAddresses: adminAddresses,
+	}
+}
+
+// BuildActionMessage 构造操作挑战需要签名的消息
+func BuildActionMessage(action string, params map[string]string, nonce string, expiresAt int64) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(action)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n%s: %s", name, params[name])
+	}
+	fmt.Fprintf(&b, "\nnonce: %s\nexpiresAt: %d", nonce, expiresAt)
+	return b.String()
+}
+
+// HashParam 返回较长或可能含换行的操作内容的SHA-256十六进制哈希，用作签名消息中的参数
+func HashParam(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkActionParams 检查操作名称和参数，参数值中的换行会让消息产生歧义
+func checkActionParams(action string, params map[string]string) error {
+	if !actionNamePattern.MatchString(action) {
+		return fmt.Errorf("无效的操作名称: %s", action)
+	}
+	for name, value := range params {
+		if !paramNamePattern.MatchString(name) || name == "nonce" || name == "expiresAt" {
+			return fmt.Errorf("无效的参数名: %s", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("参数 %s 不能包含换行", name)
+		}
+	}
+	return nil
+}
+
+// Issue 为钱包下发某个操作的挑战，返回需要签名的消息
+func (s *ChallengeService) Issue(address, action string, params map[string]string) (*models.ActionChallengeResponse, error) {
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("无效的钱包地址")
+	}
+	if err := checkActionParams(action, params); err != nil {
+		return nil, err
+	}
+
+	// 顺带删除已过期的挑战
+	s.DB.Unscoped().Where("expires_at < ?", time.Now().Add(-s.TTL)).Delete(&models.ActionChallenge{})
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("生成挑战值失败: %v", err)
+	}
+	challenge := models.ActionChallenge{
+		WalletAddress: strings.ToLower(address),
+		Action:        action,
+		Nonce:         hex.EncodeToString(nonce),
+		// 数据库时间精度为秒，消息中的过期时间也按秒表示
+		ExpiresAt: time.Now().Add(s.TTL).Truncate(time.Second),
+	}
+	if err := s.DB.Create(&challenge).Error; err != nil {
+		return nil, fmt.Errorf("保存操作挑战失败: %v", err)
+	}
+
+	return &models.ActionChallengeResponse{
+		Action:    action,
+		Nonce:     challenge.Nonce,
+		Message:   BuildActionMessage(action, params, challenge.Nonce, challenge.ExpiresAt.Unix()),
+		ExpiresAt: challenge.ExpiresAt.Unix(),
+	}, nil
+}
+
+// Verify 校验操作签名并一次性消费挑战：挑战必须属于签名者、对应该操作且未过期，
+// 签名消息按params重新构造，params应取自实际执行的请求内容
+func (s *ChallengeService) Verify(proof models.ActionProof, action string, params map[string]string) error {
+	if proof.Nonce == "" || proof.Signature == "" || !common.IsHexAddress(proof.Address) {
+		return fmt.Errorf("%w: 需要先申请操作挑战并提交钱包签名", ErrActionUnauthorized)
+	}
+	if err := checkActionParams(action, params); err != nil {
+		return fmt.Errorf("%w: %v", ErrActionUnauthorized, err)
+	}
+
+	var challenge models.ActionChallenge
+	if err := s.DB.Where("nonce = ?", proof.Nonce).First(&challenge).Error; err != nil {
+		return fmt.Errorf("%w: 挑战值不存在", ErrActionUnauthorized)
+	}
+	if challenge.Action != action {
+		return fmt.Errorf("%w: 挑战值不是为该操作下发的", ErrActionUnauthorized)
+	}
+	if !strings.EqualFold(challenge.WalletAddress, proof.Address) {
+		return fmt.Errorf("%w: 挑战值不属于该钱包", ErrActionUnauthorized)
+	}
+	if challenge.UsedAt != nil {
+		return fmt.Errorf("%w: 挑战值已被使用", ErrActionUnauthorized)
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		return fmt.Errorf("%w: 挑战值已过期", ErrActionUnauthorized)
+	}
+
+	message := BuildActionMessage(action, params, challenge.Nonce, challenge.ExpiresAt.Unix())
+	if !util.VerifyPersonalSignature(proof.Address, proof.Signature, message) {
+		return fmt.Errorf("%w: 签名与操作内容不一致", ErrActionUnauthorized)
+	}
+
+	result := s.DB.Model(&models.ActionChallenge{}).
+		Where("id = ? AND used_at IS NULL", challenge.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("消费挑战值失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: 挑战值已被使用", ErrActionUnauthorized)
+	}
+	return nil
+}
+
+// Message 按挑战值重建应签名的操作消息，供需要与客户端提交的原始消息逐字比对的接口使用；
+// 挑战不存在、不是为该操作下发或已过期时返回错误。只查询不消费，仍需调用Verify
+func (s *ChallengeService) Message(nonce, action string, params map[string]string) (string, error) {
+	if err := checkActionParams(action, params); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrActionUnauthorized, err)
+	}
+	var challenge models.ActionChallenge
+	if err := s.DB.Where("nonce = ?", nonce).First(&challenge).Error; err != nil {
+		return "", fmt.Errorf("%w: 挑战值不存在", ErrActionUnauthorized)
+	}
+	if challenge.Action != action {
+		return "", fmt.Errorf("%w: 挑战值不是为该操作下发的", ErrActionUnauthorized)
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		return "", fmt.Errorf("%w: 挑战值已过期", ErrActionUnauthorized)
+	}
+	return BuildActionMessage(action, params, challenge.Nonce, challenge.ExpiresAt.Unix()), nil
+}
+
+// IsPlatformAdmin 检查地址是否为平台管理员
+func (s *ChallengeService) IsPlatformAdmin(address string) bool {
+	for _, admin := range s.AdminAddresses {
+		if strings.EqualFold(admin, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyAdmin 校验平台管理员的操作签名
+func (s *ChallengeService) VerifyAdmin(proof models.ActionProof, action string, params map[string]string) error {
+	if !s.IsPlatformAdmin(proof.Address) {
+		return fmt.Errorf("%w: 地址 %s 不是平台管理员", ErrActionUnauthorized, proof.Address)
+	}
+	return s.Verify(proof, action, params)
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/ABE/nft/nft-go-backend/internal/blockchain"
+	"github.com/ABE/nft/nft-go-backend/internal/config"
+
+	auth_service "github.com/ABE/nft/nft-go-backend/internal/api/auth/service"
+	nft "github.com/ABE/nft/nft-go-backend/internal/api/nft/handler"
+	nft_service "github.com/ABE/nft/nft-go-backend/internal/api/nft/service"
+)
+
+// Collection 一个部署（链+主/子NFT合约）的接口处理程序和后台服务
+type Collection struct {
+	Deployment       config.Deployment
+	Client           *blockchain.EthClient
+	NFTHandlers      *nft.NFTHandlers
+	ChildNFTHandlers *nft.ChildNFTHandlers
+	TxHandlers       *nft.TxHandlers
+
+	// ChildRequestService 子NFT申请生命周期服务，用于后台过期清理
+	ChildRequestService *nft_service.ChildRequestService
+	// ChildMintService 子NFT铸造服务，用于后台确认遗留交易
+	ChildMintService *nft_service.ChildMintService
+	// TxLedgerService 链上操作账本，用于后台确认待打包的交易
+	TxLedgerService *nft_service.TxLedgerService
+	// IndexerService 合约事件索引器，未启用时为nil
+	IndexerService *nft_service.IndexerService
+	// IndexerSupervisor 驱动索引器订阅或轮询新区块，未启用索引时为nil
+	IndexerSupervisor *nft_service.IndexerSupervisor
+}
+
+// CollectionInfo /api/collections返回的部署信息
+type CollectionInfo struct {
+	Name            string `json:"name"`
+	ChainID         int64  `json:"chainId"`
+	MainNFTAddress  string `json:"mainNftAddress"`
+	ChildNFTAddress string `json:"childNftAddress"`
+	StartBlock      int64  `json:"startBlock"`
+	Confirmations   int64  `json:"confirmations"`
+	Default         bool   `json:"default"`
+	Indexed         bool   `json:"indexed"` // 是否启用了合约事件索引
+}
+
+// newCollection 为一个部署创建NFT、子NFT、账本和索引服务；Webhook与事件服务由所有部署共用
+func newCollection(db *gorm.DB, client *blockchain.EthClient, events *nft_service.EventService, challenges *auth_service.ChallengeService) *Collection {
+	cfg := client.Config
+	deployment := client.Deployment
+	parents := client.NFTContract("main")
+
+	// 创建子NFT申请生命周期服务
+	childRequestService := nft_service.NewChildRequestService(db, deployment.Name, parents, time.Duration(cfg.ChildRequestTTL)*time.Second)
+	childRequestService.Events = events
+	events.Parents[deployment.Name] = parents
+	childMintService := nft_service.NewChildMintService(client, childRequestService, time.Duration(cfg.ChildMintReceiptTimeout)*time.Second)
+	issuanceRuleService := nft_service.NewIssuanceRuleService(db, client)
+	childMintService.Rules = issuanceRuleService
+	// 接口发起的交易记入账本，由后台任务确认并写入NFT记录和申请状态
+	txLedgerService := nft_service.NewTxLedgerService(db, client)
+	txLedgerService.ChildMint = childMintService
+	childMintService.Ledger = txLedgerService
+
+	// 启用时创建合约事件索引器，保持nfts表的持有者、URI和父子关系与链上一致
+	var indexerService *nft_service.IndexerService
+	var indexerSupervisor *nft_service.IndexerSupervisor
+	if cfg.IndexerEnabled {
+		var err error
+		indexerService, err = nft_service.NewIndexerService(db, client.Client, deployment.Name, deployment.ChainID,
+			common.HexToAddress(cfg.MainNFTAddress), common.HexToAddress(cfg.ChildNFTAddress),
+			uint64(cfg.IndexerStartBlock), uint64(cfg.IndexerBatchSize))
+		if err != nil {
+			log.Printf("部署 %s 合约事件索引未启用: %v", deployment.Name, err)
+		} else {
+			indexerService.Confirmations = uint64(cfg.IndexerConfirmations)
+			indexerService.ReorgWindow = uint64(cfg.IndexerReorgWindow)
+			// WebSocket/IPC端点订阅新区块，HTTP端点轮询
+			indexerSupervisor = nft_service.NewIndexerSupervisor(indexerService, cfg.EthereumRPC, client.Client,
+				time.Duration(cfg.IndexerPollInterval)*time.Second)
+		}
+	}
+
+	childNFTHandlers := nft.NewChildNFTHandlers(client, childRequestService, childMintService, issuanceRuleService, txLedgerService)
+	childNFTHandlers.Challenges = challenges
+
+	return &Collection{
+		Deployment:       deployment,
+		Client:           client,
+		NFTHandlers:      nft.NewNFTHandlers(client, txLedgerService),
+		ChildNFTHandlers: childNFTHandlers,
+		TxHandlers:       nft.NewTxHandlers(txLedgerService),
+
+		ChildRequestService: childRequestService,
+		ChildMintService:    childMintService,
+		TxLedgerService:     txLedgerService,
+		IndexerService:      indexerService,
+		IndexerSupervisor:   indexerSupervisor,
+	}
+}
+
+// Info 返回部署信息
+func (col *Collection) Info() CollectionInfo {
+	return CollectionInfo{
+		Name:            col.Deployment.Name,
+		ChainID:         col.Deployment.ChainID,
+		MainNFTAddress:  col.Client.ContractAddress("main"),
+		ChildNFTAddress: col.Client.ContractAddress("child"),
+		StartBlock:      col.Deployment.StartBlock,
+		Confirmations:   col.Deployment.Confirmations,
+		Indexed:         col.IndexerSupervisor != nil,
+	}
+}
+
+// resolveCollection 按查询参数collection或chainId选择部署，都未指定时使用默认部署；
+// 同一条链上有多个部署时必须用collection指定
+func (router *Router) resolveCollection(c *gin.Context) (*Collection, error) {
+	if name := c.Query("collection"); name != "" {
+		for _, col := range router.Collections {
+			if col.Deployment.Name == name {
+				return col, nil
+			}
+		}
+		return nil, fmt.Errorf("未知的部署: %s", name)
+	}
+	if value := c.Query("chainId"); value != "" {
+		chainID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的chainId: %s", value)
+		}
+		var found *Collection
+		for _, col := range router.Collections {
+			if col.Deployment.ChainID != chainID {
+				continue
+			}
+			if found != nil {
+				return nil, fmt.Errorf("链 %d 上有多个部署，请用collection参数指定", chainID)
+			}
+			found = col
+		}
+		if found == nil {
+			return nil, fmt.Errorf("链 %d 上没有部署", chainID)
+		}
+		return found, nil
+	}
+	if len(router.Collections) == 0 {
+		return nil, fmt.Errorf("没有可用的部署")
+	}
+	return router.Collections[0], nil
+}
+
+// perCollection 把部署相关的处理程序包装为按请求选择部署的处理程序，未知部署返回400
+func (router *Router) perCollection(handler func(col *Collection) gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		col, err := router.resolveCollection(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		handler(col)(c)
+	}
+}
+
+// nftRoute 按请求的部署调用NFT处理程序
+func (router *Router) nftRoute(handler func(*nft.NFTHandlers, *gin.Context)) gin.HandlerFunc {
+	return router.perCollection(func(col *Collection) gin.HandlerFunc {
+		return func(c *gin.Context) { handler(col.NFTHandlers, c) }
+	})
+}
+
+// childRoute 按请求的部署调用子NFT处理程序
+func (router *Router) childRoute(handler func(*nft.ChildNFTHandlers, *gin.Context)) gin.HandlerFunc {
+	return router.perCollection(func(col *Collection) gin.HandlerFunc {
+		return func(c *gin.Context) { handler(col.ChildNFTHandlers, c) }
+	})
+}
+
+// txRoute 按请求的部署调用交易查询处理程序
+func (router *Router) txRoute(handler func(*nft.TxHandlers, *gin.Context)) gin.HandlerFunc {
+	return router.perCollection(func(col *Collection) gin.HandlerFunc {
+		return func(c *gin.Context) { handler(col.TxHandlers, c) }
+	})
+}
+
+// ListCollectionsHandler 列出已配置的部署
+func (router *Router) ListCollectionsHandler(c *gin.Context) {
+	collections := make([]CollectionInfo, 0, len(router.Collections))
+	for i, col := range router.Collections {
+		info := col.Info()
+		info.Default = i == 0
+		collections = append(collections, info)
+	}
+	c.JSON(http.StatusOK, gin.H{"collections": collections})
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -21,6 +22,29 @@ func NewDIDHandlers(service *did_vc.DIDService) *DIDHandlers {
 	}
 }
 
+// CreateDIDChallengeHandler 下发创建DID的挑战处理程序
+func (h *DIDHandlers) CreateDIDChallengeHandler(c *gin.Context) {
+	walletAddress := c.Param("walletAddress")
+	if walletAddress == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "钱包地址不能为空"})
+		return
+	}
+
+	challenge, message, err := h.Service.CreateDIDChallenge(walletAddress)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "创建挑战失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DIDChallengeResponse{
+		DID:           "did:ethr:" + walletAddress,
+		WalletAddress: walletAddress,
+		Nonce:         challenge.Nonce,
+		Message:       message,
+		ExpiresAt:     challenge.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
 // CreateDIDFromWalletHandler 从钱包创建DID处理程序
 func (h *DIDHandlers) CreateDIDFromWalletHandler(c *gin.Context) {
 	walletAddress := c.Param("walletAddress")
@@ -29,10 +53,19 @@ func (h *DIDHandlers) CreateDIDFromWalletHandler(c *gin.Context) {
 		return
 	}
 
+	// 请求体可为空：DID已存在时无需签名
+	var req models.CreateDIDFromWalletRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+			return
+		}
+	}
+
 	// 调用服务创建DID
-	did, exists, err := h.Service.CreateDIDFromWallet(walletAddress)
+	did, exists, err := h.Service.CreateDIDFromWallet(walletAddress, req.Nonce, req.Signature)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建DID失败: " + err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "创建DID失败: " + err.Error()})
 		return
 	}
 
@@ -41,6 +74,7 @@ func (h *DIDHandlers) CreateDIDFromWalletHandler(c *gin.Context) {
 		DID:           did.DIDString,
 		WalletAddress: did.WalletAddress,
 		Exists:        exists,
+		PublicKeyHex:  did.PublicKeyHex,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -109,35 +143,125 @@ func (h *DIDHandlers) ResolveDIDHandler(c *gin.Context) {
 		return
 	}
 
+	h.resolveDID(c, req.DID)
+}
+
+// ResolveDIDByPathHandler 通过路径参数解析任意支持方法的DID处理程序（did:ethr、did:key、did:web）
+func (h *DIDHandlers) ResolveDIDByPathHandler(c *gin.Context) {
+	h.resolveDID(c, c.Param("did"))
+}
+
+// resolveDID 通过通用解析器解析DID，并按解析错误码返回HTTP状态
+func (h *DIDHandlers) resolveDID(c *gin.Context, didString string) {
+	// 可选的versionTime查询参数（RFC3339），返回该时间点有效的文档版本
+	var versionTime *time.Time
+	if value := c.Query("versionTime"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "versionTime格式无效，应为RFC3339时间"})
+			return
+		}
+		versionTime = &parsed
+	}
+
 	// 调用服务解析DID
-	doc, err := h.Service.ResolveDID(req.DID)
+	var result *models.DIDResolutionResponse
+	var err error
+	if h.Service.Resolver != nil {
+		result, err = h.Service.Resolver.Resolve(c.Request.Context(), didString, versionTime)
+	} else {
+		result, err = h.Service.ResolveDID(didString, versionTime)
+	}
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "解析DID失败: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "解析DID失败: " + err.Error()})
 		return
 	}
 
-	// 构建响应
-	response := models.DIDResolutionResponse{
-		DIDDocument: *doc,
-		Status:      "resolved",
+	switch result.DIDResolutionMetadata.Error {
+	case did_vc.ResolutionErrorInvalidDID:
+		c.JSON(http.StatusBadRequest, result)
+	case did_vc.ResolutionErrorNotFound:
+		c.JSON(http.StatusNotFound, result)
+	case did_vc.ResolutionErrorMethodNotSupported:
+		c.JSON(http.StatusNotImplemented, result)
+	case did_vc.ResolutionErrorInvalidDocument:
+		c.JSON(http.StatusBadGateway, result)
+	default:
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// UpdateDIDHandler 执行经控制者签名的DID文档变更处理程序
+func (h *DIDHandlers) UpdateDIDHandler(c *gin.Context) {
+	var req models.DIDOperationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	response, err := h.Service.ApplyDIDOperation(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "更新DID失败: " + err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-// 已弃用的方法
+// RevokeDIDHandler 停用DID处理程序
+func (h *DIDHandlers) RevokeDIDHandler(c *gin.Context) {
+	var req models.RevokeDIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
 
-// CreateDIDHandler 创建DID处理程序（已弃用）
-func (h *DIDHandlers) CreateDIDHandler(c *gin.Context) {
-	c.JSON(http.StatusBadRequest, gin.H{"error": "此方法已弃用，请使用 /api/did/wallet/:walletAddress 创建DID"})
+	response, err := h.Service.DeactivateDID(req.DID, req.Signature)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "停用DID失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
-// UpdateDIDHandler 更新DID处理程序（已弃用）
-func (h *DIDHandlers) UpdateDIDHandler(c *gin.Context) {
-	c.JSON(http.StatusBadRequest, gin.H{"error": "此方法已弃用"})
+// GetDIDHistoryHandler 获取DID文档历史版本处理程序
+func (h *DIDHandlers) GetDIDHistoryHandler(c *gin.Context) {
+	didString := c.Param("did")
+	if didString == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "DID不能为空"})
+		return
+	}
+
+	versions, err := h.Service.GetDIDHistory(didString)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "获取DID历史失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"did": didString, "versions": versions})
 }
 
-// RevokeDIDHandler 撤销DID处理程序（已弃用）
-func (h *DIDHandlers) RevokeDIDHandler(c *gin.Context) {
-	c.JSON(http.StatusBadRequest, gin.H{"error": "此方法已弃用"})
+// GetDIDAnchorRecordsHandler 获取DID变更写入链上注册表的记录处理程序
+func (h *DIDHandlers) GetDIDAnchorRecordsHandler(c *gin.Context) {
+	if h.Service.Anchor == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用链上锚定"})
+		return
+	}
+
+	didString := c.Param("did")
+	records, err := h.Service.Anchor.GetDIDAnchorRecords(didString)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"did": didString, "registry": h.Service.Anchor.RegistryAddress.Hex(), "records": records})
+}
+
+// 已弃用的方法
+
+// CreateDIDHandler 创建DID处理程序（已弃用）
+func (h *DIDHandlers) CreateDIDHandler(c *gin.Context) {
+	c.JSON(http.StatusBadRequest, gin.H{"error": "此方法已弃用，请使用 /api/did/wallet/:walletAddress 创建DID"})
 }
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	did_vc "github.com/ABE/nft/nft-go-backend/internal/api/did_vc/service"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// HospitalHandlers 医院登记相关处理程序结构体
+type HospitalHandlers struct {
+	Service *did_vc.HospitalService
+}
+
+// NewHospitalHandlers 创建新的医院登记处理程序
+func NewHospitalHandlers(service *did_vc.HospitalService) *HospitalHandlers {
+	return &HospitalHandlers{
+		Service: service,
+	}
+}
+
+// OnboardHospitalHandler 医院入驻处理程序（需平台管理员签名）
+func (h *HospitalHandlers) OnboardHospitalHandler(c *gin.Context) {
+	var req models.OnboardHospitalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	hospital, err := h.Service.OnboardHospital(req)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "医院入驻失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hospital)
+}
+
+// SuspendHospitalHandler 暂停医院处理程序（需平台管理员签名）
+func (h *HospitalHandlers) SuspendHospitalHandler(c *gin.Context) {
+	h.setHospitalStatus(c, true)
+}
+
+// ReinstateHospitalHandler 恢复医院处理程序（需平台管理员签名）
+func (h *HospitalHandlers) ReinstateHospitalHandler(c *gin.Context) {
+	h.setHospitalStatus(c, false)
+}
+
+// setHospitalStatus 更新医院状态
+func (h *HospitalHandlers) setHospitalStatus(c *gin.Context, suspend bool) {
+	var req models.HospitalStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	hospital, err := h.Service.SetHospitalStatus(req, suspend)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "更新医院状态失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hospital)
+}
+
+// GrantAuthorizationHandler 授予凭证类型授权处理程序（需平台管理员签名）
+func (h *HospitalHandlers) GrantAuthorizationHandler(c *gin.Context) {
+	h.setIssuerAuthorization(c, true)
+}
+
+// RevokeAuthorizationHandler 撤销凭证类型授权处理程序（需平台管理员签名）
+func (h *HospitalHandlers) RevokeAuthorizationHandler(c *gin.Context) {
+	h.setIssuerAuthorization(c, false)
+}
+
+// setIssuerAuthorization 更新凭证类型授权
+func (h *HospitalHandlers) setIssuerAuthorization(c *gin.Context, grant bool) {
+	var req models.IssuerAuthorizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	hospital, err := h.Service.SetIssuerAuthorization(req, grant)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "更新凭证类型授权失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hospital)
+}
+
+// ListHospitalsHandler 列出医院处理程序
+func (h *HospitalHandlers) ListHospitalsHandler(c *gin.Context) {
+	hospitals, err := h.Service.ListHospitals(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"hospitals": hospitals})
+}
+
+// GetHospitalHandler 获取医院详情处理程序
+func (h *HospitalHandlers) GetHospitalHandler(c *gin.Context) {
+	hospital, err := h.Service.GetHospital(c.Param("hospitalDid"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hospital)
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	auth "github.com/ABE/nft/nft-go-backend/internal/api/auth/service"
+	did_vc "github.com/ABE/nft/nft-go-backend/internal/api/did_vc/service"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// SchemaHandlers 凭证模式相关处理程序结构体
+type SchemaHandlers struct {
+	Service    *did_vc.SchemaService
+	Challenges *auth.ChallengeService // 模式的写操作需要平台管理员签名
+}
+
+// NewSchemaHandlers 创建新的凭证模式处理程序
+func NewSchemaHandlers(service *did_vc.SchemaService, challenges *auth.ChallengeService) *SchemaHandlers {
+	return &SchemaHandlers{
+		Service:    service,
+		Challenges: challenges,
+	}
+}
+
+// authorizeAdmin 校验平台管理员对模式写操作的签名，失败时写入响应并返回false
+func (h *SchemaHandlers) authorizeAdmin(c *gin.Context, proof models.ActionProof, action string, params map[string]string) bool {
+	err := h.Challenges.VerifyAdmin(proof, action, params)
+	if err != nil {
+		c.JSON(actionErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// CreateSchemaHandler 注册凭证模式处理程序
+func (h *SchemaHandlers) CreateSchemaHandler(c *gin.Context) {
+	var req models.CreateSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	// 签名绑定模式名称、版本和模式定义的哈希
+	if !h.authorizeAdmin(c, req.Proof, "CreateSchema", map[string]string{
+		"name":       req.Name,
+		"version":    req.Version,
+		"schemaHash": auth.HashParam(req.Schema),
+	}) {
+		return
+	}
+
+	schema, err := h.Service.CreateSchema(req.Name, req.Description, req.Version, req.Author, string(req.Schema))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "注册凭证模式失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.Service.ToResponse(schema))
+}
+
+// ListSchemasHandler 列出凭证模式处理程序
+func (h *SchemaHandlers) ListSchemasHandler(c *gin.Context) {
+	schemas, err := h.Service.ListSchemas(c.Query("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取凭证模式列表失败: " + err.Error()})
+		return
+	}
+
+	responses := make([]models.SchemaResponse, 0, len(schemas))
+	for i := range schemas {
+		responses = append(responses, h.Service.ToResponse(&schemas[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schemas": responses})
+}
+
+// GetSchemaHandler 获取凭证模式处理程序
+func (h *SchemaHandlers) GetSchemaHandler(c *gin.Context) {
+	schema, err := h.Service.GetSchema(c.Param("schemaId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "获取凭证模式失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.Service.ToResponse(schema))
+}
+
+// UpdateSchemaHandler 更新凭证模式处理程序
+func (h *SchemaHandlers) UpdateSchemaHandler(c *gin.Context) {
+	var req models.UpdateSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	// 签名绑定模式ID、新描述和新模式定义的哈希
+	if !h.authorizeAdmin(c, req.Proof, "UpdateSchema", map[string]string{
+		"schemaId":        c.Param("schemaId"),
+		"descriptionHash": auth.HashParam([]byte(req.Description)),
+		"schemaHash":      auth.HashParam(req.Schema),
+	}) {
+		return
+	}
+
+	schema, err := h.Service.UpdateSchema(c.Param("schemaId"), req.Description, string(req.Schema))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "更新凭证模式失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.Service.ToResponse(schema))
+}
+
+// DeleteSchemaHandler 删除凭证模式处理程序，签名通过查询参数address、nonce、signature传递
+func (h *SchemaHandlers) DeleteSchemaHandler(c *gin.Context) {
+	schemaID := c.Param("schemaId")
+	var proof models.ActionProof
+	if err := c.ShouldBindQuery(&proof); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的查询参数: " + err.Error()})
+		return
+	}
+	if !h.authorizeAdmin(c, proof, "DeleteSchema", map[string]string{"schemaId": schemaID}) {
+		return
+	}
+
+	if err := h.Service.DeleteSchema(schemaID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "删除凭证模式失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schemaId": schemaID, "message": "凭证模式已删除"})
+}
+
+// ListPolicyAttributesHandler 列出可用于访问策略的属性名处理程序
+func (h *SchemaHandlers) ListPolicyAttributesHandler(c *gin.Context) {
+	attributes, err := h.Service.ListPolicyAttributes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取属性列表失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attributes": attributes})
+}
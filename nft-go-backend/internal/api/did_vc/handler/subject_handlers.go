@@ -0,0 +1,186 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	auth "github.com/ABE/nft/nft-go-backend/internal/api/auth/service"
+	did_vc "github.com/ABE/nft/nft-go-backend/internal/api/did_vc/service"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// SubjectHandlers 身份主体档案和凭证相关处理程序结构体
+type SubjectHandlers struct {
+	Service   *did_vc.SubjectService
+	VCService *did_vc.VCService
+}
+
+// NewSubjectHandlers 创建新的身份主体处理程序
+func NewSubjectHandlers(service *did_vc.SubjectService, vcService *did_vc.VCService) *SubjectHandlers {
+	return &SubjectHandlers{
+		Service:   service,
+		VCService: vcService,
+	}
+}
+
+// ListRoleTemplatesHandler 列出角色模板处理程序
+func (h *SubjectHandlers) ListRoleTemplatesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"roles": did_vc.ListRoleTemplates()})
+}
+
+// CreateProfileHandler 创建身份主体档案处理程序
+func (h *SubjectHandlers) CreateProfileHandler(c *gin.Context) {
+	var req models.CreateSubjectProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	profile, err := h.Service.CreateProfile(req.WalletAddress, req.Role, req.Name, req.OrganizationDID, req.Attributes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "创建档案失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// ListProfilesHandler 列出身份主体档案处理程序
+func (h *SubjectHandlers) ListProfilesHandler(c *gin.Context) {
+	profiles, err := h.Service.ListProfiles(c.Query("role"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles})
+}
+
+// GetProfilesByDIDHandler 获取主体在各角色下的档案处理程序
+func (h *SubjectHandlers) GetProfilesByDIDHandler(c *gin.Context) {
+	profiles, err := h.Service.ListProfilesByDID(c.Param("did"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles})
+}
+
+// IssueCredentialHandler 颁发主体凭证处理程序
+func (h *SubjectHandlers) IssueCredentialHandler(c *gin.Context) {
+	var req models.IssueSubjectCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	credential, err := h.VCService.IssueSubjectCredential(req.Proof, req.IssuerDID, req.SubjectDID, req.Role, req.VCType, req.VCContent, req.SchemaID)
+	if err != nil {
+		c.JSON(actionErrorStatus(err, http.StatusBadRequest), gin.H{"error": "颁发凭证失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, credential)
+}
+
+// VerifyCredentialHandler 验证主体凭证处理程序
+func (h *SubjectHandlers) VerifyCredentialHandler(c *gin.Context) {
+	var req models.VerifyDoctorVCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	result, err := h.VCService.VerifySubjectCredential(req.VCID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "验证凭证失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetCredentialsHandler 获取主体凭证列表处理程序
+func (h *SubjectHandlers) GetCredentialsHandler(c *gin.Context) {
+	subjectDID := c.Param("subjectDID")
+	credentials, err := h.VCService.GetSubjectCredentials(subjectDID, c.Query("role"), c.Query("type"), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取凭证列表失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subjectDid": subjectDID, "verifiableCredentials": credentials})
+}
+
+// RevokeCredentialHandler 撤销主体凭证处理程序
+func (h *SubjectHandlers) RevokeCredentialHandler(c *gin.Context) {
+	h.updateCredentialStatus(c, "撤销", func(req models.SubjectCredentialStatusRequest) (*models.SubjectCredential, error) {
+		return h.VCService.RevokeSubjectCredential(req.Proof, req.VCID, req.IssuerDID, req.Reason)
+	})
+}
+
+// SuspendCredentialHandler 暂停主体凭证处理程序
+func (h *SubjectHandlers) SuspendCredentialHandler(c *gin.Context) {
+	h.updateCredentialStatus(c, "暂停", func(req models.SubjectCredentialStatusRequest) (*models.SubjectCredential, error) {
+		return h.VCService.SuspendSubjectCredential(req.Proof, req.VCID, req.IssuerDID, req.Reason)
+	})
+}
+
+// ReinstateCredentialHandler 恢复主体凭证处理程序
+func (h *SubjectHandlers) ReinstateCredentialHandler(c *gin.Context) {
+	h.updateCredentialStatus(c, "恢复", func(req models.SubjectCredentialStatusRequest) (*models.SubjectCredential, error) {
+		return h.VCService.ReinstateSubjectCredential(req.Proof, req.VCID, req.IssuerDID)
+	})
+}
+
+// RenewCredentialHandler 续期主体凭证处理程序
+func (h *SubjectHandlers) RenewCredentialHandler(c *gin.Context) {
+	h.updateCredentialStatus(c, "续期", func(req models.SubjectCredentialStatusRequest) (*models.SubjectCredential, error) {
+		return h.VCService.RenewSubjectCredential(req.Proof, req.VCID, req.IssuerDID)
+	})
+}
+
+// updateCredentialStatus 解析请求并执行凭证生命周期操作
+func (h *SubjectHandlers) updateCredentialStatus(c *gin.Context, action string, apply func(models.SubjectCredentialStatusRequest) (*models.SubjectCredential, error)) {
+	var req models.SubjectCredentialStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	credential, err := apply(req)
+	if err != nil {
+		c.JSON(actionErrorStatus(err, http.StatusBadRequest), gin.H{"error": action + "凭证失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, credential)
+}
+
+// SupersedeCredentialHandler 以新内容取代主体凭证处理程序
+func (h *SubjectHandlers) SupersedeCredentialHandler(c *gin.Context) {
+	var req models.SupersedeSubjectCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	credential, err := h.VCService.SupersedeSubjectCredential(req.Proof, req.VCID, req.IssuerDID, req.VCContent, req.SchemaID)
+	if err != nil {
+		c.JSON(actionErrorStatus(err, http.StatusBadRequest), gin.H{"error": "取代凭证失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, credential)
+}
+
+// actionErrorStatus 签名授权失败的操作返回403，其余错误返回fallback
+func actionErrorStatus(err error, fallback int) int {
+	if errors.Is(err, auth.ErrActionUnauthorized) {
+		return http.StatusForbidden
+	}
+	return fallback
+}
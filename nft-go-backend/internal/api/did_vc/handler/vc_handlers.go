@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -32,9 +33,9 @@ func (h *VCHandlers) IssueCredentialHandler(c *gin.Context) {
 	}
 
 	// 调用服务颁发凭证
-	credential, err := h.Service.IssueCredential(req.IssuerDID, req.SubjectDID, req.CredentialType)
+	credential, err := h.Service.IssueCredential(req.Proof, req.IssuerDID, req.SubjectDID, req.CredentialType, req.SchemaID, req.Claims)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "颁发凭证失败: " + err.Error()})
+		c.JSON(actionErrorStatus(err, http.StatusInternalServerError), gin.H{"error": "颁发凭证失败: " + err.Error()})
 		return
 	}
 
@@ -46,6 +47,10 @@ func (h *VCHandlers) IssueCredentialHandler(c *gin.Context) {
 			Subject:  credential.SubjectDID,
 			Type:     []string{"VerifiableCredential", credential.Type},
 			IssuedAt: credential.IssuanceDate.Format("2006-01-02T15:04:05Z"),
+			CredentialSchema: map[string]interface{}{
+				"id":   credential.CredentialSchema,
+				"type": "JsonSchema",
+			},
 		},
 	}
 
@@ -96,6 +101,31 @@ func (h *VCHandlers) RevokeCredentialHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetAnchorProofHandler 获取凭证颁发/撤销记录的Merkle包含证明处理程序
+func (h *VCHandlers) GetAnchorProofHandler(c *gin.Context) {
+	if h.Service.Anchor == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用链上锚定"})
+		return
+	}
+
+	credentialID := c.Param("credentialId")
+	proofs, err := h.Service.Anchor.GetCredentialProofs(credentialID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(proofs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "凭证没有锚定记录"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"credentialId": credentialID,
+		"contract":     h.Service.Anchor.AnchorAddress.Hex(),
+		"proofs":       proofs,
+	})
+}
+
 // GetCredentialHandler 获取凭证处理程序
 func (h *VCHandlers) GetCredentialHandler(c *gin.Context) {
 	credentialID := c.Param("id")
@@ -128,6 +158,34 @@ func (h *VCHandlers) ListCredentialsHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"credentials": credentials})
 }
 
+// RequestPresentationHandler 验证者申请展示挑战处理程序
+func (h *VCHandlers) RequestPresentationHandler(c *gin.Context) {
+	var req models.RequestPresentationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	// 调用服务生成挑战
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	request, err := h.Service.CreatePresentationRequest(req.VerifierDID, req.Domain, req.CredentialTypes, ttl)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建展示请求失败: " + err.Error()})
+		return
+	}
+
+	// 构建响应
+	response := models.RequestPresentationResponse{
+		Nonce:           request.Nonce,
+		Domain:          request.Domain,
+		VerifierDID:     request.VerifierDID,
+		CredentialTypes: request.CredentialTypes,
+		ExpiresAt:       request.ExpiresAt.UTC().Format(time.RFC3339),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // CreatePresentationHandler 创建可验证表示处理程序
 func (h *VCHandlers) CreatePresentationHandler(c *gin.Context) {
 	var req models.CreatePresentationRequest
@@ -137,7 +195,7 @@ func (h *VCHandlers) CreatePresentationHandler(c *gin.Context) {
 	}
 
 	// 调用服务创建表示
-	presentation, err := h.Service.CreatePresentation(req.HolderDID, req.VerifierDID, req.CredentialIDs, req.Purpose)
+	presentation, err := h.Service.CreatePresentation(req.HolderDID, req.VerifierDID, req.CredentialIDs, req.Purpose, req.Nonce, req.Signature)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建表示失败: " + err.Error()})
 		return
@@ -150,6 +208,15 @@ func (h *VCHandlers) CreatePresentationHandler(c *gin.Context) {
 			ID:      presentation.PresentationID,
 			Type:    []string{"VerifiablePresentation"},
 			Holder:  presentation.HolderDID,
+			Proof: models.Proof{
+				Type:               "EcdsaSecp256k1RecoverySignature2020",
+				Created:            presentation.PresentationDate.UTC().Format(time.RFC3339),
+				VerificationMethod: presentation.HolderDID + "#controller",
+				ProofPurpose:       "authentication",
+				Challenge:          presentation.Challenge,
+				Domain:             presentation.Domain,
+				ProofValue:         req.Signature,
+			},
 		},
 	}
 
@@ -165,7 +232,7 @@ func (h *VCHandlers) VerifyPresentationHandler(c *gin.Context) {
 	}
 
 	// 调用服务验证表示
-	result, err := h.Service.VerifyPresentation(req.PresentationID)
+	result, err := h.Service.VerifyPresentation(req.PresentationID, req.Domain)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "验证表示失败: " + err.Error()})
 		return
@@ -241,29 +308,35 @@ func (h *VCHandlers) IssueDoctorVCHandler(c *gin.Context) {
 	}
 
 	// 调用服务颁发医生凭证
-	vc, err := h.Service.IssueDoctorVC(req.IssuerDID, req.DoctorDID, req.VCType, req.VCContent)
+	vc, err := h.Service.IssueDoctorVC(req.Proof, req.IssuerDID, req.DoctorDID, req.VCType, req.VCContent, req.SchemaID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "颁发医生凭证失败: " + err.Error()})
+		c.JSON(actionErrorStatus(err, http.StatusInternalServerError), gin.H{"error": "颁发医生凭证失败: " + err.Error()})
 		return
 	}
 
-	// 构建完整的响应，包含所有必要信息
-	response := gin.H{
+	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data": gin.H{
-			"id":        vc.VCID,
-			"vcId":      vc.VCID,
-			"doctorDID": vc.DoctorDID,
-			"issuerDID": vc.IssuerDID,
-			"type":      vc.Type,
-			"content":   vc.Content,
-			"issuedAt":  vc.IssuedAt.Format("2006-01-02T15:04:05Z"),
-			"expiresAt": vc.ExpiresAt.Format("2006-01-02T15:04:05Z"),
-			"status":    vc.Status,
-		},
-	}
+		"data":    doctorVCData(vc),
+	})
+}
 
-	c.JSON(http.StatusOK, response)
+// doctorVCData 构建医生凭证的响应数据
+func doctorVCData(vc *models.DoctorVC) gin.H {
+	return gin.H{
+		"id":           vc.VCID,
+		"vcId":         vc.VCID,
+		"doctorDID":    vc.DoctorDID,
+		"issuerDID":    vc.IssuerDID,
+		"type":         vc.Type,
+		"content":      vc.Content,
+		"issuedAt":     vc.IssuedAt.Format("2006-01-02T15:04:05Z"),
+		"expiresAt":    vc.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+		"status":       vc.Status,
+		"schemaId":     vc.SchemaID,
+		"statusReason": vc.StatusReason,
+		"replacesVcId": vc.ReplacesVCID,
+		"supersededBy": vc.SupersededBy,
+	}
 }
 
 // VerifyDoctorVCHandler 验证医生凭证处理程序
@@ -292,8 +365,8 @@ func (h *VCHandlers) GetDoctorVCsHandler(c *gin.Context) {
 		return
 	}
 
-	// 调用服务获取医生凭证列表
-	vcs, err := h.Service.GetDoctorVCs(doctorDID)
+	// 调用服务获取医生凭证列表，可按类型和状态过滤
+	vcs, err := h.Service.GetDoctorVCs(doctorDID, c.Query("type"), c.Query("status"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取医生凭证列表失败: " + err.Error()})
 		return
@@ -308,6 +381,76 @@ func (h *VCHandlers) GetDoctorVCsHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// RevokeDoctorVCHandler 撤销医生凭证处理程序
+func (h *VCHandlers) RevokeDoctorVCHandler(c *gin.Context) {
+	h.updateDoctorVCStatus(c, "撤销", func(req models.SubjectCredentialStatusRequest) (*models.SubjectCredential, error) {
+		return h.Service.RevokeSubjectCredential(req.Proof, req.VCID, req.IssuerDID, req.Reason)
+	})
+}
+
+// SuspendDoctorVCHandler 暂停医生凭证处理程序
+func (h *VCHandlers) SuspendDoctorVCHandler(c *gin.Context) {
+	h.updateDoctorVCStatus(c, "暂停", func(req models.SubjectCredentialStatusRequest) (*models.SubjectCredential, error) {
+		return h.Service.SuspendSubjectCredential(req.Proof, req.VCID, req.IssuerDID, req.Reason)
+	})
+}
+
+// ReinstateDoctorVCHandler 恢复医生凭证处理程序
+func (h *VCHandlers) ReinstateDoctorVCHandler(c *gin.Context) {
+	h.updateDoctorVCStatus(c, "恢复", func(req models.SubjectCredentialStatusRequest) (*models.SubjectCredential, error) {
+		return h.Service.ReinstateSubjectCredential(req.Proof, req.VCID, req.IssuerDID)
+	})
+}
+
+// RenewDoctorVCHandler 续期医生凭证处理程序
+func (h *VCHandlers) RenewDoctorVCHandler(c *gin.Context) {
+	h.updateDoctorVCStatus(c, "续期", func(req models.SubjectCredentialStatusRequest) (*models.SubjectCredential, error) {
+		return h.Service.RenewSubjectCredential(req.Proof, req.VCID, req.IssuerDID)
+	})
+}
+
+// updateDoctorVCStatus 解析请求并执行凭证生命周期操作
+func (h *VCHandlers) updateDoctorVCStatus(c *gin.Context, action string, apply func(models.SubjectCredentialStatusRequest) (*models.SubjectCredential, error)) {
+	var req models.SubjectCredentialStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	credential, err := apply(req)
+	if err != nil {
+		c.JSON(actionErrorStatus(err, http.StatusBadRequest), gin.H{"error": action + "医生凭证失败: " + err.Error()})
+		return
+	}
+
+	vc := credential.ToDoctorVC()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    doctorVCData(&vc),
+	})
+}
+
+// SupersedeDoctorVCHandler 以新内容取代医生凭证处理程序
+func (h *VCHandlers) SupersedeDoctorVCHandler(c *gin.Context) {
+	var req models.SupersedeSubjectCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	credential, err := h.Service.SupersedeSubjectCredential(req.Proof, req.VCID, req.IssuerDID, req.VCContent, req.SchemaID)
+	if err != nil {
+		c.JSON(actionErrorStatus(err, http.StatusBadRequest), gin.H{"error": "取代医生凭证失败: " + err.Error()})
+		return
+	}
+
+	vc := credential.ToDoctorVC()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    doctorVCData(&vc),
+	})
+}
+
 // GetDoctorDIDsHandler 获取医生DID列表处理程序
 func (h *VCHandlers) GetDoctorDIDsHandler(c *gin.Context) {
 	// 调用服务获取所有医生DID
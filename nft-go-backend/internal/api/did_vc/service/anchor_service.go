@@ -0,0 +1,586 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gorm.io/gorm"
+
+	"github.com/ABE/nft/nft-go-backend/internal/blockchain"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+	"github.com/ABE/nft/nft-go-backend/pkg/didregistry"
+	"github.com/ABE/nft/nft-go-backend/pkg/vcanchor"
+)
+
+// 凭证锚定事件
+const (
+	AnchorEventIssued  = "issued"
+	AnchorEventRevoked = "revoked"
+)
+
+// 写入注册表的属性和委托的有效期
+const registryAttributeValidity = 10 * 365 * 24 * time.Hour
+
+// 单笔锚定交易的等待时间
+const anchorTxTimeout = 2 * time.Minute
+
+// AnchorService 链上锚定服务：把DID变更写入ERC-1056注册表，并批量锚定凭证记录的Merkle根。
+// 依赖的AnchorBackend可以是真实节点，也可以是go-ethereum的模拟后端
+type AnchorService struct {
+	DB              *gorm.DB
+	Backend         blockchain.AnchorBackend
+	Auth            *bind.TransactOpts
+	Registry        *didregistry.Didregistry
+	Anchor          *vcanchor.Vcanchor
+	RegistryAddress common.Address
+	AnchorAddress   common.Address
+	BatchSize       int
+	Tx              *blockchain.TxManager // 设置后锚定交易与其他平台交易共用nonce分配和加价重发
+
+	mu sync.Mutex // 串行发送锚定交易
+
+	// 待写入注册表的DID变更，由RunDIDOperations按提交顺序逐个处理
+	queueMu     sync.Mutex
+	queue       []didAnchorJob
+	queueSignal chan struct{}
+}
+
+// didAnchorJob 一次待写入注册表的DID文档变更
+type didAnchorJob struct {
+	didString string
+	versionID uint
+	previous  *models.DIDDocument
+	current   *models.DIDDocument
+	req       *models.DIDOperationRequest
+}
+
+// NewAnchorService 创建链上锚定服务
+func NewAnchorService(db *gorm.DB, backend blockchain.AnchorBackend, auth *bind.TransactOpts, registryAddress, anchorAddress common.Address, batchSize int) (*AnchorService, error) {
+	registry, err := didregistry.NewDidregistry(registryAddress, backend)
+	if err != nil {
+		return nil, fmt.Errorf("无法创建DID注册表实例: %v", err)
+	}
+	anchor, err := vcanchor.NewVcanchor(anchorAddress, backend)
+	if err != nil {
+		return nil, fmt.Errorf("无法创建凭证锚定合约实例: %v", err)
+	}
+	if batchSize <= 0 {
+		batchSize = 256
+	}
+	return &AnchorService{
+		DB:              db,
+		Backend:         backend,
+		Auth:            auth,
+		Registry:        registry,
+		Anchor:          anchor,
+		RegistryAddress: registryAddress,
+		AnchorAddress:   anchorAddress,
+		BatchSize:       batchSize,
+		queueSignal:     make(chan struct{}, 1),
+	}, nil
+}
+
+// CredentialContentHash 计算凭证内容的keccak256
+func CredentialContentHash(content string) string {
+	return hexutil.Encode(crypto.Keccak256([]byte(content)))
+}
+
+// CredentialLeafHash 计算凭证记录的Merkle叶子：keccak256("<event>|<credentialId>|<contentHash>")
+func CredentialLeafHash(event, credentialID, contentHash string) [32]byte {
+	var leaf [32]byte
+	copy(leaf[:], crypto.Keccak256([]byte(event+"|"+credentialID+"|"+contentHash)))
+	return leaf
+}
+
+// RecordCredentialEvent 记录一条待锚定的凭证颁发/撤销事件；未启用锚定时什么也不做
+func (s *AnchorService) RecordCredentialEvent(credentialID, event, content string) {
+	if s == nil {
+		return
+	}
+	contentHash := CredentialContentHash(content)
+	leaf := CredentialLeafHash(event, credentialID, contentHash)
+	entry := models.CredentialAnchorEntry{
+		CredentialID: credentialID,
+		Event:        event,
+		ContentHash:  contentHash,
+		LeafHash:     hexutil.Encode(leaf[:]),
+	}
+	if err := s.DB.Where("leaf_hash = ?", entry.LeafHash).FirstOrCreate(&entry).Error; err != nil {
+		log.Printf("记录凭证锚定事件失败: credentialID=%s, event=%s, err=%v", credentialID, event, err)
+	}
+}
+
+// Run 按固定间隔锚定待处理的凭证记录，直到ctx取消
+func (s *AnchorService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				batch, err := s.AnchorPendingBatch(ctx)
+				if err != nil {
+					log.Printf("凭证批量锚定失败: %v", err)
+					break
+				}
+				// 没有待锚定记录或不足一整批时等待下一轮
+				if batch == nil || batch.LeafCount < s.BatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// AnchorPendingBatch 把最多BatchSize条未锚定记录组成一批，写入Merkle根并等待上链；没有待锚定记录时返回nil
+func (s *AnchorService) AnchorPendingBatch(ctx context.Context) (*models.CredentialAnchorBatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []models.CredentialAnchorEntry
+	if err := s.DB.Where("batch_id IS NULL").Order("id ASC").Limit(s.BatchSize).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("查询待锚定记录失败: %v", err)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	leaves := make([][32]byte, len(entries))
+	for i, entry := range entries {
+		leaves[i] = common.HexToHash(entry.LeafHash)
+	}
+	root := util.NewMerkleTree(leaves).Root()
+
+	// 先登记批次并占用记录，避免并发重复锚定
+	batch := models.CredentialAnchorBatch{
+		MerkleRoot: hexutil.Encode(root[:]),
+		LeafCount:  len(entries),
+		Status:     "pending",
+	}
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&batch).Error; err != nil {
+			return err
+		}
+		for i, entry := range entries {
+			result := tx.Model(&models.CredentialAnchorEntry{}).
+				Where("id = ? AND batch_id IS NULL", entry.ID).
+				Updates(map[string]interface{}{"batch_id": batch.ID, "leaf_index": i})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("记录 %d 已被其他批次占用", entry.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("登记锚定批次失败: %v", err)
+	}
+
+	receipt, err := s.sendAndWait(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return s.Anchor.AnchorRoot(opts, root, big.NewInt(int64(len(entries))))
+	})
+	if err != nil {
+		// 释放记录，下一轮重新组批
+		s.DB.Model(&models.CredentialAnchorEntry{}).Where("batch_id = ?", batch.ID).
+			Updates(map[string]interface{}{"batch_id": nil, "leaf_index": 0})
+		s.DB.Model(&batch).Updates(map[string]interface{}{"status": "failed", "failure_reason": err.Error()})
+		return nil, err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":       "anchored",
+		"tx_hash":      receipt.TxHash.Hex(),
+		"block_number": receipt.BlockNumber.Uint64(),
+		"anchored_at":  now,
+	}
+	for _, l := range receipt.Logs {
+		if event, err := s.Anchor.ParseRootAnchored(*l); err == nil {
+			updates["on_chain_id"] = event.BatchId.Uint64()
+		}
+	}
+	if err := s.DB.Model(&batch).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("更新锚定批次失败: %v", err)
+	}
+	s.DB.First(&batch, batch.ID)
+	log.Printf("凭证记录已锚定: root=%s, leaves=%d, tx=%s", batch.MerkleRoot, batch.LeafCount, batch.TxHash)
+	return &batch, nil
+}
+
+// GetCredentialProofs 返回凭证各条记录的包含证明，并调用合约核对已锚定的证明
+func (s *AnchorService) GetCredentialProofs(credentialID string) ([]models.CredentialAnchorProof, error) {
+	var entries []models.CredentialAnchorEntry
+	if err := s.DB.Where("credential_id = ?", credentialID).Order("id ASC").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("查询凭证锚定记录失败: %v", err)
+	}
+
+	proofs := make([]models.CredentialAnchorProof, 0, len(entries))
+	for _, entry := range entries {
+		proof, err := s.buildProof(&entry)
+		if err != nil {
+			return nil, err
+		}
+		proofs = append(proofs, *proof)
+	}
+	return proofs, nil
+}
+
+// CheckCredential 核对凭证当前内容与已锚定的颁发记录，并检查是否存在已锚定的撤销记录
+func (s *AnchorService) CheckCredential(credentialID, content string) (*models.CredentialAnchorStatus, error) {
+	proofs, err := s.GetCredentialProofs(credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.CredentialAnchorStatus{}
+	contentHash := CredentialContentHash(content)
+	for _, proof := range proofs {
+		if proof.Status != "anchored" {
+			continue
+		}
+		switch proof.Event {
+		case AnchorEventIssued:
+			status.Anchored = true
+			status.MerkleRoot = proof.MerkleRoot
+			status.TxHash = proof.TxHash
+			status.OnChainVerified = proof.OnChainVerified
+			status.ContentMatches = proof.ContentHash == contentHash
+		case AnchorEventRevoked:
+			if proof.OnChainVerified {
+				status.RevokedOnChain = true
+			}
+		}
+	}
+	return status, nil
+}
+
+// buildProof 由批次内全部叶子重建Merkle树并生成证明
+func (s *AnchorService) buildProof(entry *models.CredentialAnchorEntry) (*models.CredentialAnchorProof, error) {
+	proof := &models.CredentialAnchorProof{
+		CredentialID: entry.CredentialID,
+		Event:        entry.Event,
+		ContentHash:  entry.ContentHash,
+		LeafHash:     entry.LeafHash,
+		Status:       "pending",
+		Proof:        []string{},
+	}
+	if entry.BatchID == nil {
+		return proof, nil
+	}
+
+	var batch models.CredentialAnchorBatch
+	if err := s.DB.First(&batch, *entry.BatchID).Error; err != nil {
+		return nil, fmt.Errorf("查询锚定批次失败: %v", err)
+	}
+	var siblings []models.CredentialAnchorEntry
+	if err := s.DB.Where("batch_id = ?", batch.ID).Order("leaf_index ASC").Find(&siblings).Error; err != nil {
+		return nil, fmt.Errorf("查询批次记录失败: %v", err)
+	}
+	leaves := make([][32]byte, len(siblings))
+	for i, sibling := range siblings {
+		leaves[i] = common.HexToHash(sibling.LeafHash)
+	}
+
+	tree := util.NewMerkleTree(leaves)
+	path := tree.Proof(entry.LeafIndex)
+	root := common.HexToHash(batch.MerkleRoot)
+	leaf := common.HexToHash(entry.LeafHash)
+
+	proof.Status = batch.Status
+	proof.MerkleRoot = batch.MerkleRoot
+	proof.LeafIndex = entry.LeafIndex
+	proof.TxHash = batch.TxHash
+	proof.BlockNumber = batch.BlockNumber
+	for _, node := range path {
+		proof.Proof = append(proof.Proof, hexutil.Encode(node[:]))
+	}
+	proof.ProofValid = tree.Root() == [32]byte(root) && util.VerifyMerkleProof(leaf, path, root)
+
+	if batch.Status == "anchored" {
+		onChain, err := s.Anchor.VerifyInclusion(&bind.CallOpts{Context: context.Background()}, path, root, leaf)
+		if err != nil {
+			log.Printf("链上核对包含证明失败: %v", err)
+		}
+		proof.OnChainVerified = err == nil && onChain
+	}
+	return proof, nil
+}
+
+// EnqueueDIDOperation 把DID文档变更加入写入队列后立即返回；未启用锚定时什么也不做。
+// 所有变更由同一个工作协程按加入顺序写入注册表，同一DID的多次变更在链上的顺序与链下版本一致
+func (s *AnchorService) EnqueueDIDOperation(didString string, versionID uint, previous, current *models.DIDDocument, req *models.DIDOperationRequest) {
+	if s == nil {
+		return
+	}
+	s.queueMu.Lock()
+	s.queue = append(s.queue, didAnchorJob{didString: didString, versionID: versionID, previous: previous, current: current, req: req})
+	s.queueMu.Unlock()
+
+	select {
+	case s.queueSignal <- struct{}{}:
+	default:
+	}
+}
+
+// RunDIDOperations 按加入顺序把队列中的DID变更写入注册表，直到ctx取消
+func (s *AnchorService) RunDIDOperations(ctx context.Context) {
+	for {
+		for {
+			s.queueMu.Lock()
+			if len(s.queue) == 0 {
+				s.queueMu.Unlock()
+				break
+			}
+			job := s.queue[0]
+			s.queue = s.queue[1:]
+			s.queueMu.Unlock()
+
+			s.AnchorDIDOperation(job.didString, job.versionID, job.previous, job.current, job.req)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.queueSignal:
+		}
+	}
+}
+
+// AnchorDIDOperation 把一次DID文档变更写入注册表（did:ethr属性与委托约定）并记录结果
+func (s *AnchorService) AnchorDIDOperation(didString string, versionID uint, previous, current *models.DIDDocument, req *models.DIDOperationRequest) {
+	if s == nil {
+		return
+	}
+
+	record := models.DIDAnchorRecord{
+		DIDString: didString,
+		VersionID: versionID,
+		Operation: req.Operation,
+		Status:    "pending",
+	}
+	s.DB.Create(&record)
+
+	identity, ok := ethrIdentity(didString)
+	if !ok {
+		s.DB.Model(&record).Updates(map[string]interface{}{"status": "skipped", "failure_reason": "不是did:ethr标识"})
+		return
+	}
+
+	calls, err := registryCalls(s.Registry, identity, previous, current, req)
+	if err != nil || len(calls) == 0 {
+		reason := "无需写入注册表"
+		if err != nil {
+			reason = err.Error()
+		}
+		s.DB.Model(&record).Updates(map[string]interface{}{"status": "skipped", "failure_reason": reason})
+		return
+	}
+
+	var txHashes []string
+	for _, call := range calls {
+		receipt, err := s.sendAndWait(context.Background(), call)
+		if err != nil {
+			record.TxHashes = txHashes
+			record.Status = "failed"
+			record.FailureReason = err.Error()
+			s.DB.Save(&record)
+			log.Printf("DID变更写入注册表失败: did=%s, version=%d, err=%v", didString, versionID, err)
+			return
+		}
+		txHashes = append(txHashes, receipt.TxHash.Hex())
+	}
+	record.TxHashes = txHashes
+	record.Status = "anchored"
+	s.DB.Save(&record)
+}
+
+// GetDIDAnchorRecords 获取DID变更的上链记录
+func (s *AnchorService) GetDIDAnchorRecords(didString string) ([]models.DIDAnchorRecord, error) {
+	var records []models.DIDAnchorRecord
+	if err := s.DB.Where("did_string = ?", didString).Order("version_id ASC, id ASC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询DID上链记录失败: %v", err)
+	}
+	return records, nil
+}
+
+// registryTxFunc 发送一笔注册表交易
+type registryTxFunc func(opts *bind.TransactOpts) (*types.Transaction, error)
+
+// registryCalls 把DID操作翻译为注册表交易
+func registryCalls(registry *didregistry.Didregistry, identity common.Address, previous, current *models.DIDDocument, req *models.DIDOperationRequest) ([]registryTxFunc, error) {
+	validity := big.NewInt(int64(registryAttributeValidity / time.Second))
+
+	switch req.Operation {
+	case DIDOpAddVerificationMethod:
+		method := findVerificationMethod(current, req.VerificationMethod.ID)
+		if method == nil {
+			return nil, fmt.Errorf("文档中没有新增的验证方法")
+		}
+		return []registryTxFunc{verificationMethodCall(registry, identity, current, method, validity, false)}, nil
+
+	case DIDOpRemoveVerificationMethod:
+		method := findVerificationMethod(previous, req.TargetID)
+		if method == nil {
+			return nil, fmt.Errorf("原文档中没有被移除的验证方法")
+		}
+		return []registryTxFunc{verificationMethodCall(registry, identity, previous, method, validity, true)}, nil
+
+	case DIDOpAddService, DIDOpRemoveService:
+		doc, id := current, ""
+		if req.Operation == DIDOpAddService {
+			id = req.Service.ID
+		} else {
+			doc, id = previous, req.TargetID
+		}
+		for _, service := range doc.Service {
+			if service.ID != id {
+				continue
+			}
+			name := registryName("did/svc/" + service.Type)
+			value := []byte(service.ServiceEndpoint)
+			if req.Operation == DIDOpAddService {
+				return []registryTxFunc{func(opts *bind.TransactOpts) (*types.Transaction, error) {
+					return registry.SetAttribute(opts, identity, name, value, validity)
+				}}, nil
+			}
+			return []registryTxFunc{func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				return registry.RevokeAttribute(opts, identity, name, value)
+			}}, nil
+		}
+		return nil, fmt.Errorf("文档中没有对应的服务")
+
+	case DIDOpSetControllers:
+		// 注册表只能表示单个以太坊地址所有者
+		owner := identity
+		if len(current.Controller) == 1 {
+			controller, ok := ethrIdentity(current.Controller[0])
+			if !ok {
+				return nil, fmt.Errorf("控制者不是did:ethr标识，无法写入注册表")
+			}
+			owner = controller
+		} else if len(current.Controller) > 1 {
+			return nil, fmt.Errorf("注册表不支持多个控制者")
+		}
+		return []registryTxFunc{func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return registry.ChangeOwner(opts, identity, owner)
+		}}, nil
+
+	case DIDOpDeactivate:
+		// did:ethr约定所有者变更为零地址表示停用
+		return []registryTxFunc{func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return registry.ChangeOwner(opts, identity, common.Address{})
+		}}, nil
+	}
+	return nil, nil
+}
+
+// verificationMethodCall 有公钥时写入公钥属性，否则写入地址委托；用于认证的方法使用sigAuth类型
+func verificationMethodCall(registry *didregistry.Didregistry, identity common.Address, doc *models.DIDDocument, method *models.VerificationMethod, validity *big.Int, revoke bool) registryTxFunc {
+	purpose := "veriKey"
+	if containsString(doc.Authentication, method.ID) {
+		purpose = "sigAuth"
+	}
+
+	if method.PublicKeyHex != "" {
+		name := registryName("did/pub/Secp256k1/" + purpose + "/hex")
+		value, _ := hexutil.Decode("0x" + strings.TrimPrefix(method.PublicKeyHex, "0x"))
+		if revoke {
+			return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				return registry.RevokeAttribute(opts, identity, name, value)
+			}
+		}
+		return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return registry.SetAttribute(opts, identity, name, value, validity)
+		}
+	}
+
+	delegateType := registryName(purpose)
+	delegate, _ := verificationMethodAddress(*method)
+	if revoke {
+		return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return registry.RevokeDelegate(opts, identity, delegateType, delegate)
+		}
+	}
+	return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return registry.AddDelegate(opts, identity, delegateType, delegate, validity)
+	}
+}
+
+// sendAndWait 发送交易并等待回执，失败的回执视为错误
+func (s *AnchorService) sendAndWait(ctx context.Context, send registryTxFunc) (*types.Receipt, error) {
+	ctx, cancel := context.WithTimeout(ctx, anchorTxTimeout)
+	defer cancel()
+
+	if s.Tx != nil {
+		record, err := s.Tx.Send(ctx, s.Auth.From, blockchain.TxKindAnchor, blockchain.TxBuilder(send))
+		if err != nil {
+			return nil, fmt.Errorf("发送锚定交易失败: %v", err)
+		}
+		_, receipt, err := s.Tx.Wait(ctx, common.HexToHash(record.TxHash))
+		if err != nil {
+			return nil, fmt.Errorf("锚定交易 %s 未成功: %v", record.TxHash, err)
+		}
+		return receipt, nil
+	}
+
+	opts := *s.Auth
+	opts.Context = ctx
+	opts.Nonce = nil
+	tx, err := send(&opts)
+	if err != nil {
+		return nil, fmt.Errorf("发送锚定交易失败: %v", err)
+	}
+	receipt, err := bind.WaitMined(ctx, s.Backend, tx)
+	if err != nil {
+		return nil, fmt.Errorf("等待锚定交易失败: %v", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("锚定交易执行失败: %s", tx.Hash().Hex())
+	}
+	return receipt, nil
+}
+
+// ethrIdentity 从did:ethr标识（可带网络名）中取出以太坊地址
+func ethrIdentity(didString string) (common.Address, bool) {
+	if !strings.HasPrefix(didString, "did:ethr:") {
+		return common.Address{}, false
+	}
+	parts := strings.Split(didString, ":")
+	account := parts[len(parts)-1]
+	if !common.IsHexAddress(account) {
+		return common.Address{}, false
+	}
+	return common.HexToAddress(account), true
+}
+
+// registryName 把名称右补零为bytes32，超长部分截断
+func registryName(name string) [32]byte {
+	var out [32]byte
+	copy(out[:], name)
+	return out
+}
+
+// findVerificationMethod 在文档中查找验证方法
+func findVerificationMethod(doc *models.DIDDocument, id string) *models.VerificationMethod {
+	for i := range doc.VerificationMethod {
+		if doc.VerificationMethod[i].ID == id {
+			return &doc.VerificationMethod[i]
+		}
+	}
+	return nil
+}
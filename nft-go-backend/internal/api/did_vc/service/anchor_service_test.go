@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/ABE/nft/nft-go-backend/internal/blockchain"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/pkg/didregistry"
+)
+
+// newTestDB 创建内存SQLite数据库并迁移给定模型
+func newTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	// 内存数据库每个连接相互独立，只保留一个连接
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("迁移测试表失败: %v", err)
+	}
+	return db
+}
+
+// simulatedChain 模拟链及其出块协程
+type simulatedChain struct {
+	backend *simulated.Backend
+	client  simulated.Client
+	auth    *bind.TransactOpts
+}
+
+// newSimulatedChain 创建资助了交易账户的模拟链，并持续出块直到测试结束；stubs中的地址部署只含STOP的代码
+func newSimulatedChain(t *testing.T, stubs ...common.Address) *simulatedChain {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	balance, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	alloc := types.GenesisAlloc{from: {Balance: balance}}
+	for _, stub := range stubs {
+		alloc[stub] = types.Account{Code: []byte{0x00}, Balance: new(big.Int)}
+	}
+	backend := simulated.NewBackend(alloc)
+
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				backend.Commit()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+		backend.Close()
+	})
+	return &simulatedChain{backend: backend, client: backend.Client(), auth: auth}
+}
+
+func TestDIDOperationsAnchoredInOrder(t *testing.T) {
+	db := newTestDB(t, &models.DIDAnchorRecord{})
+	// 注册表地址只有STOP指令，交易总会成功，足以检验写入顺序
+	registryAddress := common.HexToAddress("0x00000000000000000000000000000000000d1d01")
+	anchorAddress := common.HexToAddress("0x00000000000000000000000000000000000a0c01")
+	chain := newSimulatedChain(t, registryAddress, anchorAddress)
+	service, err := NewAnchorService(db, chain.client, chain.auth, registryAddress, anchorAddress, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	didString := "did:ethr:0x1111111111111111111111111111111111111111"
+	svc := models.Service{ID: didString + "#hub", Type: "LinkedDomains", ServiceEndpoint: "https://hub.example.com"}
+	withService := &models.DIDDocument{ID: didString, Service: []models.Service{svc}}
+	withoutService := &models.DIDDocument{ID: didString}
+
+	// 依次加入：添加服务、移除服务、停用
+	service.EnqueueDIDOperation(didString, 2, withoutService, withService,
+		&models.DIDOperationRequest{DID: didString, Operation: DIDOpAddService, Service: &svc})
+	service.EnqueueDIDOperation(didString, 3, withService, withoutService,
+		&models.DIDOperationRequest{DID: didString, Operation: DIDOpRemoveService, TargetID: svc.ID})
+	service.EnqueueDIDOperation(didString, 4, withoutService, withoutService,
+		&models.DIDOperationRequest{DID: didString, Operation: DIDOpDeactivate})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go service.RunDIDOperations(ctx)
+
+	var records []models.DIDAnchorRecord
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		records, err = service.GetDIDAnchorRecords(didString)
+		if err != nil {
+			t.Fatal(err)
+		}
+		anchored := 0
+		for _, record := range records {
+			if record.Status == "anchored" {
+				anchored++
+			} else if record.Status != "pending" {
+				t.Fatalf("版本 %d 写入失败: %s %s", record.VersionID, record.Status, record.FailureReason)
+			}
+		}
+		if anchored == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("等待DID变更上链超时: %+v", records)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(didregistry.DidregistryMetaData.ABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMethods := []string{"setAttribute", "revokeAttribute", "changeOwner"}
+	var lastBlock, lastIndex uint64
+	for i, record := range records {
+		if record.VersionID != uint(i+2) {
+			t.Fatalf("记录 %d 的版本为 %d", i, record.VersionID)
+		}
+		if len(record.TxHashes) != 1 {
+			t.Fatalf("版本 %d 的交易数为 %d", record.VersionID, len(record.TxHashes))
+		}
+		hash := common.HexToHash(record.TxHashes[0])
+		tx, _, err := chain.client.TransactionByHash(ctx, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		method, err := parsed.MethodById(tx.Data()[:4])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if method.Name != wantMethods[i] {
+			t.Fatalf("版本 %d 调用了 %s，期望 %s", record.VersionID, method.Name, wantMethods[i])
+		}
+
+		receipt, err := chain.client.TransactionReceipt(ctx, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, index := receipt.BlockNumber.Uint64(), uint64(receipt.TransactionIndex)
+		if i > 0 && (block < lastBlock || (block == lastBlock && index <= lastIndex)) {
+			t.Fatalf("版本 %d 在链上早于前一版本: block=%d index=%d", record.VersionID, block, index)
+		}
+		lastBlock, lastIndex = block, index
+	}
+}
+
+func TestCredentialBatchAnchoredOnChain(t *testing.T) {
+	artifacts := filepath.Join("..", "..", "..", "..", "..", "artifacts", "contracts")
+	registryBin, err := blockchain.LoadArtifactBytecode(filepath.Join(artifacts, "EthereumDIDRegistry.sol", "EthereumDIDRegistry.json"))
+	if err != nil {
+		t.Skipf("缺少Hardhat编译产物: %v", err)
+	}
+	anchorBin, err := blockchain.LoadArtifactBytecode(filepath.Join(artifacts, "CredentialAnchor.sol", "CredentialAnchor.json"))
+	if err != nil {
+		t.Skipf("缺少Hardhat编译产物: %v", err)
+	}
+
+	db := newTestDB(t, &models.CredentialAnchorEntry{}, &models.CredentialAnchorBatch{})
+	chain := newSimulatedChain(t)
+	ctx := context.Background()
+
+	registryAddress, anchorAddress, err := blockchain.DeployAnchorContracts(ctx, chain.auth, chain.client, registryBin, anchorBin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := NewAnchorService(db, chain.client, chain.auth, registryAddress, anchorAddress, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service.RecordCredentialEvent("urn:uuid:a", AnchorEventIssued, `{"id":"urn:uuid:a"}`)
+	service.RecordCredentialEvent("urn:uuid:b", AnchorEventIssued, `{"id":"urn:uuid:b"}`)
+	service.RecordCredentialEvent("urn:uuid:a", AnchorEventRevoked, `{"id":"urn:uuid:a"}`)
+
+	batch, err := service.AnchorPendingBatch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch == nil || batch.Status != "anchored" || batch.LeafCount != 3 {
+		t.Fatalf("批次未锚定: %+v", batch)
+	}
+
+	status, err := service.CheckCredential("urn:uuid:a", `{"id":"urn:uuid:a"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Anchored || !status.OnChainVerified || !status.ContentMatches || !status.RevokedOnChain {
+		t.Fatalf("凭证锚定状态不正确: %+v", status)
+	}
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"crypto/elliptic"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+)
+
+// did:web文档下载限制
+const (
+	didWebTimeout  = 10 * time.Second
+	didWebMaxBytes = 1 << 20
+)
+
+// ErrDIDDocumentNotFound did:web文档不存在
+var ErrDIDDocumentNotFound = errors.New("DID文档不存在")
+
+// KeyDIDResolver 解析did:key：文档完全由标识中的公钥计算得出，支持secp256k1、Ed25519和P-256
+type KeyDIDResolver struct{}
+
+// NewKeyDIDResolver 创建did:key解析器
+func NewKeyDIDResolver() *KeyDIDResolver {
+	return &KeyDIDResolver{}
+}
+
+// Method 返回DID方法名
+func (r *KeyDIDResolver) Method() string {
+	return "key"
+}
+
+// Resolve 解析did:key；文档不可变，versionTime被忽略
+func (r *KeyDIDResolver) Resolve(ctx context.Context, didString string, versionTime *time.Time) (*models.DIDResolutionResponse, bool, error) {
+	multibase := strings.TrimPrefix(didString, "did:key:")
+	if !strings.HasPrefix(multibase, "z") {
+		return newResolutionResponse(ResolutionErrorInvalidDID), false, nil
+	}
+	raw, err := util.Base58Decode(multibase[1:])
+	if err != nil || len(raw) < 3 {
+		return newResolutionResponse(ResolutionErrorInvalidDID), false, nil
+	}
+
+	methodID := didString + "#" + multibase
+	method := models.VerificationMethod{ID: methodID, Controller: didString}
+	contexts := []string{"https://www.w3.org/ns/did/v1"}
+
+	// 按multicodec前缀（无符号varint）区分密钥类型
+	switch {
+	case raw[0] == 0xe7 && raw[1] == 0x01 && len(raw) == 2+33:
+		method.Type = "EcdsaSecp256k1VerificationKey2019"
+		method.PublicKeyHex = hex.EncodeToString(raw[2:])
+		contexts = append(contexts, "https://w3id.org/security/suites/secp256k1-2019/v1")
+	case raw[0] == 0xed && raw[1] == 0x01 && len(raw) == 2+32:
+		method.Type = "Ed25519VerificationKey2020"
+		method.PublicKeyMultibase = multibase
+		contexts = append(contexts, "https://w3id.org/security/suites/ed25519-2020/v1")
+	case raw[0] == 0x80 && raw[1] == 0x24 && len(raw) == 2+33:
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), raw[2:])
+		if x == nil {
+			return newResolutionResponse(ResolutionErrorInvalidDID), false, nil
+		}
+		method.Type = "JsonWebKey2020"
+		method.PublicKeyJwk = map[string]interface{}{
+			"kty": "EC",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(x.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(y.FillBytes(make([]byte, 32))),
+		}
+		contexts = append(contexts, "https://w3id.org/security/suites/jws-2020/v1")
+	default:
+		return newResolutionResponse(ResolutionErrorInvalidDID), false, nil
+	}
+
+	result := newResolutionResponse("")
+	result.DIDResolutionMetadata.ContentType = "application/did+ld+json"
+	result.DIDDocument = &models.DIDDocument{
+		Context:              contexts,
+		ID:                   didString,
+		VerificationMethod:   []models.VerificationMethod{method},
+		Authentication:       []string{methodID},
+		AssertionMethod:      []string{methodID},
+		CapabilityInvocation: []string{methodID},
+		CapabilityDelegation: []string{methodID},
+	}
+	return result, true, nil
+}
+
+// WebDocumentFetcher 获取did:web文档的原始内容，文档不存在时返回ErrDIDDocumentNotFound
+type WebDocumentFetcher interface {
+	FetchDIDDocument(ctx context.Context, documentURL string) ([]byte, error)
+}
+
+// HTTPDocumentFetcher 通过HTTPS下载did:web文档；Client应只访问公网地址且不跟随重定向（见util.NewPublicHTTPClient），
+// 否则公开的解析接口可被用来让服务端访问内网
+type HTTPDocumentFetcher struct {
+	Client *http.Client
+}
+
+// FetchDIDDocument 下载文档，限制大小与超时
+func (f *HTTPDocumentFetcher) FetchDIDDocument(ctx context.Context, documentURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, documentURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/did+ld+json, application/json")
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载DID文档失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, ErrDIDDocumentNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载DID文档失败: HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, didWebMaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取DID文档失败: %v", err)
+	}
+	if len(body) > didWebMaxBytes {
+		return nil, fmt.Errorf("DID文档超过 %d 字节", didWebMaxBytes)
+	}
+	return body, nil
+}
+
+// DirDocumentFetcher 本地桩：从 <Root>/<主机>/<路径>/did.json 读取did:web文档，用于开发和测试
+type DirDocumentFetcher struct {
+	Root string
+}
+
+// FetchDIDDocument 读取本地文件
+func (f *DirDocumentFetcher) FetchDIDDocument(ctx context.Context, documentURL string) ([]byte, error) {
+	u, err := url.Parse(documentURL)
+	if err != nil {
+		return nil, err
+	}
+	root := filepath.Clean(f.Root)
+	path := filepath.Join(root, u.Host, filepath.FromSlash(u.Path))
+	if !strings.HasPrefix(path, root+string(filepath.Separator)) {
+		return nil, fmt.Errorf("DID文档路径无效")
+	}
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrDIDDocumentNotFound
+	}
+	return raw, err
+}
+
+// WebDIDResolver 解析did:web
+type WebDIDResolver struct {
+	Fetcher WebDocumentFetcher
+}
+
+// NewWebDIDResolver 创建did:web解析器，stubDir不为空时从本地目录读取文档而不访问网络
+func NewWebDIDResolver(stubDir string) *WebDIDResolver {
+	if stubDir != "" {
+		return &WebDIDResolver{Fetcher: &DirDocumentFetcher{Root: stubDir}}
+	}
+	return &WebDIDResolver{Fetcher: &HTTPDocumentFetcher{Client: util.NewPublicHTTPClient(didWebTimeout, 0)}}
+}
+
+// Method 返回DID方法名
+func (r *WebDIDResolver) Method() string {
+	return "web"
+}
+
+// Resolve 解析did:web；文档由域名持有者托管，不支持按历史时间解析，versionTime被忽略
+func (r *WebDIDResolver) Resolve(ctx context.Context, didString string, versionTime *time.Time) (*models.DIDResolutionResponse, bool, error) {
+	documentURL, err := DIDWebURL(didString)
+	if err != nil {
+		return newResolutionResponse(ResolutionErrorInvalidDID), false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, didWebTimeout)
+	defer cancel()
+	raw, err := r.Fetcher.FetchDIDDocument(ctx, documentURL)
+	if errors.Is(err, ErrDIDDocumentNotFound) {
+		return newResolutionResponse(ResolutionErrorNotFound), false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	doc, err := ParseDIDDocument(raw)
+	if err != nil || doc.ID != didString {
+		return newResolutionResponse(ResolutionErrorInvalidDocument), false, nil
+	}
+
+	result := newResolutionResponse("")
+	result.DIDResolutionMetadata.ContentType = "application/did+ld+json"
+	result.DIDDocument = doc
+	return result, true, nil
+}
+
+// DIDWebURL 按did:web规范把DID转换为文档地址：
+// did:web:example.com -> https://example.com/.well-known/did.json，
+// did:web:example.com:user:alice -> https://example.com/user/alice/did.json，端口中的冒号编码为%3A
+func DIDWebURL(didString string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(didString, "did:web:"), ":")
+	host, err := url.PathUnescape(parts[0])
+	if err != nil || host == "" || strings.ContainsAny(host, "/@?#") {
+		return "", fmt.Errorf("did:web主机名无效")
+	}
+
+	path := "/.well-known"
+	if len(parts) > 1 {
+		path = ""
+		for _, segment := range parts[1:] {
+			segment, err := url.PathUnescape(segment)
+			if err != nil || segment == "" || segment == "." || segment == ".." || strings.Contains(segment, "/") {
+				return "", fmt.Errorf("did:web路径无效")
+			}
+			path += "/" + segment
+		}
+	}
+	return "https://" + host + path + "/did.json", nil
+}
+
+// ParseDIDDocument 解析外部DID文档，兼容规范允许的多种写法：
+// @context和controller可为字符串，验证关系可内嵌验证方法，serviceEndpoint可为对象，ID可为相对片段
+func ParseDIDDocument(raw []byte) (*models.DIDDocument, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("DID文档不是有效的JSON: %v", err)
+	}
+	id, _ := doc["id"].(string)
+	if !strings.HasPrefix(id, "did:") {
+		return nil, fmt.Errorf("DID文档缺少id")
+	}
+	absolute := func(value interface{}) interface{} {
+		if ref, ok := value.(string); ok && strings.HasPrefix(ref, "#") {
+			return id + ref
+		}
+		return value
+	}
+
+	for _, key := range []string{"@context", "controller"} {
+		switch value := doc[key].(type) {
+		case string:
+			doc[key] = []interface{}{value}
+		case []interface{}:
+			strs := []interface{}{}
+			for _, item := range value {
+				if s, ok := item.(string); ok {
+					strs = append(strs, s)
+				}
+			}
+			doc[key] = strs
+		}
+	}
+
+	methods, _ := doc["verificationMethod"].([]interface{})
+	for _, relationship := range []string{"authentication", "assertionMethod", "keyAgreement", "capabilityInvocation", "capabilityDelegation"} {
+		entries, _ := doc[relationship].([]interface{})
+		refs := []interface{}{}
+		for _, entry := range entries {
+			if embedded, ok := entry.(map[string]interface{}); ok {
+				methods = append(methods, embedded)
+				entry = embedded["id"]
+			}
+			if ref, ok := absolute(entry).(string); ok {
+				refs = append(refs, ref)
+			}
+		}
+		if _, present := doc[relationship]; present {
+			doc[relationship] = refs
+		}
+	}
+	for _, item := range methods {
+		if method, ok := item.(map[string]interface{}); ok {
+			method["id"] = absolute(method["id"])
+		}
+	}
+	if methods == nil {
+		methods = []interface{}{}
+	}
+	doc["verificationMethod"] = methods
+
+	if services, ok := doc["service"].([]interface{}); ok {
+		for _, item := range services {
+			service, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			service["id"] = absolute(service["id"])
+			if endpoint, isString := service["serviceEndpoint"].(string); !isString && service["serviceEndpoint"] != nil {
+				encoded, _ := json.Marshal(service["serviceEndpoint"])
+				service["serviceEndpoint"] = string(encoded)
+			} else {
+				service["serviceEndpoint"] = endpoint
+			}
+			if _, isString := service["type"].(string); !isString {
+				encoded, _ := json.Marshal(service["type"])
+				service["type"] = string(encoded)
+			}
+		}
+	}
+	// 文档中的created/updated不是规范字段，以解析元数据为准
+	delete(doc, "created")
+	delete(doc, "updated")
+
+	normalized, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var parsed models.DIDDocument
+	if err := json.Unmarshal(normalized, &parsed); err != nil {
+		return nil, fmt.Errorf("DID文档格式无效: %v", err)
+	}
+	return &parsed, nil
+}
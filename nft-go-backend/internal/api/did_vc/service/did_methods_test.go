@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	auth "github.com/ABE/nft/nft-go-backend/internal/api/auth/service"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+)
+
+// stubFetcher 按文档地址返回预置内容的did:web桩，并记录请求过的地址
+type stubFetcher struct {
+	documents map[string]string
+	requested []string
+}
+
+func (f *stubFetcher) FetchDIDDocument(ctx context.Context, documentURL string) ([]byte, error) {
+	f.requested = append(f.requested, documentURL)
+	doc, ok := f.documents[documentURL]
+	if !ok {
+		return nil, ErrDIDDocumentNotFound
+	}
+	return []byte(doc), nil
+}
+
+// keyDID 由secp256k1公钥构造did:key
+func keyDID(key *ecdsa.PrivateKey) string {
+	raw := append([]byte{0xe7, 0x01}, crypto.CompressPubkey(&key.PublicKey)...)
+	return "did:key:z" + util.Base58Encode(raw)
+}
+
+// signAction 申请操作挑战并用钱包私钥签名
+func signAction(t *testing.T, challenges *auth.ChallengeService, key *ecdsa.PrivateKey, action string, params map[string]string) models.ActionProof {
+	t.Helper()
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	challenge, err := challenges.Issue(address, action, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := crypto.Sign(util.PersonalSignHash([]byte(challenge.Message)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature[64] += 27
+	return models.ActionProof{Address: address, Nonce: challenge.Nonce, Signature: hexutil.Encode(signature)}
+}
+
+func TestDIDWebURL(t *testing.T) {
+	tests := []struct {
+		did  string
+		want string
+	}{
+		{"did:web:example.com", "https://example.com/.well-known/did.json"},
+		{"did:web:example.com:user:alice", "https://example.com/user/alice/did.json"},
+		{"did:web:example.com%3A8443", "https://example.com:8443/.well-known/did.json"},
+		{"did:web:", ""},
+		{"did:web:example.com:..", ""},
+		{"did:web:user@example.com", ""},
+	}
+	for _, tt := range tests {
+		got, err := DIDWebURL(tt.did)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s 应无效，得到 %s", tt.did, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s -> %q, %v，期望 %s", tt.did, got, err, tt.want)
+		}
+	}
+}
+
+func TestKeyDIDResolver(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	didString := keyDID(key)
+
+	result, ok, err := NewKeyDIDResolver().Resolve(context.Background(), didString, nil)
+	if err != nil || !ok {
+		t.Fatalf("解析失败: %v %+v", err, result)
+	}
+	doc := result.DIDDocument
+	if doc.ID != didString || len(doc.VerificationMethod) != 1 || len(doc.AssertionMethod) != 1 {
+		t.Fatalf("文档不正确: %+v", doc)
+	}
+	address, ok := verificationMethodAddress(doc.VerificationMethod[0])
+	if !ok || address != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("验证方法地址为 %s", address.Hex())
+	}
+
+	result, ok, _ = NewKeyDIDResolver().Resolve(context.Background(), "did:key:z1111", nil)
+	if ok || result.DIDResolutionMetadata.Error != ResolutionErrorInvalidDID {
+		t.Fatalf("无效的did:key应返回invalidDid: %+v", result.DIDResolutionMetadata)
+	}
+}
+
+func TestWebDIDResolverWithStubFetcher(t *testing.T) {
+	fetcher := &stubFetcher{documents: map[string]string{
+		"https://example.com/.well-known/did.json": `{
+			"@context": "https://www.w3.org/ns/did/v1",
+			"id": "did:web:example.com",
+			"verificationMethod": [{"id": "#owner", "type": "EcdsaSecp256k1RecoveryMethod2020", "controller": "did:web:example.com",
+				"blockchainAccountId": "eip155:1:0x1111111111111111111111111111111111111111"}],
+			"assertionMethod": ["#owner"],
+			"service": [{"id": "#hub", "type": "LinkedDomains", "serviceEndpoint": {"origins": ["https://example.com"]}}]
+		}`,
+		"https://example.com/user/bob/did.json": `{"id": "did:web:example.com:user:mallory"}`,
+	}}
+	resolver := &WebDIDResolver{Fetcher: fetcher}
+
+	result, ok, err := resolver.Resolve(context.Background(), "did:web:example.com", nil)
+	if err != nil || !ok {
+		t.Fatalf("解析失败: %v %+v", err, result)
+	}
+	doc := result.DIDDocument
+	if doc.VerificationMethod[0].ID != "did:web:example.com#owner" || doc.AssertionMethod[0] != "did:web:example.com#owner" {
+		t.Fatalf("相对ID未补全: %+v", doc)
+	}
+	if doc.Service[0].ServiceEndpoint != `{"origins":["https://example.com"]}` {
+		t.Fatalf("对象形式的服务端点未序列化: %q", doc.Service[0].ServiceEndpoint)
+	}
+
+	tests := []struct {
+		did  string
+		want string
+	}{
+		{"did:web:example.com:user:alice", ResolutionErrorNotFound},
+		{"did:web:example.com:user:bob", ResolutionErrorInvalidDocument},
+		{"did:web:exa/mple.com", ResolutionErrorInvalidDID},
+	}
+	for _, tt := range tests {
+		result, ok, err := resolver.Resolve(context.Background(), tt.did, nil)
+		if err != nil || ok || result.DIDResolutionMetadata.Error != tt.want {
+			t.Errorf("%s: ok=%v err=%v 错误码=%q，期望 %s", tt.did, ok, err, result.DIDResolutionMetadata.Error, tt.want)
+		}
+	}
+
+	want := []string{
+		"https://example.com/.well-known/did.json",
+		"https://example.com/user/alice/did.json",
+		"https://example.com/user/bob/did.json",
+	}
+	if strings.Join(fetcher.requested, " ") != strings.Join(want, " ") {
+		t.Fatalf("请求的文档地址为 %v", fetcher.requested)
+	}
+}
+
+func TestHTTPDocumentFetcherRefusesLocalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"did:web:internal"}`))
+	}))
+	defer server.Close()
+
+	fetcher := NewWebDIDResolver("").Fetcher
+	_, err := fetcher.FetchDIDDocument(context.Background(), server.URL+"/.well-known/did.json")
+	if err == nil || !strings.Contains(err.Error(), util.ErrForbiddenAddress.Error()) {
+		t.Fatalf("应拒绝访问回环地址，得到 %v", err)
+	}
+}
+
+func TestIssueCredentialRequiresIssuerControl(t *testing.T) {
+	db := newTestDB(t, &models.DID{}, &models.Hospital{}, &models.IssuerAuthorization{}, &models.ActionChallenge{},
+		&models.CredentialSchema{}, &models.VerifiableCredential{})
+	challenges := auth.NewChallengeService(db, 5*time.Minute, nil)
+
+	issuerKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	subjectKey, _ := crypto.GenerateKey()
+	issuerDID := "did:web:university.example"
+	subjectDID := keyDID(subjectKey)
+
+	fetcher := &stubFetcher{documents: map[string]string{
+		"https://university.example/.well-known/did.json": `{
+			"id": "did:web:university.example",
+			"verificationMethod": [{"id": "#owner", "type": "EcdsaSecp256k1RecoveryMethod2020", "controller": "did:web:university.example",
+				"blockchainAccountId": "eip155:1:` + crypto.PubkeyToAddress(issuerKey.PublicKey).Hex() + `"}],
+			"assertionMethod": ["#owner"]
+		}`,
+	}}
+	service := &VCService{
+		DB:         db,
+		Challenges: challenges,
+		Resolver:   NewUniversalResolver(time.Minute, NewKeyDIDResolver(), &WebDIDResolver{Fetcher: fetcher}),
+	}
+
+	schema := models.CredentialSchema{
+		SchemaID:   "schema-degree",
+		Name:       "DegreeCredential",
+		Version:    "1.0",
+		SchemaJSON: `{"type":"object","properties":{"degree":{"type":"string"}},"required":["degree"]}`,
+	}
+	if err := db.Create(&schema).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	claims := json.RawMessage(`{"degree":"MD"}`)
+	params := map[string]string{
+		"issuer":     issuerDID,
+		"subject":    subjectDID,
+		"type":       "DegreeCredential",
+		"schemaId":   "schema-degree",
+		"claimsHash": auth.HashParam(claims),
+	}
+	issue := func(proof models.ActionProof, claims json.RawMessage) error {
+		_, err := service.IssueCredential(proof, issuerDID, subjectDID, "DegreeCredential", "schema-degree", claims)
+		return err
+	}
+
+	// 没有签名
+	if err := issue(models.ActionProof{}, claims); !errors.Is(err, auth.ErrActionUnauthorized) {
+		t.Fatalf("缺少签名应被拒绝，得到 %v", err)
+	}
+	// 不在assertionMethod中的钱包
+	if err := issue(signAction(t, challenges, otherKey, "IssueCredential", params), claims); !errors.Is(err, auth.ErrActionUnauthorized) {
+		t.Fatalf("非颁发者钱包应被拒绝，得到 %v", err)
+	}
+	// 签名后篡改声明
+	if err := issue(signAction(t, challenges, issuerKey, "IssueCredential", params), json.RawMessage(`{"degree":"PhD"}`)); !errors.Is(err, auth.ErrActionUnauthorized) {
+		t.Fatalf("篡改的声明应被拒绝，得到 %v", err)
+	}
+
+	proof := signAction(t, challenges, issuerKey, "IssueCredential", params)
+	if err := issue(proof, claims); err != nil {
+		t.Fatalf("颁发失败: %v", err)
+	}
+	if err := issue(proof, claims); !errors.Is(err, auth.ErrActionUnauthorized) {
+		t.Fatalf("重放签名应被拒绝，得到 %v", err)
+	}
+}
@@ -0,0 +1,559 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gorm.io/gorm"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+)
+
+// 支持的DID文档操作
+const (
+	DIDOpAddVerificationMethod    = "addVerificationMethod"
+	DIDOpRemoveVerificationMethod = "removeVerificationMethod"
+	DIDOpAddService               = "addService"
+	DIDOpRemoveService            = "removeService"
+	DIDOpSetControllers           = "setControllers"
+	DIDOpDeactivate               = "deactivate"
+)
+
+// 支持的验证方法类型及其所需的上下文
+var verificationMethodContexts = map[string]string{
+	"EcdsaSecp256k1RecoveryMethod2020":  secp256k1RecoveryContext,
+	"EcdsaSecp256k1VerificationKey2019": "https://w3id.org/security/suites/secp256k1-2019/v1",
+}
+
+// 验证方法可声明的用途
+var verificationRelationships = map[string]bool{
+	"authentication":       true,
+	"assertionMethod":      true,
+	"capabilityInvocation": true,
+	"capabilityDelegation": true,
+}
+
+// BuildDIDOperationMessage 构造控制者需要签名的DID操作消息，绑定当前版本号防止重放
+func BuildDIDOperationMessage(req *models.DIDOperationRequest, versionID uint) string {
+	lines := []string{"DIDUpdate", "did: " + req.DID, "operation: " + req.Operation}
+	switch req.Operation {
+	case DIDOpAddVerificationMethod:
+		if vm := req.VerificationMethod; vm != nil {
+			lines = append(lines,
+				"id: "+vm.ID,
+				"type: "+vm.Type,
+				"publicKeyHex: "+vm.PublicKeyHex,
+				"blockchainAccountId: "+vm.BlockchainAccountID,
+				"relationships: "+strings.Join(req.Relationships, ","))
+		}
+	case DIDOpAddService:
+		if svc := req.Service; svc != nil {
+			lines = append(lines, "id: "+svc.ID, "type: "+svc.Type, "serviceEndpoint: "+svc.ServiceEndpoint)
+		}
+	case DIDOpRemoveVerificationMethod, DIDOpRemoveService:
+		lines = append(lines, "id: "+req.TargetID)
+	case DIDOpSetControllers:
+		lines = append(lines, "controllers: "+strings.Join(req.Controllers, ","))
+	}
+	lines = append(lines, fmt.Sprintf("version: %d", versionID))
+	return strings.Join(lines, "\n")
+}
+
+// ApplyDIDOperation 校验当前控制者签名后执行DID文档变更，并追加一个新版本
+func (s *DIDService) ApplyDIDOperation(req *models.DIDOperationRequest) (*models.DIDOperationResponse, error) {
+	var did models.DID
+	if err := s.DB.Where("did_string = ?", req.DID).First(&did).Error; err != nil {
+		return nil, fmt.Errorf("DID不存在: %v", err)
+	}
+	if err := normalizeDIDOperation(req); err != nil {
+		return nil, err
+	}
+
+	var committed, previous *models.DIDDocumentVersion
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		current, err := s.currentVersion(tx, &did)
+		if err != nil {
+			return err
+		}
+		if current.Deactivated {
+			return fmt.Errorf("DID已停用，不能再变更")
+		}
+
+		// 签名必须来自变更前文档中的控制者密钥
+		message := BuildDIDOperationMessage(req, current.VersionID)
+		signer, err := s.authorizeDIDOperation(tx, &current.Document, message, req.Signature)
+		if err != nil {
+			return err
+		}
+
+		doc, err := cloneDocument(current.Document)
+		if err != nil {
+			return err
+		}
+
+		var changes map[string]interface{}
+		deactivated := req.Operation == DIDOpDeactivate
+		if deactivated {
+			doc = models.DIDDocument{Context: []string{"https://www.w3.org/ns/did/v1"}, ID: did.DIDString}
+			changes = map[string]interface{}{"status": "deactivated", "deactivated_at": time.Now()}
+		} else {
+			if err := applyDIDOperation(&doc, req); err != nil {
+				return err
+			}
+			// 变更后必须仍有可用的控制者密钥，避免DID失去控制
+			controllers, err := s.controllerAddresses(tx, &doc)
+			if err != nil {
+				return err
+			}
+			if len(controllers) == 0 {
+				return fmt.Errorf("变更后DID文档没有可用的控制者密钥（capabilityInvocation）")
+			}
+		}
+
+		if err := s.commitVersion(tx, &did, doc, req.Operation, signer, deactivated, changes); err != nil {
+			return err
+		}
+		committed = &models.DIDDocumentVersion{VersionID: did.VersionID, Document: doc, Deactivated: deactivated}
+		previous = current
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 启用锚定时按提交顺序异步把变更写入链上注册表，不影响链下变更结果
+	s.Anchor.EnqueueDIDOperation(did.DIDString, committed.VersionID, &previous.Document, &committed.Document, req)
+
+	return &models.DIDOperationResponse{
+		DID:         did.DIDString,
+		Operation:   req.Operation,
+		VersionID:   fmt.Sprintf("%d", committed.VersionID),
+		Deactivated: committed.Deactivated,
+		DIDDocument: &committed.Document,
+	}, nil
+}
+
+// DeactivateDID 停用DID，停用后解析结果不再包含任何验证方法
+func (s *DIDService) DeactivateDID(didString, signature string) (*models.DIDOperationResponse, error) {
+	return s.ApplyDIDOperation(&models.DIDOperationRequest{
+		DID:       didString,
+		Operation: DIDOpDeactivate,
+		Signature: signature,
+	})
+}
+
+// GetDIDHistory 获取DID文档的全部历史版本
+func (s *DIDService) GetDIDHistory(didString string) ([]models.DIDDocumentVersion, error) {
+	var did models.DID
+	if err := s.DB.Where("did_string = ?", didString).First(&did).Error; err != nil {
+		return nil, fmt.Errorf("DID不存在: %v", err)
+	}
+
+	var versions []models.DIDDocumentVersion
+	if err := s.DB.Where("did_string = ?", didString).Order("version_id ASC").Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("查询DID历史失败: %v", err)
+	}
+	if len(versions) == 0 {
+		current, err := s.currentVersion(s.DB, &did)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *current)
+	}
+	return versions, nil
+}
+
+// currentVersion 获取DID的最新文档版本；尚无历史记录的DID按DID记录生成
+func (s *DIDService) currentVersion(db *gorm.DB, did *models.DID) (*models.DIDDocumentVersion, error) {
+	var version models.DIDDocumentVersion
+	err := db.Where("did_string = ?", did.DIDString).Order("version_id DESC").First(&version).Error
+	if err == nil {
+		return &version, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("查询DID文档版本失败: %v", err)
+	}
+	return &models.DIDDocumentVersion{
+		Model:       gorm.Model{CreatedAt: did.UpdatedAt},
+		DIDString:   did.DIDString,
+		VersionID:   did.VersionID,
+		Document:    s.baseDocument(did),
+		Deactivated: did.Status != "active" || did.DeactivatedAt != nil,
+	}, nil
+}
+
+// findDocumentVersion 查找指定时间点有效的文档版本及其下一个版本；versionTime为空时返回最新版本
+func (s *DIDService) findDocumentVersion(did *models.DID, versionTime *time.Time) (*models.DIDDocumentVersion, *models.DIDDocumentVersion, error) {
+	if versionTime == nil {
+		current, err := s.currentVersion(s.DB, did)
+		return current, nil, err
+	}
+
+	var version models.DIDDocumentVersion
+	err := s.DB.Where("did_string = ? AND created_at <= ?", did.DIDString, *versionTime).
+		Order("version_id DESC").First(&version).Error
+	if err == gorm.ErrRecordNotFound {
+		// 早于第一条历史记录时取最早的版本
+		err = s.DB.Where("did_string = ?", did.DIDString).Order("version_id ASC").First(&version).Error
+		if err == gorm.ErrRecordNotFound {
+			current, err := s.currentVersion(s.DB, did)
+			return current, nil, err
+		}
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询DID文档版本失败: %v", err)
+	}
+
+	var next models.DIDDocumentVersion
+	err = s.DB.Where("did_string = ? AND version_id > ?", did.DIDString, version.VersionID).
+		Order("version_id ASC").First(&next).Error
+	if err == gorm.ErrRecordNotFound {
+		return &version, nil, nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("查询DID文档版本失败: %v", err)
+	}
+	return &version, &next, nil
+}
+
+// commitVersion 追加新的文档版本并递增DID的版本号（以版本号做乐观锁）。
+// 尚无历史记录的DID会先补记其初始文档作为第一个版本
+func (s *DIDService) commitVersion(tx *gorm.DB, did *models.DID, doc models.DIDDocument, operation, signer string, deactivated bool, changes map[string]interface{}) error {
+	var count int64
+	if err := tx.Model(&models.DIDDocumentVersion{}).Where("did_string = ?", did.DIDString).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询DID文档版本失败: %v", err)
+	}
+	if count == 0 {
+		baseline := models.DIDDocumentVersion{
+			Model:     gorm.Model{CreatedAt: did.CreatedAt},
+			DIDString: did.DIDString,
+			VersionID: did.VersionID,
+			Document:  s.baseDocument(did),
+			Operation: "create",
+			SignedBy:  did.WalletAddress,
+		}
+		if err := tx.Create(&baseline).Error; err != nil {
+			return fmt.Errorf("记录初始DID文档失败: %v", err)
+		}
+	}
+
+	nextVersion := did.VersionID + 1
+	updates := map[string]interface{}{"version_id": nextVersion}
+	for column, value := range changes {
+		updates[column] = value
+	}
+	result := tx.Model(&models.DID{}).
+		Where("id = ? AND version_id = ?", did.ID, did.VersionID).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("更新DID失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("DID文档已被并发修改，请基于最新版本重新签名")
+	}
+	did.VersionID = nextVersion
+
+	version := models.DIDDocumentVersion{
+		DIDString:   did.DIDString,
+		VersionID:   nextVersion,
+		Document:    doc,
+		Deactivated: deactivated,
+		Operation:   operation,
+		SignedBy:    signer,
+	}
+	if err := tx.Create(&version).Error; err != nil {
+		return fmt.Errorf("保存DID文档版本失败: %v", err)
+	}
+	return nil
+}
+
+// authorizeDIDOperation 从签名恢复签名者地址，并确认其为文档当前控制者密钥之一
+func (s *DIDService) authorizeDIDOperation(tx *gorm.DB, doc *models.DIDDocument, message, signature string) (string, error) {
+	pubKey, err := util.RecoverPersonalSignPublicKey(signature, message)
+	if err != nil {
+		return "", err
+	}
+	signer := crypto.PubkeyToAddress(*pubKey)
+
+	controllers, err := s.controllerAddresses(tx, doc)
+	if err != nil {
+		return "", err
+	}
+	if !controllers[signer] {
+		return "", fmt.Errorf("签名者 %s 不是该DID的控制者", signer.Hex())
+	}
+	return signer.Hex(), nil
+}
+
+// controllerAddresses 收集可控制文档的地址：控制者DID（未设置时为自身）中用于capabilityInvocation的验证方法
+func (s *DIDService) controllerAddresses(tx *gorm.DB, doc *models.DIDDocument) (map[common.Address]bool, error) {
+	controllers := doc.Controller
+	if len(controllers) == 0 {
+		controllers = []string{doc.ID}
+	}
+
+	addresses := make(map[common.Address]bool)
+	for _, controller := range controllers {
+		controllerDoc := doc
+		if controller != doc.ID {
+			var controllerDID models.DID
+			if err := tx.Where("did_string = ?", controller).First(&controllerDID).Error; err != nil {
+				return nil, fmt.Errorf("控制者DID %s 不存在", controller)
+			}
+			version, err := s.currentVersion(tx, &controllerDID)
+			if err != nil {
+				return nil, err
+			}
+			if version.Deactivated {
+				continue
+			}
+			controllerDoc = &version.Document
+		}
+
+		for _, ref := range controllerDoc.CapabilityInvocation {
+			for _, method := range controllerDoc.VerificationMethod {
+				if method.ID != ref {
+					continue
+				}
+				if address, ok := verificationMethodAddress(method); ok {
+					addresses[address] = true
+				}
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// verificationMethodAddress 计算验证方法对应的以太坊地址
+func verificationMethodAddress(method models.VerificationMethod) (common.Address, bool) {
+	if method.PublicKeyHex != "" {
+		if pubKey, err := parsePublicKeyHex(method.PublicKeyHex); err == nil {
+			return crypto.PubkeyToAddress(*pubKey), true
+		}
+	}
+	if method.BlockchainAccountID != "" {
+		parts := strings.Split(method.BlockchainAccountID, ":")
+		if account := parts[len(parts)-1]; common.IsHexAddress(account) {
+			return common.HexToAddress(account), true
+		}
+	}
+	return common.Address{}, false
+}
+
+// normalizeDIDOperation 校验操作参数，并把片段形式的ID（#key-2）补全为完整ID
+func normalizeDIDOperation(req *models.DIDOperationRequest) error {
+	fullID := func(id string) string {
+		if strings.HasPrefix(id, "#") {
+			return req.DID + id
+		}
+		return id
+	}
+
+	switch req.Operation {
+	case DIDOpAddVerificationMethod:
+		if req.VerificationMethod == nil || req.VerificationMethod.ID == "" {
+			return fmt.Errorf("缺少验证方法或其ID")
+		}
+		req.VerificationMethod.ID = fullID(req.VerificationMethod.ID)
+		if req.VerificationMethod.Controller == "" {
+			req.VerificationMethod.Controller = req.DID
+		}
+		if len(req.Relationships) == 0 {
+			req.Relationships = []string{"authentication"}
+		}
+	case DIDOpAddService:
+		if req.Service == nil || req.Service.ID == "" {
+			return fmt.Errorf("缺少服务或其ID")
+		}
+		req.Service.ID = fullID(req.Service.ID)
+	case DIDOpRemoveVerificationMethod, DIDOpRemoveService:
+		if req.TargetID == "" {
+			return fmt.Errorf("缺少要移除的ID")
+		}
+		req.TargetID = fullID(req.TargetID)
+	case DIDOpSetControllers, DIDOpDeactivate:
+	default:
+		return fmt.Errorf("不支持的DID操作: %s", req.Operation)
+	}
+	return nil
+}
+
+// applyDIDOperation 将操作应用到文档副本上
+func applyDIDOperation(doc *models.DIDDocument, req *models.DIDOperationRequest) error {
+	switch req.Operation {
+	case DIDOpAddVerificationMethod:
+		method := *req.VerificationMethod
+		if !strings.HasPrefix(method.ID, doc.ID+"#") {
+			return fmt.Errorf("验证方法ID必须以 %s# 开头", doc.ID)
+		}
+		context, ok := verificationMethodContexts[method.Type]
+		if !ok {
+			return fmt.Errorf("不支持的验证方法类型: %s", method.Type)
+		}
+		if method.PublicKeyHex == "" && method.BlockchainAccountID == "" {
+			return fmt.Errorf("验证方法需提供publicKeyHex或blockchainAccountId")
+		}
+		if method.PublicKeyHex != "" {
+			if _, err := parsePublicKeyHex(method.PublicKeyHex); err != nil {
+				return err
+			}
+		}
+		if method.BlockchainAccountID != "" {
+			if _, ok := verificationMethodAddress(models.VerificationMethod{BlockchainAccountID: method.BlockchainAccountID}); !ok {
+				return fmt.Errorf("blockchainAccountId格式无效")
+			}
+		}
+		for _, existing := range doc.VerificationMethod {
+			if existing.ID == method.ID {
+				return fmt.Errorf("验证方法 %s 已存在", method.ID)
+			}
+		}
+		for _, relationship := range req.Relationships {
+			if !verificationRelationships[relationship] {
+				return fmt.Errorf("不支持的验证方法用途: %s", relationship)
+			}
+		}
+
+		doc.VerificationMethod = append(doc.VerificationMethod, method)
+		for _, relationship := range req.Relationships {
+			refs := relationshipRefs(doc, relationship)
+			*refs = append(*refs, method.ID)
+		}
+		if !containsString(doc.Context, context) {
+			doc.Context = append(doc.Context, context)
+		}
+
+	case DIDOpRemoveVerificationMethod:
+		methods := doc.VerificationMethod[:0]
+		for _, method := range doc.VerificationMethod {
+			if method.ID != req.TargetID {
+				methods = append(methods, method)
+			}
+		}
+		if len(methods) == len(doc.VerificationMethod) {
+			return fmt.Errorf("验证方法 %s 不存在", req.TargetID)
+		}
+		doc.VerificationMethod = methods
+		for relationship := range verificationRelationships {
+			refs := relationshipRefs(doc, relationship)
+			*refs = removeString(*refs, req.TargetID)
+		}
+
+	case DIDOpAddService:
+		service := *req.Service
+		if !strings.HasPrefix(service.ID, doc.ID+"#") {
+			return fmt.Errorf("服务ID必须以 %s# 开头", doc.ID)
+		}
+		if service.Type == "" {
+			return fmt.Errorf("服务类型不能为空")
+		}
+		if endpoint, err := url.Parse(service.ServiceEndpoint); err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+			return fmt.Errorf("服务端点必须是完整的URL")
+		}
+		for _, existing := range doc.Service {
+			if existing.ID == service.ID {
+				return fmt.Errorf("服务 %s 已存在", service.ID)
+			}
+		}
+		doc.Service = append(doc.Service, service)
+
+	case DIDOpRemoveService:
+		services := doc.Service[:0]
+		for _, service := range doc.Service {
+			if service.ID != req.TargetID {
+				services = append(services, service)
+			}
+		}
+		if len(services) == len(doc.Service) {
+			return fmt.Errorf("服务 %s 不存在", req.TargetID)
+		}
+		doc.Service = services
+
+	case DIDOpSetControllers:
+		var controllers []string
+		for _, controller := range req.Controllers {
+			if !strings.HasPrefix(controller, "did:") {
+				return fmt.Errorf("控制者必须是DID: %s", controller)
+			}
+			if !containsString(controllers, controller) {
+				controllers = append(controllers, controller)
+			}
+		}
+		// 仅由自身控制时省略controller属性
+		if len(controllers) == 1 && controllers[0] == doc.ID {
+			controllers = nil
+		}
+		doc.Controller = controllers
+	}
+	return nil
+}
+
+// relationshipRefs 返回文档中对应用途的验证方法引用列表
+func relationshipRefs(doc *models.DIDDocument, relationship string) *[]string {
+	switch relationship {
+	case "assertionMethod":
+		return &doc.AssertionMethod
+	case "capabilityInvocation":
+		return &doc.CapabilityInvocation
+	case "capabilityDelegation":
+		return &doc.CapabilityDelegation
+	default:
+		return &doc.Authentication
+	}
+}
+
+// parsePublicKeyHex 解析压缩或未压缩格式的secp256k1公钥
+func parsePublicKeyHex(publicKeyHex string) (*ecdsa.PublicKey, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(publicKeyHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("publicKeyHex格式无效: %v", err)
+	}
+	switch len(raw) {
+	case 33:
+		return crypto.DecompressPubkey(raw)
+	case 65:
+		return crypto.UnmarshalPubkey(raw)
+	}
+	return nil, fmt.Errorf("publicKeyHex长度无效")
+}
+
+// cloneDocument 深拷贝DID文档，避免修改历史版本
+func cloneDocument(doc models.DIDDocument) (models.DIDDocument, error) {
+	var clone models.DIDDocument
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return clone, fmt.Errorf("复制DID文档失败: %v", err)
+	}
+	if err := json.Unmarshal(raw, &clone); err != nil {
+		return clone, fmt.Errorf("复制DID文档失败: %v", err)
+	}
+	return clone, nil
+}
+
+// containsString 判断字符串是否在列表中
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString 从列表中移除指定字符串
+func removeString(list []string, value string) []string {
+	var result []string
+	for _, item := range list {
+		if item != value {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -1,77 +1,203 @@
 package service
 
 import (
+	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"gorm.io/gorm"
 
 	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
 )
 
 // DIDService DID服务结构体
 type DIDService struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	ChainID  int64              // 用于生成blockchainAccountId的链ID
+	Anchor   *AnchorService     // 链上锚定服务，未启用时为nil
+	Resolver *UniversalResolver // 通用DID解析器，用于解析平台外的DID
 }
 
 // NewDIDService 创建新的DID服务
-func NewDIDService(db *gorm.DB) *DIDService {
+func NewDIDService(db *gorm.DB, chainID int64) *DIDService {
 	return &DIDService{
-		DB: db,
+		DB:      db,
+		ChainID: chainID,
 	}
 }
 
+// DID挑战有效期
+const didChallengeTTL = 5 * time.Minute
+
+// 以太坊签名恢复验证方法所需的上下文
+const secp256k1RecoveryContext = "https://w3id.org/security/suites/secp256k1recovery-2020/v2"
+
+// BuildDIDControlMessage 构造钱包证明控制DID时需要签名的消息
+func BuildDIDControlMessage(didString, nonce string) string {
+	return fmt.Sprintf("DIDControl\ndid: %s\nnonce: %s", didString, nonce)
+}
+
 // CreateDID 创建DID（已弃用，只保留兼容性）
 func (s *DIDService) CreateDID(method, controllerAddress, publicKey string) (*models.DIDDocument, error) {
 	return nil, fmt.Errorf("此方法已弃用，请使用钱包地址创建DID")
 }
 
-// ResolveDID 解析DID
-func (s *DIDService) ResolveDID(didString string) (*models.DIDDocument, error) {
+// ResolveDID 解析DID，返回DID文档及解析元数据；解析失败时错误码写入didResolutionMetadata.error。
+// versionTime不为空时返回该时间点有效的文档版本
+func (s *DIDService) ResolveDID(didString string, versionTime *time.Time) (*models.DIDResolutionResponse, error) {
+	result := &models.DIDResolutionResponse{
+		Context: "https://w3id.org/did-resolution/v1",
+		DIDResolutionMetadata: models.DIDResolutionMetadata{
+			Retrieved: time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+
+	if !strings.HasPrefix(didString, "did:") || len(strings.Split(didString, ":")) < 3 {
+		result.DIDResolutionMetadata.Error = "invalidDid"
+		return result, nil
+	}
+
 	// 查询DID记录
 	var did models.DID
-	if err := s.DB.Where("did_string = ?", didString).First(&did).Error; err != nil {
-		return nil, fmt.Errorf("DID不存在: %v", err)
+	if err := s.DB.Where("did_string = ?", didString).First(&did).Error; err == gorm.ErrRecordNotFound {
+		result.DIDResolutionMetadata.Error = "notFound"
+		return result, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("查询DID失败: %v", err)
+	}
+
+	// 指定时间早于DID创建时间时，该DID尚不存在
+	if versionTime != nil && versionTime.Before(did.CreatedAt) {
+		result.DIDResolutionMetadata.Error = "notFound"
+		return result, nil
+	}
+
+	version, next, err := s.findDocumentVersion(&did, versionTime)
+	if err != nil {
+		return nil, err
 	}
 
-	// 创建DID文档
+	result.DIDResolutionMetadata.ContentType = "application/did+ld+json"
+	result.DIDDocumentMetadata = models.DIDDocumentMetadata{
+		Created:     did.CreatedAt.UTC().Format(time.RFC3339),
+		Updated:     version.CreatedAt.UTC().Format(time.RFC3339),
+		Deactivated: version.Deactivated,
+		VersionID:   strconv.FormatUint(uint64(version.VersionID), 10),
+	}
+	if next != nil {
+		result.DIDDocumentMetadata.NextUpdate = next.CreatedAt.UTC().Format(time.RFC3339)
+		result.DIDDocumentMetadata.NextVersionID = strconv.FormatUint(uint64(next.VersionID), 10)
+	}
+
+	doc := version.Document
+	doc.Created = result.DIDDocumentMetadata.Created
+	doc.Updated = result.DIDDocumentMetadata.Updated
+	result.DIDDocument = &doc
+	return result, nil
+}
+
+// baseDocument 根据DID记录生成初始文档：以钱包地址发布签名恢复验证方法
+func (s *DIDService) baseDocument(did *models.DID) models.DIDDocument {
 	doc := models.DIDDocument{
 		Context: []string{"https://www.w3.org/ns/did/v1"},
 		ID:      did.DIDString,
-		Controller: []string{
-			did.WalletAddress,
-		},
-		VerificationMethod: []models.VerificationMethod{
-			{
-				ID:           fmt.Sprintf("%s#keys-1", did.DIDString),
-				Type:         "EcdsaSecp256k1VerificationKey2019",
-				Controller:   did.DIDString,
-				PublicKeyHex: "", // 这里应该从数据库获取公钥
-			},
-		},
-		Authentication: []string{
-			fmt.Sprintf("%s#keys-1", did.DIDString),
-		},
-		Created: did.CreatedAt.UTC().Format(time.RFC3339),
-		Updated: did.UpdatedAt.UTC().Format(time.RFC3339),
 	}
 
-	return &doc, nil
+	// 已停用的DID不再发布任何验证方法
+	if did.Status != "active" || did.DeactivatedAt != nil {
+		return doc
+	}
+
+	doc.Context = append(doc.Context, secp256k1RecoveryContext)
+	controllerKey := fmt.Sprintf("%s#controller", did.DIDString)
+	method := models.VerificationMethod{
+		ID:           controllerKey,
+		Type:         "EcdsaSecp256k1RecoveryMethod2020",
+		Controller:   did.DIDString,
+		PublicKeyHex: did.PublicKeyHex,
+	}
+	if common.IsHexAddress(did.WalletAddress) {
+		method.BlockchainAccountID = fmt.Sprintf("eip155:%d:%s", s.ChainID, common.HexToAddress(did.WalletAddress).Hex())
+	}
+	doc.VerificationMethod = []models.VerificationMethod{method}
+	doc.Authentication = []string{controllerKey}
+	doc.AssertionMethod = []string{controllerKey}
+	doc.CapabilityInvocation = []string{controllerKey}
+	return doc
 }
 
-// UpdateDID 更新DID（已弃用）
-func (s *DIDService) UpdateDID(didString, controllerAddress, publicKey string) (*models.DIDDocument, error) {
-	return nil, fmt.Errorf("此方法已弃用")
+// CreateDIDChallenge 为钱包下发创建DID的一次性挑战
+func (s *DIDService) CreateDIDChallenge(walletAddress string) (*models.DIDChallenge, string, error) {
+	if !common.IsHexAddress(walletAddress) {
+		return nil, "", fmt.Errorf("钱包地址无效")
+	}
+
+	challenge := models.DIDChallenge{
+		WalletAddress: walletAddress,
+		Nonce:         generateChallenge(),
+		ExpiresAt:     time.Now().Add(didChallengeTTL),
+	}
+	if err := s.DB.Create(&challenge).Error; err != nil {
+		return nil, "", fmt.Errorf("保存DID挑战失败: %v", err)
+	}
+
+	didID := fmt.Sprintf("did:ethr:%s", walletAddress)
+	return &challenge, BuildDIDControlMessage(didID, challenge.Nonce), nil
 }
 
-// RevokeDID 撤销DID（已弃用）
-func (s *DIDService) RevokeDID(didString, controllerAddress string) error {
-	return fmt.Errorf("此方法已弃用")
+// recoverControllerKey 校验钱包对挑战的签名，返回恢复出的压缩公钥
+func (s *DIDService) recoverControllerKey(walletAddress, didString, nonce, signature string) (*models.DIDChallenge, string, error) {
+	if nonce == "" || signature == "" {
+		return nil, "", fmt.Errorf("需要先获取挑战并提交钱包签名")
+	}
+
+	var challenge models.DIDChallenge
+	if err := s.DB.Where("nonce = ?", nonce).First(&challenge).Error; err != nil {
+		return nil, "", fmt.Errorf("挑战值不存在")
+	}
+	if !strings.EqualFold(challenge.WalletAddress, walletAddress) {
+		return nil, "", fmt.Errorf("挑战值不属于该钱包")
+	}
+	if challenge.UsedAt != nil {
+		return nil, "", fmt.Errorf("挑战值已被使用")
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		return nil, "", fmt.Errorf("挑战值已过期")
+	}
+
+	pubKey, err := util.RecoverPersonalSignPublicKey(signature, BuildDIDControlMessage(didString, nonce))
+	if err != nil {
+		return nil, "", err
+	}
+	if crypto.PubkeyToAddress(*pubKey) != common.HexToAddress(walletAddress) {
+		return nil, "", fmt.Errorf("签名者与钱包地址不匹配")
+	}
+
+	return &challenge, hex.EncodeToString(crypto.CompressPubkey(pubKey)), nil
+}
+
+// consumeDIDChallenge 在事务中一次性消费挑战值
+func consumeDIDChallenge(tx *gorm.DB, challenge *models.DIDChallenge) error {
+	result := tx.Model(&models.DIDChallenge{}).
+		Where("id = ? AND used_at IS NULL", challenge.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("挑战值已被使用")
+	}
+	return nil
 }
 
-// CreateDIDFromWallet 从钱包地址创建DID
-func (s *DIDService) CreateDIDFromWallet(walletAddress string) (*models.DID, bool, error) {
+// CreateDIDFromWallet 从钱包地址创建DID，钱包需对挑战签名以证明控制权并登记公钥。
+// DID已存在时直接返回；若尚未登记公钥且提供了签名，则补充登记公钥
+func (s *DIDService) CreateDIDFromWallet(walletAddress, nonce, signature string) (*models.DID, bool, error) {
 	// 验证钱包地址
 	if walletAddress == "" {
 		return nil, false, fmt.Errorf("钱包地址不能为空")
@@ -81,43 +207,74 @@ func (s *DIDService) CreateDIDFromWallet(walletAddress string) (*models.DID, boo
 	var existingDID models.DID
 	result := s.DB.Where("wallet_address = ? AND status = ?", walletAddress, "active").First(&existingDID)
 	if result.Error == nil {
-		// 已存在
+		if existingDID.PublicKeyHex != "" || signature == "" {
+			return &existingDID, true, nil
+		}
+		challenge, publicKeyHex, err := s.recoverControllerKey(walletAddress, existingDID.DIDString, nonce, signature)
+		if err != nil {
+			return nil, true, err
+		}
+		err = s.DB.Transaction(func(tx *gorm.DB) error {
+			if err := consumeDIDChallenge(tx, challenge); err != nil {
+				return err
+			}
+			current, err := s.currentVersion(tx, &existingDID)
+			if err != nil {
+				return err
+			}
+			doc := current.Document
+			for i := range doc.VerificationMethod {
+				if doc.VerificationMethod[i].ID == existingDID.DIDString+"#controller" {
+					doc.VerificationMethod[i].PublicKeyHex = publicKeyHex
+				}
+			}
+			return s.commitVersion(tx, &existingDID, doc, "registerKey", existingDID.WalletAddress, false, map[string]interface{}{"public_key_hex": publicKeyHex})
+		})
+		if err != nil {
+			return nil, true, fmt.Errorf("登记公钥失败: %v", err)
+		}
+		s.DB.First(&existingDID, existingDID.ID)
 		return &existingDID, true, nil
 	} else if result.Error != gorm.ErrRecordNotFound {
 		// 查询出错
 		return nil, false, fmt.Errorf("查询DID失败: %v", result.Error)
 	}
 
-	// 不存在，创建新DID
+	// 不存在，校验签名后创建新DID
 	didID := fmt.Sprintf("did:ethr:%s", walletAddress)
+	challenge, publicKeyHex, err := s.recoverControllerKey(walletAddress, didID, nonce, signature)
+	if err != nil {
+		return nil, false, err
+	}
 
-	// 确保所有必需字段都有值
 	newDID := models.DID{
 		DIDString:     didID,
 		WalletAddress: walletAddress,
 		Status:        "active",
+		PublicKeyHex:  publicKeyHex,
+		VersionID:     1,
 	}
 
-	// 使用事务确保数据一致性
-	tx := s.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := consumeDIDChallenge(tx, challenge); err != nil {
+			return err
 		}
-	}()
-
-	if err := tx.Error; err != nil {
-		return nil, false, fmt.Errorf("开始事务失败: %v", err)
-	}
-
-	if err := tx.Create(&newDID).Error; err != nil {
-		tx.Rollback()
+		if err := tx.Create(&newDID).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.DIDDocumentVersion{
+			DIDString: newDID.DIDString,
+			VersionID: newDID.VersionID,
+			Document:  s.baseDocument(&newDID),
+			Operation: "create",
+			SignedBy:  walletAddress,
+		}).Error
+	})
+	if err != nil {
 		return nil, false, fmt.Errorf("创建DID失败: %v", err)
 	}
-
-	if err := tx.Commit().Error; err != nil {
-		return nil, false, fmt.Errorf("提交事务失败: %v", err)
-	}
+	// 创建前可能按隐式文档缓存过该地址
+	s.Resolver.Invalidate(newDID.DIDString)
 
 	return &newDID, false, nil
 }
@@ -154,119 +311,21 @@ func (s *DIDService) GetAllDIDs() ([]models.DID, error) {
 	return dids, nil
 }
 
-// CreateDoctorDID 创建医生DID
+// CreateDoctorDID 创建医生DID（医生角色的主体档案）
 func (s *DIDService) CreateDoctorDID(walletAddress, name, licenseNumber string) (*models.Doctor, error) {
 	// 验证参数
 	if walletAddress == "" || name == "" || licenseNumber == "" {
 		return nil, fmt.Errorf("钱包地址、姓名和执业编号不能为空")
 	}
 
-	// 首先检查是否已存在医生记录
-	var existingDoctor models.Doctor
-	result := s.DB.Where("wallet_address = ? AND status = ?", walletAddress, "active").First(&existingDoctor)
-	if result.Error == nil {
-		// 已存在，直接返回
-		return &existingDoctor, nil
-	} else if result.Error != gorm.ErrRecordNotFound {
-		// 查询出错
-		return nil, fmt.Errorf("查询医生DID失败: %v", result.Error)
-	}
-
-	// 生成DID标识符
-	didID := fmt.Sprintf("did:ethr:%s", walletAddress)
-
-	// 检查是否已存在相同的DID记录
-	var existingDID models.DID
-	result = s.DB.Where("did_string = ? AND status = ?", didID, "active").First(&existingDID)
-	if result.Error == nil {
-		// DID已存在，检查是否有对应的医生记录
-		result = s.DB.Where("did_string = ? AND status = ?", didID, "active").First(&existingDoctor)
-		if result.Error == nil {
-			// 医生记录也存在，直接返回
-			return &existingDoctor, nil
-		}
-		// 只有DID存在但没有医生记录，创建医生记录
-		newDoctor := models.Doctor{
-			DIDString:     didID,
-			WalletAddress: walletAddress,
-			Name:          name,
-			LicenseNumber: licenseNumber,
-			Status:        "active",
-		}
-		if err := s.DB.Create(&newDoctor).Error; err != nil {
-			return nil, fmt.Errorf("创建医生记录失败: %v", err)
-		}
-		return &newDoctor, nil
+	profile, err := NewSubjectService(s.DB).CreateProfile(walletAddress, "doctor", name, "", map[string]interface{}{
+		"licenseNumber": licenseNumber,
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	// 使用事务确保数据一致性
-	tx := s.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	if err := tx.Error; err != nil {
-		return nil, fmt.Errorf("开始事务失败: %v", err)
-	}
-
-	// 在事务中再次检查是否存在（防止并发问题）
-	var checkDoctor models.Doctor
-	result = tx.Where("wallet_address = ? AND status = ?", walletAddress, "active").First(&checkDoctor)
-	if result.Error == nil {
-		// 在事务执行期间，其他请求已经创建了医生记录
-		tx.Rollback()
-		return &checkDoctor, nil
-	}
-
-	// 创建DID记录
-	newDID := models.DID{
-		DIDString:     didID,
-		WalletAddress: walletAddress,
-		Status:        "active",
-	}
-
-	if err := tx.Create(&newDID).Error; err != nil {
-		tx.Rollback()
-		// 检查是否是重复键错误
-		if isDuplicateKeyError(err) {
-			// 可能是并发创建导致的重复，尝试获取已存在的记录
-			var existingDoc models.Doctor
-			if findErr := s.DB.Where("wallet_address = ? AND status = ?", walletAddress, "active").First(&existingDoc).Error; findErr == nil {
-				return &existingDoc, nil
-			}
-		}
-		return nil, fmt.Errorf("创建DID记录失败: %v", err)
-	}
-
-	// 创建医生记录
-	newDoctor := models.Doctor{
-		DIDString:     didID,
-		WalletAddress: walletAddress,
-		Name:          name,
-		LicenseNumber: licenseNumber,
-		Status:        "active",
-	}
-
-	if err := tx.Create(&newDoctor).Error; err != nil {
-		tx.Rollback()
-		// 检查是否是重复键错误
-		if isDuplicateKeyError(err) {
-			// 可能是并发创建导致的重复，尝试获取已存在的记录
-			var existingDoc models.Doctor
-			if findErr := s.DB.Where("wallet_address = ? AND status = ?", walletAddress, "active").First(&existingDoc).Error; findErr == nil {
-				return &existingDoc, nil
-			}
-		}
-		return nil, fmt.Errorf("创建医生记录失败: %v", err)
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return nil, fmt.Errorf("提交事务失败: %v", err)
-	}
-
-	return &newDoctor, nil
+	doctor := profile.ToDoctor()
+	return &doctor, nil
 }
 
 // isDuplicateKeyError 检查是否是重复键错误
@@ -282,27 +341,33 @@ func isDuplicateKeyError(err error) bool {
 
 // GetDoctorByDID 通过DID获取医生信息
 func (s *DIDService) GetDoctorByDID(doctorDID string) (*models.Doctor, error) {
-	var doctor models.Doctor
-	if err := s.DB.Where("did_string = ? AND status = ?", doctorDID, "active").First(&doctor).Error; err != nil {
+	profile, err := NewSubjectService(s.DB).GetProfile(doctorDID, "doctor")
+	if err != nil {
 		return nil, fmt.Errorf("未找到医生: %v", err)
 	}
+	doctor := profile.ToDoctor()
 	return &doctor, nil
 }
 
 // GetDoctorByWallet 通过钱包地址获取医生信息
 func (s *DIDService) GetDoctorByWallet(walletAddress string) (*models.Doctor, error) {
-	var doctor models.Doctor
-	if err := s.DB.Where("wallet_address = ? AND status = ?", walletAddress, "active").First(&doctor).Error; err != nil {
+	profile, err := NewSubjectService(s.DB).GetProfileByWallet(walletAddress, "doctor")
+	if err != nil {
 		return nil, fmt.Errorf("未找到医生: %v", err)
 	}
+	doctor := profile.ToDoctor()
 	return &doctor, nil
 }
 
 // GetAllDoctors 获取所有医生DID
 func (s *DIDService) GetAllDoctors() ([]models.Doctor, error) {
-	var doctors []models.Doctor
-	if err := s.DB.Where("status = ?", "active").Find(&doctors).Error; err != nil {
+	profiles, err := NewSubjectService(s.DB).ListProfiles("doctor")
+	if err != nil {
 		return nil, fmt.Errorf("获取医生列表失败: %v", err)
 	}
+	doctors := make([]models.Doctor, 0, len(profiles))
+	for _, profile := range profiles {
+		doctors = append(doctors, profile.ToDoctor())
+	}
 	return doctors, nil
 }
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+
+	auth "github.com/ABE/nft/nft-go-backend/internal/api/auth/service"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// HospitalService 受信任颁发机构（医院）登记服务
+type HospitalService struct {
+	DB         *gorm.DB
+	Challenges *auth.ChallengeService // 登记表的写操作需要平台管理员对一次性挑战的签名
+}
+
+// NewHospitalService 创建新的医院登记服务
+func NewHospitalService(db *gorm.DB, challenges *auth.ChallengeService) *HospitalService {
+	return &HospitalService{
+		DB:         db,
+		Challenges: challenges,
+	}
+}
+
+// OnboardHospital 登记新的医院并授予其可颁发的凭证类型
+func (s *HospitalService) OnboardHospital(req models.OnboardHospitalRequest) (*models.HospitalResponse, error) {
+	if !common.IsHexAddress(req.WalletAddress) {
+		return nil, fmt.Errorf("无效的医院钱包地址")
+	}
+	if len(req.CredentialTypes) == 0 {
+		return nil, fmt.Errorf("至少需要授权一种凭证类型")
+	}
+	didString := req.DIDString
+	if didString == "" {
+		didString = fmt.Sprintf("did:ethr:%s", req.WalletAddress)
+	}
+
+	err := s.Challenges.VerifyAdmin(req.Proof, "OnboardHospital", map[string]string{
+		"wallet":          strings.ToLower(req.WalletAddress),
+		"name":            req.Name,
+		"did":             didString,
+		"credentialTypes": strings.Join(req.CredentialTypes, ","),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	s.DB.Model(&models.Hospital{}).
+		Where("did_string = ? OR wallet_address = ?", didString, req.WalletAddress).
+		Count(&count)
+	if count > 0 {
+		return nil, fmt.Errorf("医院DID或钱包地址已登记")
+	}
+
+	hospital := models.Hospital{
+		DIDString:     didString,
+		WalletAddress: req.WalletAddress,
+		Name:          req.Name,
+		Status:        "active",
+		OnboardedBy:   req.Proof.Address,
+	}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&hospital).Error; err != nil {
+			return err
+		}
+		for _, credentialType := range req.CredentialTypes {
+			authorization := models.IssuerAuthorization{
+				IssuerDID:      didString,
+				CredentialType: credentialType,
+				GrantedBy:      req.Proof.Address,
+			}
+			if err := tx.Create(&authorization).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("登记医院失败: %v", err)
+	}
+
+	return s.GetHospital(didString)
+}
+
+// SetHospitalStatus 暂停或恢复医院，暂停后其颁发的凭证验证均不通过
+func (s *HospitalService) SetHospitalStatus(req models.HospitalStatusRequest, suspend bool) (*models.HospitalResponse, error) {
+	action := "ReinstateHospital"
+	if suspend {
+		action = "SuspendHospital"
+	}
+	params := map[string]string{"hospital": req.HospitalDID, "reason": req.Reason}
+	if err := s.Challenges.VerifyAdmin(req.Proof, action, params); err != nil {
+		return nil, err
+	}
+
+	var hospital models.Hospital
+	if err := s.DB.Where("did_string = ?", req.HospitalDID).First(&hospital).Error; err != nil {
+		return nil, fmt.Errorf("医院不存在: %v", err)
+	}
+
+	if suspend {
+		now := time.Now()
+		hospital.Status = "suspended"
+		hospital.SuspendedAt = &now
+		hospital.SuspensionReason = req.Reason
+	} else {
+		hospital.Status = "active"
+		hospital.SuspendedAt = nil
+		hospital.SuspensionReason = ""
+	}
+	if err := s.DB.Save(&hospital).Error; err != nil {
+		return nil, fmt.Errorf("更新医院状态失败: %v", err)
+	}
+
+	return s.GetHospital(req.HospitalDID)
+}
+
+// SetIssuerAuthorization 授予或撤销医院颁发某类凭证的权限
+func (s *HospitalService) SetIssuerAuthorization(req models.IssuerAuthorizationRequest, grant bool) (*models.HospitalResponse, error) {
+	action := "RevokeIssuerAuthorization"
+	if grant {
+		action = "GrantIssuerAuthorization"
+	}
+	params := map[string]string{"hospital": req.HospitalDID, "credentialType": req.CredentialType}
+	if err := s.Challenges.VerifyAdmin(req.Proof, action, params); err != nil {
+		return nil, err
+	}
+
+	var hospital models.Hospital
+	if err := s.DB.Where("did_string = ?", req.HospitalDID).First(&hospital).Error; err != nil {
+		return nil, fmt.Errorf("医院不存在: %v", err)
+	}
+
+	if grant {
+		authorization := models.IssuerAuthorization{
+			IssuerDID:      req.HospitalDID,
+			CredentialType: req.CredentialType,
+			GrantedBy:      req.Proof.Address,
+		}
+		err := s.DB.Where("issuer_did = ? AND credential_type = ?", req.HospitalDID, req.CredentialType).
+			FirstOrCreate(&authorization).Error
+		if err != nil {
+			return nil, fmt.Errorf("授予凭证类型授权失败: %v", err)
+		}
+	} else {
+		err := s.DB.Unscoped().
+			Where("issuer_did = ? AND credential_type = ?", req.HospitalDID, req.CredentialType).
+			Delete(&models.IssuerAuthorization{}).Error
+		if err != nil {
+			return nil, fmt.Errorf("撤销凭证类型授权失败: %v", err)
+		}
+	}
+
+	return s.GetHospital(req.HospitalDID)
+}
+
+// GetHospital 获取医院及其授权的凭证类型
+func (s *HospitalService) GetHospital(hospitalDID string) (*models.HospitalResponse, error) {
+	var hospital models.Hospital
+	if err := s.DB.Where("did_string = ?", hospitalDID).First(&hospital).Error; err != nil {
+		return nil, fmt.Errorf("医院不存在: %v", err)
+	}
+	return s.toResponse(hospital)
+}
+
+// ListHospitals 列出医院，可按状态过滤
+func (s *HospitalService) ListHospitals(status string) ([]models.HospitalResponse, error) {
+	var hospitals []models.Hospital
+	query := s.DB
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&hospitals).Error; err != nil {
+		return nil, fmt.Errorf("查询医院列表失败: %w", err)
+	}
+
+	responses := make([]models.HospitalResponse, 0, len(hospitals))
+	for _, hospital := range hospitals {
+		response, err := s.toResponse(hospital)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, *response)
+	}
+	return responses, nil
+}
+
+// toResponse 构造医院信息响应
+func (s *HospitalService) toResponse(hospital models.Hospital) (*models.HospitalResponse, error) {
+	credentialTypes := []string{}
+	err := s.DB.Model(&models.IssuerAuthorization{}).
+		Where("issuer_did = ?", hospital.DIDString).
+		Order("credential_type").
+		Pluck("credential_type", &credentialTypes).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询凭证类型授权失败: %v", err)
+	}
+	return &models.HospitalResponse{
+		Hospital:        hospital,
+		CredentialTypes: credentialTypes,
+	}, nil
+}
+
+// issuerTrustProblem 检查颁发机构当前是否可信并被授权颁发该类凭证，可信时返回空字符串；
+// mustBeRegistered为false时，未登记为医院的颁发者不受登记表约束
+func issuerTrustProblem(db *gorm.DB, issuerDID, credentialType string, mustBeRegistered bool) string {
+	var hospital models.Hospital
+	err := db.Where("did_string = ?", issuerDID).First(&hospital).Error
+	if err == gorm.ErrRecordNotFound {
+		if mustBeRegistered {
+			return "颁发者不是已登记的医院"
+		}
+		return ""
+	}
+	if err != nil {
+		return fmt.Sprintf("查询颁发机构失败: %v", err)
+	}
+
+	if hospital.Status != "active" {
+		return "颁发机构已被暂停"
+	}
+
+	var count int64
+	db.Model(&models.IssuerAuthorization{}).
+		Where("issuer_did = ? AND credential_type = ?", issuerDID, credentialType).
+		Count(&count)
+	if count == 0 {
+		return fmt.Sprintf("颁发机构未被授权颁发 %s 类型的凭证", credentialType)
+	}
+	return ""
+}
+
+// authorizeHospitalSigner 校验操作由颁发机构登记的医院钱包签名，issuerDID必须是已登记的医院
+func authorizeHospitalSigner(db *gorm.DB, challenges *auth.ChallengeService, proof models.ActionProof, issuerDID, action string, params map[string]string) error {
+	var hospital models.Hospital
+	if err := db.Where("did_string = ?", issuerDID).First(&hospital).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: 颁发者不是已登记的医院", auth.ErrActionUnauthorized)
+		}
+		return fmt.Errorf("查询颁发机构失败: %v", err)
+	}
+	if !strings.EqualFold(hospital.WalletAddress, proof.Address) {
+		return fmt.Errorf("%w: 签名者不是医院登记的钱包", auth.ErrActionUnauthorized)
+	}
+	return challenges.Verify(proof, action, params)
+}
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+)
+
+// 展示挑战默认有效期
+const defaultPresentationRequestTTL = 5 * time.Minute
+
+// 展示挑战最长有效期
+const maxPresentationRequestTTL = 24 * time.Hour
+
+// presentedCredential 展示中引用的凭证（通用凭证或主体凭证）
+type presentedCredential struct {
+	ID        string
+	Type      string
+	IssuerDID string
+	Status    string
+	ExpiresAt time.Time
+	Claims    map[string]interface{}
+	// 主体凭证必须由已登记的机构颁发
+	RequireRegisteredIssuer bool
+}
+
+// BuildPresentationMessage 构造持有者钱包需要签名的展示消息
+func BuildPresentationMessage(holderDID, nonce, domain string, credentialIDs []string) string {
+	return fmt.Sprintf("VerifiablePresentation\nholder: %s\ncredentials: %s\nnonce: %s\ndomain: %s",
+		holderDID, strings.Join(credentialIDs, ","), nonce, domain)
+}
+
+// CreatePresentationRequest 验证者发起展示请求，下发一次性挑战值
+func (s *VCService) CreatePresentationRequest(verifierDID, domain string, credentialTypes []string, ttl time.Duration) (*models.PresentationRequest, error) {
+	if domain == "" {
+		return nil, fmt.Errorf("验证者域不能为空")
+	}
+	if ttl <= 0 {
+		ttl = defaultPresentationRequestTTL
+	}
+	if ttl > maxPresentationRequestTTL {
+		ttl = maxPresentationRequestTTL
+	}
+
+	// 顺带删除已过期且未绑定表示的挑战，已绑定的保留用于验证表示
+	s.DB.Unscoped().
+		Where("status = ? AND presentation_id = ? AND expires_at < ?", "pending", "", time.Now()).
+		Delete(&models.PresentationRequest{})
+	s.DB.Model(&models.PresentationRequest{}).
+		Where("status = ? AND expires_at < ?", "pending", time.Now()).
+		Update("status", "expired")
+
+	request := models.PresentationRequest{
+		Nonce:           generateChallenge(),
+		VerifierDID:     verifierDID,
+		Domain:          domain,
+		CredentialTypes: credentialTypes,
+		ExpiresAt:       time.Now().Add(ttl),
+		Status:          "pending",
+	}
+
+	if err := s.DB.Create(&request).Error; err != nil {
+		return nil, fmt.Errorf("保存展示请求失败: %v", err)
+	}
+
+	return &request, nil
+}
+
+// CreatePresentation 持有者基于验证者的挑战值创建可验证表示
+func (s *VCService) CreatePresentation(holderDID, verifierDID string, credentialIDs []string, purpose, nonce, signature string) (*models.VerifiablePresentation, error) {
+	// 验证持有者DID
+	var holder models.DID
+	if err := s.DB.Where("did_string = ? AND status = ?", holderDID, "active").First(&holder).Error; err != nil {
+		return nil, fmt.Errorf("持有者DID无效: %v", err)
+	}
+
+	// 验证挑战值
+	var request models.PresentationRequest
+	if err := s.DB.Where("nonce = ?", nonce).First(&request).Error; err != nil {
+		return nil, fmt.Errorf("挑战值不存在: %v", err)
+	}
+	if request.Status != "pending" || request.PresentationID != "" {
+		return nil, fmt.Errorf("挑战值已被使用")
+	}
+	if request.ExpiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("挑战值已过期")
+	}
+	if verifierDID != "" && request.VerifierDID != "" && verifierDID != request.VerifierDID {
+		return nil, fmt.Errorf("验证者DID与挑战不匹配")
+	}
+
+	// 验证所有凭证并收集类型
+	presentedTypes := make(map[string]bool)
+	for _, credID := range credentialIDs {
+		cred, err := s.loadPresentedCredential(credID, holderDID)
+		if err != nil {
+			return nil, err
+		}
+		if cred.Status != "active" {
+			return nil, fmt.Errorf("凭证 %s 无效", credID)
+		}
+		if problem := issuerTrustProblem(s.DB, cred.IssuerDID, cred.Type, cred.RequireRegisteredIssuer); problem != "" {
+			return nil, fmt.Errorf("凭证 %s 无效: %s", credID, problem)
+		}
+		presentedTypes[cred.Type] = true
+	}
+
+	// 检查是否包含验证者要求的凭证类型
+	for _, requiredType := range request.CredentialTypes {
+		if !presentedTypes[requiredType] {
+			return nil, fmt.Errorf("缺少验证者要求的凭证类型: %s", requiredType)
+		}
+	}
+
+	// 验证持有者钱包签名
+	message := BuildPresentationMessage(holderDID, nonce, request.Domain, credentialIDs)
+	if !util.VerifyPersonalSignature(holder.WalletAddress, signature, message) {
+		return nil, fmt.Errorf("持有者签名验证失败")
+	}
+
+	// 生成表示ID
+	presentationID := fmt.Sprintf("urn:uuid:%s", uuid.New().String())
+
+	now := time.Now()
+	if verifierDID == "" {
+		verifierDID = request.VerifierDID
+	}
+	presentation := models.VerifiablePresentation{
+		PresentationID:   presentationID,
+		HolderDID:        holderDID,
+		VerifierDID:      verifierDID,
+		CredentialIDs:    credentialIDs,
+		Purpose:          purpose,
+		Challenge:        nonce,
+		Domain:           request.Domain,
+		PresentationDate: now,
+		Status:           "active",
+	}
+
+	proof := map[string]interface{}{
+		"type":               "EcdsaSecp256k1RecoverySignature2020",
+		"created":            now.Format(time.RFC3339),
+		"proofPurpose":       "authentication",
+		"verificationMethod": fmt.Sprintf("%s#controller", holderDID),
+		"challenge":          nonce,
+		"domain":             request.Domain,
+		"proofValue":         signature,
+	}
+	proofJSON, err := json.Marshal(proof)
+	if err != nil {
+		return nil, fmt.Errorf("序列化表示证明失败: %v", err)
+	}
+	presentation.Proof = string(proofJSON)
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// 挑战值只能绑定一个表示
+		result := tx.Model(&models.PresentationRequest{}).
+			Where("id = ? AND status = ? AND presentation_id = ?", request.ID, "pending", "").
+			Update("presentation_id", presentationID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("挑战值已被使用")
+		}
+		return tx.Create(&presentation).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存表示失败: %v", err)
+	}
+
+	return &presentation, nil
+}
+
+// VerifyPresentation 只读验证可验证表示，不消费挑战值，任何人都可以调用
+func (s *VCService) VerifyPresentation(presentationID, expectedDomain string) (*models.VerifyPresentationResponse, error) {
+	return s.verifyPresentation(presentationID, expectedDomain, false)
+}
+
+// ConsumePresentation 验证可验证表示并一次性消费其挑战值，只在依赖该表示做出决定的流程（如子NFT自动审核）中调用
+func (s *VCService) ConsumePresentation(presentationID, expectedDomain string) (*models.VerifyPresentationResponse, error) {
+	return s.verifyPresentation(presentationID, expectedDomain, true)
+}
+
+// verifyPresentation 验证可验证表示，consume为true时验证通过后消费挑战值
+func (s *VCService) verifyPresentation(presentationID, expectedDomain string, consume bool) (*models.VerifyPresentationResponse, error) {
+	// 查询表示记录
+	var presentation models.VerifiablePresentation
+	if err := s.DB.Where("presentation_id = ?", presentationID).First(&presentation).Error; err != nil {
+		return nil, fmt.Errorf("表示不存在: %v", err)
+	}
+
+	invalid := func(reason string) (*models.VerifyPresentationResponse, error) {
+		return &models.VerifyPresentationResponse{
+			Valid:     false,
+			Reason:    reason,
+			HolderDID: presentation.HolderDID,
+			Nonce:     presentation.Challenge,
+			Domain:    presentation.Domain,
+		}, nil
+	}
+
+	// 验证表示状态
+	if presentation.Status != "active" {
+		return invalid("表示已被撤销")
+	}
+
+	// 验证挑战值的新鲜度
+	var request models.PresentationRequest
+	if err := s.DB.Where("nonce = ?", presentation.Challenge).First(&request).Error; err != nil {
+		return invalid("表示未绑定验证者挑战")
+	}
+	if request.PresentationID != presentation.PresentationID {
+		return invalid("挑战值与表示不匹配")
+	}
+	if request.Status == "used" {
+		return invalid("挑战值已被使用，疑似重放")
+	}
+	if request.Status != "pending" || request.ExpiresAt.Before(time.Now()) {
+		if consume {
+			s.DB.Model(&request).Update("status", "expired")
+		}
+		return invalid("挑战值已过期")
+	}
+	if expectedDomain != "" && expectedDomain != request.Domain {
+		return invalid("验证者域不匹配")
+	}
+
+	// 验证持有者DID
+	var holder models.DID
+	if err := s.DB.Where("did_string = ? AND status = ?", presentation.HolderDID, "active").First(&holder).Error; err != nil {
+		return invalid("持有者DID无效")
+	}
+
+	// 验证持有者签名
+	var proof map[string]interface{}
+	if err := json.Unmarshal([]byte(presentation.Proof), &proof); err != nil {
+		return invalid("表示证明无效")
+	}
+	signature, _ := proof["proofValue"].(string)
+	message := BuildPresentationMessage(presentation.HolderDID, request.Nonce, request.Domain, presentation.CredentialIDs)
+	if !util.VerifyPersonalSignature(holder.WalletAddress, signature, message) {
+		return invalid("持有者签名无效")
+	}
+
+	// 验证所有包含的凭证
+	for _, credID := range presentation.CredentialIDs {
+		cred, err := s.loadPresentedCredential(credID, presentation.HolderDID)
+		if err != nil || cred.Status != "active" {
+			return invalid(fmt.Sprintf("凭证 %s 无效", credID))
+		}
+
+		// 验证凭证是否过期
+		if cred.ExpiresAt.Before(time.Now()) {
+			return invalid(fmt.Sprintf("凭证 %s 已过期", credID))
+		}
+
+		// 验证颁发机构仍然可信
+		if problem := issuerTrustProblem(s.DB, cred.IssuerDID, cred.Type, cred.RequireRegisteredIssuer); problem != "" {
+			return invalid(fmt.Sprintf("凭证 %s 无效: %s", credID, problem))
+		}
+	}
+
+	// 消费挑战值，保证一次性使用
+	now := time.Now()
+	if consume {
+		result := s.DB.Model(&models.PresentationRequest{}).
+			Where("id = ? AND status = ?", request.ID, "pending").
+			Updates(map[string]interface{}{"status": "used", "used_at": now})
+		if result.Error != nil {
+			return nil, fmt.Errorf("更新挑战状态失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return invalid("挑战值已被使用，疑似重放")
+		}
+	}
+
+	// 记录验证时间
+	s.DB.Model(&presentation).Update("last_verified", now)
+
+	return &models.VerifyPresentationResponse{
+		Valid:         true,
+		HolderDID:     presentation.HolderDID,
+		PresentedAt:   presentation.PresentationDate.Format(time.RFC3339),
+		CredentialIDs: presentation.CredentialIDs,
+		Nonce:         request.Nonce,
+		Domain:        request.Domain,
+	}, nil
+}
+
+// CollectPresentationClaims 合并表示中所有凭证的声明，用于策略评估
+func (s *VCService) CollectPresentationClaims(presentationID string) (map[string]interface{}, error) {
+	var presentation models.VerifiablePresentation
+	if err := s.DB.Where("presentation_id = ?", presentationID).First(&presentation).Error; err != nil {
+		return nil, fmt.Errorf("表示不存在: %v", err)
+	}
+
+	claims := make(map[string]interface{})
+	for _, credID := range presentation.CredentialIDs {
+		cred, err := s.loadPresentedCredential(credID, presentation.HolderDID)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range cred.Claims {
+			claims[k] = v
+		}
+	}
+	claims["did"] = presentation.HolderDID
+
+	return claims, nil
+}
+
+// loadPresentedCredential 按ID加载持有者的凭证，兼容通用凭证和主体凭证
+func (s *VCService) loadPresentedCredential(credID, holderDID string) (*presentedCredential, error) {
+	var credential models.VerifiableCredential
+	// VerifiableCredential的SubjectDID字段没有列名标签，GORM映射为subject_d_id
+	err := s.DB.Where("credential_id = ? AND subject_d_id = ?", credID, holderDID).First(&credential).Error
+	if err == nil {
+		claims := make(map[string]interface{})
+		json.Unmarshal([]byte(credential.CredentialSubject), &claims)
+		var extra map[string]interface{}
+		if json.Unmarshal([]byte(credential.Claims), &extra) == nil {
+			for k, v := range extra {
+				claims[k] = v
+			}
+		}
+		return &presentedCredential{
+			ID:        credential.CredentialID,
+			Type:      credential.Type,
+			IssuerDID: credential.IssuerDID,
+			Status:    credential.Status,
+			ExpiresAt: credential.ExpirationDate,
+			Claims:    claims,
+		}, nil
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("查询凭证失败: %v", err)
+	}
+
+	var subjectCredential models.SubjectCredential
+	if err := s.DB.Where("vcid = ? AND subject_did = ?", credID, holderDID).First(&subjectCredential).Error; err != nil {
+		return nil, fmt.Errorf("凭证 %s 无效或不属于持有者: %v", credID, err)
+	}
+	claims := make(map[string]interface{})
+	if json.Unmarshal([]byte(subjectCredential.Content), &claims) != nil {
+		claims["content"] = subjectCredential.Content
+	}
+	return &presentedCredential{
+		ID:                      subjectCredential.VCID,
+		Type:                    subjectCredential.Type,
+		IssuerDID:               subjectCredential.IssuerDID,
+		Status:                  subjectCredential.Status,
+		ExpiresAt:               subjectCredential.ExpiresAt,
+		Claims:                  claims,
+		RequireRegisteredIssuer: true,
+	}, nil
+}
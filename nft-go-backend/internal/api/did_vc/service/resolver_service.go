@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+	"github.com/ABE/nft/nft-go-backend/pkg/didregistry"
+)
+
+// DID解析错误码（didResolutionMetadata.error）
+const (
+	ResolutionErrorInvalidDID         = "invalidDid"
+	ResolutionErrorNotFound           = "notFound"
+	ResolutionErrorMethodNotSupported = "methodNotSupported"
+	ResolutionErrorInvalidDocument    = "invalidDidDocument"
+)
+
+// 解析缓存最多保留的条目数
+const resolverCacheLimit = 1024
+
+var didMethodPattern = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// DIDMethodResolver 单个DID方法的解析器。
+// 返回的cacheable表示结果可以被通用解析器缓存（平台自身维护的文档不缓存，变更后立即可见）
+type DIDMethodResolver interface {
+	Method() string
+	Resolve(ctx context.Context, didString string, versionTime *time.Time) (result *models.DIDResolutionResponse, cacheable bool, err error)
+}
+
+// cachedResolution 缓存的解析结果
+type cachedResolution struct {
+	result    *models.DIDResolutionResponse
+	expiresAt time.Time
+}
+
+// UniversalResolver 按DID方法分派的通用解析器，带结果缓存
+type UniversalResolver struct {
+	methods map[string]DIDMethodResolver
+	ttl     time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedResolution
+}
+
+// NewUniversalResolver 创建通用解析器，ttl为0时不缓存
+func NewUniversalResolver(ttl time.Duration, resolvers ...DIDMethodResolver) *UniversalResolver {
+	r := &UniversalResolver{
+		methods: make(map[string]DIDMethodResolver),
+		ttl:     ttl,
+		cache:   make(map[string]cachedResolution),
+	}
+	for _, resolver := range resolvers {
+		r.Register(resolver)
+	}
+	return r
+}
+
+// Register 注册（或替换）一个DID方法的解析器
+func (r *UniversalResolver) Register(resolver DIDMethodResolver) {
+	r.methods[resolver.Method()] = resolver
+}
+
+// Methods 返回已支持的DID方法
+func (r *UniversalResolver) Methods() []string {
+	methods := make([]string, 0, len(r.methods))
+	for method := range r.methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Resolve 解析DID（DID URL中的路径、查询和片段会被忽略）；解析失败时错误码写入didResolutionMetadata.error
+func (r *UniversalResolver) Resolve(ctx context.Context, didString string, versionTime *time.Time) (*models.DIDResolutionResponse, error) {
+	if i := strings.IndexAny(didString, "/?#"); i >= 0 {
+		didString = didString[:i]
+	}
+
+	parts := strings.SplitN(didString, ":", 3)
+	if len(parts) < 3 || parts[0] != "did" || !didMethodPattern.MatchString(parts[1]) || parts[2] == "" {
+		return newResolutionResponse(ResolutionErrorInvalidDID), nil
+	}
+	resolver, ok := r.methods[parts[1]]
+	if !ok {
+		return newResolutionResponse(ResolutionErrorMethodNotSupported), nil
+	}
+
+	key := didString
+	if versionTime != nil {
+		key += "@" + versionTime.UTC().Format(time.RFC3339Nano)
+	}
+	if cached := r.cached(key); cached != nil {
+		return cached, nil
+	}
+
+	result, cacheable, err := resolver.Resolve(ctx, didString, versionTime)
+	if err != nil {
+		return nil, err
+	}
+	if cacheable && result.DIDResolutionMetadata.Error == "" {
+		r.store(key, result)
+	}
+	return result, nil
+}
+
+// Invalidate 清除某个DID的缓存结果（含按历史时间解析的结果）
+func (r *UniversalResolver) Invalidate(didString string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key := range r.cache {
+		if key == didString || strings.HasPrefix(key, didString+"@") {
+			delete(r.cache, key)
+		}
+	}
+}
+
+// cached 返回未过期的缓存结果副本
+func (r *UniversalResolver) cached(key string) *models.DIDResolutionResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.cache[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(r.cache, key)
+		return nil
+	}
+	result := *entry.result
+	result.DIDResolutionMetadata.Cached = true
+	return &result
+}
+
+// store 写入缓存，超出上限时先清理过期条目，仍然超出则清空
+func (r *UniversalResolver) store(key string, result *models.DIDResolutionResponse) {
+	if r.ttl <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.cache) >= resolverCacheLimit {
+		now := time.Now()
+		for k, entry := range r.cache {
+			if now.After(entry.expiresAt) {
+				delete(r.cache, k)
+			}
+		}
+		if len(r.cache) >= resolverCacheLimit {
+			r.cache = make(map[string]cachedResolution)
+		}
+	}
+	r.cache[key] = cachedResolution{result: result, expiresAt: time.Now().Add(r.ttl)}
+}
+
+// newResolutionResponse 创建解析结果，errorCode不为空时表示解析失败
+func newResolutionResponse(errorCode string) *models.DIDResolutionResponse {
+	return &models.DIDResolutionResponse{
+		Context: "https://w3id.org/did-resolution/v1",
+		DIDResolutionMetadata: models.DIDResolutionMetadata{
+			Error:     errorCode,
+			Retrieved: time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+}
+
+// EthrDIDResolver 解析did:ethr：平台登记的DID以本地文档为准；
+// 其余地址在启用锚定时读取链上ERC-1056注册表，否则返回仅含所有者地址的隐式文档
+type EthrDIDResolver struct {
+	DIDService *DIDService
+}
+
+// NewEthrDIDResolver 创建did:ethr解析器
+func NewEthrDIDResolver(didService *DIDService) *EthrDIDResolver {
+	return &EthrDIDResolver{DIDService: didService}
+}
+
+// Method 返回DID方法名
+func (r *EthrDIDResolver) Method() string {
+	return "ethr"
+}
+
+// Resolve 解析did:ethr
+func (r *EthrDIDResolver) Resolve(ctx context.Context, didString string, versionTime *time.Time) (*models.DIDResolutionResponse, bool, error) {
+	identity, ok := ethrIdentity(didString)
+	if !ok {
+		return newResolutionResponse(ResolutionErrorInvalidDID), false, nil
+	}
+
+	var count int64
+	if err := r.DIDService.DB.Model(&models.DID{}).Where("did_string = ?", didString).Count(&count).Error; err != nil {
+		return nil, false, fmt.Errorf("查询DID失败: %v", err)
+	}
+	if count > 0 {
+		result, err := r.DIDService.ResolveDID(didString, versionTime)
+		return result, false, err
+	}
+
+	reference := time.Now()
+	if versionTime != nil {
+		reference = *versionTime
+	}
+	history, err := r.registryHistory(ctx, identity, reference)
+	if err != nil {
+		return nil, false, err
+	}
+
+	result := newResolutionResponse("")
+	result.DIDResolutionMetadata.ContentType = "application/did+ld+json"
+	doc := buildEthrDocument(didString, identity, r.DIDService.ChainID, history, reference)
+	result.DIDDocument = doc
+	result.DIDDocumentMetadata.Deactivated = history.owner == (common.Address{}) && history.ownerChanged
+	if history.lastBlock > 0 {
+		result.DIDDocumentMetadata.VersionID = strconv.FormatUint(history.lastBlock, 10)
+		result.DIDDocumentMetadata.Updated = history.lastUpdated.UTC().Format(time.RFC3339)
+		doc.Updated = result.DIDDocumentMetadata.Updated
+	}
+	return result, true, nil
+}
+
+// ethrRegistryHistory 从注册表事件重建的身份状态
+type ethrRegistryHistory struct {
+	owner        common.Address
+	ownerChanged bool
+	delegates    []*didregistry.DidregistryDIDDelegateChanged
+	attributes   []*didregistry.DidregistryDIDAttributeChanged
+	lastBlock    uint64
+	lastUpdated  time.Time
+}
+
+// registryHistory 沿changed/previousChange链表读取身份在reference时刻之前的全部注册表事件
+func (r *EthrDIDResolver) registryHistory(ctx context.Context, identity common.Address, reference time.Time) (*ethrRegistryHistory, error) {
+	history := &ethrRegistryHistory{owner: identity}
+	anchor := r.DIDService.Anchor
+	if anchor == nil {
+		return history, nil
+	}
+
+	callOpts := &bind.CallOpts{Context: ctx}
+	changed, err := anchor.Registry.Changed(callOpts, identity)
+	if err != nil {
+		return nil, fmt.Errorf("读取DID注册表失败: %v", err)
+	}
+
+	parsed, err := didregistry.DidregistryMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	ownerEvent := parsed.Events["DIDOwnerChanged"].ID
+	delegateEvent := parsed.Events["DIDDelegateChanged"].ID
+	attributeEvent := parsed.Events["DIDAttributeChanged"].ID
+
+	// 链表从最近一次变更向前遍历，收集后按时间正序处理
+	type blockEvents struct {
+		number uint64
+		time   time.Time
+		logs   []types.Log
+	}
+	var blocks []blockEvents
+	for block := changed.Uint64(); block > 0; {
+		number := new(big.Int).SetUint64(block)
+		header, err := anchor.Backend.HeaderByNumber(ctx, number)
+		if err != nil {
+			return nil, fmt.Errorf("读取区块 %d 失败: %v", block, err)
+		}
+		logs, err := anchor.Backend.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: number,
+			ToBlock:   number,
+			Addresses: []common.Address{anchor.RegistryAddress},
+			Topics:    [][]common.Hash{nil, {common.BytesToHash(identity.Bytes())}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("读取DID注册表事件失败: %v", err)
+		}
+
+		previous := uint64(0)
+		for _, l := range logs {
+			var change *big.Int
+			switch l.Topics[0] {
+			case ownerEvent:
+				if event, err := anchor.Registry.ParseDIDOwnerChanged(l); err == nil {
+					change = event.PreviousChange
+				}
+			case delegateEvent:
+				if event, err := anchor.Registry.ParseDIDDelegateChanged(l); err == nil {
+					change = event.PreviousChange
+				}
+			case attributeEvent:
+				if event, err := anchor.Registry.ParseDIDAttributeChanged(l); err == nil {
+					change = event.PreviousChange
+				}
+			}
+			if change != nil && change.Uint64() < block {
+				previous = change.Uint64()
+			}
+		}
+		blocks = append(blocks, blockEvents{number: block, time: time.Unix(int64(header.Time), 0), logs: logs})
+		block = previous
+	}
+
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+		if block.time.After(reference) {
+			continue
+		}
+		history.lastBlock = block.number
+		history.lastUpdated = block.time
+		for _, l := range block.logs {
+			switch l.Topics[0] {
+			case ownerEvent:
+				if event, err := anchor.Registry.ParseDIDOwnerChanged(l); err == nil {
+					history.owner = event.Owner
+					history.ownerChanged = true
+				}
+			case delegateEvent:
+				if event, err := anchor.Registry.ParseDIDDelegateChanged(l); err == nil {
+					history.delegates = append(history.delegates, event)
+				}
+			case attributeEvent:
+				if event, err := anchor.Registry.ParseDIDAttributeChanged(l); err == nil {
+					history.attributes = append(history.attributes, event)
+				}
+			}
+		}
+	}
+	return history, nil
+}
+
+// buildEthrDocument 按did:ethr规范由注册表状态生成DID文档
+func buildEthrDocument(didString string, identity common.Address, chainID int64, history *ethrRegistryHistory, reference time.Time) *models.DIDDocument {
+	doc := &models.DIDDocument{
+		Context: []string{"https://www.w3.org/ns/did/v1"},
+		ID:      didString,
+	}
+	// 所有者被设为零地址表示已停用
+	if history.ownerChanged && history.owner == (common.Address{}) {
+		return doc
+	}
+
+	doc.Context = append(doc.Context, secp256k1RecoveryContext)
+	controllerKey := didString + "#controller"
+	doc.VerificationMethod = []models.VerificationMethod{{
+		ID:                  controllerKey,
+		Type:                "EcdsaSecp256k1RecoveryMethod2020",
+		Controller:          didString,
+		BlockchainAccountID: fmt.Sprintf("eip155:%d:%s", chainID, history.owner.Hex()),
+	}}
+	doc.Authentication = []string{controllerKey}
+	doc.AssertionMethod = []string{controllerKey}
+	doc.CapabilityInvocation = []string{controllerKey}
+	if history.owner != identity {
+		doc.Controller = []string{"did:ethr:" + history.owner.Hex()}
+	}
+
+	validAt := big.NewInt(reference.Unix())
+	delegateIndex := 0
+	addMethod := func(method models.VerificationMethod, authentication, keyAgreement bool) {
+		delegateIndex++
+		method.ID = fmt.Sprintf("%s#delegate-%d", didString, delegateIndex)
+		method.Controller = didString
+		doc.VerificationMethod = append(doc.VerificationMethod, method)
+		if keyAgreement {
+			doc.KeyAgreement = append(doc.KeyAgreement, method.ID)
+			return
+		}
+		doc.AssertionMethod = append(doc.AssertionMethod, method.ID)
+		if authentication {
+			doc.Authentication = append(doc.Authentication, method.ID)
+		}
+	}
+
+	// 同一委托或属性以最后一次事件为准
+	type delegateKey struct {
+		delegateType string
+		delegate     common.Address
+	}
+	delegateValidTo := make(map[delegateKey]*big.Int)
+	var delegateOrder []delegateKey
+	for _, event := range history.delegates {
+		key := delegateKey{registryString(event.DelegateType), event.Delegate}
+		if _, seen := delegateValidTo[key]; !seen {
+			delegateOrder = append(delegateOrder, key)
+		}
+		delegateValidTo[key] = event.ValidTo
+	}
+	for _, key := range delegateOrder {
+		if delegateValidTo[key].Cmp(validAt) <= 0 {
+			continue
+		}
+		if key.delegateType != "veriKey" && key.delegateType != "sigAuth" {
+			continue
+		}
+		addMethod(models.VerificationMethod{
+			Type:                "EcdsaSecp256k1RecoveryMethod2020",
+			BlockchainAccountID: fmt.Sprintf("eip155:%d:%s", chainID, key.delegate.Hex()),
+		}, key.delegateType == "sigAuth", false)
+	}
+
+	type attributeKey struct {
+		name  string
+		value string
+	}
+	attributeValidTo := make(map[attributeKey]*big.Int)
+	var attributeOrder []attributeKey
+	for _, event := range history.attributes {
+		key := attributeKey{registryString(event.Name), string(event.Value)}
+		if _, seen := attributeValidTo[key]; !seen {
+			attributeOrder = append(attributeOrder, key)
+		}
+		attributeValidTo[key] = event.ValidTo
+	}
+	serviceIndex := 0
+	for _, key := range attributeOrder {
+		if attributeValidTo[key].Cmp(validAt) <= 0 {
+			continue
+		}
+		parts := strings.Split(key.name, "/")
+		value := []byte(key.value)
+		switch {
+		case len(parts) == 3 && parts[0] == "did" && parts[1] == "svc":
+			serviceIndex++
+			doc.Service = append(doc.Service, models.Service{
+				ID:              fmt.Sprintf("%s#service-%d", didString, serviceIndex),
+				Type:            parts[2],
+				ServiceEndpoint: key.value,
+			})
+		case len(parts) >= 4 && parts[0] == "did" && parts[1] == "pub":
+			method := models.VerificationMethod{}
+			switch parts[2] {
+			case "Secp256k1":
+				method.Type = "EcdsaSecp256k1VerificationKey2019"
+			case "Ed25519":
+				method.Type = "Ed25519VerificationKey2018"
+			case "X25519":
+				method.Type = "X25519KeyAgreementKey2019"
+			default:
+				continue
+			}
+			encoding := "hex"
+			if len(parts) >= 5 {
+				encoding = parts[4]
+			}
+			if encoding == "base58" {
+				method.PublicKeyBase58 = util.Base58Encode(value)
+			} else {
+				method.PublicKeyHex = strings.TrimPrefix(hexutil.Encode(value), "0x")
+			}
+			addMethod(method, parts[3] == "sigAuth", parts[3] == "enc")
+		}
+	}
+	return doc
+}
+
+// registryString 把右补零的bytes32还原为字符串
+func registryString(value [32]byte) string {
+	return strings.TrimRight(string(value[:]), "\x00")
+}
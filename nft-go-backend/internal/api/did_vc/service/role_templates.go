@@ -0,0 +1,155 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+)
+
+// TemplateAttribute 角色档案中的一个类型化属性
+type TemplateAttribute struct {
+	Name     string `json:"name"`     // 属性名
+	Label    string `json:"label"`    // 中文名称
+	Type     string `json:"type"`     // JSON Schema类型：string, number, integer, boolean, array
+	Required bool   `json:"required"` // 是否必填
+}
+
+// CredentialTemplate 角色可获得的凭证类型及颁发时从档案补全的声明
+type CredentialTemplate struct {
+	Type          string   `json:"type"`          // 凭证类型
+	ProfileClaims []string `json:"profileClaims"` // 颁发时从档案补全的属性（name为档案名称）
+}
+
+// RoleTemplate 角色模板：定义档案属性和可颁发的凭证
+type RoleTemplate struct {
+	Role        string               `json:"role"`        // 角色标识
+	Label       string               `json:"label"`       // 中文名称
+	Attributes  []TemplateAttribute  `json:"attributes"`  // 档案属性
+	Credentials []CredentialTemplate `json:"credentials"` // 可颁发的凭证
+}
+
+// 平台注入的属性名称
+var platformAttributeLabels = map[string]string{
+	"name":   "姓名",
+	"wallet": "钱包地址",
+	"did":    "DID",
+}
+
+// 内置角色模板
+var roleTemplates = map[string]RoleTemplate{
+	"doctor": {
+		Role:  "doctor",
+		Label: "医生",
+		Attributes: []TemplateAttribute{
+			{Name: "licenseNumber", Label: "执业编号", Type: "string", Required: true},
+			{Name: "hospital", Label: "医院", Type: "string"},
+			{Name: "department", Label: "科室", Type: "string"},
+			{Name: "title", Label: "职称", Type: "string"},
+			{Name: "specialty", Label: "专长", Type: "string"},
+		},
+		Credentials: []CredentialTemplate{
+			{Type: "执业资格", ProfileClaims: []string{"name", "licenseNumber", "hospital", "department"}},
+			{Type: "职称", ProfileClaims: []string{"name", "title", "hospital"}},
+			{Type: "科室任职", ProfileClaims: []string{"name", "hospital", "department"}},
+		},
+	},
+	"patient": {
+		Role:  "patient",
+		Label: "患者",
+		Attributes: []TemplateAttribute{
+			{Name: "medicalRecordNumber", Label: "病历号", Type: "string"},
+			{Name: "gender", Label: "性别", Type: "string"},
+			{Name: "birthDate", Label: "出生日期", Type: "string"},
+		},
+		Credentials: []CredentialTemplate{
+			{Type: "就诊凭证", ProfileClaims: []string{"name", "medicalRecordNumber"}},
+			{Type: "医保资格", ProfileClaims: []string{"name"}},
+		},
+	},
+	"researcher": {
+		Role:  "researcher",
+		Label: "研究人员",
+		Attributes: []TemplateAttribute{
+			{Name: "institution", Label: "研究机构", Type: "string", Required: true},
+			{Name: "researchField", Label: "研究领域", Type: "string"},
+			{Name: "ethicsApproval", Label: "伦理审批编号", Type: "string"},
+		},
+		Credentials: []CredentialTemplate{
+			{Type: "研究资质", ProfileClaims: []string{"name", "institution", "researchField"}},
+			{Type: "数据访问授权", ProfileClaims: []string{"name", "institution", "ethicsApproval"}},
+		},
+	},
+	"pharmacy": {
+		Role:  "pharmacy",
+		Label: "药房",
+		Attributes: []TemplateAttribute{
+			{Name: "pharmacyLicense", Label: "药品经营许可证号", Type: "string", Required: true},
+			{Name: "address", Label: "地址", Type: "string"},
+		},
+		Credentials: []CredentialTemplate{
+			{Type: "药品经营许可", ProfileClaims: []string{"name", "pharmacyLicense", "address"}},
+		},
+	},
+}
+
+// GetRoleTemplate 获取角色模板
+func GetRoleTemplate(role string) (*RoleTemplate, error) {
+	template, ok := roleTemplates[role]
+	if !ok {
+		return nil, fmt.Errorf("不支持的角色: %s", role)
+	}
+	return &template, nil
+}
+
+// ListRoleTemplates 列出所有角色模板
+func ListRoleTemplates() []RoleTemplate {
+	templates := make([]RoleTemplate, 0, len(roleTemplates))
+	for _, template := range roleTemplates {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Role < templates[j].Role })
+	return templates
+}
+
+// AttributeLabel 返回属性的中文名称，未知属性原样返回
+func AttributeLabel(attribute string) string {
+	if label, ok := platformAttributeLabels[attribute]; ok {
+		return label
+	}
+	for _, template := range ListRoleTemplates() {
+		for _, attr := range template.Attributes {
+			if attr.Name == attribute {
+				return attr.Label
+			}
+		}
+	}
+	return attribute
+}
+
+// credentialTemplate 查找角色可颁发的凭证模板
+func (t *RoleTemplate) credentialTemplate(vcType string) (*CredentialTemplate, error) {
+	for i := range t.Credentials {
+		if t.Credentials[i].Type == vcType {
+			return &t.Credentials[i], nil
+		}
+	}
+	return nil, fmt.Errorf("角色 %s 不能被颁发 %s 类型的凭证", t.Label, vcType)
+}
+
+// attributeSchema 将档案属性定义转换为JSON Schema，用于校验属性包
+func (t *RoleTemplate) attributeSchema() *util.JSONSchema {
+	closed := false
+	schema := &util.JSONSchema{
+		Type:                 "object",
+		Properties:           make(map[string]*util.JSONSchema),
+		AdditionalProperties: &closed,
+	}
+	for _, attr := range t.Attributes {
+		schema.Properties[attr.Name] = &util.JSONSchema{Type: attr.Type, Title: attr.Label}
+		if attr.Required {
+			schema.Required = append(schema.Required, attr.Name)
+		}
+	}
+	return schema
+}
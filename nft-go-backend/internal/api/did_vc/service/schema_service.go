@@ -0,0 +1,214 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+)
+
+// SchemaService 凭证模式服务
+type SchemaService struct {
+	DB *gorm.DB
+}
+
+// NewSchemaService 创建新的凭证模式服务
+func NewSchemaService(db *gorm.DB) *SchemaService {
+	return &SchemaService{
+		DB: db,
+	}
+}
+
+// CreateSchema 注册凭证模式
+func (s *SchemaService) CreateSchema(name, description, version, author, schemaJSON string) (*models.CredentialSchema, error) {
+	if name == "" || version == "" {
+		return nil, fmt.Errorf("模式名称和版本不能为空")
+	}
+
+	// 检查模式定义是否合法
+	if _, err := util.ParseJSONSchema(schemaJSON); err != nil {
+		return nil, err
+	}
+
+	// 同名同版本的模式只能注册一次
+	var count int64
+	s.DB.Model(&models.CredentialSchema{}).Where("name = ? AND version = ?", name, version).Count(&count)
+	if count > 0 {
+		return nil, fmt.Errorf("模式 %s 的版本 %s 已存在", name, version)
+	}
+
+	schema := models.CredentialSchema{
+		SchemaID:    fmt.Sprintf("urn:uuid:%s", uuid.New().String()),
+		Name:        name,
+		Description: description,
+		Version:     version,
+		Author:      author,
+		SchemaJSON:  schemaJSON,
+	}
+
+	if err := s.DB.Create(&schema).Error; err != nil {
+		return nil, fmt.Errorf("保存凭证模式失败: %v", err)
+	}
+
+	return &schema, nil
+}
+
+// GetSchema 获取凭证模式
+func (s *SchemaService) GetSchema(schemaID string) (*models.CredentialSchema, error) {
+	var schema models.CredentialSchema
+	if err := s.DB.Where("schema_id = ?", schemaID).First(&schema).Error; err != nil {
+		return nil, fmt.Errorf("凭证模式不存在: %v", err)
+	}
+	return &schema, nil
+}
+
+// GetLatestSchemaByName 按名称获取最新注册的凭证模式
+func (s *SchemaService) GetLatestSchemaByName(name string) (*models.CredentialSchema, error) {
+	var schema models.CredentialSchema
+	if err := s.DB.Where("name = ?", name).Order("created_at DESC").First(&schema).Error; err != nil {
+		return nil, fmt.Errorf("凭证类型 %s 没有注册模式: %v", name, err)
+	}
+	return &schema, nil
+}
+
+// ListSchemas 列出凭证模式
+func (s *SchemaService) ListSchemas(name string) ([]models.CredentialSchema, error) {
+	var schemas []models.CredentialSchema
+	query := s.DB
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	if err := query.Order("created_at DESC").Find(&schemas).Error; err != nil {
+		return nil, fmt.Errorf("查询凭证模式失败: %w", err)
+	}
+	return schemas, nil
+}
+
+// UpdateSchema 更新凭证模式（已被凭证引用的模式只能修改描述）
+func (s *SchemaService) UpdateSchema(schemaID, description, schemaJSON string) (*models.CredentialSchema, error) {
+	schema, err := s.GetSchema(schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if schemaJSON != "" && schemaJSON != schema.SchemaJSON {
+		if s.isSchemaReferenced(schemaID) {
+			return nil, fmt.Errorf("模式已被凭证引用，请注册新版本")
+		}
+		if _, err := util.ParseJSONSchema(schemaJSON); err != nil {
+			return nil, err
+		}
+		schema.SchemaJSON = schemaJSON
+	}
+	if description != "" {
+		schema.Description = description
+	}
+
+	if err := s.DB.Save(schema).Error; err != nil {
+		return nil, fmt.Errorf("更新凭证模式失败: %v", err)
+	}
+	return schema, nil
+}
+
+// DeleteSchema 删除凭证模式（已被凭证引用的模式不能删除）
+func (s *SchemaService) DeleteSchema(schemaID string) error {
+	schema, err := s.GetSchema(schemaID)
+	if err != nil {
+		return err
+	}
+	if s.isSchemaReferenced(schemaID) {
+		return fmt.Errorf("模式已被凭证引用，不能删除")
+	}
+	if err := s.DB.Delete(schema).Error; err != nil {
+		return fmt.Errorf("删除凭证模式失败: %v", err)
+	}
+	return nil
+}
+
+// ValidateClaims 按模式校验凭证声明，模式名称必须与凭证类型一致
+func (s *SchemaService) ValidateClaims(schemaID, credentialType string, claims map[string]interface{}) (*models.CredentialSchema, error) {
+	schema, err := s.GetSchema(schemaID)
+	if err != nil {
+		return nil, err
+	}
+	if schema.Name != credentialType {
+		return nil, fmt.Errorf("模式 %s 适用于 %s 凭证，不能用于 %s", schemaID, schema.Name, credentialType)
+	}
+
+	parsed, err := util.ParseJSONSchema(schema.SchemaJSON)
+	if err != nil {
+		return nil, fmt.Errorf("模式 %s 定义无效: %v", schemaID, err)
+	}
+
+	// 经过JSON往返，保证数值等类型与JSON Schema一致
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		return nil, fmt.Errorf("序列化凭证声明失败: %v", err)
+	}
+	var data interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("解析凭证声明失败: %v", err)
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	if errs := parsed.Validate(data); len(errs) > 0 {
+		return nil, fmt.Errorf("凭证声明不符合模式 %s: %s", schema.Name, util.FormatSchemaErrors(errs))
+	}
+	return schema, nil
+}
+
+// ToResponse 构造凭证模式响应
+func (s *SchemaService) ToResponse(schema *models.CredentialSchema) models.SchemaResponse {
+	response := models.SchemaResponse{
+		SchemaID:    schema.SchemaID,
+		Name:        schema.Name,
+		Description: schema.Description,
+		Version:     schema.Version,
+		Author:      schema.Author,
+		Schema:      json.RawMessage(schema.SchemaJSON),
+		Attributes:  []string{},
+		CreatedAt:   schema.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if parsed, err := util.ParseJSONSchema(schema.SchemaJSON); err == nil {
+		response.Attributes = parsed.AttributeNames()
+	}
+	return response
+}
+
+// ListPolicyAttributes 汇总所有已注册模式中的属性名及其来源模式
+func (s *SchemaService) ListPolicyAttributes() (map[string][]string, error) {
+	schemas, err := s.ListSchemas("")
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := make(map[string][]string)
+	for _, schema := range schemas {
+		parsed, err := util.ParseJSONSchema(schema.SchemaJSON)
+		if err != nil {
+			continue
+		}
+		for _, name := range parsed.AttributeNames() {
+			attributes[name] = append(attributes[name], schema.Name)
+		}
+	}
+	return attributes, nil
+}
+
+// isSchemaReferenced 检查是否有凭证引用了该模式
+func (s *SchemaService) isSchemaReferenced(schemaID string) bool {
+	var count int64
+	s.DB.Model(&models.VerifiableCredential{}).Where("credential_schema = ?", schemaID).Count(&count)
+	if count > 0 {
+		return true
+	}
+	s.DB.Model(&models.SubjectCredential{}).Where("schema_id = ?", schemaID).Count(&count)
+	return count > 0
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+)
+
+// SubjectService 身份主体档案服务
+type SubjectService struct {
+	DB *gorm.DB
+}
+
+// NewSubjectService 创建新的身份主体档案服务
+func NewSubjectService(db *gorm.DB) *SubjectService {
+	return &SubjectService{
+		DB: db,
+	}
+}
+
+// CreateProfile 为钱包创建指定角色的档案；档案已存在时直接返回
+func (s *SubjectService) CreateProfile(walletAddress, role, name, organizationDID string, attributes map[string]interface{}) (*models.SubjectProfile, error) {
+	if walletAddress == "" || name == "" {
+		return nil, fmt.Errorf("钱包地址和名称不能为空")
+	}
+	template, err := GetRoleTemplate(role)
+	if err != nil {
+		return nil, err
+	}
+	if attributes == nil {
+		attributes = map[string]interface{}{}
+	}
+	if err := validateProfileAttributes(template, attributes); err != nil {
+		return nil, err
+	}
+
+	// 已存在同角色档案时直接返回
+	var existing models.SubjectProfile
+	result := s.DB.Where("wallet_address = ? AND role = ? AND status = ?", walletAddress, role, "active").First(&existing)
+	if result.Error == nil {
+		return &existing, nil
+	} else if result.Error != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("查询档案失败: %v", result.Error)
+	}
+
+	didID := fmt.Sprintf("did:ethr:%s", walletAddress)
+	profile := models.SubjectProfile{
+		DIDString:       didID,
+		WalletAddress:   walletAddress,
+		Role:            role,
+		Name:            name,
+		Attributes:      attributes,
+		OrganizationDID: organizationDID,
+		Status:          "active",
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// 同一钱包的多个角色共用一个DID
+		var did models.DID
+		if err := tx.Where("did_string = ?", didID).First(&did).Error; err == gorm.ErrRecordNotFound {
+			did = models.DID{
+				DIDString:     didID,
+				WalletAddress: walletAddress,
+				Status:        "active",
+			}
+			if err := tx.Create(&did).Error; err != nil {
+				return fmt.Errorf("创建DID记录失败: %v", err)
+			}
+		} else if err != nil {
+			return fmt.Errorf("查询DID失败: %v", err)
+		}
+
+		if err := tx.Create(&profile).Error; err != nil {
+			return fmt.Errorf("创建档案失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		// 并发创建导致重复时返回已存在的记录
+		if isDuplicateKeyError(err) {
+			if findErr := s.DB.Where("wallet_address = ? AND role = ? AND status = ?", walletAddress, role, "active").First(&existing).Error; findErr == nil {
+				return &existing, nil
+			}
+		}
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// GetProfile 获取主体指定角色的有效档案
+func (s *SubjectService) GetProfile(subjectDID, role string) (*models.SubjectProfile, error) {
+	var profile models.SubjectProfile
+	if err := s.DB.Where("did_string = ? AND role = ? AND status = ?", subjectDID, role, "active").First(&profile).Error; err != nil {
+		return nil, fmt.Errorf("未找到%s档案: %v", roleLabel(role), err)
+	}
+	return &profile, nil
+}
+
+// GetProfileByWallet 通过钱包地址获取指定角色的有效档案
+func (s *SubjectService) GetProfileByWallet(walletAddress, role string) (*models.SubjectProfile, error) {
+	var profile models.SubjectProfile
+	if err := s.DB.Where("wallet_address = ? AND role = ? AND status = ?", walletAddress, role, "active").First(&profile).Error; err != nil {
+		return nil, fmt.Errorf("未找到%s档案: %v", roleLabel(role), err)
+	}
+	return &profile, nil
+}
+
+// ListProfiles 列出有效档案，可按角色过滤
+func (s *SubjectService) ListProfiles(role string) ([]models.SubjectProfile, error) {
+	var profiles []models.SubjectProfile
+	query := s.DB.Where("status = ?", "active")
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+	if err := query.Find(&profiles).Error; err != nil {
+		return nil, fmt.Errorf("获取档案列表失败: %v", err)
+	}
+	return profiles, nil
+}
+
+// ListProfilesByDID 列出主体在各角色下的档案
+func (s *SubjectService) ListProfilesByDID(subjectDID string) ([]models.SubjectProfile, error) {
+	var profiles []models.SubjectProfile
+	if err := s.DB.Where("did_string = ?", subjectDID).Find(&profiles).Error; err != nil {
+		return nil, fmt.Errorf("获取档案失败: %v", err)
+	}
+	return profiles, nil
+}
+
+// validateProfileAttributes 按角色模板校验属性包的类型和必填项
+func validateProfileAttributes(template *RoleTemplate, attributes map[string]interface{}) error {
+	// 经过JSON往返，保证数值等类型与JSON Schema一致
+	raw, err := json.Marshal(attributes)
+	if err != nil {
+		return fmt.Errorf("序列化档案属性失败: %v", err)
+	}
+	var data interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("解析档案属性失败: %v", err)
+	}
+	if errs := template.attributeSchema().Validate(data); len(errs) > 0 {
+		return fmt.Errorf("%s档案属性无效: %s", template.Label, util.FormatSchemaErrors(errs))
+	}
+	return nil
+}
+
+// roleLabel 返回角色的中文名称
+func roleLabel(role string) string {
+	if template, err := GetRoleTemplate(role); err == nil {
+		return template.Label
+	}
+	return role
+}
@@ -1,21 +1,31 @@
 package service
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	auth "github.com/ABE/nft/nft-go-backend/internal/api/auth/service"
 	"github.com/ABE/nft/nft-go-backend/internal/models"
 )
 
 // VCService 提供可验证凭证相关功能的服务
 type VCService struct {
-	DB *gorm.DB
+	DB         *gorm.DB
+	Anchor     *AnchorService         // 链上锚定服务，未启用时为nil
+	Resolver   *UniversalResolver     // 通用DID解析器，用于平台外的颁发者和主体DID
+	Challenges *auth.ChallengeService // 颁发和管理主体凭证需要颁发者钱包对一次性挑战的签名
 }
 
 // NewVCService 创建新的VC服务实例
@@ -25,20 +35,46 @@ func NewVCService(db *gorm.DB) *VCService {
 	}
 }
 
-// IssueCredential 颁发凭证
-func (s *VCService) IssueCredential(issuerDID, subjectDID, credentialType string) (*models.VerifiableCredential, error) {
+// IssueCredential 颁发凭证；颁发者须证明控制该DID：已登记的医院由其登记钱包签名，
+// 其他颁发者（包括外部did:key、did:web）由DID文档assertionMethod中密钥对应的钱包签名
+func (s *VCService) IssueCredential(proof models.ActionProof, issuerDID, subjectDID, credentialType, schemaID string, rawClaims json.RawMessage) (*models.VerifiableCredential, error) {
 	// 验证颁发者DID
-	var issuer models.DID
-	if err := s.DB.Where("did_string = ? AND status = ?", issuerDID, "active").First(&issuer).Error; err != nil {
+	if err := s.checkDIDActive(issuerDID); err != nil {
 		return nil, fmt.Errorf("颁发者DID无效: %v", err)
 	}
 
 	// 验证主体DID
-	var subject models.DID
-	if err := s.DB.Where("did_string = ? AND status = ?", subjectDID, "active").First(&subject).Error; err != nil {
+	if err := s.checkDIDActive(subjectDID); err != nil {
 		return nil, fmt.Errorf("主体DID无效: %v", err)
 	}
 
+	// 已登记为医院的颁发者需处于正常状态并被授权颁发该类型凭证
+	if problem := issuerTrustProblem(s.DB, issuerDID, credentialType, false); problem != "" {
+		return nil, fmt.Errorf("%s", problem)
+	}
+
+	if err := s.authorizeIssuerSigner(proof, issuerDID, "IssueCredential", map[string]string{
+		"issuer":     issuerDID,
+		"subject":    subjectDID,
+		"type":       credentialType,
+		"schemaId":   schemaID,
+		"claimsHash": auth.HashParam(rawClaims),
+	}); err != nil {
+		return nil, err
+	}
+
+	subjectClaims := map[string]interface{}{}
+	if len(rawClaims) > 0 && string(rawClaims) != "null" {
+		if err := json.Unmarshal(rawClaims, &subjectClaims); err != nil {
+			return nil, fmt.Errorf("凭证声明必须是JSON对象: %v", err)
+		}
+	}
+
+	// 按已注册的模式校验凭证声明
+	if _, err := NewSchemaService(s.DB).ValidateClaims(schemaID, credentialType, subjectClaims); err != nil {
+		return nil, err
+	}
+
 	// 生成凭证ID
 	credentialID := fmt.Sprintf("urn:uuid:%s", uuid.New().String())
 
@@ -47,10 +83,12 @@ func (s *VCService) IssueCredential(issuerDID, subjectDID, credentialType string
 	expiration := now.AddDate(1, 0, 0) // 1年后过期
 
 	// 创建凭证主体
-	credentialSubject := map[string]interface{}{
-		"id":   subjectDID,
-		"type": credentialType,
+	credentialSubject := map[string]interface{}{}
+	for k, v := range subjectClaims {
+		credentialSubject[k] = v
 	}
+	credentialSubject["id"] = subjectDID
+	credentialSubject["type"] = credentialType
 	credentialSubjectJSON, err := json.Marshal(credentialSubject)
 	if err != nil {
 		return nil, fmt.Errorf("序列化凭证主体失败: %v", err)
@@ -69,13 +107,13 @@ func (s *VCService) IssueCredential(issuerDID, subjectDID, credentialType string
 	}
 
 	// 创建凭证证明（在实际应用中应该使用密码学方法生成）
-	proof := map[string]interface{}{
+	vcProof := map[string]interface{}{
 		"type":               "EcdsaSecp256k1Signature2019",
 		"created":            now.Format(time.RFC3339),
 		"proofPurpose":       "assertionMethod",
 		"verificationMethod": fmt.Sprintf("%s#keys-1", issuerDID),
 	}
-	proofJSON, err := json.Marshal(proof)
+	proofJSON, err := json.Marshal(vcProof)
 	if err != nil {
 		return nil, fmt.Errorf("序列化凭证证明失败: %v", err)
 	}
@@ -86,7 +124,7 @@ func (s *VCService) IssueCredential(issuerDID, subjectDID, credentialType string
 		IssuerDID:         issuerDID,
 		SubjectDID:        subjectDID,
 		Type:              credentialType,
-		CredentialSchema:  "https://example.com/schemas/" + credentialType,
+		CredentialSchema:  schemaID,
 		Status:            "active",
 		IssuanceDate:      now,
 		ExpirationDate:    expiration,
@@ -99,6 +137,7 @@ func (s *VCService) IssueCredential(issuerDID, subjectDID, credentialType string
 	if err := s.DB.Create(&credential).Error; err != nil {
 		return nil, fmt.Errorf("保存凭证失败: %v", err)
 	}
+	s.Anchor.RecordCredentialEvent(credential.CredentialID, AnchorEventIssued, credentialAnchorContent(&credential))
 
 	return &credential, nil
 }
@@ -128,17 +167,21 @@ func (s *VCService) VerifyCredential(credentialID string) (*models.VerifyCredent
 	}
 
 	// 验证颁发者DID
-	var issuer models.DID
-	if err := s.DB.Where("did_string = ? AND status = ?", credential.IssuerDID, "active").First(&issuer).Error; err != nil {
+	if err := s.checkDIDActive(credential.IssuerDID); err != nil {
 		return &models.VerifyCredentialResponse{
 			Valid:  false,
 			Reason: "颁发者DID无效",
 		}, nil
 	}
+	if problem := issuerTrustProblem(s.DB, credential.IssuerDID, credential.Type, false); problem != "" {
+		return &models.VerifyCredentialResponse{
+			Valid:  false,
+			Reason: problem,
+		}, nil
+	}
 
 	// 验证主体DID
-	var subject models.DID
-	if err := s.DB.Where("did_string = ? AND status = ?", credential.SubjectDID, "active").First(&subject).Error; err != nil {
+	if err := s.checkDIDActive(credential.SubjectDID); err != nil {
 		return &models.VerifyCredentialResponse{
 			Valid:  false,
 			Reason: "主体DID无效",
@@ -154,6 +197,16 @@ func (s *VCService) VerifyCredential(credentialID string) (*models.VerifyCredent
 		}, nil
 	}
 
+	// 核对链上锚定记录：内容被篡改或已在链上撤销的凭证无效
+	anchorStatus, reason := s.checkAnchor(credential.CredentialID, credentialAnchorContent(&credential))
+	if reason != "" {
+		return &models.VerifyCredentialResponse{
+			Valid:  false,
+			Reason: reason,
+			Anchor: anchorStatus,
+		}, nil
+	}
+
 	// 记录验证时间
 	credential.LastVerified = &time.Time{}
 	*credential.LastVerified = time.Now()
@@ -166,6 +219,7 @@ func (s *VCService) VerifyCredential(credentialID string) (*models.VerifyCredent
 		IssuedAt:    credential.IssuanceDate.Format(time.RFC3339),
 		ExpiresAt:   credential.ExpirationDate.Format(time.RFC3339),
 		Credentials: []string{credential.Type},
+		Anchor:      anchorStatus,
 	}, nil
 }
 
@@ -191,133 +245,11 @@ func (s *VCService) RevokeCredential(credentialID, issuerDID, reason string) err
 	if err := s.DB.Save(&credential).Error; err != nil {
 		return fmt.Errorf("撤销凭证失败: %v", err)
 	}
+	s.Anchor.RecordCredentialEvent(credential.CredentialID, AnchorEventRevoked, credentialAnchorContent(&credential))
 
 	return nil
 }
 
-// CreatePresentation 创建可验证表示
-func (s *VCService) CreatePresentation(holderDID, verifierDID string, credentialIDs []string, purpose string) (*models.VerifiablePresentation, error) {
-	// 验证持有者DID
-	var holder models.DID
-	if err := s.DB.Where("did_string = ? AND status = ?", holderDID, "active").First(&holder).Error; err != nil {
-		return nil, fmt.Errorf("持有者DID无效: %v", err)
-	}
-
-	// 验证所有凭证
-	var credentials []models.VerifiableCredential
-	for _, credID := range credentialIDs {
-		var cred models.VerifiableCredential
-		if err := s.DB.Where("credential_id = ? AND status = ? AND subject_did = ?",
-			credID, "active", holderDID).First(&cred).Error; err != nil {
-			return nil, fmt.Errorf("凭证 %s 无效或不属于持有者: %v", credID, err)
-		}
-		credentials = append(credentials, cred)
-	}
-
-	// 生成表示ID
-	presentationID := fmt.Sprintf("urn:uuid:%s", uuid.New().String())
-
-	// 创建表示记录
-	now := time.Now()
-	presentation := models.VerifiablePresentation{
-		PresentationID:   presentationID,
-		HolderDID:        holderDID,
-		VerifierDID:      verifierDID,
-		CredentialIDs:    credentialIDs,
-		Purpose:          purpose,
-		Challenge:        uuid.New().String(), // 生成随机挑战值
-		PresentationDate: now,
-		Status:           "active",
-	}
-
-	// 创建证明（在实际应用中应该使用密码学方法生成）
-	proof := map[string]interface{}{
-		"type":               "EcdsaSecp256k1Signature2019",
-		"created":            now.Format(time.RFC3339),
-		"proofPurpose":       "authentication",
-		"verificationMethod": fmt.Sprintf("%s#keys-1", holderDID),
-		"challenge":          presentation.Challenge,
-	}
-	proofJSON, err := json.Marshal(proof)
-	if err != nil {
-		return nil, fmt.Errorf("序列化表示证明失败: %v", err)
-	}
-	presentation.Proof = string(proofJSON)
-
-	// 保存到数据库
-	if err := s.DB.Create(&presentation).Error; err != nil {
-		return nil, fmt.Errorf("保存表示失败: %v", err)
-	}
-
-	return &presentation, nil
-}
-
-// VerifyPresentation 验证可验证表示
-func (s *VCService) VerifyPresentation(presentationID string) (*models.VerifyPresentationResponse, error) {
-	// 查询表示记录
-	var presentation models.VerifiablePresentation
-	if err := s.DB.Where("presentation_id = ?", presentationID).First(&presentation).Error; err != nil {
-		return nil, fmt.Errorf("表示不存在: %v", err)
-	}
-
-	// 验证表示状态
-	if presentation.Status != "active" {
-		return &models.VerifyPresentationResponse{
-			Valid:  false,
-			Reason: "表示已被撤销",
-		}, nil
-	}
-
-	// 验证持有者DID
-	var holder models.DID
-	if err := s.DB.Where("did_string = ? AND status = ?", presentation.HolderDID, "active").First(&holder).Error; err != nil {
-		return &models.VerifyPresentationResponse{
-			Valid:  false,
-			Reason: "持有者DID无效",
-		}, nil
-	}
-
-	// 验证所有包含的凭证
-	for _, credID := range presentation.CredentialIDs {
-		var cred models.VerifiableCredential
-		if err := s.DB.Where("credential_id = ? AND status = ?", credID, "active").First(&cred).Error; err != nil {
-			return &models.VerifyPresentationResponse{
-				Valid:  false,
-				Reason: fmt.Sprintf("凭证 %s 无效", credID),
-			}, nil
-		}
-
-		// 验证凭证是否过期
-		if cred.ExpirationDate.Before(time.Now()) {
-			return &models.VerifyPresentationResponse{
-				Valid:  false,
-				Reason: fmt.Sprintf("凭证 %s 已过期", credID),
-			}, nil
-		}
-	}
-
-	// 验证证明（在实际应用中应该使用密码学方法验证）
-	// 这里简化处理，仅检查证明是否存在
-	if presentation.Proof == "" {
-		return &models.VerifyPresentationResponse{
-			Valid:  false,
-			Reason: "表示证明无效",
-		}, nil
-	}
-
-	// 记录验证时间
-	now := time.Now()
-	presentation.LastVerified = &now
-	s.DB.Save(&presentation)
-
-	return &models.VerifyPresentationResponse{
-		Valid:         true,
-		HolderDID:     presentation.HolderDID,
-		PresentedAt:   presentation.PresentationDate.Format(time.RFC3339),
-		CredentialIDs: presentation.CredentialIDs,
-	}, nil
-}
-
 // GetCredential 获取凭证详情
 func (s *VCService) GetCredential(credentialID string) (*models.VerifiableCredentialResponse, error) {
 	// 从数据库查询凭证
@@ -342,6 +274,10 @@ func (s *VCService) GetCredential(credentialID string) (*models.VerifiableCreden
 		CredentialSubject: map[string]interface{}{
 			"id": credential.SubjectDID,
 		},
+		CredentialSchema: map[string]interface{}{
+			"id":   credential.CredentialSchema,
+			"type": "JsonSchema",
+		},
 		Proof: models.Proof{
 			Type:               "SimpleProof2024",
 			Created:            credential.IssuanceDate.UTC().Format(time.RFC3339),
@@ -351,6 +287,14 @@ func (s *VCService) GetCredential(credentialID string) (*models.VerifiableCreden
 		},
 	}
 
+	// 解析凭证主体中的声明
+	var subjectClaims map[string]interface{}
+	if err := json.Unmarshal([]byte(credential.CredentialSubject), &subjectClaims); err == nil {
+		for k, v := range subjectClaims {
+			vc.CredentialSubject[k] = v
+		}
+	}
+
 	// 解析claims并添加到凭证主体
 	var claims map[string]interface{}
 	if err := json.Unmarshal([]byte(credential.Claims), &claims); err != nil {
@@ -437,6 +381,7 @@ func (s *VCService) GetPresentation(presentationID string) (*models.VerifiablePr
 			VerificationMethod: fmt.Sprintf("%s#keys-1", presentation.HolderDID),
 			ProofPurpose:       "authentication",
 			Challenge:          presentation.Challenge,
+			Domain:             presentation.Domain,
 			ProofValue:         presentation.Proof,
 		},
 	}
@@ -479,187 +424,545 @@ func generateChallenge() string {
 	return hex.EncodeToString(b)
 }
 
-// IssueDoctorVC 颁发医生可验证凭证
-func (s *VCService) IssueDoctorVC(issuerDID, doctorDID, vcType, vcContent string) (*models.DoctorVC, error) {
-	fmt.Printf("开始颁发医生凭证: issuerDID=%s, doctorDID=%s, vcType=%s\n", issuerDID, doctorDID, vcType)
-	
+// 主体凭证默认有效期
+const subjectCredentialValidityYears = 1
+
+// errDuplicateSubjectCredential 主体已持有同一颁发者的同类型有效凭证
+var errDuplicateSubjectCredential = errors.New("duplicate subject credential")
+
+// IssueSubjectCredential 按角色模板为主体颁发凭证，同一主体可持有多种类型的凭证；
+// proof须由颁发医院的登记钱包对IssueSubjectCredential操作签名
+func (s *VCService) IssueSubjectCredential(proof models.ActionProof, issuerDID, subjectDID, role, vcType, vcContent, schemaID string) (*models.SubjectCredential, error) {
 	// 验证参数
-	if issuerDID == "" || doctorDID == "" || vcType == "" {
-		return nil, fmt.Errorf("颁发者DID、医生DID和凭证类型不能为空")
+	if issuerDID == "" || subjectDID == "" || vcType == "" {
+		return nil, fmt.Errorf("颁发者DID、主体DID和凭证类型不能为空")
+	}
+
+	// 签名绑定颁发者、主体、角色、类型、模式和凭证内容
+	err := authorizeHospitalSigner(s.DB, s.Challenges, proof, issuerDID, "IssueSubjectCredential", map[string]string{
+		"issuer":      issuerDID,
+		"subject":     subjectDID,
+		"role":        role,
+		"type":        vcType,
+		"schemaId":    schemaID,
+		"contentHash": auth.HashParam([]byte(vcContent)),
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 验证医院DID（简化验证）
-	if issuerDID != "0x1234" && !IsHospitalDID(issuerDID) {
-		fmt.Printf("医院DID验证失败: %s\n", issuerDID)
-		return nil, fmt.Errorf("颁发者DID不是有效的医院DID")
+	// 主体必须拥有该角色的有效档案，且凭证类型属于该角色的模板
+	template, err := GetRoleTemplate(role)
+	if err != nil {
+		return nil, err
+	}
+	credentialTemplate, err := template.credentialTemplate(vcType)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := NewSubjectService(s.DB).GetProfile(subjectDID, role)
+	if err != nil {
+		return nil, err
 	}
 
-	// 验证医生DID
-	var doctor models.Doctor
-	if err := s.DB.Where("did_string = ? AND status = ?", doctorDID, "active").First(&doctor).Error; err != nil {
-		fmt.Printf("医生DID验证失败: %v\n", err)
-		return nil, fmt.Errorf("医生DID无效: %v", err)
+	// 按模板从档案补全声明，请求中提供的内容优先
+	content := map[string]interface{}{}
+	if vcContent != "" {
+		if err := json.Unmarshal([]byte(vcContent), &content); err != nil {
+			return nil, fmt.Errorf("凭证内容必须是JSON对象: %v", err)
+		}
 	}
-	fmt.Printf("找到医生记录: %+v\n", doctor)
-
-	// 检查该医生是否已经有活跃的凭证
-	// var existingVC models.DoctorVC
-	// result := s.DB.Where("doctor_did = ? AND status = ?", doctorDID, "active").First(&existingVC)
-	// fmt.Println("result:",result)
-	// if result.Error == nil {
-	// 	fmt.Printf("发现现有凭证，直接返回: %+v\n", existingVC)
-	// 	return &existingVC, nil
-	// } else if result.Error != gorm.ErrRecordNotFound {
-	// 	fmt.Printf("查询现有凭证失败: %v\n", result.Error)
-	// 	return nil, fmt.Errorf("查询现有凭证失败: %v", result.Error)
-	// }
-
-	// 简化的钱包地址检查 - 直接查询所有该钱包的凭证
-	var walletVCs []models.DoctorVC
-	var walletsToCheck []string
-	
-	// 先获取所有同钱包地址的医生DID
-	var doctorsWithSameWallet []models.Doctor
-	if err := s.DB.Where("wallet_address = ? AND status = ?", doctor.WalletAddress, "active").Find(&doctorsWithSameWallet).Error; err != nil {
-		fmt.Printf("查询同钱包医生失败: %v\n", err)
-		return nil, fmt.Errorf("查询同钱包医生失败: %v", err)
-	}
-	
-	for _, d := range doctorsWithSameWallet {
-		walletsToCheck = append(walletsToCheck, d.DIDString)
-	}
-	
-	if len(walletsToCheck) > 0 {
-		if err := s.DB.Where("doctor_did IN ? AND status = ?", walletsToCheck, "active").Find(&walletVCs).Error; err != nil {
-			fmt.Printf("查询钱包凭证失败: %v\n", err)
-			return nil, fmt.Errorf("查询钱包凭证失败: %v", err)
+	for _, claim := range credentialTemplate.ProfileClaims {
+		if _, provided := content[claim]; provided {
+			continue
 		}
-		
-		if len(walletVCs) > 0 {
-			fmt.Printf("钱包地址已经有凭证，返回第一个: %+v\n", walletVCs[0])
-			return &walletVCs[0], nil
+		if claim == "name" {
+			content[claim] = profile.Name
+		} else if value, ok := profile.Attributes[claim]; ok {
+			content[claim] = value
 		}
 	}
+	contentJSON, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("序列化凭证内容失败: %v", err)
+	}
 
-	// 生成凭证ID
-	vcID := fmt.Sprintf("vc:%s", uuid.New().String())
-	fmt.Printf("生成凭证ID: %s\n", vcID)
-
-	// 创建凭证
-	now := time.Now()
-	expiration := now.AddDate(1, 0, 0) // 1年后过期
+	credential, err := s.newSubjectCredential(issuerDID, subjectDID, role, vcType, string(contentJSON), schemaID)
+	if err != nil {
+		return nil, err
+	}
 
-	// 创建可验证凭证记录
-	doctorVC := models.DoctorVC{
-		VCID:      vcID,
-		DoctorDID: doctorDID,
-		IssuerDID: issuerDID,
-		Type:      vcType,
-		Content:   vcContent,
-		IssuedAt:  now,
-		ExpiresAt: expiration,
-		Status:    "active",
+	// 同一颁发者对同一主体的同类型凭证只能有一份处于有效或暂停状态，更新内容应使用续期或取代；
+	// 检查和保存在锁定主体档案行的事务中进行，同一主体的并发颁发依次执行
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var locked models.SubjectProfile
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, profile.ID).Error; err != nil {
+			return err
+		}
+		var count int64
+		err := tx.Model(&models.SubjectCredential{}).
+			Where("subject_did = ? AND issuer_did = ? AND type = ? AND status IN ?", subjectDID, issuerDID, vcType, []string{"active", "suspended"}).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return errDuplicateSubjectCredential
+		}
+		return tx.Create(credential).Error
+	})
+	if errors.Is(err, errDuplicateSubjectCredential) {
+		return nil, fmt.Errorf("主体已持有该颁发者的 %s 凭证，请使用续期或取代", vcType)
 	}
+	if err != nil {
+		log.Printf("保存主体凭证失败: %v", err)
+		return nil, fmt.Errorf("保存凭证失败: %v", err)
+	}
+	s.Anchor.RecordCredentialEvent(credential.VCID, AnchorEventIssued, credential.Content)
 
-	fmt.Printf("准备保存凭证: %+v\n", doctorVC)
+	return credential, nil
+}
 
-	// 保存到数据库
-	if err := s.DB.Create(&doctorVC).Error; err != nil {
-		fmt.Printf("保存医生凭证失败: %v\n", err)
-		return nil, fmt.Errorf("保存医生凭证失败: %v", err)
+// newSubjectCredential 校验颁发者和凭证内容并构造新的凭证记录（不保存）
+func (s *VCService) newSubjectCredential(issuerDID, subjectDID, role, vcType, vcContent, schemaID string) (*models.SubjectCredential, error) {
+	// 颁发者必须是已登记、未暂停且被授权颁发该类型凭证的机构
+	if problem := issuerTrustProblem(s.DB, issuerDID, vcType, true); problem != "" {
+		return nil, fmt.Errorf("%s", problem)
 	}
 
-	fmt.Printf("成功保存凭证: %+v\n", doctorVC)
-	return &doctorVC, nil
+	// 凭证内容必须是符合已注册模式的JSON对象
+	var content map[string]interface{}
+	if err := json.Unmarshal([]byte(vcContent), &content); err != nil {
+		return nil, fmt.Errorf("凭证内容必须是JSON对象: %v", err)
+	}
+	schemaService := NewSchemaService(s.DB)
+	if schemaID == "" {
+		schema, err := schemaService.GetLatestSchemaByName(vcType)
+		if err != nil {
+			return nil, err
+		}
+		schemaID = schema.SchemaID
+	}
+	if _, err := schemaService.ValidateClaims(schemaID, vcType, content); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &models.SubjectCredential{
+		VCID:       fmt.Sprintf("vc:%s", uuid.New().String()),
+		SubjectDID: subjectDID,
+		Role:       role,
+		IssuerDID:  issuerDID,
+		Type:       vcType,
+		Content:    vcContent,
+		IssuedAt:   now,
+		ExpiresAt:  now.AddDate(subjectCredentialValidityYears, 0, 0),
+		Status:     "active",
+		SchemaID:   schemaID,
+	}, nil
 }
 
-// VerifyDoctorVC 验证医生可验证凭证
-func (s *VCService) VerifyDoctorVC(vcID string) (*models.VerifyDoctorVCResponse, error) {
+// VerifySubjectCredential 验证主体凭证
+func (s *VCService) VerifySubjectCredential(vcID string) (*models.VerifySubjectCredentialResponse, error) {
 	// 查询凭证记录
-	var doctorVC models.DoctorVC
-	if err := s.DB.Where("vcid = ?", vcID).First(&doctorVC).Error; err != nil {
+	var credential models.SubjectCredential
+	if err := s.DB.Where("vcid = ?", vcID).First(&credential).Error; err != nil {
 		return nil, fmt.Errorf("凭证不存在: %v", err)
 	}
 
+	response := &models.VerifySubjectCredentialResponse{
+		SubjectDID: credential.SubjectDID,
+		Role:       credential.Role,
+		IssuerDID:  credential.IssuerDID,
+		VCType:     credential.Type,
+		Status:     credential.Status,
+	}
+	invalid := func(reason string) (*models.VerifySubjectCredentialResponse, error) {
+		response.SupersededBy = credential.SupersededBy
+		response.Reason = reason
+		return response, nil
+	}
+
 	// 验证凭证状态
-	if doctorVC.Status != "active" {
-		return &models.VerifyDoctorVCResponse{
-			Valid:  false,
-			Reason: "凭证已被撤销",
-		}, nil
+	switch credential.Status {
+	case "active":
+	case "suspended":
+		return invalid("凭证已被暂停")
+	case "superseded":
+		return invalid("凭证已被新凭证取代")
+	default:
+		return invalid("凭证已被撤销")
 	}
 
 	// 验证过期时间
-	if doctorVC.ExpiresAt.Before(time.Now()) {
-		return &models.VerifyDoctorVCResponse{
-			Valid:  false,
-			Reason: "凭证已过期",
-		}, nil
+	if credential.ExpiresAt.Before(time.Now()) {
+		return invalid("凭证已过期")
 	}
 
-	// 验证医生DID
-	var doctor models.Doctor
-	if err := s.DB.Where("did_string = ? AND status = ?", doctorVC.DoctorDID, "active").First(&doctor).Error; err != nil {
-		return &models.VerifyDoctorVCResponse{
-			Valid:  false,
-			Reason: "医生DID无效",
-		}, nil
+	// 验证主体档案
+	if _, err := NewSubjectService(s.DB).GetProfile(credential.SubjectDID, credential.Role); err != nil {
+		return invalid(fmt.Sprintf("%sDID无效", roleLabel(credential.Role)))
+	}
+
+	// 验证颁发机构（暂停或撤销授权后，其颁发的凭证一律无效）
+	if problem := issuerTrustProblem(s.DB, credential.IssuerDID, credential.Type, true); problem != "" {
+		return invalid(problem)
+	}
+
+	// 核对链上锚定记录
+	anchorStatus, reason := s.checkAnchor(credential.VCID, credential.Content)
+	response.Anchor = anchorStatus
+	if reason != "" {
+		return invalid(reason)
 	}
 
 	// 验证成功
-	return &models.VerifyDoctorVCResponse{
-		Valid:     true,
-		DoctorDID: doctorVC.DoctorDID,
-		IssuerDID: doctorVC.IssuerDID,
-		VCType:    doctorVC.Type,
-	}, nil
+	response.Valid = true
+	return response, nil
 }
 
-// GetDoctorVCs 获取医生的所有可验证凭证
-func (s *VCService) GetDoctorVCs(doctorDID string) ([]models.DoctorVC, error) {
-	// 验证医生DID
-	var doctor models.Doctor
-	if err := s.DB.Where("did_string = ? AND status = ?", doctorDID, "active").First(&doctor).Error; err != nil {
-		return nil, fmt.Errorf("医生DID无效: %v", err)
+// GetSubjectCredentials 获取主体的凭证，可按角色、类型和状态过滤
+func (s *VCService) GetSubjectCredentials(subjectDID, role, vcType, status string) ([]models.SubjectCredential, error) {
+	query := s.DB.Where("subject_did = ?", subjectDID)
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+	if vcType != "" {
+		query = query.Where("type = ?", vcType)
+	}
+	switch status {
+	case "":
+	case "expired":
+		// 过期不是持久化的状态，按有效期计算
+		query = query.Where("status = ? AND expires_at < ?", "active", time.Now())
+	case "active":
+		query = query.Where("status = ? AND expires_at >= ?", "active", time.Now())
+	default:
+		query = query.Where("status = ?", status)
 	}
 
-	// 查询所有凭证
-	var doctorVCs []models.DoctorVC
-	if err := s.DB.Where("doctor_did = ?", doctorDID).Find(&doctorVCs).Error; err != nil {
-		return nil, fmt.Errorf("获取医生凭证失败: %v", err)
+	var credentials []models.SubjectCredential
+	if err := query.Order("issued_at DESC").Find(&credentials).Error; err != nil {
+		return nil, fmt.Errorf("获取凭证失败: %v", err)
 	}
 
-	return doctorVCs, nil
+	return credentials, nil
 }
 
-// RevokeDoctorVC 撤销医生可验证凭证
-func (s *VCService) RevokeDoctorVC(vcID, issuerDID string) error {
-	// 查询凭证记录
-	var doctorVC models.DoctorVC
-	if err := s.DB.Where("vcid = ?", vcID).First(&doctorVC).Error; err != nil {
-		return fmt.Errorf("凭证不存在: %v", err)
+// loadIssuedCredential 查询凭证并确认操作由其颁发者签名，
+// 签名绑定操作名称、凭证ID、颁发者和params中的其他参数
+func (s *VCService) loadIssuedCredential(proof models.ActionProof, action, vcID, issuerDID string, params map[string]string) (*models.SubjectCredential, error) {
+	var credential models.SubjectCredential
+	if err := s.DB.Where("vcid = ?", vcID).First(&credential).Error; err != nil {
+		return nil, fmt.Errorf("凭证不存在: %v", err)
+	}
+	if credential.IssuerDID != issuerDID {
+		return nil, fmt.Errorf("只有颁发者可以变更凭证状态")
 	}
 
-	// 验证颁发者
-	if doctorVC.IssuerDID != issuerDID {
-		return fmt.Errorf("只有颁发者可以撤销凭证")
+	signed := map[string]string{"vcId": vcID, "issuer": issuerDID}
+	for name, value := range params {
+		signed[name] = value
+	}
+	if err := s.authorizeIssuerSigner(proof, issuerDID, action, signed); err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// authorizeIssuerSigner 校验操作由颁发者签名：已登记的医院须由其登记钱包签名，
+// 其他颁发者须由DID文档assertionMethod中的密钥对应的钱包签名
+func (s *VCService) authorizeIssuerSigner(proof models.ActionProof, issuerDID, action string, params map[string]string) error {
+	var hospital models.Hospital
+	err := s.DB.Where("did_string = ?", issuerDID).First(&hospital).Error
+	if err == nil {
+		if !strings.EqualFold(hospital.WalletAddress, proof.Address) {
+			return fmt.Errorf("%w: 签名者不是医院登记的钱包", auth.ErrActionUnauthorized)
+		}
+		return s.Challenges.Verify(proof, action, params)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("查询颁发机构失败: %v", err)
+	}
+
+	signers, err := s.assertionAddresses(issuerDID)
+	if err != nil {
+		return err
+	}
+	if !common.IsHexAddress(proof.Address) || !signers[common.HexToAddress(proof.Address)] {
+		return fmt.Errorf("%w: 签名者不能代表颁发者 %s 签名", auth.ErrActionUnauthorized, issuerDID)
+	}
+	return s.Challenges.Verify(proof, action, params)
+}
+
+// assertionAddresses 解析DID，返回其assertionMethod引用的验证方法对应的以太坊地址
+func (s *VCService) assertionAddresses(didString string) (map[common.Address]bool, error) {
+	if s.Resolver == nil {
+		return nil, fmt.Errorf("未配置DID解析器")
+	}
+	result, err := s.Resolver.Resolve(context.Background(), didString, nil)
+	if err != nil {
+		return nil, err
+	}
+	if result.DIDResolutionMetadata.Error != "" || result.DIDDocument == nil {
+		return nil, fmt.Errorf("DID解析失败: %s", result.DIDResolutionMetadata.Error)
+	}
+	if result.DIDDocumentMetadata.Deactivated {
+		return nil, fmt.Errorf("DID已停用")
+	}
+
+	doc := result.DIDDocument
+	addresses := make(map[common.Address]bool)
+	for _, ref := range doc.AssertionMethod {
+		if strings.HasPrefix(ref, "#") {
+			ref = doc.ID + ref
+		}
+		for _, method := range doc.VerificationMethod {
+			id := method.ID
+			if strings.HasPrefix(id, "#") {
+				id = doc.ID + id
+			}
+			if id != ref {
+				continue
+			}
+			if address, ok := verificationMethodAddress(method); ok {
+				addresses[address] = true
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// RevokeSubjectCredential 撤销主体凭证
+func (s *VCService) RevokeSubjectCredential(proof models.ActionProof, vcID, issuerDID, reason string) (*models.SubjectCredential, error) {
+	credential, err := s.loadIssuedCredential(proof, "RevokeSubjectCredential", vcID, issuerDID, map[string]string{"reason": reason})
+	if err != nil {
+		return nil, err
+	}
+	if credential.Status == "revoked" {
+		return nil, fmt.Errorf("凭证已被撤销")
 	}
 
 	// 更新凭证状态
 	now := time.Now()
-	doctorVC.Status = "revoked"
-	doctorVC.RevocationDate = &now
+	credential.Status = "revoked"
+	credential.RevocationDate = &now
+	credential.StatusReason = reason
 
-	if err := s.DB.Save(&doctorVC).Error; err != nil {
-		return fmt.Errorf("撤销凭证失败: %v", err)
+	if err := s.DB.Save(credential).Error; err != nil {
+		return nil, fmt.Errorf("撤销凭证失败: %v", err)
+	}
+	s.Anchor.RecordCredentialEvent(credential.VCID, AnchorEventRevoked, credential.Content)
+
+	return credential, nil
+}
+
+// SuspendSubjectCredential 暂停主体凭证，暂停期间验证不通过
+func (s *VCService) SuspendSubjectCredential(proof models.ActionProof, vcID, issuerDID, reason string) (*models.SubjectCredential, error) {
+	credential, err := s.loadIssuedCredential(proof, "SuspendSubjectCredential", vcID, issuerDID, map[string]string{"reason": reason})
+	if err != nil {
+		return nil, err
+	}
+	if credential.Status != "active" {
+		return nil, fmt.Errorf("只能暂停有效状态的凭证，当前状态: %s", credential.Status)
+	}
+
+	now := time.Now()
+	credential.Status = "suspended"
+	credential.SuspendedAt = &now
+	credential.StatusReason = reason
+
+	if err := s.DB.Save(credential).Error; err != nil {
+		return nil, fmt.Errorf("暂停凭证失败: %v", err)
+	}
+
+	return credential, nil
+}
+
+// ReinstateSubjectCredential 恢复被暂停的主体凭证
+func (s *VCService) ReinstateSubjectCredential(proof models.ActionProof, vcID, issuerDID string) (*models.SubjectCredential, error) {
+	credential, err := s.loadIssuedCredential(proof, "ReinstateSubjectCredential", vcID, issuerDID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if credential.Status != "suspended" {
+		return nil, fmt.Errorf("只能恢复暂停状态的凭证，当前状态: %s", credential.Status)
+	}
+	if credential.ExpiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("凭证已过期，不能恢复")
+	}
+
+	credential.Status = "active"
+	credential.SuspendedAt = nil
+	credential.StatusReason = ""
+
+	if err := s.DB.Save(credential).Error; err != nil {
+		return nil, fmt.Errorf("恢复凭证失败: %v", err)
 	}
 
+	return credential, nil
+}
+
+// RenewSubjectCredential 在到期前续期凭证：以相同内容颁发新凭证，并由新凭证取代旧凭证
+func (s *VCService) RenewSubjectCredential(proof models.ActionProof, vcID, issuerDID string) (*models.SubjectCredential, error) {
+	credential, err := s.loadIssuedCredential(proof, "RenewSubjectCredential", vcID, issuerDID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if credential.Status != "active" {
+		return nil, fmt.Errorf("只能续期有效状态的凭证，当前状态: %s", credential.Status)
+	}
+	if credential.ExpiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("凭证已过期，请重新颁发")
+	}
+
+	return s.replaceSubjectCredential(credential, credential.Content, credential.SchemaID, "续期")
+}
+
+// SupersedeSubjectCredential 以新内容颁发凭证并取代旧凭证（如职称变更）
+func (s *VCService) SupersedeSubjectCredential(proof models.ActionProof, vcID, issuerDID, vcContent, schemaID string) (*models.SubjectCredential, error) {
+	credential, err := s.loadIssuedCredential(proof, "SupersedeSubjectCredential", vcID, issuerDID, map[string]string{
+		"schemaId":    schemaID,
+		"contentHash": auth.HashParam([]byte(vcContent)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if credential.Status != "active" && credential.Status != "suspended" {
+		return nil, fmt.Errorf("只能取代有效或暂停状态的凭证，当前状态: %s", credential.Status)
+	}
+
+	return s.replaceSubjectCredential(credential, vcContent, schemaID, "取代")
+}
+
+// replaceSubjectCredential 颁发替代凭证，并在同一事务中将旧凭证标记为已取代
+func (s *VCService) replaceSubjectCredential(old *models.SubjectCredential, vcContent, schemaID, reason string) (*models.SubjectCredential, error) {
+	replacement, err := s.newSubjectCredential(old.IssuerDID, old.SubjectDID, old.Role, old.Type, vcContent, schemaID)
+	if err != nil {
+		return nil, err
+	}
+	replacement.ReplacesVCID = old.VCID
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// 以旧状态为条件更新，避免并发下同一凭证被取代两次
+		result := tx.Model(&models.SubjectCredential{}).
+			Where("id = ? AND status = ?", old.ID, old.Status).
+			Updates(map[string]interface{}{
+				"status":        "superseded",
+				"superseded_by": replacement.VCID,
+				"status_reason": reason,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("凭证状态已变化")
+		}
+		return tx.Create(replacement).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s凭证失败: %v", reason, err)
+	}
+	s.Anchor.RecordCredentialEvent(replacement.VCID, AnchorEventIssued, replacement.Content)
+
+	return replacement, nil
+}
+
+// checkDIDActive 确认DID可用：平台登记的DID须处于有效状态，其余DID须能通过通用解析器解析且未停用
+func (s *VCService) checkDIDActive(didString string) error {
+	var did models.DID
+	err := s.DB.Where("did_string = ?", didString).First(&did).Error
+	if err == nil {
+		if did.Status != "active" {
+			return fmt.Errorf("DID已停用")
+		}
+		return nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return err
+	}
+	if s.Resolver == nil {
+		return fmt.Errorf("DID不存在")
+	}
+
+	result, err := s.Resolver.Resolve(context.Background(), didString, nil)
+	if err != nil {
+		return err
+	}
+	if result.DIDResolutionMetadata.Error != "" {
+		return fmt.Errorf("DID解析失败: %s", result.DIDResolutionMetadata.Error)
+	}
+	if result.DIDDocumentMetadata.Deactivated {
+		return fmt.Errorf("DID已停用")
+	}
 	return nil
 }
 
-// 辅助函数：检查是否为医院DID
-func IsHospitalDID(did string) bool {
-	// 在实际应用中，应该查询数据库或区块链验证DID
-	// 这里简化处理，假设以"hospital"开头的DID为有效医院DID
-	return len(did) > 8 && did[0:8] == "hospital"
+// credentialAnchorContent 通用凭证参与锚定的内容
+func credentialAnchorContent(credential *models.VerifiableCredential) string {
+	return strings.Join([]string{credential.IssuerDID, credential.SubjectDID, credential.Type, credential.CredentialSubject, credential.Claims}, "|")
+}
+
+// checkAnchor 核对凭证的链上锚定记录，返回核对结果和无效原因；未启用锚定时均为空
+func (s *VCService) checkAnchor(credentialID, content string) (*models.CredentialAnchorStatus, string) {
+	if s.Anchor == nil {
+		return nil, ""
+	}
+	status, err := s.Anchor.CheckCredential(credentialID, content)
+	if err != nil {
+		log.Printf("核对凭证锚定记录失败: %v", err)
+		return nil, ""
+	}
+	if status.RevokedOnChain {
+		return status, "凭证已在链上撤销"
+	}
+	if status.Anchored && !status.ContentMatches {
+		return status, "凭证内容与链上锚定记录不一致"
+	}
+	return status, ""
+}
+
+// IssueDoctorVC 颁发医生可验证凭证（医生角色的主体凭证）
+func (s *VCService) IssueDoctorVC(proof models.ActionProof, issuerDID, doctorDID, vcType, vcContent, schemaID string) (*models.DoctorVC, error) {
+	credential, err := s.IssueSubjectCredential(proof, issuerDID, doctorDID, "doctor", vcType, vcContent, schemaID)
+	if err != nil {
+		return nil, err
+	}
+	doctorVC := credential.ToDoctorVC()
+	return &doctorVC, nil
+}
+
+// VerifyDoctorVC 验证医生可验证凭证
+func (s *VCService) VerifyDoctorVC(vcID string) (*models.VerifyDoctorVCResponse, error) {
+	result, err := s.VerifySubjectCredential(vcID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.VerifyDoctorVCResponse{
+		Valid:        result.Valid,
+		DoctorDID:    result.SubjectDID,
+		IssuerDID:    result.IssuerDID,
+		VCType:       result.VCType,
+		Status:       result.Status,
+		SupersededBy: result.SupersededBy,
+		Reason:       result.Reason,
+	}, nil
+}
+
+// GetDoctorVCs 获取医生的可验证凭证，可按类型和状态过滤
+func (s *VCService) GetDoctorVCs(doctorDID, vcType, status string) ([]models.DoctorVC, error) {
+	// 验证医生DID
+	if _, err := NewSubjectService(s.DB).GetProfile(doctorDID, "doctor"); err != nil {
+		return nil, fmt.Errorf("医生DID无效: %v", err)
+	}
+
+	credentials, err := s.GetSubjectCredentials(doctorDID, "doctor", vcType, status)
+	if err != nil {
+		return nil, err
+	}
+	doctorVCs := make([]models.DoctorVC, 0, len(credentials))
+	for _, credential := range credentials {
+		doctorVCs = append(doctorVCs, credential.ToDoctorVC())
+	}
+	return doctorVCs, nil
 }
@@ -7,12 +7,16 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/gin-gonic/gin"
+
+	auth_service "github.com/ABE/nft/nft-go-backend/internal/api/auth/service"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
 )
 
 // GetRequestAuthMiddleware GET请求的签名认证中间件
@@ -65,6 +69,79 @@ func GetRequestAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// EventStreamAction 订阅事件流签名的操作名称，挑战通过 /api/auth/challenge 申请
+const EventStreamAction = "SubscribeEvents"
+
+// EventStreamAuthMiddleware SSE事件流的认证中间件，见ChallengeQueryAuthMiddleware。
+// 浏览器EventSource无法设置请求头，只能通过查询参数认证；每次连接（包括重连）都需要新的挑战
+func EventStreamAuthMiddleware(challenges *auth_service.ChallengeService) gin.HandlerFunc {
+	return ChallengeQueryAuthMiddleware(challenges, EventStreamAction)
+}
+
+// ChallengeQueryAuthMiddleware 查询接口的认证中间件：钱包须对该操作的一次性挑战签名（无参数），
+// 挑战过期或已使用时拒绝，没有测试旁路。address、nonce、signature通过查询参数传递，每次请求都需要新的挑战
+func ChallengeQueryAuthMiddleware(challenges *auth_service.ChallengeService, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		proof := models.ActionProof{
+			Address:   c.Query("address"),
+			Nonce:     c.Query("nonce"),
+			Signature: c.Query("signature"),
+		}
+		if err := challenges.Verify(proof, action, nil); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		c.Set("walletAddress", proof.Address)
+		c.Next()
+	}
+}
+
+// rateWindow 一个客户端在当前窗口内的请求数
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// rateLimitSweepSize 计数表超过该大小时清理已结束的窗口
+const rateLimitSweepSize = 1024
+
+// RateLimitMiddleware 按客户端IP限制请求频率，每个窗口内最多limit次，超出返回429
+func RateLimitMiddleware(limit int, window time.Duration) gin.HandlerFunc {
+	var mu sync.Mutex
+	windows := make(map[string]*rateWindow)
+	return func(c *gin.Context) {
+		now := time.Now()
+		key := c.ClientIP()
+
+		mu.Lock()
+		current, ok := windows[key]
+		if !ok || now.Sub(current.start) >= window {
+			if !ok && len(windows) >= rateLimitSweepSize {
+				for k, w := range windows {
+					if now.Sub(w.start) >= window {
+						delete(windows, k)
+					}
+				}
+			}
+			current = &rateWindow{start: now}
+			windows[key] = current
+		}
+		current.count++
+		exceeded := current.count > limit
+		retryAfter := current.start.Add(window).Sub(now)
+		mu.Unlock()
+
+		if exceeded {
+			c.Header("Retry-After", fmt.Sprintf("%d", int(retryAfter.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "请求过于频繁，请稍后再试"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // SignatureAuthMiddleware 验证以太坊签名的中间件
 func SignatureAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -118,47 +195,42 @@ func SignatureAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
-// verifySignature 验证以太坊签名
-func verifySignature(address, signature, message string) bool {
-	// 1. 将消息转换为以太坊可验证的格式
-	msgHash := signHash([]byte(message))
+// TypedDataBuilder 根据请求体还原签名者应签名的EIP-712数据，返回请求声明的签名者地址和签名
+type TypedDataBuilder func(body []byte) (address, signature string, typedData *apitypes.TypedData, err error)
 
-	// 检查地址格式
-	if !common.IsHexAddress(address) {
-		return false
-	}
-	address = common.HexToAddress(address).Hex() // 标准化地址格式
-
-	// 2. 从签名中提取数据
-	sig := hexutil.MustDecode(signature)
+// TypedDataAuthMiddleware 验证EIP-712类型化数据签名的中间件，用于用户签名、平台代为提交的链上操作。
+// 与SignatureAuthMiddleware的自由格式消息不同，签名覆盖操作参数、nonce和截止时间，合约提交时会再次验证
+func TypedDataAuthMiddleware(build TypedDataBuilder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bodyBytes, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "读取请求体失败"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 
-	if len(sig) != 65 {
-		fmt.Println("签名长度不正确")
-		return false
-	}
+		address, signature, typedData, err := build(bodyBytes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求格式: " + err.Error()})
+			c.Abort()
+			return
+		}
 
-	// 检查v值并可能调整
-	v := sig[64]
-	if v > 1 {
-		sig[64] -= 27
-	}
+		signer, err := util.RecoverTypedDataSigner(*typedData, signature)
+		if err != nil || signer != common.HexToAddress(address) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "类型化数据签名验证失败"})
+			c.Abort()
+			return
+		}
 
-	// 3. 使用以太坊的函数恢复出签名者地址
-	sigPublicKey, err := crypto.Ecrecover(msgHash, sig)
-	if err != nil {
-		fmt.Println("公钥恢复错误:", err)
-		return false
+		c.Set("walletAddress", address)
+		c.Set("rawRequestBody", bodyBytes)
+		c.Next()
 	}
-
-	// 4. 从公钥派生出地址
-	var addr common.Address
-	copy(addr[:], crypto.Keccak256(sigPublicKey[1:])[12:])
-	// 5. 比较恢复出的地址与提供的地址
-	return strings.EqualFold(addr.Hex(), address)
 }
 
-// signHash 将消息转换为以太坊签名格式
-func signHash(data []byte) []byte {
-	msg := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(data), data)
-	return crypto.Keccak256([]byte(msg))
+// verifySignature 验证以太坊签名
+func verifySignature(address, signature, message string) bool {
+	return util.VerifyPersonalSignature(address, signature, message)
 }
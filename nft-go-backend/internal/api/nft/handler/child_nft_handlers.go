@@ -2,7 +2,9 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"math/big"
 	"net/http"
 	"strconv"
@@ -13,20 +15,34 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"github.com/ABE/nft/nft-go-backend/internal/api/nft/service"
+	auth "github.com/ABE/nft/nft-go-backend/internal/api/auth/service"
 	"github.com/ABE/nft/nft-go-backend/internal/blockchain"
+	did_vc "github.com/ABE/nft/nft-go-backend/internal/api/did_vc/service"
 	"github.com/ABE/nft/nft-go-backend/internal/models"
 	abe "github.com/ABE/nft/nft-go-backend/internal/api/abe/service"
 )
 
 // ChildNFTHandlers 子NFT相关处理程序结构体
 type ChildNFTHandlers struct {
-	Client *blockchain.EthClient
+	Client   *blockchain.EthClient
+	Requests *service.ChildRequestService
+	Mints    *service.ChildMintService
+	Ledger   *service.TxLedgerService
+	Rules    *service.IssuanceRuleService
+
+	// Challenges 批量处理申请需要父NFT持有者对一次性挑战签名
+	Challenges *auth.ChallengeService
 }
 
 // NewChildNFTHandlers 创建新的子NFT处理程序
-func NewChildNFTHandlers(client *blockchain.EthClient) *ChildNFTHandlers {
+func NewChildNFTHandlers(client *blockchain.EthClient, requests *service.ChildRequestService, mints *service.ChildMintService, rules *service.IssuanceRuleService, ledger *service.TxLedgerService) *ChildNFTHandlers {
 	return &ChildNFTHandlers{
-		Client: client,
+		Client:   client,
+		Requests: requests,
+		Ledger:   ledger,
+		Mints:    mints,
+		Rules:    rules,
 	}
 }
 
@@ -86,17 +102,42 @@ func (h *ChildNFTHandlers) CreateChildNFTHandler(c *gin.Context) {
 	// 转换recipient为以太坊地址
 	recipient := common.HexToAddress(req.Recipient)
 
-	// 创建子NFT
-	txHash, err := h.Client.CreateChildNFT(parentTokenID, walletAddressLower, recipient, req.URI)
+	// 签名请求必须未过期且nonce仍有效，否则合约会拒绝
+	signer, nonce, deadline, err := parseTypedDataSignedRequest(&req.TypedDataSignedRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.Client.CheckMetaTx(signer, nonce, deadline); err != nil {
+		c.JSON(metaTxErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// 提交持有者签名的CreateChildRequest创建子NFT
+	txHash, err := h.Client.CreateChildNFTWithSignature(signer, parentTokenID, recipient, req.URI, deadline, req.Signature)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建子NFT失败: " + err.Error()})
 		return
 	}
 
+	// 记入交易账本并等待最终状态，超时返回pending；确认后由账本写入子NFT记录
+	record, err := h.Ledger.Submit(blockchain.TxKindCreateChild, walletAddressLower, requestPayloadHash(c), txHash,
+		map[string]string{"parentTokenId": req.ParentTokenID, "uri": req.URI})
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "子NFT创建交易未成功: " + err.Error(), "transactionHash": txHash, "status": record.Status})
+		return
+	}
+
 	// 构造响应
+	message := "子NFT创建交易已确认"
+	if record.Status == models.TxStatusPending {
+		message = "子NFT创建交易已提交，等待打包，可通过 /api/tx/" + txHash + " 查询结果"
+	}
 	response := models.TransactionResponse{
 		TransactionHash: txHash,
-		Message:         "子NFT创建交易已提交",
+		Status:          record.Status,
+		TokenID:         record.TokenID,
+		Message:         message,
 	}
 
 	// 返回JSON响应
@@ -140,6 +181,12 @@ func (h *ChildNFTHandlers) RequestChildNFTHandler(c *gin.Context) {
 		return
 	}
 
+	// 只能为签名钱包自己申请，发行规则和子NFT接收者都按申请者地址计算
+	if !strings.EqualFold(req.ApplicantAddress, walletAddress) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "申请者地址必须是签名钱包地址"})
+		return
+	}
+
 	// 验证父NFT是否存在
 	parentTokenID, ok := new(big.Int).SetString(req.ParentTokenId, 10)
 	if !ok {
@@ -158,26 +205,57 @@ func (h *ChildNFTHandlers) RequestChildNFTHandler(c *gin.Context) {
 
 	fmt.Printf("父NFT所有者: %s\n", owner)
 
+	// 检查父NFT持有者设置的发行规则
+	rule, err := h.Rules.CheckRequest(req.ParentTokenId, req.ApplicantAddress, time.Now())
+	var violation *service.IssuanceRuleViolation
+	if errors.As(err, &violation) {
+		c.JSON(http.StatusForbidden, gin.H{"error": violation.Message, "rule": violation.Rule})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 创建申请记录
 	request := models.ChildNFTRequest{
 		ParentTokenId:    req.ParentTokenId,
 		ApplicantAddress: req.ApplicantAddress,
 		URI:              req.URI,
-		Status:           "pending",
 		VCCredentials:    req.VCCredentials,
 		AutoApproved:     false,
 	}
 
 	// 如果提供了VC凭证且要求自动审核，尝试进行策略验证
 	var autoApproved bool = false
+	var policySatisfied bool = false
 	var policyResult map[string]interface{}
 
-	if req.AutoApprove && req.VCCredentials != "" {
+	if req.AutoApprove && rule != nil && !rule.AutoApproveAllowed {
+		policyResult = map[string]interface{}{
+			"reason":                 "父NFT持有者不允许自动审核",
+			"rule":                   service.IssuanceRuleAutoApproveDisabled,
+			"manual_review_required": true,
+		}
+	} else if req.AutoApprove && req.PresentationID == "" {
+		policyResult = map[string]interface{}{
+			"reason":                 "自动审核需要提供基于验证者挑战签名的可验证展示(presentationId)",
+			"manual_review_required": true,
+		}
+	} else if req.AutoApprove {
 		fmt.Printf("开始自动审核流程...\n")
 
+		// 验证可验证展示（校验签名、挑战值新鲜度并一次性消费）
+		presentedClaims, presentationErr := h.verifyApplicantPresentation(req.PresentationID, req.ParentTokenId, req.ApplicantAddress)
+
 		// 获取父NFT的访问策略
 		accessPolicy, err := h.getAccessPolicyForNFT(req.ParentTokenId)
-		if err != nil {
+		if presentationErr != nil {
+			policyResult = map[string]interface{}{
+				"reason":                 "可验证展示验证失败: " + presentationErr.Error(),
+				"manual_review_required": true,
+			}
+		} else if err != nil {
 			fmt.Printf("获取NFT访问策略失败: %v\n", err)
 		} else if accessPolicy != "" {
 			fmt.Printf("NFT访问策略: %s\n", accessPolicy)
@@ -185,8 +263,8 @@ func (h *ChildNFTHandlers) RequestChildNFTHandler(c *gin.Context) {
 			// 创建ABE服务实例进行策略验证
 			abeService := abe.NewABEService(models.DB)
 
-			// 验证VC凭证是否满足策略
-			satisfied, verificationResult, err := abeService.VerifyVCAgainstPolicy(req.VCCredentials, accessPolicy)
+			// 验证展示中的凭证声明是否满足策略
+			satisfied, verificationResult, err := abeService.VerifyVCAgainstPolicy(presentedClaims, accessPolicy)
 			if err != nil {
 				fmt.Printf("策略验证过程出错: %v\n", err)
 				policyResult = map[string]interface{}{
@@ -195,39 +273,11 @@ func (h *ChildNFTHandlers) RequestChildNFTHandler(c *gin.Context) {
 				}
 			} else {
 				policyResult = verificationResult
+				policyResult["presentationId"] = req.PresentationID
 
 				if satisfied {
 					fmt.Printf("VC凭证满足访问策略，自动审核通过\n")
-					autoApproved = true
-					request.Status = "auto_approved"
-					request.AutoApproved = true
-
-					// 自动创建子NFT
-					recipientAddr := common.HexToAddress(req.ApplicantAddress)
-					txHash, err := h.Client.CreateChildNFT(parentTokenID, owner, recipientAddr, req.URI)
-					if err != nil {
-						fmt.Printf("自动创建子NFT失败: %v\n", err)
-						// 如果创建失败，回退到手动审核
-						autoApproved = false
-						request.Status = "pending"
-						request.AutoApproved = false
-						policyResult["auto_creation_error"] = err.Error()
-					} else {
-						fmt.Printf("自动创建子NFT成功，交易哈希: %s\n", txHash)
-						request.ChildTokenID = fmt.Sprintf("auto_%d", time.Now().Unix())
-						policyResult["transaction_hash"] = txHash
-
-						// 将子NFT信息保存到NFT表中
-						childNFT := models.NFT{
-							TokenID:       request.ChildTokenID,
-							Owner:         req.ApplicantAddress,
-							URI:           req.URI,
-							IsChildNFT:    true,
-							ParentTokenID: req.ParentTokenId,
-							ContractType:  "child",
-						}
-						models.DB.Create(&childNFT)
-					}
+					policySatisfied = true
 				} else {
 					fmt.Printf("VC凭证不满足访问策略，需要手动审核\n")
 					policyResult["reason"] = "VC凭证不满足访问策略要求"
@@ -249,26 +299,53 @@ func (h *ChildNFTHandlers) RequestChildNFTHandler(c *gin.Context) {
 	}
 
 	fmt.Printf("保存申请记录到数据库...\n")
-	result := models.DB.Create(&request)
-	if result.Error != nil {
-		fmt.Printf("保存申请记录失败: %v\n", result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存申请记录失败: " + result.Error.Error()})
+	if err := h.Requests.Create(&request, normalizeAddress(walletAddress), "提交申请"); err != nil {
+		log.Printf("保存子NFT申请记录失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	fmt.Printf("申请记录保存成功，ID: %d\n", request.ID)
 
+	// 凭证满足策略时自动批准并创建子NFT，交易未能发送则申请保持待审批
+	if policySatisfied {
+		approved, err := h.Mints.AutoApprove(c.Request.Context(), request.ID, request.Version,
+			"凭证满足访问策略，自动审核通过", parentTokenID, requestPayloadHash(c))
+		if approved != nil {
+			request = *approved
+		}
+		if err != nil && !errors.Is(err, service.ErrChildMintPending) {
+			log.Printf("申请 %d 自动创建子NFT失败: %v", request.ID, err)
+			policyResult["auto_creation_error"] = err.Error()
+		} else {
+			autoApproved = true
+		}
+		if request.TxHash != "" {
+			policyResult["transaction_hash"] = request.TxHash
+		}
+		policyResultJSON, _ := json.Marshal(policyResult)
+		request.PolicyResult = string(policyResultJSON)
+		models.DB.Model(&request).Update("policy_result", request.PolicyResult)
+	}
+
 	// 构建响应
 	response := gin.H{
 		"requestId":    request.ID,
 		"ownerAddress": owner,
 		"autoApproved": autoApproved,
+		"status":       request.Status,
+		"version":      request.Version,
+		"expiresAt":    request.ExpiresAt,
 	}
 
 	if autoApproved {
 		response["message"] = "VC凭证验证通过，子NFT申请已自动审核并创建"
-		response["transactionHash"] = policyResult["transaction_hash"]
+		if request.Status == models.ChildRequestStatusMinting {
+			response["message"] = "VC凭证验证通过，子NFT申请已自动审核，创建交易等待确认"
+		}
+		response["transactionHash"] = request.TxHash
 		response["childTokenId"] = request.ChildTokenID
+		response["blockNumber"] = request.BlockNumber
 	} else {
 		response["message"] = "子NFT申请已提交，等待父NFT持有者审批"
 		if policyResult != nil {
@@ -279,11 +356,57 @@ func (h *ChildNFTHandlers) RequestChildNFTHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// childNFTPresentationDomain 子NFT自动审核所用展示挑战的验证者域
+func childNFTPresentationDomain(parentTokenID string) string {
+	return "child-nft:" + parentTokenID
+}
+
+// verifyApplicantPresentation 验证申请者提交的可验证展示，返回合并后的凭证声明JSON
+func (h *ChildNFTHandlers) verifyApplicantPresentation(presentationID, parentTokenID, applicantAddress string) (string, error) {
+	vcService := did_vc.NewVCService(models.DB)
+
+	domain := childNFTPresentationDomain(parentTokenID)
+	result, err := vcService.VerifyPresentation(presentationID, domain)
+	if err != nil {
+		return "", err
+	}
+	if !result.Valid {
+		return "", fmt.Errorf("%s", result.Reason)
+	}
+
+	// 展示持有者必须是申请者本人，核对后才消费挑战值，他人无法用别人的展示消耗其挑战
+	var holder models.DID
+	if err := models.DB.Where("did_string = ?", result.HolderDID).First(&holder).Error; err != nil {
+		return "", fmt.Errorf("持有者DID不存在: %v", err)
+	}
+	if normalizeAddress(holder.WalletAddress) != normalizeAddress(applicantAddress) {
+		return "", fmt.Errorf("展示持有者与申请者不一致")
+	}
+	if result, err = vcService.ConsumePresentation(presentationID, domain); err != nil {
+		return "", err
+	}
+	if !result.Valid {
+		return "", fmt.Errorf("%s", result.Reason)
+	}
+
+	claims, err := vcService.CollectPresentationClaims(presentationID)
+	if err != nil {
+		return "", err
+	}
+	claims["wallet"] = applicantAddress
+
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("序列化凭证声明失败: %v", err)
+	}
+	return string(claimsJSON), nil
+}
+
 // getAccessPolicyForNFT 获取NFT的访问策略
 func (h *ChildNFTHandlers) getAccessPolicyForNFT(tokenId string) (string, error) {
 	// 第一步：根据token ID查询NFT记录，获取URI
 	var nft models.NFT
-	nftResult := models.DB.Where("token_id = ?", tokenId).First(&nft)
+	nftResult := models.DB.Scopes(h.Client.NFTContract("main").Scope).Where("token_id = ?", tokenId).First(&nft)
 	if nftResult.Error != nil {
 		fmt.Printf("Token %s 在NFT表中未找到记录: %v\n", tokenId, nftResult.Error)
 		return "", fmt.Errorf("NFT %s 不存在", tokenId)
@@ -332,104 +455,79 @@ func (h *ChildNFTHandlers) getAccessPolicyForNFT(tokenId string) (string, error)
 	return metadata.Policy, nil
 }
 
-// GetAllRequestsHandler 获取所有的子NFT申请记录
+// GetAllRequestsHandler 分页查询与当前钱包相关的子NFT申请记录。
+// 视图由路径参数或view查询参数指定：incoming（持有父NFT）、outgoing（自己提交）、all（默认）；
+// 支持status（逗号分隔）、parentTokenId、applicant、from/to（RFC3339，按创建时间）、autoApproved过滤，
+// sort=createdAt|updatedAt、order=asc|desc，limit和cursor分页
 func (h *ChildNFTHandlers) GetAllRequestsHandler(c *gin.Context) {
-	// 获取验证后的钱包地址
-	walletAddressInterface, exists := c.Get("walletAddress")
-	if !exists {
-		fmt.Printf("无法获取钱包地址，用户未经过身份验证\n")
+	walletAddress := c.GetString("walletAddress")
+	if walletAddress == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未经过身份验证"})
 		return
 	}
 
-	walletAddress, ok := walletAddressInterface.(string)
-	if !ok {
-		fmt.Printf("钱包地址类型转换失败\n")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "钱包地址格式错误"})
-		return
+	filter := service.ChildRequestFilter{
+		View:          c.Param("view"),
+		ParentTokenID: c.Query("parentTokenId"),
+		Applicant:     c.Query("applicant"),
+		Sort:          c.Query("sort"),
+		Cursor:        c.Query("cursor"),
 	}
-
-	fmt.Printf("处理请求列表查询，钱包地址: %s\n", walletAddress)
-
-	// 查询该地址拥有的所有NFT
-	var nfts []models.NFT
-	result := models.DB.Where("owner = ? AND (is_child_nft = ? OR is_child_nft IS NULL)", walletAddress, false).Find(&nfts)
-	if result.Error != nil {
-		fmt.Printf("查询NFT失败: %v\n", result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询NFT失败: " + result.Error.Error()})
-		return
+	if filter.View == "" {
+		filter.View = c.Query("view")
 	}
-	fmt.Printf("找到用户拥有的NFT数量: %d\n", len(nfts))
-
-	// 收集所有tokenId
-	var tokenIds []string
-	for _, nft := range nfts {
-		tokenIds = append(tokenIds, nft.TokenID)
-		fmt.Printf("用户拥有的NFT: TokenID=%s\n", nft.TokenID)
+	if status := c.Query("status"); status != "" {
+		for _, value := range strings.Split(status, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				filter.Statuses = append(filter.Statuses, value)
+			}
+		}
 	}
-
-	// 查询所有申请记录 - 这里只查询与用户相关的记录
-	var allRequests []models.ChildNFTRequest
-	// 查询自己申请的或者申请自己NFT的记录
-	models.DB.Where("applicant_address = ? OR parent_token_id IN (?)", walletAddress, tokenIds).Find(&allRequests)
-	fmt.Printf("找到与用户相关的申请记录数量: %d\n", len(allRequests))
-
-	// 构建响应数据
-	var responseRequests []models.ChildNFTRequestWithParentInfo
-
-	// 处理所有申请记录
-	for _, req := range allRequests {
-		// 检查是否是申请自己的NFT
-		isMyNFT := false
-		for _, tokenId := range tokenIds {
-			if tokenId == req.ParentTokenId {
-				isMyNFT = true
-				break
+	switch strings.ToLower(c.DefaultQuery("order", "desc")) {
+	case "asc":
+		filter.Ascending = true
+	case "desc":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order只能是asc或desc"})
+		return
+	}
+	for param, target := range map[string]**time.Time{"from": &filter.CreatedFrom, "to": &filter.CreatedTo} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的%s时间，应为RFC3339格式", param)})
+				return
 			}
+			*target = &parsed
 		}
-
-		// 设置RequestId字段为ID的字符串形式
-		req.RequestId = fmt.Sprintf("%d", req.ID)
-
-		// 调试输出
-		fmt.Printf("处理请求 ID=%d, RequestId=%s, 状态=%s\n", req.ID, req.RequestId, req.Status)
-
-		var parentNFTOwner string
-		var canOperate bool
-
-		if isMyNFT {
-			// 这是别人申请自己的NFT
-			fmt.Printf("找到申请用户NFT的请求: ID=%d, ParentTokenId=%s, 申请者=%s\n",
-				req.ID, req.ParentTokenId, req.ApplicantAddress)
-			parentNFTOwner = walletAddress
-			canOperate = true // 作为父NFT拥有者可以操作
-		} else {
-			// 这是自己申请的NFT
-			fmt.Printf("找到用户自己的申请: ID=%d, ParentTokenId=%s\n", req.ID, req.ParentTokenId)
-			// 获取父NFT拥有者信息
-			var parentNFT models.NFT
-			models.DB.Where("token_id = ?", req.ParentTokenId).First(&parentNFT)
-			parentNFTOwner = parentNFT.Owner
-			canOperate = false // 作为申请者不能操作，只能查看
+	}
+	if value := c.Query("autoApproved"); value != "" {
+		autoApproved, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的autoApproved参数"})
+			return
 		}
-
-		responseReq := models.ChildNFTRequestWithParentInfo{
-			ChildNFTRequest: req,
-			ParentNFTOwner:  parentNFTOwner,
-			CanOperate:      canOperate,
+		filter.AutoApproved = &autoApproved
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的limit参数"})
+			return
 		}
-
-		// 确保ID字段正确设置
-		fmt.Printf("响应请求对象: ID=%d, RequestId=%s, CanOperate=%v\n",
-			responseReq.ID, responseReq.RequestId, responseReq.CanOperate)
-
-		responseRequests = append(responseRequests, responseReq)
+		filter.Limit = limit
 	}
 
-	fmt.Printf("返回给客户端的请求数量: %d\n", len(responseRequests))
-
-	// 始终返回一个数组，即使为空
-	c.JSON(http.StatusOK, gin.H{"requests": responseRequests})
+	page, err := h.Requests.List(walletAddress, filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidChildRequestQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
 }
 
 // ProcessRequestHandler 处理子NFT申请
@@ -531,11 +629,22 @@ func (h *ChildNFTHandlers) ProcessRequestHandler(c *gin.Context) {
 		return
 	}
 
+	// 可选：客户端看到的申请版本号（乐观锁）与处理原因
+	var expectedVersion uint
+	if versionVal, exists := rawRequest["version"]; exists {
+		version, ok := versionVal.(float64)
+		if !ok || version < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的version字段"})
+			return
+		}
+		expectedVersion = uint(version)
+	}
+	reason, _ := rawRequest["reason"].(string)
+
 	// 查询申请记录
-	var request models.ChildNFTRequest
-	result := models.DB.First(&request, requestID)
-	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "申请记录不存在"})
+	request, err := h.Requests.Get(requestID)
+	if err != nil {
+		c.JSON(childRequestErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -559,48 +668,262 @@ func (h *ChildNFTHandlers) ProcessRequestHandler(c *gin.Context) {
 	}
 
 	if action == "approve" {
-		// 批准申请，创建子NFT
-		recipient := common.HexToAddress(request.ApplicantAddress)
-		txHash, err := h.Client.CreateChildNFT(parentTokenID, walletAddress, recipient, request.URI)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "创建子NFT失败: " + err.Error()})
+		// 批准需要父NFT持有者对该申请的CreateChildRequest签名，由合约验证后创建子NFT
+		var signed struct {
+			Authorization *models.ChildMintAuthorization `json:"authorization"`
+		}
+		if err := json.Unmarshal(bodyBytes, &signed); err != nil || signed.Authorization == nil ||
+			signed.Authorization.Signature == "" || signed.Authorization.Nonce == "" || signed.Authorization.Deadline == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "批准申请需要authorization字段（父NFT持有者对CreateChildRequest的签名、nonce和deadline）"})
 			return
 		}
-
-		// 更新申请状态并记录子NFT的tokenID
-		request.Status = "approved"
-		// 这里需要获取新创建的子NFT的tokenID，暂时使用时间戳作为占位符
-		// 在实际应用中，应该从交易回执中获取实际的tokenID
-		request.ChildTokenID = fmt.Sprintf("child_%d", time.Now().Unix())
-		models.DB.Save(&request)
-
-		// 将子NFT信息保存到NFT表中
-		childNFT := models.NFT{
-			TokenID:       request.ChildTokenID,
-			Owner:         request.ApplicantAddress,
-			URI:           request.URI,
-			IsChildNFT:    true,
-			ParentTokenID: request.ParentTokenId,
-			ContractType:  "child",
+		if reason == "" {
+			reason = "父NFT持有者批准"
+		}
+		// 先占用申请再创建子NFT，重复批准会因状态不允许而失败，不会再次铸造
+		approved, err := h.Mints.Approve(c.Request.Context(), request.ID, expectedVersion,
+			normalizedWallet, reason, parentTokenID, owner, signed.Authorization, requestPayloadHash(c))
+		if errors.Is(err, service.ErrChildMintPending) {
+			c.JSON(http.StatusAccepted, gin.H{
+				"message":         "申请已批准，创建子NFT的交易等待确认",
+				"transactionHash": approved.TxHash,
+				"status":          approved.Status,
+				"version":         approved.Version,
+			})
+			return
+		}
+		if err != nil {
+			status := childRequestErrorStatus(err)
+			response := gin.H{"error": "创建子NFT失败: " + err.Error()}
+			var violation *service.IssuanceRuleViolation
+			if errors.As(err, &violation) {
+				response["rule"] = violation.Rule
+			}
+			if approved != nil {
+				// 占用成功但交易未能发送（已回滚为pending）或交易执行失败（failed）
+				status = http.StatusInternalServerError
+				response["status"] = approved.Status
+				response["transactionHash"] = approved.TxHash
+			}
+			c.JSON(status, response)
+			return
 		}
-		models.DB.Create(&childNFT)
 
 		c.JSON(http.StatusOK, gin.H{
 			"message":         "申请已批准，子NFT已创建",
-			"transactionHash": txHash,
-			"childTokenId":    request.ChildTokenID,
+			"transactionHash": approved.TxHash,
+			"blockNumber":     approved.BlockNumber,
+			"childTokenId":    approved.ChildTokenID,
+			"status":          approved.Status,
+			"version":         approved.Version,
 		})
 	} else {
 		// 拒绝申请
-		request.Status = "rejected"
-		models.DB.Save(&request)
+		if reason == "" {
+			reason = "父NFT持有者拒绝"
+		}
+		rejected, err := h.Requests.Transition(request.ID, expectedVersion, models.ChildRequestStatusRejected, normalizedWallet, reason, nil)
+		if err != nil {
+			c.JSON(childRequestErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"message": "申请已拒绝",
+			"status":  rejected.Status,
+			"version": rejected.Version,
 		})
 	}
 }
 
+// BatchProcessRequestsHandler 父NFT持有者用一次签名批量批准或拒绝子NFT申请，返回逐项结果
+func (h *ChildNFTHandlers) BatchProcessRequestsHandler(c *gin.Context) {
+	walletAddress := c.GetString("walletAddress")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未经过身份验证"})
+		return
+	}
+
+	var req models.BatchProcessRequestsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+	if len(req.Items) > service.MaxBatchProcessItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("单次最多处理 %d 个申请", service.MaxBatchProcessItems)})
+		return
+	}
+
+	// 签名消息必须与按本批次申请和一次性挑战重新构造的消息完全一致，挑战有过期时间且验证后即被消费
+	params := service.BatchProcessParams(h.Client.Deployment.Name, req.Items)
+	expected, err := h.Challenges.Message(req.Nonce, service.BatchProcessAction, params)
+	if err == nil && req.Message != expected {
+		err = fmt.Errorf("%w: 签名消息与本批次的申请和操作不一致", auth.ErrActionUnauthorized)
+	}
+	if err == nil {
+		proof := models.ActionProof{Address: walletAddress, Nonce: req.Nonce, Signature: req.Signature}
+		err = h.Challenges.Verify(proof, service.BatchProcessAction, params)
+	}
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":          err.Error(),
+			"expectedParams": params,
+		})
+		return
+	}
+
+	results := h.Mints.ProcessBatch(c.Request.Context(), walletAddress, req.Items, requestPayloadHash(c))
+	succeeded := 0
+	for _, result := range results {
+		if result.Success {
+			succeeded++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":   results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	})
+}
+
+// CancelRequestHandler 申请者撤回尚未处理的子NFT申请
+func (h *ChildNFTHandlers) CancelRequestHandler(c *gin.Context) {
+	walletAddress := c.GetString("walletAddress")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未经过身份验证"})
+		return
+	}
+
+	var req models.CancelChildNFTRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	request, err := h.Requests.Cancel(req.RequestID, req.Version, normalizeAddress(walletAddress), req.Reason)
+	if err != nil {
+		c.JSON(childRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "申请已撤回",
+		"status":  request.Status,
+		"version": request.Version,
+	})
+}
+
+// GetRequestHistoryHandler 查询子NFT申请的状态迁移历史，仅申请者和父NFT持有者可见
+func (h *ChildNFTHandlers) GetRequestHistoryHandler(c *gin.Context) {
+	walletAddress := c.GetString("walletAddress")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未经过身份验证"})
+		return
+	}
+
+	requestID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求ID格式"})
+		return
+	}
+
+	request, err := h.Requests.Get(uint(requestID))
+	if err != nil {
+		c.JSON(childRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	// 申请者之外只有父NFT的当前链上持有者可以查看，数据库中的持有者可能尚未同步
+	if !strings.EqualFold(request.ApplicantAddress, walletAddress) {
+		parentTokenID, _ := new(big.Int).SetString(request.ParentTokenId, 10)
+		owner, _, _, err := h.Client.GetNFTInfo(parentTokenID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取NFT信息失败: " + err.Error()})
+			return
+		}
+		if !strings.EqualFold(owner, walletAddress) {
+			c.JSON(http.StatusForbidden, gin.H{"error": service.ErrChildRequestForbidden.Error()})
+			return
+		}
+	}
+
+	history, err := h.Requests.History(request.ID)
+	if err != nil {
+		c.JSON(childRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"request": request,
+		"history": history,
+	})
+}
+
+// SetIssuanceRulesHandler 父NFT持有者设置子NFT发行规则
+func (h *ChildNFTHandlers) SetIssuanceRulesHandler(c *gin.Context) {
+	walletAddress := c.GetString("walletAddress")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未经过身份验证"})
+		return
+	}
+
+	var req models.SetIssuanceRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+
+	rule, err := h.Rules.SetRule(walletAddress, &req)
+	if errors.Is(err, service.ErrChildRequestForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有NFT所有者可以设置发行规则"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "发行规则已保存", "rule": rule})
+}
+
+// GetIssuanceRulesHandler 查询父NFT的子NFT发行规则
+func (h *ChildNFTHandlers) GetIssuanceRulesHandler(c *gin.Context) {
+	rule, err := h.Rules.GetRule(c.Param("tokenId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if rule == nil {
+		c.JSON(http.StatusOK, gin.H{"rule": nil, "message": "该NFT未设置发行规则"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rule": rule})
+}
+
+// childRequestErrorStatus 把申请状态机错误映射为HTTP状态码
+func childRequestErrorStatus(err error) int {
+	var violation *service.IssuanceRuleViolation
+	switch {
+	case errors.As(err, &violation):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrChildRequestNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrChildRequestForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, blockchain.ErrMetaTxSignature):
+		return http.StatusForbidden
+	case errors.Is(err, blockchain.ErrMetaTxExpired):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrChildRequestConflict),
+		errors.Is(err, service.ErrChildRequestTransition),
+		errors.Is(err, service.ErrChildRequestExpired),
+		errors.Is(err, blockchain.ErrMetaTxNonce):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // normalizeAddress 将以太坊地址转换为小写格式（去除0x前缀后转小写，再添加0x前缀）
 func normalizeAddress(addr string) string {
 	if len(addr) < 4 {
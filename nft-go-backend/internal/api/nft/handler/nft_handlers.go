@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -19,51 +20,83 @@ type NFTHandlers struct {
 }
 
 // NewNFTHandlers 创建新的NFT处理程序
-func NewNFTHandlers(client *blockchain.EthClient) *NFTHandlers {
+func NewNFTHandlers(client *blockchain.EthClient, ledger *service.TxLedgerService) *NFTHandlers {
 	return &NFTHandlers{
-		Service: service.NewNFTService(client),
+		Service: service.NewNFTService(client, ledger),
 	}
 }
 
-// GetAllNFTsHandler 获取所有NFT - 只显示主NFT
+// GetAllNFTsHandler 分页获取所有NFT - 只显示主NFT；查询参数consistency为db、chain或db-verified，offset、limit为分页参数
 func (h *NFTHandlers) GetAllNFTsHandler(c *gin.Context) {
-	nftResponses, err := h.Service.GetAllMainNFTs()
+	mode, err := h.Service.Consistency(c.Query("consistency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	offset, limit := 0, service.DefaultNFTPageSize
+	if value := c.Query("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的offset参数"})
+			return
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的limit参数"})
+			return
+		}
+	}
+	if limit > service.MaxNFTPageSize {
+		limit = service.MaxNFTPageSize
+	}
+
+	nftResponses, total, err := h.Service.GetAllMainNFTs(c.Request.Context(), mode, offset, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询NFT失败: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"nfts": nftResponses})
+	c.JSON(http.StatusOK, gin.H{"nfts": nftResponses, "consistency": mode, "total": total, "offset": offset, "limit": limit})
 }
 
-// GetUserNFTsHandler 获取指定用户的NFT
+// GetUserNFTsHandler 获取指定用户的NFT；查询参数consistency为db、chain或db-verified
 func (h *NFTHandlers) GetUserNFTsHandler(c *gin.Context) {
 	address := c.Param("address")
+	mode, err := h.Service.Consistency(c.Query("consistency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	nftResponses, err := h.Service.GetUserNFTs(address)
+	nftResponses, err := h.Service.GetUserNFTs(c.Request.Context(), address, mode)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询NFT失败: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"nfts": nftResponses})
+	c.JSON(http.StatusOK, gin.H{"nfts": nftResponses, "consistency": mode})
 }
 
-// GetMyNFTsHandler 获取当前用户的NFT (需要认证) - 包含子NFT
+// GetMyNFTsHandler 获取当前用户的NFT (需要认证) - 包含子NFT；查询参数consistency为db、chain或db-verified
 func (h *NFTHandlers) GetMyNFTsHandler(c *gin.Context) {
 	walletAddress, exists := c.Get("walletAddress")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未经过身份验证"})
 		return
 	}
+	mode, err := h.Service.Consistency(c.Query("consistency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	nftResponses, err := h.Service.GetMyNFTs(walletAddress.(string))
+	nftResponses, err := h.Service.GetMyNFTs(c.Request.Context(), walletAddress.(string), mode)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"nfts": nftResponses})
+	c.JSON(http.StatusOK, gin.H{"nfts": nftResponses, "consistency": mode})
 }
 
 // GetNFTHandler 获取NFT信息的处理程序
@@ -120,10 +153,20 @@ func (h *NFTHandlers) MintNFTHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求地址与签名地址不匹配"})
 		return
 	}
+	_, nonce, deadline, err := parseTypedDataSignedRequest(&req.TypedDataSignedRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	response, err := h.Service.MintNFT(walletAddress.(string), req.URI)
+	response, err := h.Service.MintNFT(walletAddress.(string), req.URI, nonce, deadline, req.Signature, requestPayloadHash(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if response != nil {
+			// 交易已发送但回滚或被替换
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "transactionHash": response.TransactionHash, "status": response.Status})
+			return
+		}
+		c.JSON(metaTxErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -153,7 +196,7 @@ func (h *NFTHandlers) UpdateMetadataHandler(c *gin.Context) {
 		return
 	}
 
-	response, err := h.Service.UpdateMetadata(walletAddress.(string), req.TokenID, req.ContractType, req.NewURI)
+	response, err := h.Service.UpdateMetadata(walletAddress.(string), req.TokenID, req.ContractType, req.NewURI, requestPayloadHash(c))
 	if err != nil {
 		if strings.Contains(err.Error(), "只有") {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
@@ -203,7 +246,7 @@ func (h *NFTHandlers) UpdateNFTURIHandler(c *gin.Context) {
 		return
 	}
 
-	response, err := h.Service.UpdateNFTURI(walletAddress.(string), req.TokenID, req.NewURI)
+	response, err := h.Service.UpdateNFTURI(walletAddress.(string), req.TokenID, req.NewURI, requestPayloadHash(c))
 	if err != nil {
 		if strings.Contains(err.Error(), "只有") {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+
+	"github.com/ABE/nft/nft-go-backend/internal/api/nft/service"
+)
+
+// txHashPattern 交易哈希格式
+var txHashPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+
+// TxHandlers 链上操作账本查询处理程序
+type TxHandlers struct {
+	Ledger *service.TxLedgerService
+}
+
+// NewTxHandlers 创建账本查询处理程序
+func NewTxHandlers(ledger *service.TxLedgerService) *TxHandlers {
+	return &TxHandlers{Ledger: ledger}
+}
+
+// GetTransactionHandler 查询接口发起的交易的状态、回执数据和解码后的事件
+func (h *TxHandlers) GetTransactionHandler(c *gin.Context) {
+	txHash := c.Param("hash")
+	if !txHashPattern.MatchString(txHash) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的交易哈希"})
+		return
+	}
+
+	record, err := h.Ledger.Get(c.Request.Context(), txHash)
+	if errors.Is(err, service.ErrChainTransactionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// requestPayloadHash 签名中间件保存的原始请求体的keccak256，记入交易账本
+func requestPayloadHash(c *gin.Context) string {
+	rawBody, exists := c.Get("rawRequestBody")
+	if !exists {
+		return ""
+	}
+	bodyBytes, ok := rawBody.([]byte)
+	if !ok {
+		return ""
+	}
+	return crypto.Keccak256Hash(bodyBytes).Hex()
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/gin-gonic/gin"
+
+	"github.com/ABE/nft/nft-go-backend/internal/api/nft/service"
+	"github.com/ABE/nft/nft-go-backend/internal/blockchain"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// GetMintTypedDataHandler 返回用户铸造NFT时需要用eth_signTypedData_v4签名的数据，nonce取主合约当前值，
+// 截止时间为META_TX_TTL秒后。签名后连同nonce、deadline提交到 /api/nft/mint
+func (h *NFTHandlers) GetMintTypedDataHandler(c *gin.Context) {
+	address := c.Query("address")
+	uri := c.Query("uri")
+	if !common.IsHexAddress(address) || uri == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要有效的address和uri"})
+		return
+	}
+
+	client := h.Service.Client
+	to := common.HexToAddress(address)
+	nonce, err := client.MetaTxNonce(to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	typedData := client.MintRequestTypedData(to, uri, nonce, metaTxDeadline(client))
+	c.JSON(http.StatusOK, gin.H{"typedData": typedData})
+}
+
+// GetCreateChildTypedDataHandler 返回父NFT持有者创建子NFT时需要签名的数据，签名后提交到 /api/nft/createChild
+func (h *ChildNFTHandlers) GetCreateChildTypedDataHandler(c *gin.Context) {
+	address := c.Query("address")
+	recipient := c.Query("recipient")
+	uri := c.Query("uri")
+	parentTokenID, ok := new(big.Int).SetString(c.Query("parentTokenId"), 10)
+	if !common.IsHexAddress(address) || !common.IsHexAddress(recipient) || uri == "" || !ok || parentTokenID.Sign() < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要有效的address、parentTokenId、recipient和uri"})
+		return
+	}
+
+	owner := common.HexToAddress(address)
+	nonce, err := h.Client.MetaTxNonce(owner)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	typedData := h.Client.CreateChildRequestTypedData(owner, parentTokenID, common.HexToAddress(recipient), uri, nonce, metaTxDeadline(h.Client))
+	c.JSON(http.StatusOK, gin.H{"typedData": typedData})
+}
+
+// GetApproveRequestTypedDataHandler 返回父NFT持有者批准申请时需要签名的CreateChildRequest：owner为父NFT的链上持有者，
+// receiver和uri取自申请。offset为同一批次中排在该申请之前的批准数量，批量批准时第k项的nonce为当前值加k。
+// 签名后作为authorization提交到 /api/nft/process-request 或 /api/nft/process-requests
+func (h *ChildNFTHandlers) GetApproveRequestTypedDataHandler(c *gin.Context) {
+	requestID, err := strconv.ParseUint(c.Query("requestId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要有效的requestId"})
+		return
+	}
+	offset := int64(0)
+	if raw := c.Query("offset"); raw != "" {
+		offset, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || offset < 0 || offset >= service.MaxBatchProcessItems {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("offset必须在0到%d之间", service.MaxBatchProcessItems-1)})
+			return
+		}
+	}
+
+	request, err := h.Requests.Get(uint(requestID))
+	if err != nil {
+		c.JSON(childRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	parentTokenID, ok := new(big.Int).SetString(request.ParentTokenId, 10)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "无效的父token ID"})
+		return
+	}
+	owner, _, _, err := h.Client.GetNFTInfo(parentTokenID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取NFT信息失败: " + err.Error()})
+		return
+	}
+	ownerAddress := common.HexToAddress(owner)
+	nonce, err := h.Client.MetaTxNonce(ownerAddress)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	nonce.Add(nonce, big.NewInt(offset))
+
+	typedData := h.Client.CreateChildRequestTypedData(ownerAddress, parentTokenID, common.HexToAddress(request.ApplicantAddress), request.URI, nonce, metaTxDeadline(h.Client))
+	c.JSON(http.StatusOK, gin.H{"typedData": typedData})
+}
+
+// MintRequestTypedData 从铸造请求体还原用户签名的MintRequest，供类型化数据签名中间件验证
+func (h *NFTHandlers) MintRequestTypedData(body []byte) (string, string, *apitypes.TypedData, error) {
+	var req models.MintRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", "", nil, err
+	}
+	to, nonce, deadline, err := parseTypedDataSignedRequest(&req.TypedDataSignedRequest)
+	if err != nil {
+		return "", "", nil, err
+	}
+	typedData := h.Service.Client.MintRequestTypedData(to, req.URI, nonce, deadline)
+	return req.Address, req.Signature, &typedData, nil
+}
+
+// CreateChildRequestTypedData 从创建子NFT请求体还原父NFT持有者签名的CreateChildRequest
+func (h *ChildNFTHandlers) CreateChildRequestTypedData(body []byte) (string, string, *apitypes.TypedData, error) {
+	var req models.CreateChildRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", "", nil, err
+	}
+	owner, nonce, deadline, err := parseTypedDataSignedRequest(&req.TypedDataSignedRequest)
+	if err != nil {
+		return "", "", nil, err
+	}
+	parentTokenID, ok := new(big.Int).SetString(req.ParentTokenID, 10)
+	if !ok || parentTokenID.Sign() < 0 {
+		return "", "", nil, fmt.Errorf("无效的父token ID")
+	}
+	if !common.IsHexAddress(req.Recipient) {
+		return "", "", nil, fmt.Errorf("无效的接收者地址")
+	}
+	typedData := h.Client.CreateChildRequestTypedData(owner, parentTokenID, common.HexToAddress(req.Recipient), req.URI, nonce, deadline)
+	return req.Address, req.Signature, &typedData, nil
+}
+
+// parseTypedDataSignedRequest 解析签名请求中的签名者地址、nonce和截止时间
+func parseTypedDataSignedRequest(req *models.TypedDataSignedRequest) (common.Address, *big.Int, *big.Int, error) {
+	if !common.IsHexAddress(req.Address) {
+		return common.Address{}, nil, nil, fmt.Errorf("无效的以太坊地址格式")
+	}
+	nonce, ok := new(big.Int).SetString(req.Nonce, 10)
+	if !ok || nonce.Sign() < 0 {
+		return common.Address{}, nil, nil, fmt.Errorf("无效的nonce")
+	}
+	deadline, ok := new(big.Int).SetString(req.Deadline, 10)
+	if !ok || deadline.Sign() <= 0 {
+		return common.Address{}, nil, nil, fmt.Errorf("无效的deadline")
+	}
+	return common.HexToAddress(req.Address), nonce, deadline, nil
+}
+
+// metaTxDeadline 新签名请求的截止时间
+func metaTxDeadline(client *blockchain.EthClient) *big.Int {
+	return big.NewInt(time.Now().Add(time.Duration(client.Config.MetaTxTTL) * time.Second).Unix())
+}
+
+// metaTxErrorStatus 签名请求失效对应的HTTP状态码：过期为400，nonce已被使用为409
+func metaTxErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, blockchain.ErrMetaTxExpired):
+		return http.StatusBadRequest
+	case errors.Is(err, blockchain.ErrMetaTxNonce):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
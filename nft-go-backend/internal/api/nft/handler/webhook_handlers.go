@@ -0,0 +1,190 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	auth "github.com/ABE/nft/nft-go-backend/internal/api/auth/service"
+	"github.com/ABE/nft/nft-go-backend/internal/api/nft/service"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// eventStreamHeartbeat SSE心跳间隔，防止代理因空闲断开连接
+const eventStreamHeartbeat = 25 * time.Second
+
+// WebhookHandlers Webhook登记与事件流处理程序
+type WebhookHandlers struct {
+	Webhooks   *service.WebhookService
+	Events     *service.EventService
+	Challenges *auth.ChallengeService // 写操作需要钱包对一次性操作挑战的签名
+}
+
+// NewWebhookHandlers 创建Webhook处理程序
+func NewWebhookHandlers(webhooks *service.WebhookService, events *service.EventService, challenges *auth.ChallengeService) *WebhookHandlers {
+	return &WebhookHandlers{
+		Webhooks:   webhooks,
+		Events:     events,
+		Challenges: challenges,
+	}
+}
+
+// authorize 校验并消费写操作的一次性挑战签名，失败时返回403和服务端用于构造消息的参数
+func (h *WebhookHandlers) authorize(c *gin.Context, proof models.ActionProof, action string, params map[string]string) bool {
+	if err := h.Challenges.Verify(proof, action, params); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":          err.Error(),
+			"expectedParams": params,
+		})
+		return false
+	}
+	return true
+}
+
+// RegisterWebhookHandler 登记Webhook，响应中的密钥只返回这一次。签名绑定接收地址、事件类型、密钥和描述
+func (h *WebhookHandlers) RegisterWebhookHandler(c *gin.Context) {
+	var req models.RegisterWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+	if !h.authorize(c, req.Proof, service.RegisterWebhookAction, service.RegisterWebhookParams(&req)) {
+		return
+	}
+
+	endpoint, secret, err := h.Webhooks.Register(req.Proof.Address, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"webhook": endpoint,
+		"secret":  secret,
+	})
+}
+
+// ListWebhooksHandler 列出当前钱包登记的Webhook
+func (h *WebhookHandlers) ListWebhooksHandler(c *gin.Context) {
+	walletAddress := c.GetString("walletAddress")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未经过身份验证"})
+		return
+	}
+
+	endpoints, err := h.Webhooks.List(walletAddress)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询Webhook失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks":   endpoints,
+		"eventTypes": service.ChildRequestEventTypes,
+	})
+}
+
+// DeleteWebhookHandler 删除当前钱包登记的Webhook
+func (h *WebhookHandlers) DeleteWebhookHandler(c *gin.Context) {
+	var req models.WebhookActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+	if !h.authorize(c, req.Proof, service.DeleteWebhookAction, service.WebhookActionParams(req.ID)) {
+		return
+	}
+
+	if err := h.Webhooks.Delete(req.Proof.Address, req.ID); err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ListDeadLettersHandler 列出当前钱包重试用尽的投递
+func (h *WebhookHandlers) ListDeadLettersHandler(c *gin.Context) {
+	walletAddress := c.GetString("walletAddress")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未经过身份验证"})
+		return
+	}
+
+	letters, err := h.Webhooks.ListDeadLetters(walletAddress)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询死信失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deadLetters": letters})
+}
+
+// RedeliverDeadLetterHandler 重新投递一条死信
+func (h *WebhookHandlers) RedeliverDeadLetterHandler(c *gin.Context) {
+	var req models.WebhookActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求体: " + err.Error()})
+		return
+	}
+	if !h.authorize(c, req.Proof, service.RedeliverDeadLetterAction, service.WebhookActionParams(req.ID)) {
+		return
+	}
+
+	delivery, err := h.Webhooks.Redeliver(req.Proof.Address, req.ID)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"delivery": delivery,
+	})
+}
+
+// StreamEventsHandler 以Server-Sent Events推送与当前钱包相关的子NFT申请事件
+func (h *WebhookHandlers) StreamEventsHandler(c *gin.Context) {
+	walletAddress := c.GetString("walletAddress")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未经过身份验证"})
+		return
+	}
+
+	sub := h.Events.Subscribe(walletAddress)
+	defer h.Events.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	io.WriteString(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+			return true
+		}
+	})
+}
+
+// webhookErrorStatus 将Webhook服务错误映射为HTTP状态码
+func webhookErrorStatus(err error) int {
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
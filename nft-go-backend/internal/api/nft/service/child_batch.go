@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// MaxBatchProcessItems 单次批量处理的申请数量上限
+const MaxBatchProcessItems = 50
+
+// BatchProcessStatement 批量处理签名消息中的申请声明，按提交顺序列出申请ID和操作，
+// 例如 "process-requests:12:approve,13:reject"，使一次签名只对应这一组操作
+func BatchProcessStatement(items []models.BatchProcessItem) string {
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = fmt.Sprintf("%d:%s", item.RequestID, item.Action)
+	}
+	return "process-requests:" + strings.Join(parts, ",")
+}
+
+// BatchProcessAction 批量处理申请签名的操作名称，消息通过 /api/auth/challenge 申请
+const BatchProcessAction = "ProcessChildRequests"
+
+// BatchProcessParams 批量处理签名绑定的参数：部署名称和本批次的BatchProcessStatement，
+// 同一签名不能用于其他部署、申请子集或其他操作
+func BatchProcessParams(collection string, items []models.BatchProcessItem) map[string]string {
+	return map[string]string{
+		"collection": collection,
+		"items":      BatchProcessStatement(items),
+	}
+}
+
+// ProcessBatch 以父NFT持有者身份批量处理申请：拒绝立即生效；批准需要每项附带持有者的CreateChildRequest签名，
+// 签名使用合约中连续的nonce，因此批准按顺序逐个提交并等待回执后再处理下一项，前一项未确认时后续批准因nonce未轮到而失败。
+// 每个申请单独记录结果，单个失败不影响其他申请；payloadHash为批量请求体的哈希
+func (s *ChildMintService) ProcessBatch(ctx context.Context, wallet string, items []models.BatchProcessItem, payloadHash string) []models.BatchProcessResult {
+	results := make([]models.BatchProcessResult, len(items))
+	owners := make(map[string]string)
+	seen := make(map[uint]bool)
+
+	for i, item := range items {
+		result := &results[i]
+		result.RequestID = item.RequestID
+		result.Action = item.Action
+
+		if seen[item.RequestID] {
+			result.Error = "同一申请在批次中重复出现"
+			continue
+		}
+		seen[item.RequestID] = true
+
+		request, err := s.Requests.Get(item.RequestID)
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		parentTokenID, ok := new(big.Int).SetString(request.ParentTokenId, 10)
+		if !ok {
+			result.Error = "无效的父token ID"
+			continue
+		}
+
+		// 同一父NFT只查询一次链上持有者
+		owner, cached := owners[request.ParentTokenId]
+		if !cached {
+			owner, _, _, err = s.Client.GetNFTInfo(parentTokenID)
+			if err != nil {
+				result.Error = "获取NFT信息失败: " + err.Error()
+				continue
+			}
+			owners[request.ParentTokenId] = owner
+		}
+		if !strings.EqualFold(owner, wallet) {
+			result.Error = ErrChildRequestForbidden.Error()
+			continue
+		}
+
+		actor := strings.ToLower(wallet)
+		if item.Action == "reject" {
+			reason := item.Reason
+			if reason == "" {
+				reason = "父NFT持有者批量拒绝"
+			}
+			rejected, err := s.Requests.Transition(request.ID, item.Version, models.ChildRequestStatusRejected, actor, reason, nil)
+			fillBatchResult(result, rejected, err)
+			continue
+		}
+
+		reason := item.Reason
+		if reason == "" {
+			reason = "父NFT持有者批量批准"
+		}
+		approved, err := s.Approve(ctx, request.ID, item.Version, actor, reason, parentTokenID, owner, item.Authorization, payloadHash)
+		fillBatchResult(result, approved, err)
+	}
+	return results
+}
+
+// fillBatchResult 根据处理后的申请和错误填写单项结果；交易待确认视为已受理
+func fillBatchResult(result *models.BatchProcessResult, request *models.ChildNFTRequest, err error) {
+	if request != nil {
+		result.Status = request.Status
+		result.Version = request.Version
+		result.TxHash = request.TxHash
+		result.BlockNumber = request.BlockNumber
+		result.ChildTokenID = request.ChildTokenID
+	}
+	if err != nil && !errors.Is(err, ErrChildMintPending) {
+		result.Error = err.Error()
+		var violation *IssuanceRuleViolation
+		if errors.As(err, &violation) {
+			result.Rule = violation.Rule
+		}
+		return
+	}
+	result.Success = true
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ABE/nft/nft-go-backend/internal/blockchain"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// reconcileReceiptWait 后台确认遗留申请时每笔交易的等待时间
+const reconcileReceiptWait = 5 * time.Second
+
+// ErrChildMintPending 交易已发送但在等待时间内未确认，申请保持minting状态，由后台任务继续确认
+var ErrChildMintPending = errors.New("创建子NFT的交易尚未确认")
+
+// ChildMintService 批准一个部署的子NFT申请后发送创建交易，并根据交易回执确认真实的子NFT tokenID
+type ChildMintService struct {
+	Client         *blockchain.EthClient
+	Requests       *ChildRequestService
+	ReceiptTimeout time.Duration        // 等待单笔交易回执的最长时间
+	Rules          *IssuanceRuleService // 发行规则，为nil时不检查
+	Ledger         *TxLedgerService     // 交易账本，为nil时不记录
+}
+
+// NewChildMintService 创建子NFT铸造服务
+func NewChildMintService(client *blockchain.EthClient, requests *ChildRequestService, receiptTimeout time.Duration) *ChildMintService {
+	return &ChildMintService{
+		Client:         client,
+		Requests:       requests,
+		ReceiptTimeout: receiptTimeout,
+	}
+}
+
+// Approve 父NFT持有者批准申请：先检查持有者对该申请的CreateChildRequest签名，再把申请占用为minting，
+// 然后把签名提交到合约的createChildWithSignature并等待回执。签名无效或已失效时不占用申请；
+// 占用失败时返回的申请为nil；交易未能发送时申请回滚为pending；未及时确认时返回ErrChildMintPending。
+// payloadHash为触发审批的请求体哈希，记入交易账本
+func (s *ChildMintService) Approve(ctx context.Context, requestID, expectedVersion uint, actor, reason string, parentTokenID *big.Int, parentOwner string, authorization *models.ChildMintAuthorization, payloadHash string) (*models.ChildNFTRequest, error) {
+	send, err := s.ownerSigned(requestID, parentTokenID, parentOwner, authorization)
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := s.submit(requestID, expectedVersion, false, actor, reason, payloadHash, send)
+	if err != nil {
+		return claimed, err
+	}
+	return s.Confirm(ctx, claimed)
+}
+
+// AutoApprove 按发行规则自动批准申请，由平台账户调用合约的createAutoApprovedChild创建子NFT，
+// 合约把创建者记录为父NFT的当前持有者。这是唯一不需要持有者签名的创建方式，只用于自动批准
+func (s *ChildMintService) AutoApprove(ctx context.Context, requestID, expectedVersion uint, reason string, parentTokenID *big.Int, payloadHash string) (*models.ChildNFTRequest, error) {
+	send := func(recipient common.Address, uri string) (string, error) {
+		return s.Client.CreateAutoApprovedChild(parentTokenID, recipient, uri)
+	}
+	claimed, err := s.submit(requestID, expectedVersion, true, ChildRequestSystemActor, reason, payloadHash, send)
+	if err != nil {
+		return claimed, err
+	}
+	return s.Confirm(ctx, claimed)
+}
+
+// childMintSender 发送创建子NFT的交易，返回交易哈希
+type childMintSender func(recipient common.Address, uri string) (string, error)
+
+// ownerSigned 检查父NFT持有者对申请的CreateChildRequest签名（receiver为申请者，uri为申请的URI），
+// 返回提交该签名的发送函数
+func (s *ChildMintService) ownerSigned(requestID uint, parentTokenID *big.Int, parentOwner string, authorization *models.ChildMintAuthorization) (childMintSender, error) {
+	if authorization == nil {
+		return nil, fmt.Errorf("%w: 批准申请需要父NFT持有者对CreateChildRequest的签名", blockchain.ErrMetaTxSignature)
+	}
+	nonce, ok := new(big.Int).SetString(authorization.Nonce, 10)
+	if !ok || nonce.Sign() < 0 {
+		return nil, fmt.Errorf("%w: 无效的nonce", blockchain.ErrMetaTxSignature)
+	}
+	deadline, ok := new(big.Int).SetString(authorization.Deadline, 10)
+	if !ok || deadline.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: 无效的deadline", blockchain.ErrMetaTxSignature)
+	}
+	request, err := s.Requests.Get(requestID)
+	if err != nil {
+		return nil, err
+	}
+	owner := common.HexToAddress(parentOwner)
+	recipient := common.HexToAddress(request.ApplicantAddress)
+	if err := s.Client.CheckCreateChildSignature(owner, parentTokenID, recipient, request.URI, nonce, deadline, authorization.Signature); err != nil {
+		return nil, err
+	}
+	return func(recipient common.Address, uri string) (string, error) {
+		return s.Client.CreateChildNFTWithSignature(owner, parentTokenID, recipient, uri, deadline, authorization.Signature)
+	}, nil
+}
+
+// submit 占用申请并用send发送创建交易，成功时返回带交易哈希的minting申请
+func (s *ChildMintService) submit(requestID, expectedVersion uint, auto bool, actor, reason, payloadHash string, send childMintSender) (*models.ChildNFTRequest, error) {
+	// 总量上限的统计与占用在同一事务中完成，避免并发批准超发
+	claimed, err := s.Requests.TransitionChecked(requestID, expectedVersion, models.ChildRequestStatusMinting, actor, reason, map[string]interface{}{
+		"auto_approved":  auto,
+		"tx_hash":        "",
+		"block_number":   0,
+		"child_token_id": "",
+	}, func(tx *gorm.DB, request *models.ChildNFTRequest) error {
+		return s.Rules.ReserveSupply(tx, request.Collection, request.ParentTokenId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	txHash, err := send(common.HexToAddress(claimed.ApplicantAddress), claimed.URI)
+	if err != nil {
+		if rolledBack, rollbackErr := s.Requests.Transition(claimed.ID, claimed.Version, models.ChildRequestStatusPending,
+			ChildRequestSystemActor, "发送创建子NFT交易失败: "+err.Error(), map[string]interface{}{"auto_approved": false}); rollbackErr != nil {
+			log.Printf("回滚子NFT申请 %d 失败: %v", claimed.ID, rollbackErr)
+		} else {
+			claimed = rolledBack
+		}
+		return claimed, err
+	}
+
+	claimed.TxHash = txHash
+	if err := s.Requests.DB.Model(claimed).Update("tx_hash", txHash).Error; err != nil {
+		return claimed, fmt.Errorf("记录交易哈希失败: %v", err)
+	}
+	if s.Ledger != nil {
+		// 账本的后台任务在交易有结果后确认申请，不依赖调用方等待
+		if _, err := s.Ledger.Record(blockchain.TxKindCreateChild, actor, payloadHash, txHash, map[string]string{
+			"requestId":     fmt.Sprintf("%d", claimed.ID),
+			"parentTokenId": claimed.ParentTokenId,
+			"uri":           claimed.URI,
+		}); err != nil {
+			log.Printf("%v", err)
+		}
+	}
+	return claimed, nil
+}
+
+// Confirm 等待minting申请的交易回执：成功时记录真实的子NFT tokenID、区块号并写入NFT表，回滚时标记为failed
+func (s *ChildMintService) Confirm(ctx context.Context, request *models.ChildNFTRequest) (*models.ChildNFTRequest, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.ReceiptTimeout)
+	defer cancel()
+
+	result, err := s.Client.WaitForChildNFT(ctx, common.HexToHash(request.TxHash))
+	if err != nil && result == nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return request, ErrChildMintPending
+		}
+		return request, err
+	}
+	if err != nil {
+		// 交易回滚或回执中缺少预期事件
+		failed, transitionErr := s.Requests.Transition(request.ID, request.Version, models.ChildRequestStatusFailed,
+			ChildRequestSystemActor, err.Error(), map[string]interface{}{"block_number": result.BlockNumber})
+		if transitionErr != nil {
+			return request, transitionErr
+		}
+		return failed, err
+	}
+
+	status := models.ChildRequestStatusApproved
+	if request.AutoApproved {
+		status = models.ChildRequestStatusAutoApproved
+	}
+	childTokenID := result.ChildTokenID.String()
+	confirmed, err := s.Requests.Transition(request.ID, request.Version, status, ChildRequestSystemActor,
+		fmt.Sprintf("交易已在区块 %d 确认，子NFT tokenID %s", result.BlockNumber, childTokenID),
+		map[string]interface{}{"child_token_id": childTokenID, "block_number": result.BlockNumber})
+	if err != nil {
+		return request, err
+	}
+
+	// 将子NFT信息保存到NFT表中；事件监听可能已先写入，冲突时更新
+	childNFT := models.NFT{
+		ChainID:       s.Client.Config.ChainID,
+		Contract:      s.Client.ContractAddress("child"),
+		TokenID:       childTokenID,
+		Owner:         result.Receiver.Hex(),
+		URI:           confirmed.URI,
+		IsChildNFT:    true,
+		ParentTokenID: result.ParentTokenID.String(),
+		ContractType:  "child",
+	}
+	if err := s.Requests.DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"owner", "uri", "is_child_nft", "parent_token_id"}),
+	}).Create(&childNFT).Error; err != nil {
+		log.Printf("保存子NFT %s 失败: %v", childTokenID, err)
+	}
+	return confirmed, nil
+}
+
+// Reconcile 继续确认本部署超过等待时间仍处于minting状态的申请
+func (s *ChildMintService) Reconcile(ctx context.Context) error {
+	var stale []models.ChildNFTRequest
+	if err := s.Requests.DB.Where("collection = ? AND status = ? AND tx_hash <> '' AND updated_at < ?",
+		s.Requests.Collection, models.ChildRequestStatusMinting, time.Now().Add(-s.ReceiptTimeout)).Find(&stale).Error; err != nil {
+		return fmt.Errorf("查询待确认的子NFT申请失败: %v", err)
+	}
+	// 没有交易哈希的minting申请无法判断交易是否已发送，不自动回滚以免重复铸造
+	for i := range stale {
+		// 交易早已发送，回执要么已存在要么仍在排队，只做短暂等待
+		confirmCtx, cancel := context.WithTimeout(ctx, reconcileReceiptWait)
+		_, err := s.Confirm(confirmCtx, &stale[i])
+		cancel()
+		if err != nil && !errors.Is(err, ErrChildMintPending) {
+			log.Printf("确认子NFT申请 %d 失败: %v", stale[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// Run 定期确认遗留的minting申请，直到ctx取消
+func (s *ChildMintService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reconcile(ctx); err != nil {
+				log.Printf("%v", err)
+			}
+		}
+	}
+}
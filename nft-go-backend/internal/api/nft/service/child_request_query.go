@@ -0,0 +1,236 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// 申请列表视图
+const (
+	ChildRequestViewAll      = "all"      // 收到的和提交的申请
+	ChildRequestViewIncoming = "incoming" // 当前钱包持有父NFT的申请
+	ChildRequestViewOutgoing = "outgoing" // 当前钱包提交的申请
+)
+
+// 申请列表排序字段
+const (
+	ChildRequestSortCreatedAt = "createdAt"
+	ChildRequestSortUpdatedAt = "updatedAt"
+)
+
+// 申请列表分页大小
+const (
+	DefaultChildRequestPageSize = 50
+	MaxChildRequestPageSize     = 200
+)
+
+// ListChildRequestsAction 按视图查询申请列表签名的操作名称（无参数），挑战通过 /api/auth/challenge 申请
+const ListChildRequestsAction = "ListChildRequests"
+
+// ErrInvalidChildRequestQuery 列表查询参数无效
+var ErrInvalidChildRequestQuery = errors.New("无效的查询参数")
+
+// ChildRequestFilter 申请列表的过滤、排序与分页条件，零值表示不过滤
+type ChildRequestFilter struct {
+	View          string
+	Statuses      []string
+	ParentTokenID string
+	Applicant     string
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	AutoApproved  *bool
+	Sort          string // createdAt或updatedAt，默认createdAt
+	Ascending     bool   // 默认按时间倒序
+	Limit         int
+	Cursor        string // 上一页返回的nextCursor
+}
+
+// childRequestCursor 游标内容：上一页最后一条记录的排序值和ID
+type childRequestCursor struct {
+	ID uint       `json:"id"`
+	At *time.Time `json:"at,omitempty"` // 按updatedAt排序时使用
+}
+
+// List 以SQL分页查询本部署与钱包相关的申请；收到的申请通过持有的主NFT子查询匹配，使用(ID)或(排序时间, ID)作为游标
+func (s *ChildRequestService) List(wallet string, filter ChildRequestFilter) (*models.GetAllRequestsResponse, error) {
+	if err := normalizeChildRequestFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	ownedParents := s.DB.Model(&models.NFT{}).Scopes(s.Parents.Scope).Select("token_id").Where("owner = ?", wallet)
+	query := s.DB.Model(&models.ChildNFTRequest{}).Where("collection = ?", s.Collection)
+	switch filter.View {
+	case ChildRequestViewIncoming:
+		query = query.Where("parent_token_id IN (?)", ownedParents)
+	case ChildRequestViewOutgoing:
+		query = query.Where("applicant_address = ?", wallet)
+	default:
+		query = query.Where(s.DB.Where("parent_token_id IN (?)", ownedParents).Or("applicant_address = ?", wallet))
+	}
+
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.ParentTokenID != "" {
+		query = query.Where("parent_token_id = ?", filter.ParentTokenID)
+	}
+	if filter.Applicant != "" {
+		query = query.Where("applicant_address = ?", filter.Applicant)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", *filter.CreatedTo)
+	}
+	if filter.AutoApproved != nil {
+		query = query.Where("auto_approved = ?", *filter.AutoApproved)
+	}
+
+	// ID随创建时间单调递增，按createdAt排序时直接使用主键
+	direction, compare := "DESC", "<"
+	if filter.Ascending {
+		direction, compare = "ASC", ">"
+	}
+	if filter.Cursor != "" {
+		cursor, err := decodeChildRequestCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if filter.Sort == ChildRequestSortUpdatedAt {
+			if cursor.At == nil {
+				return nil, fmt.Errorf("%w: 游标与排序字段不匹配", ErrInvalidChildRequestQuery)
+			}
+			query = query.Where(fmt.Sprintf("(updated_at %s ? OR (updated_at = ? AND id %s ?))", compare, compare), *cursor.At, *cursor.At, cursor.ID)
+		} else {
+			query = query.Where(fmt.Sprintf("id %s ?", compare), cursor.ID)
+		}
+	}
+	if filter.Sort == ChildRequestSortUpdatedAt {
+		query = query.Order("updated_at " + direction)
+	}
+	query = query.Order("id " + direction)
+
+	// 多取一条判断是否还有下一页
+	var requests []models.ChildNFTRequest
+	if err := query.Limit(filter.Limit + 1).Find(&requests).Error; err != nil {
+		return nil, fmt.Errorf("查询申请记录失败: %v", err)
+	}
+	page := &models.GetAllRequestsResponse{Requests: []models.ChildNFTRequestWithParentInfo{}}
+	if len(requests) > filter.Limit {
+		requests = requests[:filter.Limit]
+		page.HasMore = true
+		last := requests[len(requests)-1]
+		cursor := childRequestCursor{ID: last.ID}
+		if filter.Sort == ChildRequestSortUpdatedAt {
+			cursor.At = &last.UpdatedAt
+		}
+		page.NextCursor = encodeChildRequestCursor(cursor)
+	}
+
+	owners, err := s.parentOwners(requests)
+	if err != nil {
+		return nil, err
+	}
+	for _, request := range requests {
+		request.RequestId = fmt.Sprintf("%d", request.ID)
+		owner := owners[request.ParentTokenId]
+		page.Requests = append(page.Requests, models.ChildNFTRequestWithParentInfo{
+			ChildNFTRequest: request,
+			ParentNFTOwner:  owner,
+			CanOperate:      owner != "" && strings.EqualFold(owner, wallet),
+		})
+	}
+	return page, nil
+}
+
+// parentOwners 一次查询本页申请涉及的父NFT持有者
+func (s *ChildRequestService) parentOwners(requests []models.ChildNFTRequest) (map[string]string, error) {
+	owners := make(map[string]string)
+	var tokenIDs []string
+	for _, request := range requests {
+		if _, ok := owners[request.ParentTokenId]; !ok {
+			owners[request.ParentTokenId] = ""
+			tokenIDs = append(tokenIDs, request.ParentTokenId)
+		}
+	}
+	if len(tokenIDs) == 0 {
+		return owners, nil
+	}
+	var parents []models.NFT
+	if err := s.DB.Scopes(s.Parents.Scope).Select("token_id", "owner").Where("token_id IN ?", tokenIDs).Find(&parents).Error; err != nil {
+		return nil, fmt.Errorf("查询父NFT失败: %v", err)
+	}
+	for _, parent := range parents {
+		owners[parent.TokenID] = parent.Owner
+	}
+	return owners, nil
+}
+
+// normalizeChildRequestFilter 校验并补全默认值
+func normalizeChildRequestFilter(filter *ChildRequestFilter) error {
+	switch filter.View {
+	case "":
+		filter.View = ChildRequestViewAll
+	case ChildRequestViewAll, ChildRequestViewIncoming, ChildRequestViewOutgoing:
+	default:
+		return fmt.Errorf("%w: 未知的视图 %s", ErrInvalidChildRequestQuery, filter.View)
+	}
+	switch filter.Sort {
+	case "":
+		filter.Sort = ChildRequestSortCreatedAt
+	case ChildRequestSortCreatedAt, ChildRequestSortUpdatedAt:
+	default:
+		return fmt.Errorf("%w: 不支持按 %s 排序", ErrInvalidChildRequestQuery, filter.Sort)
+	}
+	for _, status := range filter.Statuses {
+		if _, known := childRequestTransitions[status]; !known && !isTerminalChildRequestStatus(status) {
+			return fmt.Errorf("%w: 未知的状态 %s", ErrInvalidChildRequestQuery, status)
+		}
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedTo.After(*filter.CreatedFrom) {
+		return fmt.Errorf("%w: 结束时间必须晚于开始时间", ErrInvalidChildRequestQuery)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultChildRequestPageSize
+	}
+	if filter.Limit > MaxChildRequestPageSize {
+		filter.Limit = MaxChildRequestPageSize
+	}
+	return nil
+}
+
+// isTerminalChildRequestStatus 判断是否为终态
+func isTerminalChildRequestStatus(status string) bool {
+	switch status {
+	case models.ChildRequestStatusApproved, models.ChildRequestStatusAutoApproved, models.ChildRequestStatusRejected,
+		models.ChildRequestStatusCancelled, models.ChildRequestStatusExpired:
+		return true
+	}
+	return false
+}
+
+// encodeChildRequestCursor 把游标编码为不透明字符串
+func encodeChildRequestCursor(cursor childRequestCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeChildRequestCursor 解析客户端传回的游标
+func decodeChildRequestCursor(value string) (*childRequestCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 无效的游标", ErrInvalidChildRequestQuery)
+	}
+	var cursor childRequestCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == 0 {
+		return nil, fmt.Errorf("%w: 无效的游标", ErrInvalidChildRequestQuery)
+	}
+	return &cursor, nil
+}
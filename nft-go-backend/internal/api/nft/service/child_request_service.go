@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// ChildRequestSystemActor 系统自动执行的状态迁移（过期、交易确认与回滚）记录的操作者
+const ChildRequestSystemActor = "system"
+
+// 子NFT申请状态机错误
+var (
+	ErrChildRequestNotFound   = errors.New("申请记录不存在")
+	ErrChildRequestConflict   = errors.New("申请已被并发修改，请刷新后重试")
+	ErrChildRequestExpired    = errors.New("申请已过期")
+	ErrChildRequestForbidden  = errors.New("无权操作该申请")
+	ErrChildRequestTransition = errors.New("不允许的状态迁移")
+)
+
+// childRequestTransitions 允许的状态迁移：批准后先进入minting等待交易回执，
+// 回执确认后进入approved/auto_approved，交易回滚则为failed，持有者可对failed申请重新批准；
+// minting回到pending仅用于交易未能发送时由系统回滚
+var childRequestTransitions = map[string][]string{
+	models.ChildRequestStatusPending: {
+		models.ChildRequestStatusMinting,
+		models.ChildRequestStatusRejected,
+		models.ChildRequestStatusCancelled,
+		models.ChildRequestStatusExpired,
+	},
+	models.ChildRequestStatusMinting: {
+		models.ChildRequestStatusApproved,
+		models.ChildRequestStatusAutoApproved,
+		models.ChildRequestStatusFailed,
+		models.ChildRequestStatusPending,
+	},
+	models.ChildRequestStatusFailed: {
+		models.ChildRequestStatusMinting,
+		models.ChildRequestStatusRejected,
+	},
+}
+
+// CanTransitionChildRequest 判断状态迁移是否被允许
+func CanTransitionChildRequest(from, to string) bool {
+	for _, allowed := range childRequestTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ChildRequestService 一个部署的子NFT申请生命周期服务：状态迁移、撤回、过期与历史
+type ChildRequestService struct {
+	DB         *gorm.DB
+	Collection string                // 部署名称，只处理该部署的申请
+	Parents    models.NFTContractKey // 部署的主NFT合约，用于查询父NFT持有者
+	TTL        time.Duration         // 待审批申请的有效期，0表示永不过期
+	Events     *EventService         // 状态变化提交后发布事件，可为nil
+}
+
+// NewChildRequestService 创建部署的子NFT申请服务
+func NewChildRequestService(db *gorm.DB, collection string, parents models.NFTContractKey, ttl time.Duration) *ChildRequestService {
+	return &ChildRequestService{DB: db, Collection: collection, Parents: parents, TTL: ttl}
+}
+
+// Create 以pending状态保存新申请并记录初始历史
+func (s *ChildRequestService) Create(request *models.ChildNFTRequest, actor, reason string) error {
+	request.Collection = s.Collection
+	request.Status = models.ChildRequestStatusPending
+	request.Version = 1
+	if s.TTL > 0 {
+		expiresAt := time.Now().Add(s.TTL)
+		request.ExpiresAt = &expiresAt
+	}
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(request).Error; err != nil {
+			return fmt.Errorf("保存申请记录失败: %v", err)
+		}
+		return s.recordTransition(tx, request.ID, "", request.Status, request.Version, actor, reason)
+	})
+	if err != nil {
+		return err
+	}
+	s.Events.Publish(request, EventRequestCreated, actor, reason)
+	return nil
+}
+
+// Get 查询本部署的申请记录，其他部署的申请视为不存在
+func (s *ChildRequestService) Get(requestID uint) (*models.ChildNFTRequest, error) {
+	var request models.ChildNFTRequest
+	if err := s.DB.Where("collection = ?", s.Collection).First(&request, requestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChildRequestNotFound
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// Transition 把申请迁移到新状态。expectedVersion不为0时必须与当前版本一致；
+// 更新以状态和版本号为条件，并发修改时返回ErrChildRequestConflict。changes为同时更新的其他列
+func (s *ChildRequestService) Transition(requestID, expectedVersion uint, to, actor, reason string, changes map[string]interface{}) (*models.ChildNFTRequest, error) {
+	return s.TransitionChecked(requestID, expectedVersion, to, actor, reason, changes, nil)
+}
+
+// TransitionChecked 与Transition相同，但在同一事务中先执行check，check返回错误时不迁移；
+// 用于需要与状态迁移原子完成的检查，如批准时的发行总量上限
+func (s *ChildRequestService) TransitionChecked(requestID, expectedVersion uint, to, actor, reason string, changes map[string]interface{}, check func(tx *gorm.DB, request *models.ChildNFTRequest) error) (*models.ChildNFTRequest, error) {
+	var request models.ChildNFTRequest
+	var from string
+	expired := false
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection = ?", s.Collection).First(&request, requestID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrChildRequestNotFound
+			}
+			return err
+		}
+		if expectedVersion != 0 && request.Version != expectedVersion {
+			return ErrChildRequestConflict
+		}
+		if !CanTransitionChildRequest(request.Status, to) {
+			return fmt.Errorf("%w: %s -> %s", ErrChildRequestTransition, request.Status, to)
+		}
+
+		// 已超过有效期的待审批申请不能再被处理，顺带将其标记为过期
+		if request.Status == models.ChildRequestStatusPending && to != models.ChildRequestStatusExpired &&
+			request.ExpiresAt != nil && time.Now().After(*request.ExpiresAt) {
+			expired = true
+			to, actor, reason, changes = models.ChildRequestStatusExpired, ChildRequestSystemActor, "超过有效期未处理", nil
+		} else if check != nil {
+			if err := check(tx, &request); err != nil {
+				return err
+			}
+		}
+		from = request.Status
+		return s.apply(tx, &request, to, actor, reason, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if eventType := childRequestEventType(from, to, request.AutoApproved); eventType != "" {
+		s.Events.Publish(&request, eventType, actor, reason)
+	}
+	if expired {
+		return &request, ErrChildRequestExpired
+	}
+	return &request, nil
+}
+
+// Cancel 申请者撤回自己的待审批申请
+func (s *ChildRequestService) Cancel(requestID, expectedVersion uint, applicant, reason string) (*models.ChildNFTRequest, error) {
+	request, err := s.Get(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(request.ApplicantAddress, applicant) {
+		return nil, ErrChildRequestForbidden
+	}
+	if expectedVersion == 0 {
+		expectedVersion = request.Version
+	}
+	if reason == "" {
+		reason = "申请者撤回"
+	}
+	return s.Transition(requestID, expectedVersion, models.ChildRequestStatusCancelled, applicant, reason, nil)
+}
+
+// ExpireStale 将本部署已超过有效期的待审批申请标记为过期，返回处理数量
+func (s *ChildRequestService) ExpireStale(now time.Time) (int, error) {
+	var stale []models.ChildNFTRequest
+	if err := s.DB.Where("collection = ? AND status = ? AND expires_at IS NOT NULL AND expires_at < ?", s.Collection, models.ChildRequestStatusPending, now).
+		Find(&stale).Error; err != nil {
+		return 0, fmt.Errorf("查询过期申请失败: %v", err)
+	}
+
+	expired := 0
+	for _, request := range stale {
+		_, err := s.Transition(request.ID, request.Version, models.ChildRequestStatusExpired, ChildRequestSystemActor, "超过有效期未处理", nil)
+		// 期间已被处理或撤回的申请直接跳过
+		if errors.Is(err, ErrChildRequestConflict) || errors.Is(err, ErrChildRequestTransition) {
+			continue
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// RunExpiry 定期清理过期申请，直到ctx取消
+func (s *ChildRequestService) RunExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if count, err := s.ExpireStale(time.Now()); err != nil {
+				log.Printf("清理过期子NFT申请失败: %v", err)
+			} else if count > 0 {
+				log.Printf("已将 %d 个子NFT申请标记为过期", count)
+			}
+		}
+	}
+}
+
+// RequestHistoryAction 查询申请状态迁移历史签名的操作名称（无参数），挑战通过 /api/auth/challenge 申请
+const RequestHistoryAction = "GetChildRequestHistory"
+
+// History 按时间顺序返回申请的状态迁移历史
+func (s *ChildRequestService) History(requestID uint) ([]models.ChildNFTRequestTransition, error) {
+	if _, err := s.Get(requestID); err != nil {
+		return nil, err
+	}
+	var transitions []models.ChildNFTRequestTransition
+	if err := s.DB.Where("request_id = ?", requestID).Order("id ASC").Find(&transitions).Error; err != nil {
+		return nil, fmt.Errorf("查询申请历史失败: %v", err)
+	}
+	return transitions, nil
+}
+
+// apply 以当前状态和版本号为条件更新申请，并写入迁移历史
+func (s *ChildRequestService) apply(tx *gorm.DB, request *models.ChildNFTRequest, to, actor, reason string, changes map[string]interface{}) error {
+	from := request.Status
+	nextVersion := request.Version + 1
+	updates := map[string]interface{}{
+		"status":        to,
+		"version":       nextVersion,
+		"status_reason": reason,
+	}
+	for column, value := range changes {
+		updates[column] = value
+	}
+	result := tx.Model(&models.ChildNFTRequest{}).
+		Where("id = ? AND status = ? AND version = ?", request.ID, from, request.Version).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("更新申请状态失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrChildRequestConflict
+	}
+	if err := s.recordTransition(tx, request.ID, from, to, nextVersion, actor, reason); err != nil {
+		return err
+	}
+	return tx.First(request, request.ID).Error
+}
+
+// recordTransition 写入一条状态迁移历史
+func (s *ChildRequestService) recordTransition(tx *gorm.DB, requestID uint, from, to string, version uint, actor, reason string) error {
+	transition := models.ChildNFTRequestTransition{
+		RequestID:  requestID,
+		FromStatus: from,
+		ToStatus:   to,
+		Version:    version,
+		Actor:      actor,
+		Reason:     reason,
+	}
+	if err := tx.Create(&transition).Error; err != nil {
+		return fmt.Errorf("记录申请状态历史失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// 子NFT申请事件类型
+const (
+	EventRequestCreated      = "request.created"       // 新申请提交
+	EventRequestAutoApproved = "request.auto_approved" // 凭证满足策略，自动批准并开始铸造
+	EventRequestApproved     = "request.approved"      // 父NFT持有者批准并开始铸造
+	EventRequestRejected     = "request.rejected"
+	EventRequestCancelled    = "request.cancelled"
+	EventRequestExpired      = "request.expired"
+	EventMintConfirmed       = "mint.confirmed" // 创建子NFT的交易已确认
+	EventMintFailed          = "mint.failed"    // 交易回滚或未能发送
+)
+
+// ChildRequestEventTypes 全部可订阅的事件类型
+var ChildRequestEventTypes = []string{
+	EventRequestCreated,
+	EventRequestAutoApproved,
+	EventRequestApproved,
+	EventRequestRejected,
+	EventRequestCancelled,
+	EventRequestExpired,
+	EventMintConfirmed,
+	EventMintFailed,
+}
+
+// eventSubscriberBuffer 每个SSE订阅者的事件缓冲，消费过慢时丢弃新事件
+const eventSubscriberBuffer = 32
+
+// childRequestEventType 把状态迁移映射为事件类型，不需要通知的迁移返回空字符串
+func childRequestEventType(from, to string, autoApproved bool) string {
+	switch to {
+	case models.ChildRequestStatusMinting:
+		if autoApproved {
+			return EventRequestAutoApproved
+		}
+		return EventRequestApproved
+	case models.ChildRequestStatusApproved, models.ChildRequestStatusAutoApproved:
+		return EventMintConfirmed
+	case models.ChildRequestStatusFailed:
+		return EventMintFailed
+	case models.ChildRequestStatusPending:
+		if from == models.ChildRequestStatusMinting {
+			return EventMintFailed
+		}
+	case models.ChildRequestStatusRejected:
+		return EventRequestRejected
+	case models.ChildRequestStatusCancelled:
+		return EventRequestCancelled
+	case models.ChildRequestStatusExpired:
+		return EventRequestExpired
+	}
+	return ""
+}
+
+// EventSubscriber 一个SSE连接的订阅，只接收与Address相关（父NFT持有者或申请者）的事件
+type EventSubscriber struct {
+	Address string
+	C       chan models.ChildRequestEvent
+}
+
+// EventService 子NFT申请事件分发：推送给在线的SSE订阅者，并为登记的Webhook生成投递任务
+type EventService struct {
+	DB       *gorm.DB
+	Webhooks *WebhookService
+	Parents  map[string]models.NFTContractKey // 各部署的主NFT合约，用于查询父NFT持有者
+
+	mu          sync.RWMutex
+	subscribers map[*EventSubscriber]struct{}
+}
+
+// NewEventService 创建事件服务
+func NewEventService(db *gorm.DB, webhooks *WebhookService) *EventService {
+	return &EventService{
+		DB:          db,
+		Webhooks:    webhooks,
+		Parents:     make(map[string]models.NFTContractKey),
+		subscribers: make(map[*EventSubscriber]struct{}),
+	}
+}
+
+// Subscribe 为钱包地址创建事件订阅
+func (s *EventService) Subscribe(address string) *EventSubscriber {
+	sub := &EventSubscriber{
+		Address: strings.ToLower(address),
+		C:       make(chan models.ChildRequestEvent, eventSubscriberBuffer),
+	}
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe 取消订阅并关闭通道
+func (s *EventService) Unsubscribe(sub *EventSubscriber) {
+	s.mu.Lock()
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.C)
+	}
+	s.mu.Unlock()
+}
+
+// Publish 发布申请事件；事件服务为nil时不做任何事
+func (s *EventService) Publish(request *models.ChildNFTRequest, eventType, actor, reason string) {
+	if s == nil {
+		return
+	}
+
+	event := models.ChildRequestEvent{
+		ID:            uuid.New().String(),
+		Type:          eventType,
+		RequestID:     request.ID,
+		Collection:    request.Collection,
+		ParentTokenID: request.ParentTokenId,
+		Applicant:     request.ApplicantAddress,
+		Status:        request.Status,
+		Version:       request.Version,
+		Actor:         actor,
+		Reason:        reason,
+		TxHash:        request.TxHash,
+		BlockNumber:   request.BlockNumber,
+		ChildTokenID:  request.ChildTokenID,
+		OccurredAt:    time.Now(),
+	}
+	if parents, ok := s.Parents[request.Collection]; ok {
+		var parent models.NFT
+		if err := s.DB.Scopes(parents.Scope).Where("token_id = ?", request.ParentTokenId).First(&parent).Error; err == nil {
+			event.ParentOwner = parent.Owner
+		}
+	}
+	recipients := eventRecipients(event)
+
+	s.mu.RLock()
+	for sub := range s.subscribers {
+		if !containsString(recipients, sub.Address) {
+			continue
+		}
+		select {
+		case sub.C <- event:
+		default:
+			log.Printf("SSE订阅者 %s 缓冲已满，丢弃事件 %s", sub.Address, event.ID)
+		}
+	}
+	s.mu.RUnlock()
+
+	if err := s.Webhooks.Enqueue(event, recipients); err != nil {
+		log.Printf("生成Webhook投递失败: %v", err)
+	}
+}
+
+// eventRecipients 事件的接收者：父NFT持有者和申请者（小写地址）
+func eventRecipients(event models.ChildRequestEvent) []string {
+	var recipients []string
+	for _, address := range []string{event.ParentOwner, event.Applicant} {
+		address = strings.ToLower(address)
+		if address != "" && !containsString(recipients, address) {
+			recipients = append(recipients, address)
+		}
+	}
+	return recipients
+}
+
+// containsString 判断切片是否包含指定字符串
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,583 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/pkg/childnft"
+	"github.com/ABE/nft/nft-go-backend/pkg/mainnft"
+)
+
+// 索引器默认参数
+const (
+	DefaultIndexerBatchSize   = 2000 // 每次FilterLogs查询的最大区块数
+	DefaultIndexerReorgWindow = 128  // 保留区块哈希和修改日志的区块数，即可回滚的最大重组深度
+)
+
+// IndexerBackend 索引器所需的链访问接口，ethclient.Client满足该接口
+type IndexerBackend interface {
+	bind.ContractCaller
+	ethereum.LogFilterer
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// indexedToken 索引器更新的一个NFT，按合约类型和tokenID区分
+type indexedToken struct {
+	ContractType string
+	TokenID      string
+}
+
+// journalKey 同一区块内同一token只需记录一次修改前的快照
+type journalKey struct {
+	token       indexedToken
+	blockNumber uint64
+}
+
+// IndexerService 把MainNFT和ChildNFT的合约事件同步到nfts表：Transfer更新持有者，
+// MetadataUpdate/BatchMetadataUpdate刷新URI，ChildTokenMinted记录子NFT与父NFT的关系。
+// 从配置的起始区块用FilterLogs回填，之后跟随新区块，进度保存在indexer_checkpoints表。
+// 只处理达到确认数的区块；重组窗口内的区块记录哈希，对nfts表的修改先写入快照日志，
+// 检查点区块的哈希与链上不一致时回滚到共同祖先再重新处理
+type IndexerService struct {
+	DB            *gorm.DB
+	Backend       IndexerBackend
+	Name          string // 部署名称，区分各部署的索引进度
+	ChainID       int64
+	MainAddress   common.Address
+	ChildAddress  common.Address
+	StartBlock    uint64 // 没有检查点时开始回填的区块
+	BatchSize     uint64
+	Confirmations uint64 // 区块至少有多少个后续区块才处理，0表示处理到最新区块
+	ReorgWindow   uint64 // 最新区块之前多少个区块内记录哈希和修改日志
+
+	latestHead uint64 // 最近一次同步时的最新区块，原子读写
+
+	mainCaller    *mainnft.MainnftCaller
+	childCaller   *childnft.ChildnftCaller
+	mainFilterer  *mainnft.MainnftFilterer
+	childFilterer *childnft.ChildnftFilterer
+}
+
+// NewIndexerService 为一个部署创建合约事件索引器
+func NewIndexerService(db *gorm.DB, backend IndexerBackend, name string, chainID int64, mainAddress, childAddress common.Address, startBlock, batchSize uint64) (*IndexerService, error) {
+	if batchSize == 0 {
+		batchSize = DefaultIndexerBatchSize
+	}
+	mainCaller, err := mainnft.NewMainnftCaller(mainAddress, backend)
+	if err != nil {
+		return nil, err
+	}
+	childCaller, err := childnft.NewChildnftCaller(childAddress, backend)
+	if err != nil {
+		return nil, err
+	}
+	mainFilterer, err := mainnft.NewMainnftFilterer(mainAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	childFilterer, err := childnft.NewChildnftFilterer(childAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &IndexerService{
+		DB:            db,
+		Backend:       backend,
+		Name:          name,
+		ChainID:       chainID,
+		MainAddress:   mainAddress,
+		ChildAddress:  childAddress,
+		StartBlock:    startBlock,
+		BatchSize:     batchSize,
+		ReorgWindow:   DefaultIndexerReorgWindow,
+		mainCaller:    mainCaller,
+		childCaller:   childCaller,
+		mainFilterer:  mainFilterer,
+		childFilterer: childFilterer,
+	}, nil
+}
+
+// Checkpoint 查询索引进度，尚未处理过任何区块时返回nil
+func (s *IndexerService) Checkpoint() (*models.IndexerCheckpoint, error) {
+	var checkpoint models.IndexerCheckpoint
+	err := s.DB.Where("name = ?", s.Name).First(&checkpoint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询索引进度失败: %v", err)
+	}
+	return &checkpoint, nil
+}
+
+// Sync 检测重组后从检查点之后处理到已确认的最新区块，返回已处理的最后一个区块
+func (s *IndexerService) Sync(ctx context.Context) (uint64, error) {
+	head, err := s.Backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("获取最新区块失败: %v", err)
+	}
+	latest := head.Number.Uint64()
+	atomic.StoreUint64(&s.latestHead, latest)
+	if latest < s.Confirmations {
+		return 0, nil
+	}
+	safe := latest - s.Confirmations
+
+	checkpoint, err := s.Checkpoint()
+	if err != nil {
+		return 0, err
+	}
+	if checkpoint != nil && checkpoint.BlockHash != "" {
+		header, err := s.Backend.HeaderByNumber(ctx, new(big.Int).SetUint64(checkpoint.BlockNumber))
+		if err != nil {
+			return checkpoint.BlockNumber, fmt.Errorf("获取区块 %d 失败: %v", checkpoint.BlockNumber, err)
+		}
+		if header.Hash().Hex() != checkpoint.BlockHash {
+			if checkpoint, err = s.rollbackReorg(ctx, checkpoint); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	from := s.StartBlock
+	var processed uint64
+	if checkpoint != nil {
+		from = checkpoint.BlockNumber + 1
+		processed = checkpoint.BlockNumber
+	}
+	var trackFrom uint64
+	if latest > s.ReorgWindow {
+		trackFrom = latest - s.ReorgWindow
+	}
+
+	for from <= safe {
+		to := from + s.BatchSize - 1
+		if to > safe {
+			to = safe
+		}
+		if err := s.processRange(ctx, from, to, trackFrom); err != nil {
+			return processed, err
+		}
+		processed = to
+		from = to + 1
+	}
+	if processed > s.ReorgWindow {
+		if err := s.prune(processed - s.ReorgWindow); err != nil {
+			log.Printf("清理索引修改日志失败: %v", err)
+		}
+	}
+	return processed, nil
+}
+
+// LatestHead 最近一次同步时看到的最新区块
+func (s *IndexerService) LatestHead() uint64 {
+	return atomic.LoadUint64(&s.latestHead)
+}
+
+// processRange 查询并应用[from, to]区块内的事件，与检查点在同一事务中提交；
+// 不低于trackFrom的区块记录哈希和修改前快照，供重组时回滚。
+// tokenURI在事务外按当前链上状态读取，回填时得到的是最新URI
+func (s *IndexerService) processRange(ctx context.Context, from, to, trackFrom uint64) error {
+	toHeader, err := s.Backend.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+	if err != nil {
+		return fmt.Errorf("获取区块 %d 失败: %v", to, err)
+	}
+	logs, err := s.Backend.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{s.MainAddress, s.ChildAddress},
+	})
+	if err != nil {
+		return fmt.Errorf("查询区块 %d-%d 的事件失败: %v", from, to, err)
+	}
+	// 查询期间发生重组时，区块to的事件与之前取得的区块哈希不一致，放弃本批等待下次同步
+	toHash := toHeader.Hash()
+	for _, vLog := range logs {
+		if vLog.BlockNumber == to && vLog.BlockHash != toHash {
+			return fmt.Errorf("区块 %d 在查询期间发生重组", to)
+		}
+	}
+
+	refresh, err := s.tokensToRefresh(logs)
+	if err != nil {
+		return err
+	}
+	uris := make(map[indexedToken]string, len(refresh))
+	for token := range refresh {
+		uri, err := s.tokenURI(ctx, token)
+		if err != nil {
+			// 已销毁或尚未设置URI的token跳过，不影响其他事件
+			log.Printf("读取 %s NFT %s 的URI失败: %v", token.ContractType, token.TokenID, err)
+			continue
+		}
+		uris[token] = uri
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		batch := &indexBatch{s: s, tx: tx, trackFrom: trackFrom, journaled: make(map[journalKey]bool)}
+		blocks := map[uint64]string{to: toHash.Hex()}
+		for _, vLog := range logs {
+			// 订阅推送的被移除日志由重组检测统一回滚，这里忽略
+			if vLog.Removed {
+				continue
+			}
+			blocks[vLog.BlockNumber] = vLog.BlockHash.Hex()
+			if err := batch.applyLog(vLog); err != nil {
+				return err
+			}
+		}
+		for token, uri := range uris {
+			if err := batch.journal(token, refresh[token]); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.NFT{}).Scopes(s.contract(token.ContractType).Scope).
+				Where("token_id = ?", token.TokenID).
+				Update("uri", uri).Error; err != nil {
+				return fmt.Errorf("更新NFT URI失败: %v", err)
+			}
+		}
+		for number, hash := range blocks {
+			if number < trackFrom {
+				continue
+			}
+			block := models.IndexedBlock{Indexer: s.Name, Number: number, Hash: hash}
+			if err := tx.Clauses(clause.OnConflict{
+				DoUpdates: clause.AssignmentColumns([]string{"hash"}),
+			}).Create(&block).Error; err != nil {
+				return fmt.Errorf("记录区块哈希失败: %v", err)
+			}
+		}
+		return s.saveCheckpoint(tx, to, toHash.Hex())
+	})
+	if err != nil {
+		return err
+	}
+	if len(logs) > 0 {
+		log.Printf("部署 %s 已索引区块 %d-%d，处理 %d 条合约事件", s.Name, from, to, len(logs))
+	}
+	return nil
+}
+
+// rollbackReorg 检查点所在区块已不在主链上：从新到旧比较记录的区块哈希找到共同祖先，
+// 按相反顺序恢复祖先之后的修改前快照，并把检查点退回祖先，之后的区块由Sync重新处理
+func (s *IndexerService) rollbackReorg(ctx context.Context, checkpoint *models.IndexerCheckpoint) (*models.IndexerCheckpoint, error) {
+	var blocks []models.IndexedBlock
+	if err := s.DB.Where("indexer = ? AND number < ?", s.Name, checkpoint.BlockNumber).Order("number DESC").Find(&blocks).Error; err != nil {
+		return nil, fmt.Errorf("查询已索引区块失败: %v", err)
+	}
+
+	ancestor := models.IndexerCheckpoint{Name: s.Name}
+	found := false
+	for _, block := range blocks {
+		header, err := s.Backend.HeaderByNumber(ctx, new(big.Int).SetUint64(block.Number))
+		if err != nil {
+			return nil, fmt.Errorf("获取区块 %d 失败: %v", block.Number, err)
+		}
+		if header.Hash().Hex() == block.Hash {
+			ancestor.BlockNumber, ancestor.BlockHash = block.Number, block.Hash
+			found = true
+			break
+		}
+	}
+	if !found {
+		// 重组深度超过记录窗口，退回到最早记录的区块之前，尽量恢复快照
+		// 最早记录的是创世区块时无法再往前退，停在区块0
+		if len(blocks) > 0 {
+			if earliest := blocks[len(blocks)-1].Number; earliest > 0 {
+				ancestor.BlockNumber = earliest - 1
+			}
+		} else if checkpoint.BlockNumber > 0 {
+			ancestor.BlockNumber = checkpoint.BlockNumber - 1
+		}
+		log.Printf("重组深度超过 %d 个区块的记录窗口，退回到区块 %d", s.ReorgWindow, ancestor.BlockNumber)
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var entries []models.IndexerJournal
+		if err := tx.Where("indexer = ? AND block_number > ?", s.Name, ancestor.BlockNumber).Order("id DESC").Find(&entries).Error; err != nil {
+			return fmt.Errorf("查询索引修改日志失败: %v", err)
+		}
+		for _, entry := range entries {
+			if err := s.restoreJournal(tx, entry); err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("indexer = ? AND block_number > ?", s.Name, ancestor.BlockNumber).Delete(&models.IndexerJournal{}).Error; err != nil {
+			return fmt.Errorf("删除索引修改日志失败: %v", err)
+		}
+		if err := tx.Where("indexer = ? AND number > ?", s.Name, ancestor.BlockNumber).Delete(&models.IndexedBlock{}).Error; err != nil {
+			return fmt.Errorf("删除已索引区块失败: %v", err)
+		}
+		log.Printf("部署 %s 检测到区块重组：区块 %d 已不在主链上，回滚 %d 条修改到区块 %d", s.Name, checkpoint.BlockNumber, len(entries), ancestor.BlockNumber)
+		return s.saveCheckpoint(tx, ancestor.BlockNumber, ancestor.BlockHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ancestor, nil
+}
+
+// restoreJournal 把NFT记录恢复为快照中的状态
+func (s *IndexerService) restoreJournal(tx *gorm.DB, entry models.IndexerJournal) error {
+	contract := s.contract(entry.ContractType)
+	if !entry.Existed {
+		return tx.Unscoped().Scopes(contract.Scope).Where("token_id = ?", entry.TokenID).Delete(&models.NFT{}).Error
+	}
+	nft := models.NFT{
+		ChainID:       contract.ChainID,
+		Contract:      contract.Address,
+		TokenID:       entry.TokenID,
+		Owner:         entry.Owner,
+		URI:           entry.URI,
+		IsChildNFT:    entry.IsChildNFT,
+		ParentTokenID: entry.ParentTokenID,
+		ContractType:  entry.ContractType,
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"owner", "uri", "is_child_nft", "parent_token_id", "deleted_at"}),
+	}).Create(&nft).Error; err != nil {
+		return fmt.Errorf("回滚 %s NFT %s 失败: %v", entry.ContractType, entry.TokenID, err)
+	}
+	return nil
+}
+
+// prune 删除早于below的区块哈希和修改日志，这些区块已超出重组窗口
+func (s *IndexerService) prune(below uint64) error {
+	if err := s.DB.Where("indexer = ? AND block_number < ?", s.Name, below).Delete(&models.IndexerJournal{}).Error; err != nil {
+		return err
+	}
+	return s.DB.Where("indexer = ? AND number < ?", s.Name, below).Delete(&models.IndexedBlock{}).Error
+}
+
+// tokensToRefresh 收集需要重新读取URI的token：新铸造的token和元数据更新事件涉及的token，
+// 同一批次中新铸造的token已单独收集，批量更新只需匹配数据库中已有的token
+func (s *IndexerService) tokensToRefresh(logs []types.Log) (map[indexedToken]uint64, error) {
+	// 值为触发刷新的最后一个事件所在区块，URI的修改日志记在该区块下
+	refresh := make(map[indexedToken]uint64)
+	for _, vLog := range logs {
+		contractType, ok := s.contractType(vLog.Address)
+		if !ok || vLog.Removed {
+			continue
+		}
+		if tokenID, minted := s.parseMint(vLog); minted {
+			refresh[indexedToken{contractType, tokenID.String()}] = vLog.BlockNumber
+			continue
+		}
+		if tokenID, ok := s.parseMetadataUpdate(vLog); ok {
+			refresh[indexedToken{contractType, tokenID.String()}] = vLog.BlockNumber
+			continue
+		}
+		if fromID, toID, ok := s.parseBatchMetadataUpdate(vLog); ok {
+			var tokenIDs []string
+			if err := s.DB.Model(&models.NFT{}).Scopes(s.contract(contractType).Scope).Pluck("token_id", &tokenIDs).Error; err != nil {
+				return nil, fmt.Errorf("查询NFT失败: %v", err)
+			}
+			for _, tokenID := range tokenIDs {
+				id, ok := new(big.Int).SetString(tokenID, 10)
+				if ok && id.Cmp(fromID) >= 0 && id.Cmp(toID) <= 0 {
+					refresh[indexedToken{contractType, tokenID}] = vLog.BlockNumber
+				}
+			}
+		}
+	}
+	return refresh, nil
+}
+
+// indexBatch 一批事件的写入上下文
+type indexBatch struct {
+	s         *IndexerService
+	tx        *gorm.DB
+	trackFrom uint64 // 不低于该区块的修改记录快照
+	journaled map[journalKey]bool
+}
+
+// applyLog 把单个事件写入nfts表
+func (b *indexBatch) applyLog(vLog types.Log) error {
+	switch vLog.Address {
+	case b.s.MainAddress:
+		if transfer, err := b.s.mainFilterer.ParseTransfer(vLog); err == nil {
+			return b.applyTransfer("main", transfer.TokenId, transfer.To, vLog.BlockNumber)
+		}
+		if created, err := b.s.mainFilterer.ParseChildNFTCreated(vLog); err == nil {
+			log.Printf("子NFT创建: 父Token ID %s, 接收者 %s", created.TokenId, created.Receiver.Hex())
+		}
+	case b.s.ChildAddress:
+		if transfer, err := b.s.childFilterer.ParseTransfer(vLog); err == nil {
+			return b.applyTransfer("child", transfer.TokenId, transfer.To, vLog.BlockNumber)
+		}
+		if minted, err := b.s.childFilterer.ParseChildTokenMinted(vLog); err == nil {
+			child := models.NFT{
+				ChainID:       b.s.ChainID,
+				Contract:      b.s.ChildAddress.Hex(),
+				TokenID:       minted.ChildTokenId.String(),
+				Owner:         minted.Receiver.Hex(),
+				IsChildNFT:    true,
+				ParentTokenID: minted.ParentTokenId.String(),
+				ContractType:  "child",
+			}
+			if err := b.journal(indexedToken{"child", child.TokenID}, vLog.BlockNumber); err != nil {
+				return err
+			}
+			if err := b.tx.Clauses(clause.OnConflict{
+				DoUpdates: clause.AssignmentColumns([]string{"is_child_nft", "parent_token_id"}),
+			}).Create(&child).Error; err != nil {
+				return fmt.Errorf("记录子NFT %s 的父NFT失败: %v", child.TokenID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// applyTransfer 铸造和转移更新持有者，销毁时删除记录
+func (b *indexBatch) applyTransfer(contractType string, tokenID *big.Int, to common.Address, blockNumber uint64) error {
+	if err := b.journal(indexedToken{contractType, tokenID.String()}, blockNumber); err != nil {
+		return err
+	}
+	contract := b.s.contract(contractType)
+	if to == (common.Address{}) {
+		return b.tx.Unscoped().Scopes(contract.Scope).Where("token_id = ?", tokenID.String()).Delete(&models.NFT{}).Error
+	}
+	nft := models.NFT{
+		ChainID:      contract.ChainID,
+		Contract:     contract.Address,
+		TokenID:      tokenID.String(),
+		Owner:        to.Hex(),
+		IsChildNFT:   contractType == "child",
+		ContractType: contractType,
+	}
+	if err := b.tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"owner", "deleted_at"}),
+	}).Create(&nft).Error; err != nil {
+		return fmt.Errorf("更新 %s NFT %s 的持有者失败: %v", contractType, nft.TokenID, err)
+	}
+	return nil
+}
+
+// journal 在重组窗口内修改NFT记录前保存快照，同一区块内每个token只保存第一次修改前的状态
+func (b *indexBatch) journal(token indexedToken, blockNumber uint64) error {
+	key := journalKey{token, blockNumber}
+	if blockNumber < b.trackFrom || b.journaled[key] {
+		return nil
+	}
+	b.journaled[key] = true
+
+	entry := models.IndexerJournal{
+		Indexer:      b.s.Name,
+		BlockNumber:  blockNumber,
+		ContractType: token.ContractType,
+		TokenID:      token.TokenID,
+	}
+	var current models.NFT
+	err := b.tx.Scopes(b.s.contract(token.ContractType).Scope).Where("token_id = ?", token.TokenID).First(&current).Error
+	switch {
+	case err == nil:
+		entry.Existed = true
+		entry.Owner = current.Owner
+		entry.URI = current.URI
+		entry.IsChildNFT = current.IsChildNFT
+		entry.ParentTokenID = current.ParentTokenID
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("查询NFT记录失败: %v", err)
+	}
+	if err := b.tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("记录索引修改日志失败: %v", err)
+	}
+	return nil
+}
+
+// saveCheckpoint 记录已处理的最后一个区块及其哈希
+func (s *IndexerService) saveCheckpoint(tx *gorm.DB, blockNumber uint64, blockHash string) error {
+	checkpoint := models.IndexerCheckpoint{Name: s.Name, BlockNumber: blockNumber, BlockHash: blockHash}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"block_number", "block_hash", "updated_at"}),
+	}).Create(&checkpoint).Error; err != nil {
+		return fmt.Errorf("保存索引进度失败: %v", err)
+	}
+	return nil
+}
+
+// contractType 按日志地址判断来源合约
+func (s *IndexerService) contractType(address common.Address) (string, bool) {
+	switch address {
+	case s.MainAddress:
+		return "main", true
+	case s.ChildAddress:
+		return "child", true
+	}
+	return "", false
+}
+
+// contract 按合约类型返回nfts表中本部署合约的标识
+func (s *IndexerService) contract(contractType string) models.NFTContractKey {
+	if contractType == "child" {
+		return models.NFTContractKey{ChainID: s.ChainID, Address: s.ChildAddress.Hex()}
+	}
+	return models.NFTContractKey{ChainID: s.ChainID, Address: s.MainAddress.Hex()}
+}
+
+// parseMint 解析从零地址转出的Transfer事件
+func (s *IndexerService) parseMint(vLog types.Log) (*big.Int, bool) {
+	if vLog.Address == s.MainAddress {
+		if transfer, err := s.mainFilterer.ParseTransfer(vLog); err == nil && transfer.From == (common.Address{}) {
+			return transfer.TokenId, true
+		}
+		return nil, false
+	}
+	if transfer, err := s.childFilterer.ParseTransfer(vLog); err == nil && transfer.From == (common.Address{}) {
+		return transfer.TokenId, true
+	}
+	return nil, false
+}
+
+// parseMetadataUpdate 解析EIP-4906 MetadataUpdate事件
+func (s *IndexerService) parseMetadataUpdate(vLog types.Log) (*big.Int, bool) {
+	if vLog.Address == s.MainAddress {
+		if update, err := s.mainFilterer.ParseMetadataUpdate(vLog); err == nil {
+			return update.TokenId, true
+		}
+		return nil, false
+	}
+	if update, err := s.childFilterer.ParseMetadataUpdate(vLog); err == nil {
+		return update.TokenId, true
+	}
+	return nil, false
+}
+
+// parseBatchMetadataUpdate 解析EIP-4906 BatchMetadataUpdate事件
+func (s *IndexerService) parseBatchMetadataUpdate(vLog types.Log) (*big.Int, *big.Int, bool) {
+	if vLog.Address == s.MainAddress {
+		if update, err := s.mainFilterer.ParseBatchMetadataUpdate(vLog); err == nil {
+			return update.FromTokenId, update.ToTokenId, true
+		}
+		return nil, nil, false
+	}
+	if update, err := s.childFilterer.ParseBatchMetadataUpdate(vLog); err == nil {
+		return update.FromTokenId, update.ToTokenId, true
+	}
+	return nil, nil, false
+}
+
+// tokenURI 读取token当前的URI
+func (s *IndexerService) tokenURI(ctx context.Context, token indexedToken) (string, error) {
+	tokenID, ok := new(big.Int).SetString(token.TokenID, 10)
+	if !ok {
+		return "", fmt.Errorf("无效的token ID")
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	if token.ContractType == "main" {
+		return s.mainCaller.TokenURI(opts, tokenID)
+	}
+	return s.childCaller.TokenURI(opts, tokenID)
+}
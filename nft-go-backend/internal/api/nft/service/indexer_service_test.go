@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"gorm.io/gorm"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// transferEmitterCode 按调用数据 (from, to, tokenId) 发出ERC-721 Transfer事件的合约代码：
+// CALLDATALOAD三个参数作为topic1-3，Transfer事件签名作为topic0，LOG4后STOP
+func transferEmitterCode() []byte {
+	code := []byte{
+		0x60, 0x40, 0x35, // tokenId
+		0x60, 0x20, 0x35, // to
+		0x60, 0x00, 0x35, // from
+		0x7f, // PUSH32 事件签名
+	}
+	code = append(code, crypto.Keccak256([]byte("Transfer(address,address,uint256)"))...)
+	return append(code, 0x60, 0x00, 0x60, 0x00, 0xa4, 0x00)
+}
+
+// indexerChain 部署了Transfer事件合约的模拟链，由测试手动出块
+type indexerChain struct {
+	t       *testing.T
+	backend *simulated.Backend
+	client  simulated.Client
+	key     *ecdsa.PrivateKey
+	main    common.Address
+	child   common.Address
+}
+
+func newIndexerChain(t *testing.T) *indexerChain {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	chain := &indexerChain{
+		t:     t,
+		key:   key,
+		main:  common.HexToAddress("0x00000000000000000000000000000000000a11ce"),
+		child: common.HexToAddress("0x00000000000000000000000000000000000c41d0"),
+	}
+	chain.backend = simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: balance},
+		chain.main:                            {Code: transferEmitterCode(), Balance: new(big.Int)},
+		chain.child:                           {Code: transferEmitterCode(), Balance: new(big.Int)},
+	})
+	t.Cleanup(func() { chain.backend.Close() })
+	chain.client = chain.backend.Client()
+	return chain
+}
+
+// send 以指定nonce发送交易，由调用方出块。分叉后交易池会重新放回旧链上被丢弃的交易，
+// 用相同nonce和更高费用发送可以替换它们
+func (c *indexerChain) send(nonce uint64, to common.Address, data []byte, feeMultiplier int64) {
+	c.t.Helper()
+	tx, err := types.SignNewTx(c.key, types.LatestSignerForChainID(big.NewInt(1337)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1337),
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1e9 * feeMultiplier),
+		GasFeeCap: big.NewInt(100e9 * feeMultiplier),
+		Gas:       100000,
+		To:        &to,
+		Data:      data,
+	})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	// 交易池在后台切换到新链头，切换前仍按旧链状态校验nonce
+	for attempt := 0; ; attempt++ {
+		err = c.client.SendTransaction(context.Background(), tx)
+		if err == nil {
+			break
+		}
+		if !strings.Contains(err.Error(), "nonce too low") || attempt >= 200 {
+			c.t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// transferData MainNFT事件合约的调用数据
+func transferData(from, to common.Address, tokenID int64) []byte {
+	data := append(common.LeftPadBytes(from.Bytes(), 32), common.LeftPadBytes(to.Bytes(), 32)...)
+	return append(data, common.LeftPadBytes(big.NewInt(tokenID).Bytes(), 32)...)
+}
+
+// owners 返回MainNFT各token的持有者
+func owners(t *testing.T, db *gorm.DB) map[string]string {
+	t.Helper()
+	var nfts []models.NFT
+	if err := db.Where("contract_type = ?", "main").Find(&nfts).Error; err != nil {
+		t.Fatal(err)
+	}
+	result := make(map[string]string, len(nfts))
+	for _, nft := range nfts {
+		result[nft.TokenID] = nft.Owner
+	}
+	return result
+}
+
+func TestIndexerRollsBackReorg(t *testing.T) {
+	chain := newIndexerChain(t)
+	db := newTestDB(t, &models.NFT{}, &models.IndexerCheckpoint{}, &models.IndexedBlock{}, &models.IndexerJournal{})
+	indexer, err := NewIndexerService(db, chain.client, "test", 1337, chain.main, chain.child, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	alice := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	carol := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	// 区块1铸造#1给alice，区块2铸造#2给bob，区块3把#1转给bob
+	chain.send(0, chain.main, transferData(common.Address{}, alice, 1), 1)
+	ancestor := chain.backend.Commit()
+	chain.send(1, chain.main, transferData(common.Address{}, bob, 2), 1)
+	chain.backend.Commit()
+	chain.send(2, chain.main, transferData(alice, bob, 1), 1)
+	chain.backend.Commit()
+	if processed, err := indexer.Sync(ctx); err != nil || processed != 3 {
+		t.Fatalf("同步到区块 %d: %v", processed, err)
+	}
+	got := owners(t, db)
+	if len(got) != 2 || got["1"] != bob.Hex() || got["2"] != bob.Hex() {
+		t.Fatalf("重组前的持有者不正确: %v", got)
+	}
+
+	// 从区块1分叉：替换掉被丢弃的两笔交易，新链上区块2铸造#3给carol并做一笔不产生事件的转账，区块3、4为空块
+	if err := chain.backend.Fork(ancestor); err != nil {
+		t.Fatal(err)
+	}
+	chain.send(1, chain.main, transferData(common.Address{}, carol, 3), 2)
+	chain.send(2, carol, nil, 2)
+	chain.backend.Commit()
+	chain.backend.Commit()
+	head := chain.backend.Commit()
+
+	processed, err := indexer.Sync(ctx)
+	if err != nil || processed != 4 {
+		t.Fatalf("重组后同步到区块 %d: %v", processed, err)
+	}
+	got = owners(t, db)
+	if len(got) != 2 || got["1"] != alice.Hex() || got["3"] != carol.Hex() {
+		t.Fatalf("重组后的持有者应回滚为分叉链上的状态: %v", got)
+	}
+	checkpoint, err := indexer.Checkpoint()
+	if err != nil || checkpoint.BlockNumber != 4 || checkpoint.BlockHash != head.Hex() {
+		t.Fatalf("检查点应指向新链的区块4: %+v %v", checkpoint, err)
+	}
+}
+
+func TestIndexerRollbackBeyondWindowStopsAtGenesis(t *testing.T) {
+	chain := newIndexerChain(t)
+	db := newTestDB(t, &models.NFT{}, &models.IndexerCheckpoint{}, &models.IndexedBlock{}, &models.IndexerJournal{})
+	indexer, err := NewIndexerService(db, chain.client, "test", 1337, chain.main, chain.child, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.backend.Commit()
+
+	// 记录的区块哈希都不在主链上，且最早记录的是区块0
+	blocks := []models.IndexedBlock{{Indexer: "test", Number: 0, Hash: "0xstale0"}, {Indexer: "test", Number: 1, Hash: "0xstale1"}}
+	if err := db.Create(&blocks).Error; err != nil {
+		t.Fatal(err)
+	}
+	ancestor, err := indexer.rollbackReorg(context.Background(), &models.IndexerCheckpoint{Name: "test", BlockNumber: 2, BlockHash: "0xstale2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ancestor.BlockNumber != 0 {
+		t.Fatalf("应退回到区块0，得到 %d", ancestor.BlockNumber)
+	}
+	var remaining int64
+	db.Model(&models.IndexedBlock{}).Count(&remaining)
+	if remaining != 1 {
+		t.Fatalf("应只保留区块0的记录，剩余 %d 条", remaining)
+	}
+	checkpoint, err := indexer.Checkpoint()
+	if err != nil || checkpoint.BlockNumber != 0 {
+		t.Fatalf("检查点应退回到区块0: %+v %v", checkpoint, err)
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// 索引器运行模式
+const (
+	IndexerModeSubscription = "subscription" // 订阅新区块，每个新区块触发一次同步
+	IndexerModePolling      = "polling"      // 按间隔用eth_getLogs轮询
+)
+
+// 索引器健康状态
+const (
+	IndexerStatusStarting = "starting"
+	IndexerStatusOK       = "ok"
+	IndexerStatusDegraded = "degraded" // 最近一次同步失败，或订阅断开正在重连
+)
+
+// 订阅重连的退避范围，订阅保持indexerStableSubscription以上才把退避重置为最小值
+const (
+	indexerMinBackoff         = time.Second
+	indexerMaxBackoff         = time.Minute
+	indexerStableSubscription = time.Minute
+)
+
+// errIndexerSubscriptionClosed 订阅被节点关闭且没有返回错误
+var errIndexerSubscriptionClosed = errors.New("新区块订阅已关闭")
+
+// HeadSubscriber 新区块订阅接口，ethclient.Client在WebSocket和IPC连接上支持
+type HeadSubscriber interface {
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+// IndexerHealth 索引器健康信息，由/api/health返回
+type IndexerHealth struct {
+	Collection    string     `json:"collection"` // 索引的部署
+	ChainID       int64      `json:"chainId"`
+	Status        string     `json:"status"`
+	Mode          string     `json:"mode"`
+	Subscribed    bool       `json:"subscribed"`
+	LastBlock     uint64     `json:"lastBlock"`  // 已处理的最后一个区块
+	LatestHead    uint64     `json:"latestHead"` // 最近看到的最新区块
+	Lag           uint64     `json:"lag"`
+	LastSyncAt    *time.Time `json:"lastSyncAt,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorAt   *time.Time `json:"lastErrorAt,omitempty"`
+	Reconnects    int        `json:"reconnects"`
+	NextReconnect *time.Time `json:"nextReconnect,omitempty"`
+}
+
+// IndexerSupervisor 驱动索引器：WebSocket/IPC端点订阅新区块，订阅失败或断开时以指数退避重连，
+// 等待重连期间及HTTP端点用eth_getLogs轮询。每次同步都从检查点继续，断线期间的区块不会遗漏
+type IndexerSupervisor struct {
+	Indexer      *IndexerService
+	Heads        HeadSubscriber // 为nil时只轮询
+	PollInterval time.Duration
+
+	mu     sync.RWMutex
+	health IndexerHealth
+}
+
+// NewIndexerSupervisor 创建索引器监督者；rpcURL为HTTP地址时不订阅，直接轮询
+func NewIndexerSupervisor(indexer *IndexerService, rpcURL string, heads HeadSubscriber, pollInterval time.Duration) *IndexerSupervisor {
+	if !SupportsSubscription(rpcURL) {
+		heads = nil
+	}
+	mode := IndexerModePolling
+	if heads != nil {
+		mode = IndexerModeSubscription
+	}
+	return &IndexerSupervisor{
+		Indexer:      indexer,
+		Heads:        heads,
+		PollInterval: pollInterval,
+		health:       IndexerHealth{Status: IndexerStatusStarting, Mode: mode},
+	}
+}
+
+// SupportsSubscription 判断RPC端点是否支持订阅：WebSocket和IPC支持，HTTP不支持
+func SupportsSubscription(rpcURL string) bool {
+	lower := strings.ToLower(rpcURL)
+	return !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://")
+}
+
+// Run 运行索引器直到ctx取消
+func (s *IndexerSupervisor) Run(ctx context.Context) {
+	if s.Heads == nil {
+		s.poll(ctx, nil)
+		return
+	}
+
+	backoff := indexerMinBackoff
+	for ctx.Err() == nil {
+		heads := make(chan *types.Header, 16)
+		sub, err := s.Heads.SubscribeNewHead(ctx, heads)
+		if err != nil {
+			log.Printf("部署 %s 订阅新区块失败，%s 后重试: %v", s.Indexer.Name, backoff, err)
+			s.waitReconnect(ctx, err, backoff)
+			backoff = nextIndexerBackoff(backoff)
+			continue
+		}
+
+		subscribedAt := time.Now()
+		s.recordSubscribed()
+		log.Printf("部署 %s 已订阅新区块，开始跟随链上事件", s.Indexer.Name)
+		// 先追上断线期间的区块
+		s.sync(ctx)
+		err = s.follow(ctx, sub, heads)
+		sub.Unsubscribe()
+		if ctx.Err() != nil {
+			return
+		}
+		// 订阅保持足够长时间才视为连接已恢复；接受订阅后很快断开的端点继续按退避间隔重连
+		if time.Since(subscribedAt) >= indexerStableSubscription {
+			backoff = indexerMinBackoff
+		}
+		log.Printf("部署 %s 新区块订阅中断，%s 后重连: %v", s.Indexer.Name, backoff, err)
+		s.waitReconnect(ctx, err, backoff)
+		backoff = nextIndexerBackoff(backoff)
+	}
+}
+
+// waitReconnect 记录断开并等待backoff后再重连，等待期间改为轮询，保持索引不中断
+func (s *IndexerSupervisor) waitReconnect(ctx context.Context, err error, backoff time.Duration) {
+	retryAt := time.Now().Add(backoff)
+	s.recordDisconnect(err, &retryAt)
+	s.poll(ctx, time.After(backoff))
+}
+
+// nextIndexerBackoff 下一次重连的退避时间
+func nextIndexerBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > indexerMaxBackoff {
+		backoff = indexerMaxBackoff
+	}
+	return backoff
+}
+
+// follow 每收到一个新区块同步一次，直到订阅出错或ctx取消
+func (s *IndexerSupervisor) follow(ctx context.Context, sub ethereum.Subscription, heads <-chan *types.Header) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			if err == nil {
+				err = errIndexerSubscriptionClosed
+			}
+			return err
+		case <-heads:
+			// 合并积压的新区块，只同步一次
+			for len(heads) > 0 {
+				<-heads
+			}
+			s.sync(ctx)
+		}
+	}
+}
+
+// poll 按PollInterval轮询同步，直到ctx取消或until触发；until为nil时一直轮询
+func (s *IndexerSupervisor) poll(ctx context.Context, until <-chan time.Time) {
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+	for {
+		s.sync(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-until:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync 同步一次并记录结果
+func (s *IndexerSupervisor) sync(ctx context.Context) {
+	processed, err := s.Indexer.Sync(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil || processed > 0 {
+		s.health.LastBlock = processed
+	}
+	s.health.LatestHead = s.Indexer.LatestHead()
+	if err != nil {
+		log.Printf("部署 %s 合约事件索引失败: %v", s.Indexer.Name, err)
+		s.health.LastError = err.Error()
+		s.health.LastErrorAt = &now
+		s.health.Status = IndexerStatusDegraded
+		return
+	}
+	s.health.LastSyncAt = &now
+	if s.health.Mode == IndexerModePolling || s.health.Subscribed {
+		s.health.Status = IndexerStatusOK
+	}
+}
+
+// recordSubscribed 记录订阅成功
+func (s *IndexerSupervisor) recordSubscribed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.Subscribed = true
+	s.health.NextReconnect = nil
+}
+
+// recordDisconnect 记录订阅失败或断开
+func (s *IndexerSupervisor) recordDisconnect(err error, retryAt *time.Time) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.Subscribed = false
+	s.health.Reconnects++
+	s.health.LastError = err.Error()
+	s.health.LastErrorAt = &now
+	s.health.NextReconnect = retryAt
+	s.health.Status = IndexerStatusDegraded
+}
+
+// Health 返回健康信息快照；超过三个轮询间隔未成功同步时视为降级
+func (s *IndexerSupervisor) Health() IndexerHealth {
+	s.mu.RLock()
+	health := s.health
+	s.mu.RUnlock()
+
+	health.Collection, health.ChainID = s.Indexer.Name, s.Indexer.ChainID
+	if health.LatestHead > health.LastBlock {
+		health.Lag = health.LatestHead - health.LastBlock
+	}
+	if health.Status == IndexerStatusOK && health.LastSyncAt != nil && time.Since(*health.LastSyncAt) > 3*s.PollInterval && health.Mode == IndexerModePolling {
+		health.Status = IndexerStatusDegraded
+	}
+	return health
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// droppingSubscriber 接受订阅后立即断开的端点
+type droppingSubscriber struct {
+	subscribes atomic.Int32
+}
+
+func (d *droppingSubscriber) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	d.subscribes.Add(1)
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		return errors.New("connection reset")
+	}), nil
+}
+
+func TestIndexerSupervisorBacksOffAfterDroppedSubscription(t *testing.T) {
+	chain := newIndexerChain(t)
+	db := newTestDB(t, &models.NFT{}, &models.IndexerCheckpoint{}, &models.IndexedBlock{}, &models.IndexerJournal{})
+	indexer, err := NewIndexerService(db, chain.client, "test", 1337, chain.main, chain.child, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	heads := &droppingSubscriber{}
+	supervisor := NewIndexerSupervisor(indexer, "ws://127.0.0.1:8546", heads, 100*time.Millisecond)
+
+	// 退避从1秒开始：1.5秒内只应重连一次，而不是反复订阅
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	supervisor.Run(ctx)
+
+	if n := heads.subscribes.Load(); n != 2 {
+		t.Fatalf("应订阅2次，实际 %d 次", n)
+	}
+	health := supervisor.Health()
+	if health.Subscribed || health.Reconnects != 2 || health.NextReconnect == nil {
+		t.Fatalf("断开后应处于等待重连状态: %+v", health)
+	}
+	if health.LastSyncAt == nil {
+		t.Fatal("等待重连期间应继续轮询同步")
+	}
+}
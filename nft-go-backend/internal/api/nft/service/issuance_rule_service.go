@@ -0,0 +1,297 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ABE/nft/nft-go-backend/internal/blockchain"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// 子NFT发行规则代码，申请被拦截时返回给客户端
+const (
+	IssuanceRuleMaxSupply           = "max_supply"
+	IssuanceRulePerApplicantLimit   = "per_applicant_limit"
+	IssuanceRuleWindowNotStarted    = "window_not_started"
+	IssuanceRuleWindowEnded         = "window_ended"
+	IssuanceRuleDenyList            = "deny_list"
+	IssuanceRuleAllowList           = "allow_list"
+	IssuanceRuleRequiredCredential  = "required_credential"
+	IssuanceRuleAutoApproveDisabled = "auto_approve_disabled"
+)
+
+// 计入发行总量的申请状态（已发行或正在铸造）
+var issuedChildRequestStatuses = []string{
+	models.ChildRequestStatusMinting,
+	models.ChildRequestStatusApproved,
+	models.ChildRequestStatusAutoApproved,
+}
+
+// 计入申请者个人限额的申请状态（尚在处理中或已发行）
+var activeChildRequestStatuses = append([]string{models.ChildRequestStatusPending, models.ChildRequestStatusFailed}, issuedChildRequestStatuses...)
+
+// IssuanceRuleViolation 申请违反的发行规则
+type IssuanceRuleViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error 实现error接口
+func (v *IssuanceRuleViolation) Error() string {
+	return v.Message
+}
+
+// IssuanceRuleService 一个部署的子NFT发行规则服务
+type IssuanceRuleService struct {
+	DB     *gorm.DB
+	Client *blockchain.EthClient
+}
+
+// NewIssuanceRuleService 创建发行规则服务
+func NewIssuanceRuleService(db *gorm.DB, client *blockchain.EthClient) *IssuanceRuleService {
+	return &IssuanceRuleService{DB: db, Client: client}
+}
+
+// GetRule 查询父NFT的发行规则，未设置时返回nil
+func (s *IssuanceRuleService) GetRule(parentTokenID string) (*models.ChildIssuanceRule, error) {
+	var rule models.ChildIssuanceRule
+	err := s.DB.Where("collection = ? AND parent_token_id = ?", s.Client.Collection(), parentTokenID).First(&rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询发行规则失败: %v", err)
+	}
+	return &rule, nil
+}
+
+// SetRule 由父NFT的链上持有者创建或替换发行规则
+func (s *IssuanceRuleService) SetRule(wallet string, req *models.SetIssuanceRulesRequest) (*models.ChildIssuanceRule, error) {
+	parentTokenID, ok := new(big.Int).SetString(req.ParentTokenID, 10)
+	if !ok {
+		return nil, fmt.Errorf("无效的父token ID")
+	}
+	owner, _, _, err := s.Client.GetNFTInfo(parentTokenID)
+	if err != nil {
+		return nil, fmt.Errorf("获取NFT信息失败: %v", err)
+	}
+	if !strings.EqualFold(owner, wallet) {
+		return nil, ErrChildRequestForbidden
+	}
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return nil, fmt.Errorf("截止时间必须晚于开放时间")
+	}
+
+	rule, err := s.GetRule(req.ParentTokenID)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		rule = &models.ChildIssuanceRule{Collection: s.Client.Collection(), ParentTokenID: req.ParentTokenID}
+	}
+	rule.MaxSupply = req.MaxSupply
+	rule.PerApplicantLimit = req.PerApplicantLimit
+	rule.StartsAt = req.StartsAt
+	rule.EndsAt = req.EndsAt
+	rule.AllowList = normalizeRuleList(req.AllowList)
+	rule.DenyList = normalizeRuleList(req.DenyList)
+	rule.RequiredVCType = strings.TrimSpace(req.RequiredVCType)
+	rule.RequiredVCIssuer = strings.TrimSpace(req.RequiredVCIssuer)
+	rule.AutoApproveAllowed = req.AutoApproveAllowed == nil || *req.AutoApproveAllowed
+	rule.UpdatedBy = strings.ToLower(wallet)
+
+	created := rule.ID == 0
+	if err := s.DB.Save(rule).Error; err != nil {
+		return nil, fmt.Errorf("保存发行规则失败: %v", err)
+	}
+	// 新建记录时GORM会以数据库默认值代替布尔零值，需显式写入
+	if created && !rule.AutoApproveAllowed {
+		if err := s.DB.Model(rule).Update("auto_approve_allowed", false).Error; err != nil {
+			return nil, fmt.Errorf("保存发行规则失败: %v", err)
+		}
+	}
+	return rule, nil
+}
+
+// CheckRequest 检查新申请是否满足父NFT的发行规则，返回第一条被违反的规则；未设置规则时总是通过
+func (s *IssuanceRuleService) CheckRequest(parentTokenID, applicant string, now time.Time) (*models.ChildIssuanceRule, error) {
+	rule, err := s.GetRule(parentTokenID)
+	if err != nil || rule == nil {
+		return rule, err
+	}
+
+	if rule.StartsAt != nil && now.Before(*rule.StartsAt) {
+		return rule, &IssuanceRuleViolation{IssuanceRuleWindowNotStarted, fmt.Sprintf("申请将于 %s 开放", rule.StartsAt.Format(time.RFC3339))}
+	}
+	if rule.EndsAt != nil && !now.Before(*rule.EndsAt) {
+		return rule, &IssuanceRuleViolation{IssuanceRuleWindowEnded, fmt.Sprintf("申请已于 %s 截止", rule.EndsAt.Format(time.RFC3339))}
+	}
+
+	// 名单同时按钱包地址和申请者名下的DID匹配
+	identities := []string{strings.ToLower(applicant)}
+	var dids []models.DID
+	if err := s.DB.Where("LOWER(wallet_address) = ?", strings.ToLower(applicant)).Find(&dids).Error; err != nil {
+		return rule, fmt.Errorf("查询申请者DID失败: %v", err)
+	}
+	for _, did := range dids {
+		identities = append(identities, strings.ToLower(did.DIDString))
+	}
+	if matchRuleList(rule.DenyList, identities) {
+		return rule, &IssuanceRuleViolation{IssuanceRuleDenyList, "申请者在该NFT的禁止名单中"}
+	}
+	if len(rule.AllowList) > 0 && !matchRuleList(rule.AllowList, identities) {
+		return rule, &IssuanceRuleViolation{IssuanceRuleAllowList, "申请者不在该NFT的允许名单中"}
+	}
+
+	if err := checkSupply(s.DB, rule); err != nil {
+		return rule, err
+	}
+	if rule.PerApplicantLimit > 0 {
+		var count int64
+		if err := s.DB.Model(&models.ChildNFTRequest{}).
+			Where("collection = ? AND parent_token_id = ? AND LOWER(applicant_address) = ? AND status IN ?",
+				rule.Collection, parentTokenID, strings.ToLower(applicant), activeChildRequestStatuses).
+			Count(&count).Error; err != nil {
+			return rule, fmt.Errorf("统计申请者申请数量失败: %v", err)
+		}
+		if uint(count) >= rule.PerApplicantLimit {
+			return rule, &IssuanceRuleViolation{IssuanceRulePerApplicantLimit, fmt.Sprintf("每个申请者最多 %d 个有效申请或子NFT", rule.PerApplicantLimit)}
+		}
+	}
+
+	if rule.RequiredVCType != "" || rule.RequiredVCIssuer != "" {
+		if err := s.checkCredential(rule, dids, now); err != nil {
+			return rule, err
+		}
+	}
+	return rule, nil
+}
+
+// ReserveSupply 在批准申请的事务中锁定父NFT的发行规则后检查总量上限，统计与随后的状态迁移一起提交；
+// 并发批准同一父NFT的申请时在规则行上依次执行，不会超发
+func (s *IssuanceRuleService) ReserveSupply(tx *gorm.DB, collection, parentTokenID string) error {
+	if s == nil {
+		return nil
+	}
+	var rule models.ChildIssuanceRule
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("collection = ? AND parent_token_id = ?", collection, parentTokenID).
+		First(&rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询发行规则失败: %v", err)
+	}
+	return checkSupply(tx, &rule)
+}
+
+// checkSupply 检查已发行和正在铸造的子NFT数量是否已达上限
+func checkSupply(db *gorm.DB, rule *models.ChildIssuanceRule) error {
+	if rule.MaxSupply == 0 {
+		return nil
+	}
+	var issued int64
+	if err := db.Model(&models.ChildNFTRequest{}).
+		Where("collection = ? AND parent_token_id = ? AND status IN ?", rule.Collection, rule.ParentTokenID, issuedChildRequestStatuses).
+		Count(&issued).Error; err != nil {
+		return fmt.Errorf("统计已发行子NFT失败: %v", err)
+	}
+	if uint(issued) >= rule.MaxSupply {
+		return &IssuanceRuleViolation{IssuanceRuleMaxSupply, fmt.Sprintf("该NFT的子NFT已达上限 %d", rule.MaxSupply)}
+	}
+	return nil
+}
+
+// checkCredential 检查申请者名下DID是否持有规则要求类型和颁发者的有效凭证，
+// 通用凭证（verifiable_credentials）和医生等主体凭证（subject_credentials）都计入
+func (s *IssuanceRuleService) checkCredential(rule *models.ChildIssuanceRule, dids []models.DID, now time.Time) error {
+	violation := &IssuanceRuleViolation{IssuanceRuleRequiredCredential, requiredCredentialMessage(rule)}
+	if len(dids) == 0 {
+		return violation
+	}
+	subjects := make([]string, len(dids))
+	for i, did := range dids {
+		subjects[i] = did.DIDString
+	}
+
+	// VerifiableCredential的SubjectDID、IssuerDID字段没有列名标签，GORM映射为subject_d_id、issuer_d_id
+	query := s.DB.Model(&models.VerifiableCredential{}).Where("subject_d_id IN ? AND status = ?", subjects, "active")
+	if rule.RequiredVCType != "" {
+		query = query.Where("credential_type = ?", rule.RequiredVCType)
+	}
+	if rule.RequiredVCIssuer != "" {
+		query = query.Where(&models.VerifiableCredential{IssuerDID: rule.RequiredVCIssuer})
+	}
+	var credentials []models.VerifiableCredential
+	if err := query.Find(&credentials).Error; err != nil {
+		return fmt.Errorf("查询申请者凭证失败: %v", err)
+	}
+	for _, credential := range credentials {
+		if credential.ExpirationDate.IsZero() || credential.ExpirationDate.After(now) {
+			return nil
+		}
+	}
+
+	subjectQuery := s.DB.Model(&models.SubjectCredential{}).
+		Where("subject_did IN ? AND status = ? AND expires_at > ?", subjects, "active", now)
+	if rule.RequiredVCType != "" {
+		subjectQuery = subjectQuery.Where("type = ?", rule.RequiredVCType)
+	}
+	if rule.RequiredVCIssuer != "" {
+		subjectQuery = subjectQuery.Where("issuer_did = ?", rule.RequiredVCIssuer)
+	}
+	var count int64
+	if err := subjectQuery.Count(&count).Error; err != nil {
+		return fmt.Errorf("查询申请者主体凭证失败: %v", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	return violation
+}
+
+// requiredCredentialMessage 描述规则要求的凭证
+func requiredCredentialMessage(rule *models.ChildIssuanceRule) string {
+	switch {
+	case rule.RequiredVCType != "" && rule.RequiredVCIssuer != "":
+		return fmt.Sprintf("申请者需持有由 %s 颁发的有效 %s 凭证", rule.RequiredVCIssuer, rule.RequiredVCType)
+	case rule.RequiredVCType != "":
+		return fmt.Sprintf("申请者需持有有效的 %s 凭证", rule.RequiredVCType)
+	default:
+		return fmt.Sprintf("申请者需持有由 %s 颁发的有效凭证", rule.RequiredVCIssuer)
+	}
+}
+
+// normalizeRuleList 去除空白和重复项，钱包地址与DID统一小写比较
+func normalizeRuleList(entries []string) []string {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" || seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		normalized = append(normalized, entry)
+	}
+	return normalized
+}
+
+// matchRuleList 判断任一身份标识是否在名单中
+func matchRuleList(list, identities []string) bool {
+	for _, entry := range list {
+		for _, identity := range identities {
+			if strings.EqualFold(entry, identity) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// newTestDB 创建内存SQLite数据库并迁移给定模型
+func newTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	// 内存数据库每个连接相互独立，只保留一个连接
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("迁移测试表失败: %v", err)
+	}
+	return db
+}
+
+func TestCheckCredentialAcceptsSubjectCredentials(t *testing.T) {
+	db := newTestDB(t, &models.VerifiableCredential{}, &models.SubjectCredential{})
+	service := &IssuanceRuleService{DB: db}
+	now := time.Now()
+	dids := []models.DID{{DIDString: "did:ethr:0xabc"}}
+
+	credentials := []models.SubjectCredential{
+		{VCID: "vc-expired", SubjectDID: "did:ethr:0xabc", Role: "doctor", IssuerDID: "did:ethr:hospital", Type: "执业资格",
+			IssuedAt: now.AddDate(-2, 0, 0), ExpiresAt: now.AddDate(-1, 0, 0), Status: "active"},
+		{VCID: "vc-revoked", SubjectDID: "did:ethr:0xabc", Role: "doctor", IssuerDID: "did:ethr:hospital", Type: "职称",
+			IssuedAt: now, ExpiresAt: now.AddDate(1, 0, 0), Status: "revoked"},
+		{VCID: "vc-valid", SubjectDID: "did:ethr:0xabc", Role: "doctor", IssuerDID: "did:ethr:hospital", Type: "医师资格",
+			IssuedAt: now, ExpiresAt: now.AddDate(1, 0, 0), Status: "active"},
+	}
+	if err := db.Create(&credentials).Error; err != nil {
+		t.Fatal(err)
+	}
+	degree := models.VerifiableCredential{CredentialID: "urn:uuid:degree", IssuerDID: "did:ethr:university", SubjectDID: "did:ethr:0xabc",
+		Type: "学位", Status: "active", IssuanceDate: now, ExpirationDate: now.AddDate(1, 0, 0)}
+	if err := db.Create(&degree).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		vcType string
+		issuer string
+		pass   bool
+	}{
+		{"医师资格", "", true},
+		{"医师资格", "did:ethr:hospital", true},
+		{"", "did:ethr:hospital", true},
+		{"医师资格", "did:ethr:other", false},
+		{"执业资格", "", false},
+		{"职称", "", false},
+		{"学位", "did:ethr:university", true},
+		{"学位", "did:ethr:hospital", false},
+	}
+	for _, tt := range tests {
+		rule := &models.ChildIssuanceRule{RequiredVCType: tt.vcType, RequiredVCIssuer: tt.issuer}
+		err := service.checkCredential(rule, dids, now)
+		var violation *IssuanceRuleViolation
+		if tt.pass && err != nil {
+			t.Errorf("type=%q issuer=%q 应通过，得到 %v", tt.vcType, tt.issuer, err)
+		}
+		if !tt.pass && (!errors.As(err, &violation) || violation.Rule != IssuanceRuleRequiredCredential) {
+			t.Errorf("type=%q issuer=%q 应违反凭证规则，得到 %v", tt.vcType, tt.issuer, err)
+		}
+	}
+}
+
+func TestReserveSupplyBlocksApprovalOverMaxSupply(t *testing.T) {
+	db := newTestDB(t, &models.ChildIssuanceRule{}, &models.ChildNFTRequest{}, &models.ChildNFTRequestTransition{})
+	requests := &ChildRequestService{DB: db, Collection: "default"}
+	rules := &IssuanceRuleService{DB: db}
+
+	if err := db.Create(&models.ChildIssuanceRule{Collection: "default", ParentTokenID: "1", MaxSupply: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+	pending := []models.ChildNFTRequest{
+		{Collection: "default", ParentTokenId: "1", ApplicantAddress: "0xaaa", URI: "ipfs://a", Status: models.ChildRequestStatusPending},
+		{Collection: "default", ParentTokenId: "1", ApplicantAddress: "0xbbb", URI: "ipfs://b", Status: models.ChildRequestStatusPending},
+	}
+	if err := db.Create(&pending).Error; err != nil {
+		t.Fatal(err)
+	}
+	reserve := func(tx *gorm.DB, request *models.ChildNFTRequest) error {
+		return rules.ReserveSupply(tx, request.Collection, request.ParentTokenId)
+	}
+
+	if _, err := requests.TransitionChecked(pending[0].ID, 0, models.ChildRequestStatusMinting, "0xowner", "", nil, reserve); err != nil {
+		t.Fatalf("第一个申请应可批准: %v", err)
+	}
+	_, err := requests.TransitionChecked(pending[1].ID, 0, models.ChildRequestStatusMinting, "0xowner", "", nil, reserve)
+	var violation *IssuanceRuleViolation
+	if !errors.As(err, &violation) || violation.Rule != IssuanceRuleMaxSupply {
+		t.Fatalf("超过总量上限应被拒绝，得到 %v", err)
+	}
+
+	second, err := requests.Get(pending[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Status != models.ChildRequestStatusPending || second.Version != pending[1].Version {
+		t.Fatalf("被拒绝的批准不应改变申请: status=%s version=%d", second.Status, second.Version)
+	}
+}
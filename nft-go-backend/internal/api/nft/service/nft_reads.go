@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ABE/nft/nft-go-backend/internal/blockchain"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// 列表接口的一致性模式
+const (
+	ConsistencyDB       = "db"          // 只读nfts表，最快，可能落后于链上
+	ConsistencyChain    = "chain"       // 通过ERC721Enumerable从链上读取，不读nfts表
+	ConsistencyVerified = "db-verified" // 读nfts表后在链上核对持有者、URI和父NFT，返回链上状态并报告不一致，不写回nfts表
+)
+
+// 全部NFT列表的分页大小
+const (
+	DefaultNFTPageSize = 50
+	MaxNFTPageSize     = 200
+)
+
+// ErrInvalidConsistency 一致性模式无效
+var ErrInvalidConsistency = errors.New("无效的一致性模式")
+
+// Consistency 校验列表接口的一致性模式，为空时使用NFT_READ_CONSISTENCY
+func (s *NFTService) Consistency(mode string) (string, error) {
+	if mode == "" {
+		mode = s.Client.Config.NFTReadConsistency
+	}
+	switch mode {
+	case "":
+		return ConsistencyDB, nil
+	case ConsistencyDB, ConsistencyChain, ConsistencyVerified:
+		return mode, nil
+	}
+	return "", fmt.Errorf("%w: %s（可选db、chain、db-verified）", ErrInvalidConsistency, mode)
+}
+
+// chainNFTs 从链上读取地址持有的token
+func (s *NFTService) chainNFTs(ctx context.Context, reader *blockchain.TokenReader, contractType string, owner common.Address) ([]models.NFT, error) {
+	tokenIDs, err := reader.TokensOfOwner(ctx, contractType, owner)
+	if err != nil {
+		return nil, fmt.Errorf("从链上列出NFT失败: %v", err)
+	}
+	return s.readChainNFTs(ctx, reader, contractType, tokenIDs)
+}
+
+// readChainNFTs 从链上读取给定token的状态
+func (s *NFTService) readChainNFTs(ctx context.Context, reader *blockchain.TokenReader, contractType string, tokenIDs []*big.Int) ([]models.NFT, error) {
+	states, err := reader.ReadTokens(ctx, contractType, tokenIDs)
+	if err != nil {
+		return nil, fmt.Errorf("从链上读取NFT失败: %v", err)
+	}
+
+	nfts := make([]models.NFT, 0, len(states))
+	for _, state := range states {
+		nfts = append(nfts, s.nftFromChain(contractType, state))
+	}
+	return nfts, nil
+}
+
+// nftFromChain 把链上状态转换为NFT记录
+func (s *NFTService) nftFromChain(contractType string, state blockchain.TokenState) models.NFT {
+	nft := models.NFT{
+		ChainID:      s.Client.Config.ChainID,
+		Contract:     s.Client.ContractAddress(contractType),
+		TokenID:      state.TokenID.String(),
+		Owner:        state.Owner.Hex(),
+		URI:          state.URI,
+		IsChildNFT:   contractType == "child",
+		ContractType: contractType,
+	}
+	if state.ParentTokenID != nil {
+		nft.ParentTokenID = state.ParentTokenID.String()
+	}
+	return nft
+}
+
+// verifyNFTs 在同一区块上核对数据库记录：链上已不存在的token不返回，持有者、URI或父NFT不一致时返回链上状态并记录日志。
+// 读取的是最新区块，尚未达到确认数、可能被重组，因此不写回nfts表，nfts表只由索引器按确认区块和修改日志更新
+func (s *NFTService) verifyNFTs(ctx context.Context, nfts []models.NFT) ([]models.NFT, error) {
+	if len(nfts) == 0 {
+		return nfts, nil
+	}
+	reader, err := s.Client.NewTokenReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	onChain := make(map[string]blockchain.TokenState)
+	for _, contractType := range []string{"main", "child"} {
+		contract := s.Client.ContractAddress(contractType)
+		var tokenIDs []*big.Int
+		for _, nft := range nfts {
+			if tokenID, ok := new(big.Int).SetString(nft.TokenID, 10); ok && nft.Contract == contract {
+				tokenIDs = append(tokenIDs, tokenID)
+			}
+		}
+		if len(tokenIDs) == 0 {
+			continue
+		}
+		states, err := reader.ReadTokens(ctx, contractType, tokenIDs)
+		if err != nil {
+			return nil, fmt.Errorf("在链上核对NFT失败: %v", err)
+		}
+		for _, state := range states {
+			onChain[contract+"/"+state.TokenID.String()] = state
+		}
+	}
+
+	verified := make([]models.NFT, 0, len(nfts))
+	for _, nft := range nfts {
+		state, ok := onChain[nft.Contract+"/"+nft.TokenID]
+		if !ok {
+			log.Printf("NFT %s/%s 在区块 %s 已不存在，不返回", nft.Contract, nft.TokenID, reader.Block)
+			continue
+		}
+		contractType := "main"
+		if nft.Contract == s.Client.ContractAddress("child") {
+			contractType = "child"
+		}
+		actual := s.nftFromChain(contractType, state)
+		if contractType == "main" {
+			actual.ParentTokenID = nft.ParentTokenID
+		}
+		if !strings.EqualFold(nft.Owner, actual.Owner) || nft.URI != actual.URI || nft.ParentTokenID != actual.ParentTokenID {
+			log.Printf("NFT %s/%s 与区块 %s 的链上状态不一致: 持有者 %s -> %s, URI %q -> %q, 父NFT %q -> %q",
+				nft.Contract, nft.TokenID, reader.Block, nft.Owner, actual.Owner, nft.URI, actual.URI, nft.ParentTokenID, actual.ParentTokenID)
+			nft.Owner, nft.URI, nft.ParentTokenID = actual.Owner, actual.URI, actual.ParentTokenID
+		}
+		verified = append(verified, nft)
+	}
+	return verified, nil
+}
+
+// ownedNFTs 按一致性模式查询地址持有的主NFT和子NFT
+func (s *NFTService) ownedNFTs(ctx context.Context, address, mode string) ([]models.NFT, error) {
+	if mode == ConsistencyChain {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("无效的钱包地址: %s", address)
+		}
+		owner := common.HexToAddress(address)
+		reader, err := s.Client.NewTokenReader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		mainNFTs, err := s.chainNFTs(ctx, reader, "main", owner)
+		if err != nil {
+			return nil, err
+		}
+		childNFTs, err := s.chainNFTs(ctx, reader, "child", owner)
+		if err != nil {
+			return nil, err
+		}
+		return append(mainNFTs, childNFTs...), nil
+	}
+
+	// 查询用户拥有的主NFT
+	var mainNFTs []models.NFT
+	result := models.DB.Scopes(s.Client.NFTContract("main").Scope).Where("owner = ? AND (is_child_nft = ? OR is_child_nft IS NULL)", address, false).Find(&mainNFTs)
+	if result.Error != nil {
+		return nil, fmt.Errorf("查询主NFT失败: %v", result.Error)
+	}
+
+	// 查询用户拥有的子NFT
+	var childNFTs []models.NFT
+	childResult := models.DB.Scopes(s.Client.NFTContract("child").Scope).Where("owner = ? AND is_child_nft = ?", address, true).Find(&childNFTs)
+	if childResult.Error != nil {
+		return nil, fmt.Errorf("查询子NFT失败: %v", childResult.Error)
+	}
+
+	// 合并主NFT和子NFT
+	nfts := append(mainNFTs, childNFTs...)
+	if mode != ConsistencyVerified {
+		return nfts, nil
+	}
+	nfts, err := s.verifyNFTs(ctx, nfts)
+	if err != nil {
+		return nil, err
+	}
+	// 链上已转出的token不再属于该地址
+	owned := nfts[:0]
+	for _, nft := range nfts {
+		if strings.EqualFold(nft.Owner, address) {
+			owned = append(owned, nft)
+		}
+	}
+	return owned, nil
+}
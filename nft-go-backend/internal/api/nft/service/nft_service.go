@@ -1,73 +1,124 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"math/big"
 	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
 
 	"github.com/ABE/nft/nft-go-backend/internal/blockchain"
 	"github.com/ABE/nft/nft-go-backend/internal/models"
 )
 
+// 列表接口同时解析元数据的最大并发数
+const metadataConcurrency = 8
+
 // NFTService NFT业务逻辑服务
 type NFTService struct {
 	Client *blockchain.EthClient
+	Ledger *TxLedgerService
 }
 
 // NewNFTService 创建新的NFT服务
-func NewNFTService(client *blockchain.EthClient) *NFTService {
+func NewNFTService(client *blockchain.EthClient, ledger *TxLedgerService) *NFTService {
 	return &NFTService{
 		Client: client,
+		Ledger: ledger,
 	}
 }
 
-// GetAllMainNFTs 获取所有主NFT
-func (s *NFTService) GetAllMainNFTs() ([]models.NFTResponseWithMetadata, error) {
+// GetAllMainNFTs 分页获取主NFT，mode为一致性模式，返回本页NFT和总数。
+// chain模式只对[offset, offset+limit)内的索引调用tokenByIndex，limit为0时使用默认值，超过上限时截断
+func (s *NFTService) GetAllMainNFTs(ctx context.Context, mode string, offset, limit int) ([]models.NFTResponseWithMetadata, int64, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = DefaultNFTPageSize
+	}
+	if limit > MaxNFTPageSize {
+		limit = MaxNFTPageSize
+	}
+
 	var nfts []models.NFT
-	// 只查询主NFT，排除子NFT
-	result := models.DB.Where("is_child_nft = ? OR is_child_nft IS NULL", false).Find(&nfts)
-	if result.Error != nil {
-		return nil, result.Error
+	var total int64
+	if mode == ConsistencyChain {
+		reader, err := s.Client.NewTokenReader(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		tokenIDs, supply, err := reader.ListTokensRange(ctx, "main", int64(offset), int64(limit))
+		if err != nil {
+			return nil, 0, fmt.Errorf("从链上列出NFT失败: %v", err)
+		}
+		if nfts, err = s.readChainNFTs(ctx, reader, "main", tokenIDs); err != nil {
+			return nil, 0, err
+		}
+		total = supply.Int64()
+	} else {
+		// 只查询主NFT，排除子NFT
+		mainNFTs := func() *gorm.DB {
+			return models.DB.Model(&models.NFT{}).Scopes(s.Client.NFTContract("main").Scope).Where("is_child_nft = ? OR is_child_nft IS NULL", false)
+		}
+		if err := mainNFTs().Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+		if err := mainNFTs().Order("id ASC").Offset(offset).Limit(limit).Find(&nfts).Error; err != nil {
+			return nil, 0, err
+		}
+		if mode == ConsistencyVerified {
+			var err error
+			if nfts, err = s.verifyNFTs(ctx, nfts); err != nil {
+				return nil, 0, err
+			}
+		}
 	}
 
 	// 获取NFT元数据
 	var nftResponses []models.NFTResponseWithMetadata
-	for _, nft := range nfts {
-		metadata, _ := s.FetchNFTMetadata(nft.URI)
-
+	metadata, metadataErrors := s.resolveMetadata(ctx, nfts)
+	for i, nft := range nfts {
 		response := models.NFTResponseWithMetadata{
 			NFTResponse: models.NFTResponse{
+				ChainID:      nft.ChainID,
+				Contract:     nft.Contract,
 				TokenID:      nft.TokenID,
 				Owner:        nft.Owner,
 				URI:          nft.URI,
 				IsChildNFT:   false, // 明确标识为主NFT
 				ContractType: "main",
 			},
-			Metadata: metadata,
+			Metadata:      metadata[i],
+			MetadataError: metadataErrors[i],
 		}
 
 		nftResponses = append(nftResponses, response)
 	}
 
-	return nftResponses, nil
+	return nftResponses, total, nil
 }
 
-// GetUserNFTs 获取指定用户的NFT
-func (s *NFTService) GetUserNFTs(address string) ([]models.NFTResponseWithMetadata, error) {
-	var nfts []models.NFT
-	result := models.DB.Where("owner = ?", address).Find(&nfts)
-	if result.Error != nil {
-		return nil, result.Error
+// GetUserNFTs 获取指定用户的NFT，mode为一致性模式
+func (s *NFTService) GetUserNFTs(ctx context.Context, address, mode string) ([]models.NFTResponseWithMetadata, error) {
+	nfts, err := s.ownedNFTs(ctx, address, mode)
+	if err != nil {
+		return nil, err
 	}
 
 	// 获取NFT元数据
 	var nftResponses []models.NFTResponseWithMetadata
-	for _, nft := range nfts {
-		metadata, _ := s.FetchNFTMetadata(nft.URI)
-
+	metadata, metadataErrors := s.resolveMetadata(ctx, nfts)
+	for i, nft := range nfts {
 		response := models.NFTResponseWithMetadata{
 			NFTResponse: models.NFTResponse{
+				ChainID:       nft.ChainID,
+				Contract:      nft.Contract,
 				TokenID:       nft.TokenID,
 				Owner:         nft.Owner,
 				URI:           nft.URI,
@@ -75,7 +126,8 @@ func (s *NFTService) GetUserNFTs(address string) ([]models.NFTResponseWithMetada
 				ParentTokenID: nft.ParentTokenID,
 				ContractType:  nft.ContractType,
 			},
-			Metadata: metadata,
+			Metadata:      metadata[i],
+			MetadataError: metadataErrors[i],
 		}
 
 		nftResponses = append(nftResponses, response)
@@ -84,32 +136,21 @@ func (s *NFTService) GetUserNFTs(address string) ([]models.NFTResponseWithMetada
 	return nftResponses, nil
 }
 
-// GetMyNFTs 获取当前用户的NFT (包含子NFT)
-func (s *NFTService) GetMyNFTs(walletAddress string) ([]models.NFTResponseWithMetadata, error) {
-	// 查询用户拥有的主NFT
-	var mainNFTs []models.NFT
-	result := models.DB.Where("owner = ? AND (is_child_nft = ? OR is_child_nft IS NULL)", walletAddress, false).Find(&mainNFTs)
-	if result.Error != nil {
-		return nil, fmt.Errorf("查询主NFT失败: %v", result.Error)
-	}
-
-	// 查询用户拥有的子NFT
-	var childNFTs []models.NFT
-	childResult := models.DB.Where("owner = ? AND is_child_nft = ?", walletAddress, true).Find(&childNFTs)
-	if childResult.Error != nil {
-		return nil, fmt.Errorf("查询子NFT失败: %v", childResult.Error)
+// GetMyNFTs 获取当前用户的NFT (包含子NFT)，mode为一致性模式
+func (s *NFTService) GetMyNFTs(ctx context.Context, walletAddress, mode string) ([]models.NFTResponseWithMetadata, error) {
+	allNFTs, err := s.ownedNFTs(ctx, walletAddress, mode)
+	if err != nil {
+		return nil, err
 	}
 
-	// 合并主NFT和子NFT
-	allNFTs := append(mainNFTs, childNFTs...)
-
 	// 构建响应
 	var nftResponses []models.NFTResponseWithMetadata
-	for _, nft := range allNFTs {
-		metadata, _ := s.FetchNFTMetadata(nft.URI)
-
+	metadata, metadataErrors := s.resolveMetadata(ctx, allNFTs)
+	for i, nft := range allNFTs {
 		response := models.NFTResponseWithMetadata{
 			NFTResponse: models.NFTResponse{
+				ChainID:       nft.ChainID,
+				Contract:      nft.Contract,
 				TokenID:       nft.TokenID,
 				Owner:         nft.Owner,
 				URI:           nft.URI,
@@ -118,7 +159,8 @@ func (s *NFTService) GetMyNFTs(walletAddress string) ([]models.NFTResponseWithMe
 				ParentTokenID: nft.ParentTokenID,
 				ContractType:  nft.ContractType,
 			},
-			Metadata: metadata,
+			Metadata:      metadata[i],
+			MetadataError: metadataErrors[i],
 		}
 
 		nftResponses = append(nftResponses, response)
@@ -137,10 +179,12 @@ func (s *NFTService) GetNFTInfo(tokenIDStr string) (*models.NFTResponse, error)
 
 	// 首先尝试从数据库获取NFT信息
 	var nft models.NFT
-	result := models.DB.Where("token_id = ?", tokenIDStr).First(&nft)
+	result := models.DB.Scopes(s.Client.NFTContract("main").Scope).Where("token_id = ?", tokenIDStr).First(&nft)
 	if result.Error == nil {
 		// 从数据库找到了NFT
 		return &models.NFTResponse{
+			ChainID:     nft.ChainID,
+			Contract:    nft.Contract,
 			TokenID:     nft.TokenID,
 			Owner:       nft.Owner,
 			URI:         nft.URI,
@@ -156,15 +200,20 @@ func (s *NFTService) GetNFTInfo(tokenIDStr string) (*models.NFTResponse, error)
 
 	// 存储到数据库
 	nft = models.NFT{
-		TokenID:     tokenID.String(),
-		Owner:       owner,
-		URI:         uri,
-		TotalSupply: totalSupply,
+		ChainID:      s.Client.Config.ChainID,
+		Contract:     s.Client.ContractAddress("main"),
+		TokenID:      tokenID.String(),
+		Owner:        owner,
+		URI:          uri,
+		TotalSupply:  totalSupply,
+		ContractType: "main",
 	}
 	models.DB.Create(&nft)
 
 	// 构造响应
 	response := &models.NFTResponse{
+		ChainID:     nft.ChainID,
+		Contract:    nft.Contract,
 		TokenID:     tokenID.String(),
 		Owner:       owner,
 		URI:         uri,
@@ -174,55 +223,48 @@ func (s *NFTService) GetNFTInfo(tokenIDStr string) (*models.NFTResponse, error)
 	return response, nil
 }
 
-// MintNFT 铸造NFT
-func (s *NFTService) MintNFT(walletAddress, uri string) (*models.TransactionResponse, error) {
-	// 铸造NFT
-	txHash, err := s.Client.MintNFT(walletAddress, uri)
-	if err != nil {
-		return nil, fmt.Errorf("铸造NFT失败: %v", err)
+// MintNFT 代用户提交其签名的MintRequest铸造NFT；交易记入账本，确认后由账本根据Transfer事件写入真实的tokenID。
+// 签名过期或nonce失效时返回ErrMetaTxExpired/ErrMetaTxNonce，不发送交易
+func (s *NFTService) MintNFT(walletAddress, uri string, nonce, deadline *big.Int, signature, payloadHash string) (*models.TransactionResponse, error) {
+	to := common.HexToAddress(walletAddress)
+	if err := s.Client.CheckMetaTx(to, nonce, deadline); err != nil {
+		return nil, err
 	}
 
-	// 获取当前的总供应量作为tokenID
-	totalSupply, err := s.Client.GetCurrentTotalSupply()
+	// 铸造NFT，合约验证签名后铸造给签名者
+	txHash, err := s.Client.MintNFTWithSignature(to, uri, deadline, signature)
 	if err != nil {
-		// 即使获取失败，也继续处理，不影响铸造结果
-		return &models.TransactionResponse{
-			TransactionHash: txHash,
-			Message:         "NFT铸造交易已提交，但无法保存到数据库",
-		}, nil
-	}
-
-	// 新铸造的NFT的tokenID应该是当前总供应量减1
-	tokenID := new(big.Int).Sub(totalSupply, big.NewInt(1))
-
-	// 立即保存到数据库
-	nft := models.NFT{
-		TokenID:      tokenID.String(),
-		Owner:        walletAddress,
-		URI:          uri,
-		IsChildNFT:   false,
-		ContractType: "main",
+		return nil, fmt.Errorf("铸造NFT失败: %v", err)
 	}
-	models.DB.Create(&nft)
 
-	// 构造响应
+	// 等待交易最终状态，失败时同时返回交易哈希和状态
+	record, err := s.Ledger.Submit(blockchain.TxKindMint, walletAddress, payloadHash, txHash, map[string]string{"uri": uri})
 	response := &models.TransactionResponse{
 		TransactionHash: txHash,
-		Message:         "NFT铸造交易已提交",
+		Status:          record.Status,
+		TokenID:         record.TokenID,
+	}
+	if err != nil {
+		response.Message = "NFT铸造交易未成功"
+		return response, fmt.Errorf("铸造NFT交易未成功: %v", err)
+	}
+	if record.Status == models.TxStatusPending {
+		response.Message = "NFT铸造交易已提交，等待打包，可通过 /api/tx/" + txHash + " 查询结果"
+	} else {
+		response.Message = "NFT铸造交易已确认"
 	}
-
 	return response, nil
 }
 
 // UpdateMetadata 更新NFT元数据
-func (s *NFTService) UpdateMetadata(walletAddress, tokenID, contractType, newURI string) (*models.TransactionResponse, error) {
+func (s *NFTService) UpdateMetadata(walletAddress, tokenID, contractType, newURI, payloadHash string) (*models.TransactionResponse, error) {
 	// 转换tokenID为big.Int
 	tokenIDBigInt, ok := new(big.Int).SetString(tokenID, 10)
 	if !ok {
 		return nil, fmt.Errorf("无效的token ID")
 	}
 
-	var txHash string
+	var txHash, status string
 
 	// 根据合约类型更新元数据
 	if contractType == "main" {
@@ -242,10 +284,12 @@ func (s *NFTService) UpdateMetadata(walletAddress, tokenID, contractType, newURI
 		if err != nil {
 			return nil, fmt.Errorf("更新主NFT元数据失败: %v", err)
 		}
-
-		// 更新数据库记录
-		models.DB.Model(&models.NFT{}).Where("token_id = ? AND contract_type = ?", tokenID, "main").
-			Update("uri", newURI)
+		// 交易确认后由账本更新数据库记录
+		record, err := s.Ledger.Submit(blockchain.TxKindUpdateMainURI, walletAddress, payloadHash, txHash, map[string]string{"tokenId": tokenID, "uri": newURI})
+		if err != nil {
+			return nil, fmt.Errorf("更新主NFT元数据交易 %s 未成功: %v", txHash, err)
+		}
+		status = record.Status
 
 	} else if contractType == "child" {
 		// 验证子NFT所有权
@@ -263,10 +307,12 @@ func (s *NFTService) UpdateMetadata(walletAddress, tokenID, contractType, newURI
 		if err != nil {
 			return nil, fmt.Errorf("更新子NFT元数据失败: %v", err)
 		}
-
-		// 更新数据库记录
-		models.DB.Model(&models.NFT{}).Where("token_id = ? AND contract_type = ?", tokenID, "child").
-			Update("uri", newURI)
+		// 交易确认后由账本更新数据库记录
+		record, err := s.Ledger.Submit(blockchain.TxKindUpdateChildURI, walletAddress, payloadHash, txHash, map[string]string{"tokenId": tokenID, "uri": newURI})
+		if err != nil {
+			return nil, fmt.Errorf("更新子NFT元数据交易 %s 未成功: %v", txHash, err)
+		}
+		status = record.Status
 
 	} else {
 		return nil, fmt.Errorf("无效的合约类型")
@@ -275,6 +321,7 @@ func (s *NFTService) UpdateMetadata(walletAddress, tokenID, contractType, newURI
 	// 构造响应
 	response := &models.TransactionResponse{
 		TransactionHash: txHash,
+		Status:          status,
 		Message:         "元数据更新交易已提交",
 	}
 
@@ -282,7 +329,7 @@ func (s *NFTService) UpdateMetadata(walletAddress, tokenID, contractType, newURI
 }
 
 // UpdateNFTURI 更新NFT元数据URI
-func (s *NFTService) UpdateNFTURI(walletAddress, tokenID, newURI string) (*models.TransactionResponse, error) {
+func (s *NFTService) UpdateNFTURI(walletAddress, tokenID, newURI, payloadHash string) (*models.TransactionResponse, error) {
 	// 转换tokenID为big.Int
 	tokenIDBigInt, ok := new(big.Int).SetString(tokenID, 10)
 	if !ok {
@@ -305,173 +352,72 @@ func (s *NFTService) UpdateNFTURI(walletAddress, tokenID, newURI string) (*model
 	if err != nil {
 		return nil, fmt.Errorf("更新NFT URI失败: %v", err)
 	}
-
-	// 更新数据库记录
-	result := models.DB.Model(&models.NFT{}).Where("token_id = ?", tokenID).Update("uri", newURI)
-	if result.Error != nil {
-		// 即使数据库更新失败，区块链操作已成功，也要返回成功响应
-		fmt.Printf("数据库更新失败，但区块链操作成功: %v\n", result.Error)
+	// 交易确认后由账本更新数据库记录
+	record, err := s.Ledger.Submit(blockchain.TxKindUpdateMainURI, walletAddress, payloadHash, txHash, map[string]string{"tokenId": tokenID, "uri": newURI})
+	if err != nil {
+		return nil, fmt.Errorf("更新NFT URI交易 %s 未成功: %v", txHash, err)
 	}
 
 	// 构造响应
 	response := &models.TransactionResponse{
 		TransactionHash: txHash,
+		Status:          record.Status,
 		Message:         "NFT URI更新交易已提交",
 	}
 
 	return response, nil
 }
 
-// FetchNFTMetadata 获取NFT元数据
-func (s *NFTService) FetchNFTMetadata(uri string) (*models.NFTMetadata, error) {
-	fmt.Printf("获取元数据，URI: %s\n", uri)
-
-	// 处理空URI
-	if uri == "" {
-		fmt.Printf("警告: URI为空，返回默认元数据\n")
-		return &models.NFTMetadata{
-			Name:        "未命名NFT",
-			Description: "This NFT has no metadata",
-			Image:       "",
-			Attributes: []models.Attribute{
-				{TraitType: "Type", Value: "Unknown"},
-				{TraitType: "Rarity", Value: "Unknown"},
-			},
-		}, nil
-	}
-
-	// 检查URI是否是JSON字符串
-	if strings.HasPrefix(strings.TrimSpace(uri), "{") && strings.HasSuffix(strings.TrimSpace(uri), "}") {
-		fmt.Printf("URI是JSON字符串，尝试直接解析\n")
-		var metadata models.NFTMetadata
-		err := json.Unmarshal([]byte(uri), &metadata)
+// FetchNFTMetadata 获取NFT元数据；本平台创建的IPFS元数据直接从数据库读取，其余由元数据解析器下载并校验
+func (s *NFTService) FetchNFTMetadata(ctx context.Context, uri string) (*models.NFTMetadata, error) {
+	if ipfsHash, ok := strings.CutPrefix(strings.TrimSpace(uri), "ipfs://"); ok && ipfsHash != "" {
+		var record models.NFTMetadataDB
+		err := models.DB.Where(&models.NFTMetadataDB{IPFSHash: ipfsHash}).First(&record).Error
 		if err == nil {
-			fmt.Printf("成功从URI中解析元数据: %s\n", metadata.Name)
-			return &metadata, nil
+			return platformMetadata(record), nil
+		}
+		// 不是本平台创建的元数据时从IPFS网关获取；查询出错也退回网关，但记录日志
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("查询元数据 %s 失败，改从IPFS网关获取: %v", ipfsHash, err)
 		}
-		fmt.Printf("解析URI中的JSON失败: %v\n", err)
-	}
-
-	// 检查URI是否为IPFS链接
-	if strings.HasPrefix(uri, "ipfs://") {
-		return s.handleIPFSMetadata(uri)
-	}
-
-	// 如果是HTTP链接或相对路径，创建基于URI的元数据
-	if strings.HasPrefix(uri, "http") || strings.HasPrefix(uri, "/") {
-		return s.handleHTTPMetadata(uri)
 	}
-
-	// 默认元数据 - 处理其他类型的URI
-	return s.handleDefaultMetadata(uri)
+	return s.Client.FetchMetadata(ctx, uri)
 }
 
-// handleIPFSMetadata 处理IPFS类型的元数据
-func (s *NFTService) handleIPFSMetadata(uri string) (*models.NFTMetadata, error) {
-	// 从URI中提取IPFS哈希
-	ipfsHash := uri[len("ipfs://"):]
-	fmt.Printf("提取IPFS哈希: %s，长度: %d\n", ipfsHash, len(ipfsHash))
-
-	// 尝试从数据库中查找该IPFS哈希对应的元数据
-	var metadata models.NFTMetadataDB
-	result := models.DB.Where("ip_fs_hash = ?", ipfsHash).First(&metadata)
-	if result.Error == nil {
-		fmt.Printf("从数据库找到元数据: %s\n", metadata.Name)
-		// 从数据库中找到了元数据
-		return &models.NFTMetadata{
-			Name:        metadata.Name,
-			Description: metadata.Description,
-			Image:       metadata.Image,
-			Attributes: []models.Attribute{
-				{TraitType: "Type", Value: "Main NFT"},
-				{TraitType: "Rarity", Value: "Common"},
-				{TraitType: "Policy", Value: metadata.Policy},
-				{TraitType: "Encrypted_ciphertext", Value: metadata.Ciphertext},
-			},
-		}, nil
-	}
-
-	// 如果数据库中没有找到，创建一个基本的元数据
-	fmt.Printf("数据库中未找到元数据，创建基本元数据\n")
-
-	// 使用完整的IPFS哈希，但在显示名称时可以截取一部分
-	displayHash := ipfsHash
-	if len(ipfsHash) > 8 {
-		displayHash = ipfsHash[:8] + "..."
-	}
-
-	// 选择一个可靠的IPFS网关作为图像URL
-	imageUrl := uri // 默认使用原始URI
-
-	// IPFS网关列表
-	ipfsGateways := []string{
-		"https://dweb.link/ipfs/",
-		"https://cloudflare-ipfs.com/ipfs/",
-		"https://gateway.pinata.cloud/ipfs/",
-		"https://ipfs.io/ipfs/",
-		"https://cf-ipfs.com/ipfs/",
-		"https://ipfs.fleek.co/ipfs/",
-	}
-
-	// 使用第一个网关作为图像URL
-	if len(ipfsGateways) > 0 {
-		imageUrl = ipfsGateways[0] + ipfsHash
-	}
-
+// platformMetadata 按创建时上传到IPFS的格式还原本平台的元数据
+func platformMetadata(record models.NFTMetadataDB) *models.NFTMetadata {
 	return &models.NFTMetadata{
-		Name:        "NFT #" + displayHash,
-		Description: "This is an NFT created on our platform",
-		Image:       imageUrl, // 使用IPFS网关URL作为图像链接
+		Name:        record.Name,
+		Description: record.Description,
+		Image:       record.Image,
+		ExternalURL: record.ExternalURL,
 		Attributes: []models.Attribute{
-			{TraitType: "Type", Value: "Main NFT"},
-			{TraitType: "Rarity", Value: "Common"},
+			{TraitType: "Policy", Value: record.Policy},
+			{TraitType: "Encrypted_ciphertext", Value: record.Ciphertext},
 		},
-	}, nil
-}
-
-// handleHTTPMetadata 处理HTTP类型的元数据
-func (s *NFTService) handleHTTPMetadata(uri string) (*models.NFTMetadata, error) {
-	// 从URI中提取最后一部分作为ID
-	parts := strings.Split(uri, "/")
-	idPart := ""
-	if len(parts) > 0 {
-		idPart = parts[len(parts)-1]
 	}
-	fmt.Printf("从URI提取ID部分: %s\n", idPart)
-
-	name := "NFT"
-	if idPart != "" {
-		name = "NFT #" + idPart
-	}
-
-	return &models.NFTMetadata{
-		Name:        name,
-		Description: "This is an NFT created on our platform",
-		Image:       uri,
-		Attributes: []models.Attribute{
-			{TraitType: "Type", Value: "Main NFT"},
-			{TraitType: "Rarity", Value: "Common"},
-		},
-	}, nil
 }
 
-// handleDefaultMetadata 处理默认类型的元数据
-func (s *NFTService) handleDefaultMetadata(uri string) (*models.NFTMetadata, error) {
-	fmt.Printf("使用默认元数据处理URI: %s\n", uri)
-
-	// 不限制URI长度，但在显示时可以截取部分
-	displayUri := uri
-	if len(uri) > 10 {
-		displayUri = uri[:5] + "..." + uri[len(uri)-5:]
-	}
-
-	return &models.NFTMetadata{
-		Name:        "NFT #" + displayUri,
-		Description: "This is an NFT created on our platform",
-		Image:       uri,
-		Attributes: []models.Attribute{
-			{TraitType: "Type", Value: "Main NFT"},
-			{TraitType: "Rarity", Value: "Common"},
-		},
-	}, nil
+// resolveMetadata 并发解析一组NFT的元数据，返回与nfts顺序一致的元数据和失败原因
+func (s *NFTService) resolveMetadata(ctx context.Context, nfts []models.NFT) ([]*models.NFTMetadata, []string) {
+	metadata := make([]*models.NFTMetadata, len(nfts))
+	failures := make([]string, len(nfts))
+	slots := make(chan struct{}, metadataConcurrency)
+	var wg sync.WaitGroup
+	for i, nft := range nfts {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, uri string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			result, err := s.FetchNFTMetadata(ctx, uri)
+			if err != nil {
+				failures[i] = err.Error()
+				return
+			}
+			metadata[i] = result
+		}(i, nft.URI)
+	}
+	wg.Wait()
+	return metadata, failures
 } 
\ No newline at end of file
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ABE/nft/nft-go-backend/internal/blockchain"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+func TestFetchNFTMetadataReadsPlatformRecords(t *testing.T) {
+	db := newTestDB(t, &models.NFTMetadataDB{})
+	previous := models.DB
+	models.DB = db
+	t.Cleanup(func() { models.DB = previous })
+
+	record := models.NFTMetadataDB{Name: "病历摘要", Description: "加密的病历", Image: "ipfs://image",
+		Policy: "doctor", Ciphertext: "ciphertext", IPFSHash: "QmPlatform"}
+	if err := db.Create(&record).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 没有配置元数据解析器，只有数据库命中时才能成功
+	service := &NFTService{Client: &blockchain.EthClient{}}
+
+	metadata, err := service.FetchNFTMetadata(context.Background(), "ipfs://QmPlatform")
+	if err != nil {
+		t.Fatalf("应从数据库读取本平台的元数据: %v", err)
+	}
+	if metadata.Name != "病历摘要" || len(metadata.Attributes) != 2 || metadata.Attributes[0].Value != "doctor" {
+		t.Fatalf("元数据不正确: %+v", metadata)
+	}
+
+	for _, uri := range []string{"ipfs://QmOther", "ipfs://"} {
+		if _, err := service.FetchNFTMetadata(context.Background(), uri); err == nil {
+			t.Errorf("%s 不是本平台的元数据，应交给解析器处理", uri)
+		}
+	}
+}
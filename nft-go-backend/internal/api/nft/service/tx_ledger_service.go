@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ABE/nft/nft-go-backend/internal/blockchain"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// ledgerResolveBatch 后台任务每轮处理的待确认记录数
+const ledgerResolveBatch = 100
+
+// ErrChainTransactionNotFound 账本中没有该交易
+var ErrChainTransactionNotFound = errors.New("交易记录不存在")
+
+// TxLedgerService 一个部署的链上操作账本：记录接口发起的交易，交易有结果后补充回执和事件，
+// 并执行后续数据库写入（NFT记录、URI、子NFT申请状态）
+type TxLedgerService struct {
+	DB        *gorm.DB
+	Client    *blockchain.EthClient
+	ChildMint *ChildMintService // 用于确认审批产生的子NFT申请，为nil时跳过
+}
+
+// NewTxLedgerService 创建链上操作账本服务
+func NewTxLedgerService(db *gorm.DB, client *blockchain.EthClient) *TxLedgerService {
+	return &TxLedgerService{DB: db, Client: client}
+}
+
+// Record 把已发送的交易记入账本
+func (s *TxLedgerService) Record(operation, wallet, payloadHash, txHash string, params map[string]string) (*models.ChainTransaction, error) {
+	record := &models.ChainTransaction{
+		Collection:  s.Client.Collection(),
+		Operation:   operation,
+		Wallet:      wallet,
+		PayloadHash: payloadHash,
+		TxHash:      txHash,
+		Params:      params,
+		Status:      models.TxStatusPending,
+	}
+	if err := s.DB.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("记录交易失败: %v", err)
+	}
+	return record, nil
+}
+
+// Submit 记录交易后在TX_WAIT_TIMEOUT内等待最终状态，有结果时立即执行后续写入。
+// 返回的记录状态为pending表示仍在等待，由后台任务继续确认；交易回滚、被替换或广播失败时同时返回错误
+func (s *TxLedgerService) Submit(operation, wallet, payloadHash, txHash string, params map[string]string) (*models.ChainTransaction, error) {
+	record, err := s.Record(operation, wallet, payloadHash, txHash, params)
+	status, waitErr := s.Client.AwaitTransaction(txHash)
+	if err != nil {
+		// 交易已发送，记账失败不影响返回交易状态
+		log.Printf("%v", err)
+		return &models.ChainTransaction{Collection: s.Client.Collection(), Operation: operation, Wallet: wallet, TxHash: txHash, Status: status}, waitErr
+	}
+	if status == "" || status == models.TxStatusPending {
+		return record, waitErr
+	}
+	if err := s.Resolve(context.Background(), record); err != nil {
+		log.Printf("确认交易 %s 失败: %v", txHash, err)
+	}
+	return record, waitErr
+}
+
+// Get 按交易哈希查询本部署的账本记录，仍在等待的交易先尝试确认一次
+func (s *TxLedgerService) Get(ctx context.Context, txHash string) (*models.ChainTransaction, error) {
+	var record models.ChainTransaction
+	err := s.DB.Where("collection = ? AND tx_hash = ?", s.Client.Collection(), common.HexToHash(txHash).Hex()).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrChainTransactionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询交易记录失败: %v", err)
+	}
+	if record.Status == models.TxStatusPending {
+		if err := s.Resolve(ctx, &record); err != nil {
+			log.Printf("确认交易 %s 失败: %v", record.TxHash, err)
+		}
+	}
+	return &record, nil
+}
+
+// Resolve 查询交易的最终状态，有结果时写入回执数据和事件并执行后续写入；仍在等待时不做修改
+func (s *TxLedgerService) Resolve(ctx context.Context, record *models.ChainTransaction) error {
+	status, receipt, reason, err := s.finalStatus(ctx, common.HexToHash(record.TxHash))
+	if err != nil || status == models.TxStatusPending {
+		return err
+	}
+
+	now := time.Now()
+	record.Status = status
+	record.Error = reason
+	record.ResolvedAt = &now
+	var childMint *blockchain.ChildMintResult
+	if receipt != nil {
+		record.MinedHash = receipt.TxHash.Hex()
+		record.BlockNumber = receipt.BlockNumber.Uint64()
+		record.BlockHash = receipt.BlockHash.Hex()
+		record.GasUsed = receipt.GasUsed
+		if receipt.EffectiveGasPrice != nil {
+			record.EffectiveGasPrice = receipt.EffectiveGasPrice.String()
+		}
+		events, err := blockchain.DecodeReceiptEvents(receipt, s.mainAddress(), s.childAddress())
+		if err != nil {
+			return err
+		}
+		record.Events = events
+		if status == models.TxStatusMined {
+			if childMint, err = s.tokenFromReceipt(record, receipt); err != nil {
+				record.Error = err.Error()
+			}
+		}
+	}
+
+	// 只有把记录从pending改为最终状态的一方执行后续写入
+	resolved := false
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(record).Where("status = ?", models.TxStatusPending).
+			Select("status", "error", "resolved_at", "mined_hash", "block_number", "block_hash", "gas_used", "effective_gas_price", "events", "token_id").
+			Updates(record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		resolved = true
+		if status != models.TxStatusMined {
+			return nil
+		}
+		return s.applyFollowUp(tx, record, childMint)
+	})
+	if err != nil {
+		return fmt.Errorf("更新交易记录失败: %v", err)
+	}
+	if resolved {
+		s.confirmChildRequest(ctx, record)
+	}
+	return nil
+}
+
+// finalStatus 返回交易的最终状态；交易管理器发送的交易以其记录为准，其他交易直接查询回执
+func (s *TxLedgerService) finalStatus(ctx context.Context, txHash common.Hash) (string, *types.Receipt, string, error) {
+	managed, err := s.Client.Tx.Lookup(txHash)
+	if errors.Is(err, blockchain.ErrTxNotManaged) {
+		receipt, err := s.Client.Client.TransactionReceipt(ctx, txHash)
+		if errors.Is(err, ethereum.NotFound) {
+			return models.TxStatusPending, nil, "", nil
+		}
+		if err != nil {
+			return "", nil, "", fmt.Errorf("查询交易回执失败: %v", err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return models.TxStatusReverted, receipt, blockchain.ErrTransactionReverted.Error(), nil
+		}
+		return models.TxStatusMined, receipt, "", nil
+	}
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	switch managed.Status {
+	case models.TxStatusPending:
+		return managed.Status, nil, "", nil
+	case models.TxStatusReplaced:
+		return managed.Status, nil, blockchain.ErrTransactionReplaced.Error(), nil
+	case models.TxStatusFailed:
+		return managed.Status, nil, managed.Error, nil
+	}
+	receipt, err := s.Client.Client.TransactionReceipt(ctx, common.HexToHash(managed.MinedHash))
+	if err != nil {
+		return "", nil, "", fmt.Errorf("查询交易回执失败: %v", err)
+	}
+	reason := ""
+	if managed.Status == models.TxStatusReverted {
+		reason = blockchain.ErrTransactionReverted.Error()
+	}
+	return managed.Status, receipt, reason, nil
+}
+
+// tokenFromReceipt 从回执中取出铸造或创建的tokenID
+func (s *TxLedgerService) tokenFromReceipt(record *models.ChainTransaction, receipt *types.Receipt) (*blockchain.ChildMintResult, error) {
+	switch record.Operation {
+	case blockchain.TxKindMint:
+		for _, event := range record.Events {
+			if event.Contract == "main" && event.Name == "Transfer" && common.HexToAddress(event.Args["from"]) == (common.Address{}) {
+				record.TokenID = event.Args["tokenId"]
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("交易回执中没有NFT的铸造事件")
+	case blockchain.TxKindCreateChild:
+		result, err := blockchain.ParseChildMintReceipt(receipt, s.mainAddress(), s.childAddress())
+		if err != nil {
+			return nil, err
+		}
+		record.TokenID = result.ChildTokenID.String()
+		return result, nil
+	}
+	return nil, nil
+}
+
+// applyFollowUp 交易成功后写入NFT记录或URI；索引器可能已先写入，冲突时更新
+func (s *TxLedgerService) applyFollowUp(tx *gorm.DB, record *models.ChainTransaction, childMint *blockchain.ChildMintResult) error {
+	upsert := clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"owner", "uri", "is_child_nft", "parent_token_id"})}
+	switch record.Operation {
+	case blockchain.TxKindMint:
+		if record.TokenID == "" {
+			return nil
+		}
+		var owner string
+		for _, event := range record.Events {
+			if event.Contract == "main" && event.Name == "Transfer" && event.Args["tokenId"] == record.TokenID {
+				owner = event.Args["to"]
+			}
+		}
+		return tx.Clauses(upsert).Create(&models.NFT{
+			ChainID:      s.Client.Config.ChainID,
+			Contract:     s.Client.ContractAddress("main"),
+			TokenID:      record.TokenID,
+			Owner:        owner,
+			URI:          record.Params["uri"],
+			ContractType: "main",
+		}).Error
+	case blockchain.TxKindCreateChild:
+		if childMint == nil {
+			return nil
+		}
+		return tx.Clauses(upsert).Create(&models.NFT{
+			ChainID:       s.Client.Config.ChainID,
+			Contract:      s.Client.ContractAddress("child"),
+			TokenID:       record.TokenID,
+			Owner:         childMint.Receiver.Hex(),
+			URI:           record.Params["uri"],
+			IsChildNFT:    true,
+			ParentTokenID: childMint.ParentTokenID.String(),
+			ContractType:  "child",
+		}).Error
+	case blockchain.TxKindUpdateMainURI, blockchain.TxKindUpdateChildURI:
+		contractType := "main"
+		if record.Operation == blockchain.TxKindUpdateChildURI {
+			contractType = "child"
+		}
+		return tx.Model(&models.NFT{}).Scopes(s.Client.NFTContract(contractType).Scope).Where("token_id = ?", record.Params["tokenId"]).
+			Update("uri", record.Params["uri"]).Error
+	}
+	return nil
+}
+
+// confirmChildRequest 审批产生的交易有结果后，确认仍处于minting状态的子NFT申请
+func (s *TxLedgerService) confirmChildRequest(ctx context.Context, record *models.ChainTransaction) {
+	if s.ChildMint == nil || record.Params["requestId"] == "" {
+		return
+	}
+	requestID, err := strconv.ParseUint(record.Params["requestId"], 10, 64)
+	if err != nil {
+		return
+	}
+	var request models.ChildNFTRequest
+	if err := s.DB.First(&request, requestID).Error; err != nil {
+		log.Printf("查询子NFT申请 %d 失败: %v", requestID, err)
+		return
+	}
+	if request.Status != models.ChildRequestStatusMinting || request.TxHash != record.TxHash {
+		return
+	}
+	if _, err := s.ChildMint.Confirm(ctx, &request); err != nil && !errors.Is(err, ErrChildMintPending) {
+		log.Printf("确认子NFT申请 %d 失败: %v", requestID, err)
+	}
+}
+
+// ResolvePending 确认本部署账本中仍在等待的交易
+func (s *TxLedgerService) ResolvePending(ctx context.Context) error {
+	var pending []models.ChainTransaction
+	if err := s.DB.Where("collection = ? AND status = ?", s.Client.Collection(), models.TxStatusPending).Order("id").Limit(ledgerResolveBatch).Find(&pending).Error; err != nil {
+		return fmt.Errorf("查询待确认交易失败: %v", err)
+	}
+	for i := range pending {
+		if err := s.Resolve(ctx, &pending[i]); err != nil {
+			log.Printf("确认交易 %s 失败: %v", pending[i].TxHash, err)
+		}
+	}
+	return nil
+}
+
+// Run 定期确认账本中仍在等待的交易，直到ctx取消
+func (s *TxLedgerService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ResolvePending(ctx); err != nil {
+				log.Printf("%v", err)
+			}
+		}
+	}
+}
+
+// mainAddress 主NFT合约地址
+func (s *TxLedgerService) mainAddress() common.Address {
+	return common.HexToAddress(s.Client.Config.MainNFTAddress)
+}
+
+// childAddress 子NFT合约地址
+func (s *TxLedgerService) childAddress() common.Address {
+	return common.HexToAddress(s.Client.Config.ChildNFTAddress)
+}
@@ -0,0 +1,435 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	auth "github.com/ABE/nft/nft-go-backend/internal/api/auth/service"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+)
+
+// Webhook投递状态
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+// Webhook相关限制
+const (
+	maxWebhooksPerOwner   = 10
+	minWebhookSecretLen   = 16
+	webhookDeliveryBatch  = 100
+	webhookMaxBackoff     = 6 * time.Hour
+	webhookResponseMaxLen = 512 // 丢弃响应体时最多读取的字节数，响应内容不会保存
+)
+
+// ErrWebhookNotFound Webhook或死信不存在，或不属于当前钱包
+var ErrWebhookNotFound = errors.New("Webhook不存在")
+
+// Webhook操作签名的操作名称，挑战通过 /api/auth/challenge 申请，每个挑战只能使用一次。
+// 查询类操作没有参数，由查询参数address、nonce、signature提交签名
+const (
+	RegisterWebhookAction        = "RegisterWebhook"
+	DeleteWebhookAction          = "DeleteWebhook"
+	RedeliverDeadLetterAction    = "RedeliverWebhookDeadLetter"
+	ListWebhooksAction           = "ListWebhooks"
+	ListWebhookDeadLettersAction = "ListWebhookDeadLetters"
+)
+
+// RegisterWebhookParams 登记Webhook签名绑定的参数：接收地址、事件类型，以及密钥和描述的SHA-256（未提供时为空），
+// 截获的签名不能用来登记其他地址或替换密钥
+func RegisterWebhookParams(req *models.RegisterWebhookRequest) map[string]string {
+	params := map[string]string{
+		"url":         req.URL,
+		"events":      strings.Join(req.Events, ","),
+		"secret":      "",
+		"description": "",
+	}
+	if req.Secret != "" {
+		params["secret"] = auth.HashParam([]byte(req.Secret))
+	}
+	if req.Description != "" {
+		params["description"] = auth.HashParam([]byte(req.Description))
+	}
+	return params
+}
+
+// WebhookActionParams 删除Webhook或重新投递死信签名绑定的参数
+func WebhookActionParams(id uint) map[string]string {
+	return map[string]string{"id": strconv.FormatUint(uint64(id), 10)}
+}
+
+// WebhookService 管理Webhook登记，并以HMAC签名、指数退避重试的方式投递事件，重试用尽后转入死信表
+type WebhookService struct {
+	DB          *gorm.DB
+	Client      *http.Client  // 只连接公网地址且不跟随重定向
+	MaxAttempts int           // 最多尝试次数，用尽后转入死信
+	RetryBase   time.Duration // 第一次重试的等待时间，之后每次翻倍
+	AllowHTTP   bool          // 是否允许登记http地址，仅开发模式开启
+
+	wake chan struct{}
+}
+
+// NewWebhookService 创建Webhook服务
+func NewWebhookService(db *gorm.DB, timeout time.Duration, maxAttempts int, retryBase time.Duration) *WebhookService {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &WebhookService{
+		DB:          db,
+		Client:      util.NewPublicHTTPClient(timeout, 0),
+		MaxAttempts: maxAttempts,
+		RetryBase:   retryBase,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Register 为钱包登记Webhook；未提供密钥时生成一个，返回值中的密钥只在此时可见
+func (s *WebhookService) Register(owner string, req *models.RegisterWebhookRequest) (*models.WebhookEndpoint, string, error) {
+	if err := s.checkURL(req.URL); err != nil {
+		return nil, "", err
+	}
+	for _, eventType := range req.Events {
+		if !containsString(ChildRequestEventTypes, eventType) {
+			return nil, "", fmt.Errorf("未知的事件类型: %s", eventType)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, "", fmt.Errorf("生成Webhook密钥失败: %v", err)
+		}
+		secret = hex.EncodeToString(raw)
+	} else if len(secret) < minWebhookSecretLen {
+		return nil, "", fmt.Errorf("Webhook密钥至少 %d 个字符", minWebhookSecretLen)
+	}
+
+	owner = strings.ToLower(owner)
+	var count int64
+	if err := s.DB.Model(&models.WebhookEndpoint{}).Where("owner_address = ?", owner).Count(&count).Error; err != nil {
+		return nil, "", fmt.Errorf("查询Webhook失败: %v", err)
+	}
+	if count >= maxWebhooksPerOwner {
+		return nil, "", fmt.Errorf("每个钱包最多登记 %d 个Webhook", maxWebhooksPerOwner)
+	}
+
+	endpoint := &models.WebhookEndpoint{
+		OwnerAddress: owner,
+		URL:          req.URL,
+		Secret:       secret,
+		Events:       req.Events,
+		Active:       true,
+		Description:  req.Description,
+	}
+	if endpoint.Events == nil {
+		endpoint.Events = []string{}
+	}
+	if err := s.DB.Create(endpoint).Error; err != nil {
+		return nil, "", fmt.Errorf("保存Webhook失败: %v", err)
+	}
+	return endpoint, secret, nil
+}
+
+// checkURL 检查Webhook地址：开发模式外必须是https；主机为IP时必须是公网地址。
+// 域名解析结果在每次投递拨号时检查，解析到内网地址的请求会被拒绝
+func (s *WebhookService) checkURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("Webhook地址必须是有效的http(s) URL")
+	}
+	if parsed.Scheme == "http" && !s.AllowHTTP {
+		return fmt.Errorf("Webhook地址必须使用https")
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("Webhook地址不能包含用户信息")
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("Webhook地址%v", util.ErrForbiddenAddress)
+	}
+	if ip := net.ParseIP(host); ip != nil && !util.IsPublicIP(ip) {
+		return fmt.Errorf("Webhook地址%v", util.ErrForbiddenAddress)
+	}
+	return nil
+}
+
+// List 列出钱包登记的Webhook
+func (s *WebhookService) List(owner string) ([]models.WebhookEndpoint, error) {
+	endpoints := []models.WebhookEndpoint{}
+	err := s.DB.Where("owner_address = ?", strings.ToLower(owner)).Order("id ASC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+// Delete 删除钱包登记的Webhook，尚未投递的任务随之失效
+func (s *WebhookService) Delete(owner string, id uint) error {
+	result := s.DB.Where("id = ? AND owner_address = ?", id, strings.ToLower(owner)).Delete(&models.WebhookEndpoint{})
+	if result.Error != nil {
+		return fmt.Errorf("删除Webhook失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// ListDeadLetters 列出钱包的死信
+func (s *WebhookService) ListDeadLetters(owner string) ([]models.WebhookDeadLetter, error) {
+	letters := []models.WebhookDeadLetter{}
+	err := s.DB.Where("owner_address = ?", strings.ToLower(owner)).Order("id DESC").Find(&letters).Error
+	return letters, err
+}
+
+// Redeliver 把死信重新加入投递队列，重试次数从零开始计算
+func (s *WebhookService) Redeliver(owner string, deadLetterID uint) (*models.WebhookDelivery, error) {
+	var letter models.WebhookDeadLetter
+	if err := s.DB.Where("id = ? AND owner_address = ?", deadLetterID, strings.ToLower(owner)).First(&letter).Error; err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	var endpoint models.WebhookEndpoint
+	if err := s.DB.First(&endpoint, letter.EndpointID).Error; err != nil {
+		return nil, fmt.Errorf("Webhook已删除，无法重新投递")
+	}
+
+	delivery := &models.WebhookDelivery{
+		EndpointID:    letter.EndpointID,
+		EventID:       letter.EventID,
+		EventType:     letter.EventType,
+		Payload:       letter.Payload,
+		Status:        WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(delivery).Error; err != nil {
+			return err
+		}
+		return tx.Model(&letter).Update("redelivered_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("重新投递失败: %v", err)
+	}
+	s.signal()
+	return delivery, nil
+}
+
+// Enqueue 为接收者登记的、订阅了该事件的Webhook生成投递任务；服务为nil时不做任何事
+func (s *WebhookService) Enqueue(event models.ChildRequestEvent, recipients []string) error {
+	if s == nil || len(recipients) == 0 {
+		return nil
+	}
+	var endpoints []models.WebhookEndpoint
+	if err := s.DB.Where("owner_address IN ? AND active = ?", recipients, true).Find(&endpoints).Error; err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	queued := 0
+	for _, endpoint := range endpoints {
+		if len(endpoint.Events) > 0 && !containsString(endpoint.Events, event.Type) {
+			continue
+		}
+		delivery := models.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        WebhookDeliveryPending,
+			NextAttemptAt: time.Now(),
+		}
+		if err := s.DB.Create(&delivery).Error; err != nil {
+			return err
+		}
+		queued++
+	}
+	if queued > 0 {
+		s.signal()
+	}
+	return nil
+}
+
+// Run 投递到期的任务，直到ctx取消；新任务入队时立即唤醒
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		for {
+			count, err := s.DeliverDue(ctx)
+			if err != nil {
+				log.Printf("Webhook投递失败: %v", err)
+				break
+			}
+			if count < webhookDeliveryBatch {
+				break
+			}
+		}
+	}
+}
+
+// DeliverDue 尝试投递一批到期的任务，返回本批数量
+func (s *WebhookService) DeliverDue(ctx context.Context) (int, error) {
+	var due []models.WebhookDelivery
+	if err := s.DB.Where("status = ? AND next_attempt_at <= ?", WebhookDeliveryPending, time.Now()).
+		Order("next_attempt_at ASC").Limit(webhookDeliveryBatch).Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("查询待投递任务失败: %v", err)
+	}
+	for i := range due {
+		if err := s.attempt(ctx, &due[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(due), nil
+}
+
+// attempt 投递一次，并根据结果更新任务状态
+func (s *WebhookService) attempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	var endpoint models.WebhookEndpoint
+	if err := s.DB.First(&endpoint, delivery.EndpointID).Error; err != nil || !endpoint.Active {
+		return s.DB.Model(delivery).Updates(map[string]interface{}{
+			"status":     WebhookDeliveryDead,
+			"last_error": "Webhook已删除或停用",
+		}).Error
+	}
+
+	delivery.Attempts++
+	code, sendErr := s.send(ctx, &endpoint, delivery)
+	if sendErr == nil {
+		now := time.Now()
+		return s.DB.Model(delivery).Updates(map[string]interface{}{
+			"status":        WebhookDeliveryDelivered,
+			"attempts":      delivery.Attempts,
+			"response_code": code,
+			"last_error":    "",
+			"delivered_at":  &now,
+		}).Error
+	}
+
+	if delivery.Attempts < s.MaxAttempts {
+		return s.DB.Model(delivery).Updates(map[string]interface{}{
+			"attempts":        delivery.Attempts,
+			"response_code":   code,
+			"last_error":      sendErr.Error(),
+			"next_attempt_at": time.Now().Add(s.backoff(delivery.Attempts)),
+		}).Error
+	}
+
+	// 重试用尽，转入死信表
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(delivery).Updates(map[string]interface{}{
+			"status":        WebhookDeliveryDead,
+			"attempts":      delivery.Attempts,
+			"response_code": code,
+			"last_error":    sendErr.Error(),
+		}).Error; err != nil {
+			return err
+		}
+		letter := models.WebhookDeadLetter{
+			DeliveryID:   delivery.ID,
+			EndpointID:   endpoint.ID,
+			OwnerAddress: endpoint.OwnerAddress,
+			EventID:      delivery.EventID,
+			EventType:    delivery.EventType,
+			Payload:      delivery.Payload,
+			Attempts:     delivery.Attempts,
+			LastError:    sendErr.Error(),
+		}
+		return tx.Create(&letter).Error
+	})
+}
+
+// send 发送一次HTTP请求，2xx视为成功；返回的错误只包含状态码或错误类别，不包含响应内容和底层网络细节，
+// 避免通过投递记录和死信读取内网服务的响应
+func (s *WebhookService) send(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", delivery.EventID)
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhookPayload(endpoint.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return 0, webhookSendError(err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, webhookResponseMaxLen))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// webhookSendError 把请求错误归为固定的几类
+func webhookSendError(err error) error {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, util.ErrForbiddenAddress):
+		return errors.New("目标地址不是公网地址")
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return errors.New("请求超时")
+	default:
+		return errors.New("连接失败")
+	}
+}
+
+// backoff 第n次失败后的等待时间：RetryBase * 2^(n-1)，不超过webhookMaxBackoff
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	wait := s.RetryBase
+	for i := 1; i < attempts && wait < webhookMaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > webhookMaxBackoff {
+		wait = webhookMaxBackoff
+	}
+	return wait
+}
+
+// signal 唤醒投递循环
+func (s *WebhookService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// SignWebhookPayload 计算Webhook签名：HMAC-SHA256(secret, timestamp + "." + body) 的十六进制，
+// 接收方应以同样方式计算并比较X-Webhook-Signature，同时校验时间戳防止重放
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -1,6 +1,10 @@
 package api
 
 import (
+	"context"
+	"log"
+	"time"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/ABE/nft/nft-go-backend/internal/blockchain"
@@ -8,40 +12,117 @@ import (
 
 	abe "github.com/ABE/nft/nft-go-backend/internal/api/abe/handler"
 	nft "github.com/ABE/nft/nft-go-backend/internal/api/nft/handler"
+	auth "github.com/ABE/nft/nft-go-backend/internal/api/auth/handler"
+	auth_service "github.com/ABE/nft/nft-go-backend/internal/api/auth/service"
 	did_vc "github.com/ABE/nft/nft-go-backend/internal/api/did_vc/handler"
 	abe_service "github.com/ABE/nft/nft-go-backend/internal/api/abe/service"
 	did_vc_service "github.com/ABE/nft/nft-go-backend/internal/api/did_vc/service"
+	nft_service "github.com/ABE/nft/nft-go-backend/internal/api/nft/service"
 
 )
 
+// 每个IP每分钟最多申请的展示挑战数
+const presentationRequestRateLimit = 30
+
 // Router 主路由结构体
 type Router struct {
-	NFTHandlers      *nft.NFTHandlers
-	ChildNFTHandlers *nft.ChildNFTHandlers
+	AuthHandlers     *auth.ChallengeHandlers
 	MetadataHandlers *nft.MetadataHandlers
 	ABEHandlers      *abe.ABEHandlers
 	DIDHandlers      *did_vc.DIDHandlers
 	VCHandlers       *did_vc.VCHandlers
+	SchemaHandlers   *did_vc.SchemaHandlers
+	HospitalHandlers *did_vc.HospitalHandlers
+	SubjectHandlers  *did_vc.SubjectHandlers
+	WebhookHandlers  *nft.WebhookHandlers
+
+	// Collections 每个部署的NFT、子NFT和交易接口，第一个为默认部署
+	Collections []*Collection
+
+	// AnchorService 链上锚定服务，未启用锚定时为nil
+	AnchorService *did_vc_service.AnchorService
+	// WebhookService 子NFT申请事件的Webhook投递服务，用于后台重试
+	WebhookService *nft_service.WebhookService
 }
 
-// NewRouter 创建新的路由实例
-func NewRouter(client *blockchain.EthClient) *Router {
+// NewRouter 创建新的路由实例；DID与锚定使用默认部署的链
+func NewRouter(registry *blockchain.Registry) *Router {
 	// 获取数据库连接
 	db := models.GetDB()
+	client := registry.Default()
 	abeService := abe_service.NewABEService(db)
 
+	// 签名授权写操作的一次性挑战
+	challengeService := auth_service.NewChallengeService(db,
+		time.Duration(client.Config.ActionChallengeTTL)*time.Second,
+		client.Config.PlatformAdminAddresses)
+
 	// 创建DID服务
-	didService := did_vc_service.NewDIDService(db)
+	didService := did_vc_service.NewDIDService(db, client.Config.ChainID)
 	// 创建VC服务
 	vcService := did_vc_service.NewVCService(db)
+	vcService.Challenges = challengeService
+	// 创建凭证模式服务
+	schemaService := did_vc_service.NewSchemaService(db)
+	// 创建身份主体档案服务
+	subjectService := did_vc_service.NewSubjectService(db)
+	// 创建医院登记服务
+	hospitalService := did_vc_service.NewHospitalService(db, challengeService)
+	// 申请事件通过SSE推送给在线用户，并投递到登记的Webhook
+	webhookService := nft_service.NewWebhookService(db,
+		time.Duration(client.Config.WebhookTimeout)*time.Second,
+		int(client.Config.WebhookMaxAttempts),
+		time.Duration(client.Config.WebhookRetryBase)*time.Second)
+	webhookService.AllowHTTP = client.Config.DevMode
+	eventService := nft_service.NewEventService(db, webhookService)
+
+	// 每个部署各自的NFT、子NFT申请、账本和索引服务
+	var collections []*Collection
+	for _, deploymentClient := range registry.Clients {
+		collections = append(collections, newCollection(db, deploymentClient, eventService, challengeService))
+	}
+
+	// 启用时创建链上锚定服务，失败只记录日志，不影响链下功能
+	var anchorService *did_vc_service.AnchorService
+	if client.Config.AnchoringEnabled {
+		registryAddress, anchorAddress, err := client.SetupAnchorContracts(context.Background())
+		if err == nil {
+			anchorService, err = did_vc_service.NewAnchorService(db, client.Client, client.Auth, registryAddress, anchorAddress, int(client.Config.AnchorBatchSize))
+		}
+		if err == nil {
+			anchorService.Tx = client.Tx
+		}
+		if err != nil {
+			log.Printf("链上锚定未启用: %v", err)
+		} else {
+			didService.Anchor = anchorService
+			vcService.Anchor = anchorService
+		}
+	}
+
+	// 通用DID解析器：did:ethr（本地与链上注册表）、did:key、did:web
+	resolver := did_vc_service.NewUniversalResolver(
+		time.Duration(client.Config.DIDResolverCacheTTL)*time.Second,
+		did_vc_service.NewEthrDIDResolver(didService),
+		did_vc_service.NewKeyDIDResolver(),
+		did_vc_service.NewWebDIDResolver(client.Config.DIDWebStubDir),
+	)
+	didService.Resolver = resolver
+	vcService.Resolver = resolver
 
 	return &Router{
-		NFTHandlers:      nft.NewNFTHandlers(client),
-		ChildNFTHandlers: nft.NewChildNFTHandlers(client),
+		AuthHandlers:     auth.NewChallengeHandlers(challengeService),
 		MetadataHandlers: nft.NewMetadataHandlers(client),
 		ABEHandlers:      abe.NewABEHandlers(abeService),
 		DIDHandlers:      did_vc.NewDIDHandlers(didService),
 		VCHandlers:       did_vc.NewVCHandlers(vcService, didService),
+		SchemaHandlers:   did_vc.NewSchemaHandlers(schemaService, challengeService),
+		HospitalHandlers: did_vc.NewHospitalHandlers(hospitalService),
+		SubjectHandlers:  did_vc.NewSubjectHandlers(subjectService, vcService),
+		WebhookHandlers:  nft.NewWebhookHandlers(webhookService, eventService, challengeService),
+		Collections:      collections,
+		AnchorService:    anchorService,
+		WebhookService:   webhookService,
 	}
 }
 
@@ -66,15 +147,42 @@ func (router *Router) SetupRoutes(r *gin.Engine) {
 		})
 	})
 
-	// 健康检查
+	// 健康检查，启用索引时附带各部署的索引器状态
 	api.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
+		status := "ok"
+		var indexers []nft_service.IndexerHealth
+		for _, col := range router.Collections {
+			if col.IndexerSupervisor == nil {
+				continue
+			}
+			indexer := col.IndexerSupervisor.Health()
+			if indexer.Status == nft_service.IndexerStatusDegraded {
+				status = "degraded"
+			}
+			indexers = append(indexers, indexer)
+		}
+		if indexers == nil {
+			c.JSON(200, gin.H{"status": status})
+			return
+		}
+		c.JSON(200, gin.H{"status": status, "indexers": indexers})
 	})
 
+	// 已配置的部署；NFT、子NFT和交易接口用查询参数collection或chainId选择部署，未指定时使用默认部署
+	api.GET("/collections", router.ListCollectionsHandler)
+
+	// 签名授权写操作的一次性挑战：签名消息绑定操作名称和参数，nonce只能使用一次
+	api.POST("/auth/challenge", router.AuthHandlers.CreateChallengeHandler)
+
 	// 不需要签名验证的路由
-	api.GET("/nft/:tokenId", router.NFTHandlers.GetNFTHandler)
-	api.GET("/nfts", router.NFTHandlers.GetAllNFTsHandler)
-	api.GET("/nfts/user/:address", router.NFTHandlers.GetUserNFTsHandler)
+	api.GET("/nft/:tokenId", router.nftRoute((*nft.NFTHandlers).GetNFTHandler))
+	api.GET("/nfts", router.nftRoute((*nft.NFTHandlers).GetAllNFTsHandler))
+	api.GET("/nft/issuance-rules/:tokenId", router.childRoute((*nft.ChildNFTHandlers).GetIssuanceRulesHandler))
+	api.GET("/nfts/user/:address", router.nftRoute((*nft.NFTHandlers).GetUserNFTsHandler))
+	api.GET("/tx/:hash", router.txRoute((*nft.TxHandlers).GetTransactionHandler))
+	api.GET("/nft/typed-data/mint", router.nftRoute((*nft.NFTHandlers).GetMintTypedDataHandler))                             // 铸造NFT需要签名的EIP-712数据
+	api.GET("/nft/typed-data/create-child", router.childRoute((*nft.ChildNFTHandlers).GetCreateChildTypedDataHandler))       // 创建子NFT需要签名的EIP-712数据
+	api.GET("/nft/typed-data/approve-request", router.childRoute((*nft.ChildNFTHandlers).GetApproveRequestTypedDataHandler)) // 批准子NFT申请需要签名的EIP-712数据
 
 	// 元数据相关路由（不需要认证）
 	api.POST("/metadata", router.MetadataHandlers.CreateMetadataHandler)
@@ -106,21 +214,32 @@ func (router *Router) SetupRoutes(r *gin.Engine) {
 		did.GET("/list", router.DIDHandlers.GetAllDIDsHandler) // 获取所有DID列表
 
 		// 钱包相关的DID操作
-		did.POST("/wallet/:walletAddress", router.DIDHandlers.CreateDIDFromWalletHandler) // 通过钱包地址创建DID
-		did.GET("/wallet/:walletAddress", router.DIDHandlers.GetDIDByWalletHandler)       // 获取钱包的DID信息
-		did.GET("/list/:walletAddress", router.DIDHandlers.ListDIDsByWalletHandler)       // 列出钱包的所有DID
+		did.POST("/wallet/:walletAddress/challenge", router.DIDHandlers.CreateDIDChallengeHandler) // 获取创建DID的签名挑战
+		did.POST("/wallet/:walletAddress", router.DIDHandlers.CreateDIDFromWalletHandler)          // 通过钱包地址创建DID（提交挑战签名）
+		did.GET("/wallet/:walletAddress", router.DIDHandlers.GetDIDByWalletHandler)                // 获取钱包的DID信息
+		did.GET("/list/:walletAddress", router.DIDHandlers.ListDIDsByWalletHandler)                // 列出钱包的所有DID
+
+		// 身份主体档案（医生、患者、研究人员、药房等）
+		did.GET("/subject/roles", router.SubjectHandlers.ListRoleTemplatesHandler)       // 角色模板
+		did.POST("/subject/create", router.SubjectHandlers.CreateProfileHandler)         // 创建档案
+		did.GET("/subject/list", router.SubjectHandlers.ListProfilesHandler)             // 列出档案（?role=）
+		did.GET("/subject/profile/:did", router.SubjectHandlers.GetProfilesByDIDHandler) // 获取DID的各角色档案
 
-		// 医生DID相关操作
+		// 医生DID相关操作（医生角色档案的别名）
 		did.POST("/doctor/create", router.VCHandlers.CreateDoctorDIDHandler) // 创建医生DID
 		did.GET("/doctor/list", router.VCHandlers.GetDoctorDIDsHandler)      // 获取医生DID列表
 
-		// 保留DID解析功能
-		did.POST("/resolve", router.DIDHandlers.ResolveDIDHandler) // 解析DID文档
+		// DID解析与文档变更
+		did.POST("/resolve", router.DIDHandlers.ResolveDIDHandler)             // 解析DID文档（?versionTime=按历史时间解析）
+		did.GET("/resolve/:did", router.DIDHandlers.ResolveDIDByPathHandler)   // 通用解析器：did:ethr、did:key、did:web
+		did.POST("/update", router.DIDHandlers.UpdateDIDHandler)               // 签名变更DID文档（验证方法、服务、控制者）
+		did.POST("/deactivate", router.DIDHandlers.RevokeDIDHandler)           // 签名停用DID
+		did.POST("/revoke", router.DIDHandlers.RevokeDIDHandler)               // 停用DID（兼容旧路径）
+		did.GET("/history/:did", router.DIDHandlers.GetDIDHistoryHandler)      // DID文档历史版本
+		did.GET("/anchor/:did", router.DIDHandlers.GetDIDAnchorRecordsHandler) // DID变更的链上注册表记录
 
 		// 已弃用的方法（返回错误提示）
 		did.POST("/create", router.DIDHandlers.CreateDIDHandler) // 已弃用
-		did.POST("/update", router.DIDHandlers.UpdateDIDHandler) // 已弃用
-		did.POST("/revoke", router.DIDHandlers.RevokeDIDHandler) // 已弃用
 	}
 
 	// VC路由
@@ -130,28 +249,74 @@ func (router *Router) SetupRoutes(r *gin.Engine) {
 		vc.POST("/issue", router.VCHandlers.IssueCredentialHandler)
 		vc.POST("/verify", router.VCHandlers.VerifyCredentialHandler)
 		vc.POST("/revoke", router.VCHandlers.RevokeCredentialHandler)
+		vc.POST("/presentation/request", RateLimitMiddleware(presentationRequestRateLimit, time.Minute), router.VCHandlers.RequestPresentationHandler)
 		vc.POST("/presentation/create", router.VCHandlers.CreatePresentationHandler)
 		vc.POST("/presentation/verify", router.VCHandlers.VerifyPresentationHandler)
+		vc.GET("/anchor/proof/:credentialId", router.VCHandlers.GetAnchorProofHandler) // 凭证记录的Merkle包含证明
+
+		// 凭证模式，写操作需要平台管理员对操作挑战的签名
+		vc.POST("/schemas", router.SchemaHandlers.CreateSchemaHandler)
+		vc.GET("/schemas", router.SchemaHandlers.ListSchemasHandler)
+		vc.GET("/schemas/attributes", router.SchemaHandlers.ListPolicyAttributesHandler) // 策略可用的属性名
+		vc.GET("/schemas/id/:schemaId", router.SchemaHandlers.GetSchemaHandler)
+		vc.PUT("/schemas/id/:schemaId", router.SchemaHandlers.UpdateSchemaHandler)
+		vc.DELETE("/schemas/id/:schemaId", router.SchemaHandlers.DeleteSchemaHandler)
 
-		// 医生VC相关操作
-		vc.POST("/doctor/issue", router.VCHandlers.IssueDoctorVCHandler)    // 颁发医生凭证
-		vc.POST("/doctor/verify", router.VCHandlers.VerifyDoctorVCHandler)  // 验证医生凭证
-		vc.GET("/doctor/:doctorDID", router.VCHandlers.GetDoctorVCsHandler) // 获取医生凭证列表
+		// 身份主体凭证
+		vc.POST("/subject/issue", router.SubjectHandlers.IssueCredentialHandler)
+		vc.POST("/subject/verify", router.SubjectHandlers.VerifyCredentialHandler)
+		vc.GET("/subject/:subjectDID", router.SubjectHandlers.GetCredentialsHandler) // ?role=&type=&status=
+		vc.POST("/subject/revoke", router.SubjectHandlers.RevokeCredentialHandler)
+		vc.POST("/subject/suspend", router.SubjectHandlers.SuspendCredentialHandler)
+		vc.POST("/subject/reinstate", router.SubjectHandlers.ReinstateCredentialHandler)
+		vc.POST("/subject/renew", router.SubjectHandlers.RenewCredentialHandler)
+		vc.POST("/subject/supersede", router.SubjectHandlers.SupersedeCredentialHandler)
+
+		// 医生VC相关操作（医生角色主体凭证的别名）
+		vc.POST("/doctor/issue", router.VCHandlers.IssueDoctorVCHandler)         // 颁发医生凭证
+		vc.POST("/doctor/verify", router.VCHandlers.VerifyDoctorVCHandler)       // 验证医生凭证
+		vc.GET("/doctor/:doctorDID", router.VCHandlers.GetDoctorVCsHandler)      // 获取医生凭证列表（?type=&status=）
+		vc.POST("/doctor/revoke", router.VCHandlers.RevokeDoctorVCHandler)       // 撤销医生凭证
+		vc.POST("/doctor/suspend", router.VCHandlers.SuspendDoctorVCHandler)     // 暂停医生凭证
+		vc.POST("/doctor/reinstate", router.VCHandlers.ReinstateDoctorVCHandler) // 恢复医生凭证
+		vc.POST("/doctor/renew", router.VCHandlers.RenewDoctorVCHandler)         // 到期前续期医生凭证
+		vc.POST("/doctor/supersede", router.VCHandlers.SupersedeDoctorVCHandler) // 以新内容取代医生凭证
 	}
 
+	// 医院（受信任颁发机构）登记路由，写操作在服务内校验平台管理员签名
+	hospital := api.Group("/hospital")
+	{
+		hospital.POST("/onboard", router.HospitalHandlers.OnboardHospitalHandler)
+		hospital.POST("/suspend", router.HospitalHandlers.SuspendHospitalHandler)
+		hospital.POST("/reinstate", router.HospitalHandlers.ReinstateHospitalHandler)
+		hospital.POST("/authorizations/grant", router.HospitalHandlers.GrantAuthorizationHandler)
+		hospital.POST("/authorizations/revoke", router.HospitalHandlers.RevokeAuthorizationHandler)
+		hospital.GET("/list", router.HospitalHandlers.ListHospitalsHandler)
+		hospital.GET("/detail/:hospitalDid", router.HospitalHandlers.GetHospitalHandler)
+	}
+
+	// 用户签名EIP-712请求、平台代为提交的链上操作
+	api.POST("/nft/mint", router.perCollection(func(col *Collection) gin.HandlerFunc {
+		return TypedDataAuthMiddleware(col.NFTHandlers.MintRequestTypedData)
+	}), router.nftRoute((*nft.NFTHandlers).MintNFTHandler))
+	api.POST("/nft/createChild", router.perCollection(func(col *Collection) gin.HandlerFunc {
+		return TypedDataAuthMiddleware(col.ChildNFTHandlers.CreateChildRequestTypedData)
+	}), router.childRoute((*nft.ChildNFTHandlers).CreateChildNFTHandler))
+
 	// 需要签名验证的路由
 	secured := api.Group("")
 	secured.Use(SignatureAuthMiddleware())
 	{
 		// NFT相关
-		secured.POST("/nft/mint", router.NFTHandlers.MintNFTHandler)
-		secured.POST("/nft/update-metadata", router.NFTHandlers.UpdateMetadataHandler)
-		secured.POST("/nft/update-uri", router.NFTHandlers.UpdateNFTURIHandler)
+		secured.POST("/nft/update-metadata", router.nftRoute((*nft.NFTHandlers).UpdateMetadataHandler))
+		secured.POST("/nft/update-uri", router.nftRoute((*nft.NFTHandlers).UpdateNFTURIHandler))
 
 		// 子NFT相关
-		secured.POST("/nft/createChild", router.ChildNFTHandlers.CreateChildNFTHandler)
-		secured.POST("/nft/request-child", router.ChildNFTHandlers.RequestChildNFTHandler)
-		secured.POST("/nft/process-request", router.ChildNFTHandlers.ProcessRequestHandler)
+		secured.POST("/nft/request-child", router.childRoute((*nft.ChildNFTHandlers).RequestChildNFTHandler))
+		secured.POST("/nft/process-request", router.childRoute((*nft.ChildNFTHandlers).ProcessRequestHandler))
+		secured.POST("/nft/cancel-request", router.childRoute((*nft.ChildNFTHandlers).CancelRequestHandler))          // 申请者撤回申请
+		secured.POST("/nft/process-requests", router.childRoute((*nft.ChildNFTHandlers).BatchProcessRequestsHandler)) // 批量批准或拒绝申请
+		secured.POST("/nft/issuance-rules", router.childRoute((*nft.ChildNFTHandlers).SetIssuanceRulesHandler))       // 设置子NFT发行规则
 
 		// 集成NFT+ABE相关
 		// secured.POST("/nft/mint-encrypted", router.NFTHandlers.MintEncryptedNFTHandler)
@@ -162,9 +327,25 @@ func (router *Router) SetupRoutes(r *gin.Engine) {
 	apiAuth.Use(GetRequestAuthMiddleware())
 	{
 		// NFT相关
-		apiAuth.GET("/nft/my-nfts", router.NFTHandlers.GetMyNFTsHandler)
+		apiAuth.GET("/nft/my-nfts", router.nftRoute((*nft.NFTHandlers).GetMyNFTsHandler))
 
 		// 子NFT相关
-		apiAuth.GET("/nft/all-requests", router.ChildNFTHandlers.GetAllRequestsHandler)
+		apiAuth.GET("/nft/all-requests", router.childRoute((*nft.ChildNFTHandlers).GetAllRequestsHandler))
 	}
+
+	// 申请列表的incoming或outgoing视图（参数同all-requests）和申请状态迁移历史，通过查询参数提交对一次性挑战的签名
+	api.GET("/nft/requests/:view", ChallengeQueryAuthMiddleware(router.AuthHandlers.Service, nft_service.ListChildRequestsAction),
+		router.childRoute((*nft.ChildNFTHandlers).GetAllRequestsHandler))
+	api.GET("/nft/request/:id/history", ChallengeQueryAuthMiddleware(router.AuthHandlers.Service, nft_service.RequestHistoryAction),
+		router.childRoute((*nft.ChildNFTHandlers).GetRequestHistoryHandler))
+
+	// 申请事件流（SSE），通过查询参数提交对一次性挑战的签名
+	api.GET("/events", EventStreamAuthMiddleware(router.AuthHandlers.Service), router.WebhookHandlers.StreamEventsHandler)
+
+	// 申请事件Webhook：写操作在请求体中提交对一次性挑战的签名，查询通过查询参数提交
+	api.POST("/webhooks", router.WebhookHandlers.RegisterWebhookHandler)
+	api.POST("/webhooks/delete", router.WebhookHandlers.DeleteWebhookHandler)
+	api.POST("/webhooks/redeliver", router.WebhookHandlers.RedeliverDeadLetterHandler) // 重新投递死信
+	api.GET("/webhooks", ChallengeQueryAuthMiddleware(router.AuthHandlers.Service, nft_service.ListWebhooksAction), router.WebhookHandlers.ListWebhooksHandler)
+	api.GET("/webhooks/dead-letters", ChallengeQueryAuthMiddleware(router.AuthHandlers.Service, nft_service.ListWebhookDeadLettersAction), router.WebhookHandlers.ListDeadLettersHandler)
 }
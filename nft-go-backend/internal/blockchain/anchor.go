@@ -0,0 +1,105 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/ABE/nft/nft-go-backend/pkg/didregistry"
+	"github.com/ABE/nft/nft-go-backend/pkg/vcanchor"
+)
+
+// AnchorBackend 锚定合约所需的链访问能力，ethclient.Client与模拟后端的客户端均满足
+type AnchorBackend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+}
+
+// SetupAnchorContracts 返回锚定合约地址：已配置地址时直接绑定；只有开发模式下才会在未配置时从Hardhat编译产物部署，
+// 否则每次启动都会部署新合约，之前的注册表和锚定记录随之失效
+func (ec *EthClient) SetupAnchorContracts(ctx context.Context) (common.Address, common.Address, error) {
+	cfg := ec.Config
+	if cfg.DIDRegistryAddress != "" && cfg.CredentialAnchorAddress != "" {
+		return common.HexToAddress(cfg.DIDRegistryAddress), common.HexToAddress(cfg.CredentialAnchorAddress), nil
+	}
+	if !cfg.DevMode {
+		return common.Address{}, common.Address{}, fmt.Errorf("启用锚定时必须配置DID_REGISTRY_ADDRESS和CREDENTIAL_ANCHOR_ADDRESS（只有DEV_MODE=true时才会自动部署）")
+	}
+
+	registryBin, err := LoadArtifactBytecode(filepath.Join(cfg.AnchorArtifactsDir, "EthereumDIDRegistry.sol", "EthereumDIDRegistry.json"))
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
+	anchorBin, err := LoadArtifactBytecode(filepath.Join(cfg.AnchorArtifactsDir, "CredentialAnchor.sol", "CredentialAnchor.json"))
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
+
+	registryAddress, anchorAddress, err := DeployAnchorContracts(ctx, ec.Auth, ec.Client, registryBin, anchorBin)
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
+	log.Printf("已部署锚定合约: DID注册表=%s, 凭证锚定=%s（请写入DID_REGISTRY_ADDRESS和CREDENTIAL_ANCHOR_ADDRESS）",
+		registryAddress.Hex(), anchorAddress.Hex())
+	return registryAddress, anchorAddress, nil
+}
+
+// DeployAnchorContracts 部署DID注册表和凭证锚定合约，部署账户成为两者的登记员
+func DeployAnchorContracts(ctx context.Context, auth *bind.TransactOpts, backend AnchorBackend, registryBin, anchorBin []byte) (common.Address, common.Address, error) {
+	registryAddress, err := deployContract(ctx, auth, backend, didregistry.DidregistryMetaData.ABI, registryBin)
+	if err != nil {
+		return common.Address{}, common.Address{}, fmt.Errorf("部署DID注册表失败: %v", err)
+	}
+	anchorAddress, err := deployContract(ctx, auth, backend, vcanchor.VcanchorMetaData.ABI, anchorBin)
+	if err != nil {
+		return common.Address{}, common.Address{}, fmt.Errorf("部署凭证锚定合约失败: %v", err)
+	}
+	return registryAddress, anchorAddress, nil
+}
+
+// deployContract 部署合约并等待上链
+func deployContract(ctx context.Context, auth *bind.TransactOpts, backend AnchorBackend, abiJSON string, bytecode []byte) (common.Address, error) {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return common.Address{}, err
+	}
+	opts := *auth
+	opts.Context = ctx
+	opts.Nonce = nil
+	address, tx, _, err := bind.DeployContract(&opts, parsed, bytecode, backend)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if _, err := bind.WaitDeployed(ctx, backend, tx); err != nil {
+		return common.Address{}, err
+	}
+	return address, nil
+}
+
+// LoadArtifactBytecode 从Hardhat编译产物中读取合约字节码
+func LoadArtifactBytecode(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取编译产物失败（请先执行 npx hardhat compile）: %v", err)
+	}
+	var artifact struct {
+		Bytecode string `json:"bytecode"`
+	}
+	if err := json.Unmarshal(raw, &artifact); err != nil {
+		return nil, fmt.Errorf("解析编译产物失败: %v", err)
+	}
+	bytecode, err := hexutil.Decode(artifact.Bytecode)
+	if err != nil || len(bytecode) == 0 {
+		return nil, fmt.Errorf("编译产物 %s 中没有字节码", path)
+	}
+	return bytecode, nil
+}
@@ -2,66 +2,53 @@ package blockchain
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"errors"
 	"fmt"
-	"log"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/gin-gonic/gin"
 
 	"github.com/ABE/nft/nft-go-backend/internal/config"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
 	"github.com/ABE/nft/nft-go-backend/pkg/childnft"
 	"github.com/ABE/nft/nft-go-backend/pkg/mainnft"
 )
 
-// EthClient 以太坊客户端结构体
+// 平台账户交易的操作类型，记录在交易管理器的交易记录中
+const (
+	TxKindMint           = "mint"
+	TxKindCreateChild    = "createChild"
+	TxKindUpdateMainURI  = "updateMainURI"
+	TxKindUpdateChildURI = "updateChildURI"
+	TxKindAnchor         = "anchor"
+)
+
+// EthClient 以太坊客户端结构体，对应一个合约部署
 type EthClient struct {
 	Client     *ethclient.Client
 	MainNFT    *mainnft.Mainnft
 	ChildNFT   *childnft.Childnft
 	Auth       *bind.TransactOpts
 	CallOpts   *bind.CallOpts
-	PrivateKey *ecdsa.PrivateKey
-	Config     *config.Config
+	Signer     Signer
+	Config     *config.Config // 链、RPC和合约地址已替换为该部署的值
+	Deployment config.Deployment
+	Tx         *TxManager        // 平台账户的交易都通过交易管理器发送，同一条链上的部署共用
+	Metadata   *MetadataResolver // tokenURI元数据解析器，所有部署共用
 }
 
-// NewEthClient 创建新的以太坊客户端
-func NewEthClient(cfg *config.Config) (*EthClient, error) {
-	// 连接到以太坊节点
-	client, err := ethclient.Dial(cfg.EthereumRPC)
-	if err != nil {
-		return nil, fmt.Errorf("无法连接到以太坊客户端: %v", err)
-	}
-
-	// 加载私钥
-	privateKey, err := crypto.HexToECDSA(cfg.PrivateKey)
-	if err != nil {
-		return nil, fmt.Errorf("无法加载私钥: %v", err)
-	}
-
-	// 从私钥获取公钥
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("无法获取公钥")
-	}
-
-	// 从公钥获取地址
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	log.Println("使用地址:", fromAddress.Hex())
+// newEthClient 为部署创建以太坊客户端，节点连接、签名器和交易管理器由注册表按链共用
+func newEthClient(cfg *config.Config, deployment config.Deployment, client *ethclient.Client, signer Signer, txManager *TxManager) (*EthClient, error) {
+	cfg = cfg.ForDeployment(deployment)
+	fromAddress := signer.Address()
 
 	// 创建交易选项
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, big.NewInt(cfg.ChainID))
-	if err != nil {
-		return nil, fmt.Errorf("无法创建交易选项: %v", err)
-	}
-
-	// 设置默认gas限制
-	auth.GasLimit = uint64(3000000)
+	auth := NewSignerTransactor(signer, big.NewInt(cfg.ChainID))
 
 	// 创建只读调用选项
 	callOpts := &bind.CallOpts{
@@ -90,50 +77,53 @@ func NewEthClient(cfg *config.Config) (*EthClient, error) {
 		ChildNFT:   childNFT,
 		Auth:       auth,
 		CallOpts:   callOpts,
-		PrivateKey: privateKey,
+		Signer:     signer,
 		Config:     cfg,
+		Deployment: deployment,
+		Tx:         txManager,
 	}, nil
 }
 
-// UpdateTransactOpts 更新交易选项（nonce和gasPrice）
-func (ec *EthClient) UpdateTransactOpts() error {
-	// 获取nonce
-	nonce, err := ec.Client.PendingNonceAt(context.Background(), ec.Auth.From)
-	if err != nil {
-		return fmt.Errorf("无法获取nonce: %v", err)
-	}
-	ec.Auth.Nonce = big.NewInt(int64(nonce))
-
-	// 获取当前gas价格
-	gasPrice, err := ec.Client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return fmt.Errorf("无法获取gas价格: %v", err)
-	}
-	ec.Auth.GasPrice = gasPrice
+// newTxManager 按配置创建一条链的交易管理器，gas由估算得出，费用和加价策略来自配置
+func newTxManager(cfg *config.Config, client *ethclient.Client, chainID int64, signer Signer) *TxManager {
+	txManager := NewTxManager(models.GetDB(), client, big.NewInt(chainID))
+	txManager.GasMarginPercent = uint64(cfg.TxGasMarginPercent)
+	txManager.BumpAfter = time.Duration(cfg.TxBumpAfter) * time.Second
+	txManager.BumpPercent = cfg.TxBumpPercent
+	if cfg.TxMaxFeeGwei > 0 {
+		txManager.MaxFeeCap = new(big.Int).Mul(big.NewInt(cfg.TxMaxFeeGwei), big.NewInt(1e9))
+	}
+	txManager.AddSigner(signer)
+	return txManager
+}
 
-	return nil
+// Collection 部署名称，即接口的collection参数
+func (ec *EthClient) Collection() string {
+	return ec.Deployment.Name
 }
 
-// CheckTokenExists 检查token是否存在
-func (ec *EthClient) CheckTokenExists(tokenID *big.Int) (bool, error) {
-	// 先检查总供应量
-	totalSupply, err := ec.MainNFT.TotalSupply(ec.CallOpts)
-	if err != nil {
-		return false, fmt.Errorf("无法获取总供应量: %v", err)
+// ContractAddress 按合约类型（main或child）返回合约地址的校验和格式，与nfts表的contract列一致
+func (ec *EthClient) ContractAddress(contractType string) string {
+	if contractType == "child" {
+		return common.HexToAddress(ec.Config.ChildNFTAddress).Hex()
 	}
+	return common.HexToAddress(ec.Config.MainNFTAddress).Hex()
+}
 
-	// 如果tokenID >= totalSupply，则token不存在
-	if tokenID.Cmp(totalSupply) >= 0 {
-		return false, nil
-	}
+// NFTContract 按合约类型返回nfts表中该部署合约的标识
+func (ec *EthClient) NFTContract(contractType string) models.NFTContractKey {
+	return models.NFTContractKey{ChainID: ec.Config.ChainID, Address: ec.ContractAddress(contractType)}
+}
 
-	// 尝试获取owner，如果失败则token不存在
-	_, err = ec.MainNFT.OwnerOf(ec.CallOpts, tokenID)
+// CheckTokenExists 检查token是否存在；tokenID不一定连续，已销毁的token调用ownerOf会回滚
+func (ec *EthClient) CheckTokenExists(tokenID *big.Int) (bool, error) {
+	_, err := ec.MainNFT.OwnerOf(ec.CallOpts, tokenID)
 	if err != nil {
-		// 如果错误信息包含"nonexistent"或"invalid"，说明token不存在
-		return false, nil
+		if isRevert(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("查询token持有者失败: %v", err)
 	}
-
 	return true, nil
 }
 
@@ -182,79 +172,51 @@ func (ec *EthClient) GetNextTokenID() (*big.Int, error) {
 	return totalSupply, nil
 }
 
-// ListAllTokens 列出所有已铸造的tokens
+// ListAllTokens 通过ERC721Enumerable列出所有现存的主NFT，tokenByIndex分批调用
 func (ec *EthClient) ListAllTokens() ([]*big.Int, error) {
-	totalSupply, err := ec.MainNFT.TotalSupply(ec.CallOpts)
+	reader, err := ec.NewTokenReader(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("无法获取总供应量: %v", err)
-	}
-
-	var tokens []*big.Int
-	for i := int64(0); i < totalSupply.Int64(); i++ {
-		tokenID := big.NewInt(i)
-		exists, err := ec.CheckTokenExists(tokenID)
-		if err != nil {
-			log.Printf("检查token %d 时出错: %v", i, err)
-			continue
-		}
-		if exists {
-			tokens = append(tokens, tokenID)
-		}
+		return nil, err
 	}
-
-	return tokens, nil
+	return reader.ListTokens(context.Background(), "main")
 }
 
-// PerformContractOperation 使用平台私钥执行合约操作
-func (ec *EthClient) PerformContractOperation(operation func(*bind.TransactOpts) (common.Hash, error)) (string, error) {
-	// 更新交易选项
-	if err := ec.UpdateTransactOpts(); err != nil {
-		return "", err
-	}
-
-	// 执行操作
-	txHash, err := operation(ec.Auth)
+// PerformContractOperation 使用平台账户通过交易管理器发送合约交易，返回交易哈希；kind记录操作类型
+func (ec *EthClient) PerformContractOperation(kind string, operation TxBuilder) (string, error) {
+	record, err := ec.Tx.Send(context.Background(), ec.Auth.From, kind, operation)
 	if err != nil {
 		return "", err
 	}
-
-	return txHash.Hex(), nil
+	return record.TxHash, nil
 }
 
-// MintNFT 铸造NFT - 使用mintTo函数直接铸造给指定用户
-func (ec *EthClient) MintNFT(userAddress string, uri string) (string, error) {
-	// 验证用户地址格式
-	if !common.IsHexAddress(userAddress) {
-		return "", fmt.Errorf("无效的用户地址格式: %s", userAddress)
+// AwaitTransaction 在TX_WAIT_TIMEOUT内等待交易的最终状态，超时返回pending，之后由后台任务继续跟踪。
+// 交易回滚、被替换或广播失败时同时返回状态和错误
+func (ec *EthClient) AwaitTransaction(txHash string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(ec.Config.TxWaitTimeout)*time.Second)
+	defer cancel()
+	record, _, err := ec.Tx.Wait(ctx, common.HexToHash(txHash))
+	if record == nil {
+		return "", err
 	}
-
-	operation := func(auth *bind.TransactOpts) (common.Hash, error) {
-		// 使用mintTo函数，将NFT直接铸造给指定用户
-		tx, err := ec.MainNFT.MintTo(auth, common.HexToAddress(userAddress), uri)
-		if err != nil {
-			return common.Hash{}, fmt.Errorf("铸造NFT失败: %v", err)
-		}
-		return tx.Hash(), nil
+	if errors.Is(err, context.DeadlineExceeded) {
+		return models.TxStatusPending, nil
 	}
-
-	return ec.PerformContractOperation(operation)
+	return record.Status, err
 }
 
-// CreateChildNFT 创建子NFT - 平台代表用户创建
-func (ec *EthClient) CreateChildNFT(parentTokenID *big.Int, userAddress string, recipient common.Address, uri string) (string, error) {
-	// 验证用户地址格式
-	if !common.IsHexAddress(userAddress) {
-		return "", fmt.Errorf("无效的用户地址格式: %s", userAddress)
-	}
-	operation := func(auth *bind.TransactOpts) (common.Hash, error) {
-		tx, err := ec.MainNFT.CreateChildNFTWithURI(auth, common.HexToAddress(userAddress), parentTokenID, recipient, uri)
+// CreateAutoApprovedChild 按发行规则自动批准申请时由平台账户创建子NFT，合约把创建者记录为父NFT的当前持有者。
+// 这是平台账户唯一不需要持有者签名的子NFT创建方式；持有者批准或自己发起时使用CreateChildNFTWithSignature
+func (ec *EthClient) CreateAutoApprovedChild(parentTokenID *big.Int, recipient common.Address, uri string) (string, error) {
+	operation := func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		tx, err := ec.MainNFT.CreateAutoApprovedChild(auth, parentTokenID, recipient, uri)
 		if err != nil {
-			return common.Hash{}, fmt.Errorf("创建子NFT失败: %v", err)
+			return nil, fmt.Errorf("创建子NFT失败: %v", err)
 		}
-		return tx.Hash(), nil
+		return tx, nil
 	}
 
-	return ec.PerformContractOperation(operation)
+	return ec.PerformContractOperation(TxKindCreateChild, operation)
 }
 
 // GetWalletAddressFromContext 从Gin上下文获取钱包地址
@@ -302,40 +264,40 @@ func (ec *EthClient) GetChildNFTInfo(tokenID *big.Int) (string, string, error) {
 
 // UpdateMainNFTMetadata 更新主NFT元数据
 func (ec *EthClient) UpdateMainNFTMetadata(tokenID *big.Int, newURI string) (string, error) {
-	operation := func(auth *bind.TransactOpts) (common.Hash, error) {
+	operation := func(auth *bind.TransactOpts) (*types.Transaction, error) {
 		tx, err := ec.MainNFT.SetSpecificTokenURI(auth, auth.From, tokenID, newURI)
 		if err != nil {
-			return common.Hash{}, fmt.Errorf("更新主NFT元数据失败: %v", err)
+			return nil, fmt.Errorf("更新主NFT元数据失败: %v", err)
 		}
-		return tx.Hash(), nil
+		return tx, nil
 	}
 
-	return ec.PerformContractOperation(operation)
+	return ec.PerformContractOperation(TxKindUpdateMainURI, operation)
 }
 
 // UpdateChildNFTMetadata 更新子NFT元数据
 func (ec *EthClient) UpdateChildNFTMetadata(tokenID *big.Int, newURI string) (string, error) {
-	operation := func(auth *bind.TransactOpts) (common.Hash, error) {
+	operation := func(auth *bind.TransactOpts) (*types.Transaction, error) {
 		tx, err := ec.ChildNFT.SetSpecificTokenURI(auth, tokenID, newURI)
 		if err != nil {
-			return common.Hash{}, fmt.Errorf("更新子NFT元数据失败: %v", err)
+			return nil, fmt.Errorf("更新子NFT元数据失败: %v", err)
 		}
-		return tx.Hash(), nil
+		return tx, nil
 	}
 
-	return ec.PerformContractOperation(operation)
+	return ec.PerformContractOperation(TxKindUpdateChildURI, operation)
 }
 
 // MintNFTToSelf 铸造NFT给平台自己（原有的mint函数保留，以防某些场景需要）
 func (ec *EthClient) MintNFTToSelf(uri string) (string, error) {
-	operation := func(auth *bind.TransactOpts) (common.Hash, error) {
+	operation := func(auth *bind.TransactOpts) (*types.Transaction, error) {
 		tx, err := ec.MainNFT.MintTo(auth, auth.From, uri)
 		if err != nil {
-			return common.Hash{}, fmt.Errorf("铸造NFT失败: %v", err)
+			return nil, fmt.Errorf("铸造NFT失败: %v", err)
 		}
-		return tx.Hash(), nil
+		return tx, nil
 	}
 
-	return ec.PerformContractOperation(operation)
+	return ec.PerformContractOperation(TxKindMint, operation)
 }
 
@@ -0,0 +1,383 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/ABE/nft/nft-go-backend/pkg/childnft"
+	"github.com/ABE/nft/nft-go-backend/pkg/mainnft"
+)
+
+// defaultReadBatchSize 未配置CHAIN_READ_BATCH_SIZE时每批的调用数
+const defaultReadBatchSize = 100
+
+// multicall3ABI Multicall3的aggregate3方法，allowFailure为true时单个调用回滚不影响其他调用
+const multicall3ABI = `[{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+var parsedMulticall3ABI = mustParseABI(multicall3ABI)
+
+// errCallReverted 单个只读调用回滚，如查询不存在的token
+var errCallReverted = errors.New("合约调用回滚")
+
+// TokenState 链上读取的token状态
+type TokenState struct {
+	TokenID       *big.Int
+	Owner         common.Address
+	URI           string
+	ParentTokenID *big.Int // 只有子NFT有
+}
+
+// TokenReader 在固定区块上通过ERC721Enumerable批量读取一个部署的主、子NFT合约，
+// 同一次列表查询的各批调用看到同一个链上状态。配置了Multicall3时每批合并为一次eth_call，否则使用JSON-RPC批量请求
+type TokenReader struct {
+	Client    *EthClient
+	Block     *big.Int // 读取的区块
+	BatchSize int
+	Multicall *common.Address // 为nil时使用JSON-RPC批量请求
+}
+
+// contractCall 一次只读合约调用
+type contractCall struct {
+	To   common.Address
+	Data []byte
+}
+
+// callResult 只读调用的结果，Err为errCallReverted表示调用回滚
+type callResult struct {
+	Data []byte
+	Err  error
+}
+
+// call3 Multicall3.aggregate3的单个调用
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// multicallResult Multicall3.aggregate3的单个结果
+type multicallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// NewTokenReader 创建读取当前最新区块的读取器
+func (ec *EthClient) NewTokenReader(ctx context.Context) (*TokenReader, error) {
+	block, err := ec.Client.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取最新区块失败: %v", err)
+	}
+	reader := &TokenReader{
+		Client:    ec,
+		Block:     new(big.Int).SetUint64(block),
+		BatchSize: int(ec.Config.ChainReadBatchSize),
+	}
+	if reader.BatchSize <= 0 {
+		reader.BatchSize = defaultReadBatchSize
+	}
+	if address := ec.Config.Multicall3Address; address != "" {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("无效的Multicall3地址: %s", address)
+		}
+		multicall := common.HexToAddress(address)
+		reader.Multicall = &multicall
+	}
+	return reader, nil
+}
+
+// ListTokens 通过totalSupply和tokenByIndex列出合约的全部token
+func (r *TokenReader) ListTokens(ctx context.Context, contractType string) ([]*big.Int, error) {
+	parsed, address, err := r.contract(contractType)
+	if err != nil {
+		return nil, err
+	}
+	total, err := r.callUint(ctx, parsed, address, "totalSupply")
+	if err != nil {
+		return nil, fmt.Errorf("无法获取总供应量: %v", err)
+	}
+	return r.enumerate(ctx, parsed, address, 0, total, "tokenByIndex")
+}
+
+// ListTokensRange 通过tokenByIndex只列出索引[offset, offset+limit)内的token，同时返回totalSupply
+func (r *TokenReader) ListTokensRange(ctx context.Context, contractType string, offset, limit int64) ([]*big.Int, *big.Int, error) {
+	parsed, address, err := r.contract(contractType)
+	if err != nil {
+		return nil, nil, err
+	}
+	total, err := r.callUint(ctx, parsed, address, "totalSupply")
+	if err != nil {
+		return nil, nil, fmt.Errorf("无法获取总供应量: %v", err)
+	}
+	end := new(big.Int).SetInt64(offset + limit)
+	if end.Cmp(total) > 0 {
+		end = total
+	}
+	tokenIDs, err := r.enumerate(ctx, parsed, address, offset, end, "tokenByIndex")
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokenIDs, total, nil
+}
+
+// TokensOfOwner 通过balanceOf和tokenOfOwnerByIndex列出地址持有的token
+func (r *TokenReader) TokensOfOwner(ctx context.Context, contractType string, owner common.Address) ([]*big.Int, error) {
+	parsed, address, err := r.contract(contractType)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := r.callUint(ctx, parsed, address, "balanceOf", owner)
+	if err != nil {
+		return nil, fmt.Errorf("无法获取持有数量: %v", err)
+	}
+	return r.enumerate(ctx, parsed, address, 0, balance, "tokenOfOwnerByIndex", owner)
+}
+
+// ReadTokens 批量读取token的持有者和URI，子NFT同时读取父tokenID；已销毁或不存在的token不返回
+func (r *TokenReader) ReadTokens(ctx context.Context, contractType string, tokenIDs []*big.Int) ([]TokenState, error) {
+	parsed, address, err := r.contract(contractType)
+	if err != nil {
+		return nil, err
+	}
+	methods := []string{"ownerOf", "tokenURI"}
+	if contractType == "child" {
+		methods = append(methods, "getParentTokenId")
+	}
+
+	calls := make([]contractCall, 0, len(tokenIDs)*len(methods))
+	for _, tokenID := range tokenIDs {
+		for _, method := range methods {
+			data, err := parsed.Pack(method, tokenID)
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, contractCall{To: address, Data: data})
+		}
+	}
+	results, err := r.batchCall(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]TokenState, 0, len(tokenIDs))
+	for i, tokenID := range tokenIDs {
+		tokenResults := results[i*len(methods) : (i+1)*len(methods)]
+		// ownerOf回滚表示token不存在
+		if errors.Is(tokenResults[0].Err, errCallReverted) {
+			continue
+		}
+		values := make([]interface{}, len(methods))
+		for j, method := range methods {
+			if tokenResults[j].Err != nil {
+				return nil, fmt.Errorf("读取token %s 的%s失败: %v", tokenID, method, tokenResults[j].Err)
+			}
+			out, err := parsed.Unpack(method, tokenResults[j].Data)
+			if err != nil || len(out) == 0 {
+				return nil, fmt.Errorf("解析token %s 的%s失败: %v", tokenID, method, err)
+			}
+			values[j] = out[0]
+		}
+		state := TokenState{TokenID: tokenID}
+		var ok bool
+		if state.Owner, ok = values[0].(common.Address); !ok {
+			return nil, fmt.Errorf("token %s 的ownerOf返回类型无效", tokenID)
+		}
+		if state.URI, ok = values[1].(string); !ok {
+			return nil, fmt.Errorf("token %s 的tokenURI返回类型无效", tokenID)
+		}
+		if len(values) > 2 {
+			if state.ParentTokenID, ok = values[2].(*big.Int); !ok {
+				return nil, fmt.Errorf("token %s 的getParentTokenId返回类型无效", tokenID)
+			}
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// contract 返回main或child合约的ABI和地址
+func (r *TokenReader) contract(contractType string) (*abi.ABI, common.Address, error) {
+	metadata := mainnft.MainnftMetaData
+	if contractType == "child" {
+		metadata = childnft.ChildnftMetaData
+	}
+	parsed, err := metadata.GetAbi()
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	return parsed, common.HexToAddress(r.Client.ContractAddress(contractType)), nil
+}
+
+// callUint 调用返回uint256的方法
+func (r *TokenReader) callUint(ctx context.Context, parsed *abi.ABI, address common.Address, method string, args ...interface{}) (*big.Int, error) {
+	data, err := parsed.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := r.Client.Client.CallContract(ctx, ethereum.CallMsg{To: &address, Data: data}, r.Block)
+	if err != nil {
+		return nil, err
+	}
+	out, err := parsed.Unpack(method, output)
+	if err != nil || len(out) == 0 {
+		return nil, fmt.Errorf("解析%s返回值失败: %v", method, err)
+	}
+	value, ok := out[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("%s返回类型无效", method)
+	}
+	return value, nil
+}
+
+// enumerate 以索引start..end-1批量调用tokenByIndex或tokenOfOwnerByIndex，prefix为索引前的参数
+func (r *TokenReader) enumerate(ctx context.Context, parsed *abi.ABI, address common.Address, start int64, end *big.Int, method string, prefix ...interface{}) ([]*big.Int, error) {
+	if !end.IsInt64() {
+		return nil, fmt.Errorf("token数量过大: %s", end)
+	}
+	if start < 0 || start >= end.Int64() {
+		return nil, nil
+	}
+	calls := make([]contractCall, 0, end.Int64()-start)
+	for i := start; i < end.Int64(); i++ {
+		args := append(append([]interface{}{}, prefix...), big.NewInt(i))
+		data, err := parsed.Pack(method, args...)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, contractCall{To: address, Data: data})
+	}
+	results, err := r.batchCall(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenIDs := make([]*big.Int, 0, len(results))
+	for i, result := range results {
+		if result.Err != nil {
+			return nil, fmt.Errorf("%s(%d)失败: %v", method, start+int64(i), result.Err)
+		}
+		out, err := parsed.Unpack(method, result.Data)
+		if err != nil || len(out) == 0 {
+			return nil, fmt.Errorf("解析%s(%d)返回值失败: %v", method, start+int64(i), err)
+		}
+		tokenID, ok := out[0].(*big.Int)
+		if !ok {
+			return nil, fmt.Errorf("%s返回类型无效", method)
+		}
+		tokenIDs = append(tokenIDs, tokenID)
+	}
+	return tokenIDs, nil
+}
+
+// batchCall 按BatchSize分批执行只读调用，结果顺序与calls一致；单个调用回滚记入结果，请求失败时返回错误
+func (r *TokenReader) batchCall(ctx context.Context, calls []contractCall) ([]callResult, error) {
+	results := make([]callResult, 0, len(calls))
+	for start := 0; start < len(calls); start += r.BatchSize {
+		end := start + r.BatchSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		var batch []callResult
+		var err error
+		if r.Multicall != nil {
+			batch, err = r.multicall(ctx, calls[start:end])
+		} else {
+			batch, err = r.rpcBatch(ctx, calls[start:end])
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, batch...)
+	}
+	return results, nil
+}
+
+// rpcBatch 把一批eth_call合并为一个JSON-RPC批量请求
+func (r *TokenReader) rpcBatch(ctx context.Context, calls []contractCall) ([]callResult, error) {
+	block := hexutil.EncodeBig(r.Block)
+	elems := make([]rpc.BatchElem, len(calls))
+	outputs := make([]hexutil.Bytes, len(calls))
+	for i, call := range calls {
+		elems[i] = rpc.BatchElem{
+			Method: "eth_call",
+			Args:   []interface{}{map[string]interface{}{"to": call.To, "data": hexutil.Bytes(call.Data)}, block},
+			Result: &outputs[i],
+		}
+	}
+	if err := r.Client.Client.Client().BatchCallContext(ctx, elems); err != nil {
+		return nil, fmt.Errorf("批量读取合约失败: %v", err)
+	}
+
+	results := make([]callResult, len(calls))
+	for i, elem := range elems {
+		switch {
+		case elem.Error == nil:
+			results[i].Data = outputs[i]
+		case isRevert(elem.Error):
+			results[i].Err = errCallReverted
+		default:
+			return nil, fmt.Errorf("批量读取合约失败: %v", elem.Error)
+		}
+	}
+	return results, nil
+}
+
+// multicall 把一批调用合并为一次Multicall3.aggregate3调用
+func (r *TokenReader) multicall(ctx context.Context, calls []contractCall) ([]callResult, error) {
+	packed := make([]call3, len(calls))
+	for i, call := range calls {
+		packed[i] = call3{Target: call.To, AllowFailure: true, CallData: call.Data}
+	}
+	data, err := parsedMulticall3ABI.Pack("aggregate3", packed)
+	if err != nil {
+		return nil, err
+	}
+	output, err := r.Client.Client.CallContract(ctx, ethereum.CallMsg{To: r.Multicall, Data: data}, r.Block)
+	if err != nil {
+		return nil, fmt.Errorf("Multicall3调用失败: %v", err)
+	}
+	out, err := parsedMulticall3ABI.Unpack("aggregate3", output)
+	if err != nil || len(out) == 0 {
+		return nil, fmt.Errorf("解析Multicall3返回值失败: %v", err)
+	}
+	returned := *abi.ConvertType(out[0], new([]multicallResult)).(*[]multicallResult)
+	if len(returned) != len(calls) {
+		return nil, fmt.Errorf("Multicall3返回 %d 个结果，应为 %d 个", len(returned), len(calls))
+	}
+
+	results := make([]callResult, len(calls))
+	for i, result := range returned {
+		if !result.Success {
+			results[i].Err = errCallReverted
+			continue
+		}
+		results[i].Data = result.ReturnData
+	}
+	return results, nil
+}
+
+// isRevert 判断eth_call的错误是否为合约回滚
+func isRevert(err error) bool {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "revert")
+}
+
+// mustParseABI 解析内置的ABI
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
@@ -0,0 +1,167 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+)
+
+// 主合约EIP-712域和签名请求类型，与MainNFT.sol保持一致
+const (
+	TypedDataDomainName           = "MainABE"
+	TypedDataDomainVersion        = "1"
+	PrimaryTypeMintRequest        = "MintRequest"
+	PrimaryTypeCreateChildRequest = "CreateChildRequest"
+)
+
+var (
+	// ErrMetaTxExpired 签名请求已超过截止时间
+	ErrMetaTxExpired = errors.New("签名请求已过期")
+	// ErrMetaTxNonce 签名请求的nonce与主合约中的当前值不一致（已使用或尚未轮到）
+	ErrMetaTxNonce = errors.New("签名请求的nonce已失效")
+	// ErrMetaTxSignature 签名与请求内容不符，或签名者不是请求中的账户
+	ErrMetaTxSignature = errors.New("签名请求的签名无效")
+)
+
+// typedDataTypes 签名请求的EIP-712类型定义
+var typedDataTypes = apitypes.Types{
+	"EIP712Domain": {
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	},
+	PrimaryTypeMintRequest: {
+		{Name: "to", Type: "address"},
+		{Name: "uri", Type: "string"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "deadline", Type: "uint256"},
+	},
+	PrimaryTypeCreateChildRequest: {
+		{Name: "owner", Type: "address"},
+		{Name: "parentTokenId", Type: "uint256"},
+		{Name: "receiver", Type: "address"},
+		{Name: "uri", Type: "string"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "deadline", Type: "uint256"},
+	},
+}
+
+// MintRequestTypedData 构造用户铸造主NFT时需要签名的EIP-712数据
+func (ec *EthClient) MintRequestTypedData(to common.Address, uri string, nonce, deadline *big.Int) apitypes.TypedData {
+	return ec.typedData(PrimaryTypeMintRequest, apitypes.TypedDataMessage{
+		"to":       to.Hex(),
+		"uri":      uri,
+		"nonce":    nonce.String(),
+		"deadline": deadline.String(),
+	})
+}
+
+// CreateChildRequestTypedData 构造父NFT持有者创建子NFT时需要签名的EIP-712数据
+func (ec *EthClient) CreateChildRequestTypedData(owner common.Address, parentTokenID *big.Int, receiver common.Address, uri string, nonce, deadline *big.Int) apitypes.TypedData {
+	return ec.typedData(PrimaryTypeCreateChildRequest, apitypes.TypedDataMessage{
+		"owner":         owner.Hex(),
+		"parentTokenId": parentTokenID.String(),
+		"receiver":      receiver.Hex(),
+		"uri":           uri,
+		"nonce":         nonce.String(),
+		"deadline":      deadline.String(),
+	})
+}
+
+// typedData 用主合约的域构造类型化数据，只包含域和主类型的定义
+func (ec *EthClient) typedData(primaryType string, message apitypes.TypedDataMessage) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": typedDataTypes["EIP712Domain"],
+			primaryType:    typedDataTypes[primaryType],
+		},
+		PrimaryType: primaryType,
+		Domain: apitypes.TypedDataDomain{
+			Name:              TypedDataDomainName,
+			Version:           TypedDataDomainVersion,
+			ChainId:           math.NewHexOrDecimal256(ec.Config.ChainID),
+			VerifyingContract: common.HexToAddress(ec.Config.MainNFTAddress).Hex(),
+		},
+		Message: message,
+	}
+}
+
+// MetaTxNonce 查询账户在主合约中下一个可用的签名nonce
+func (ec *EthClient) MetaTxNonce(account common.Address) (*big.Int, error) {
+	nonce, err := ec.MainNFT.Nonces(ec.CallOpts, account)
+	if err != nil {
+		return nil, fmt.Errorf("查询签名nonce失败: %v", err)
+	}
+	return nonce, nil
+}
+
+// CheckMetaTx 检查签名请求在提交前仍然有效：未过期且nonce等于主合约中的当前值
+func (ec *EthClient) CheckMetaTx(account common.Address, nonce, deadline *big.Int) error {
+	if deadline.Cmp(big.NewInt(time.Now().Unix())) <= 0 {
+		return ErrMetaTxExpired
+	}
+	current, err := ec.MetaTxNonce(account)
+	if err != nil {
+		return err
+	}
+	if current.Cmp(nonce) != 0 {
+		return fmt.Errorf("%w: 当前为 %s", ErrMetaTxNonce, current.String())
+	}
+	return nil
+}
+
+// CheckCreateChildSignature 提交前检查父NFT持有者对CreateChildRequest的签名：签名者为owner，且请求未过期、nonce为当前值。
+// 合约提交时会再次验证，这里提前拒绝，避免占用申请并发送必然回滚的交易
+func (ec *EthClient) CheckCreateChildSignature(owner common.Address, parentTokenID *big.Int, receiver common.Address, uri string, nonce, deadline *big.Int, signature string) error {
+	typedData := ec.CreateChildRequestTypedData(owner, parentTokenID, receiver, uri, nonce, deadline)
+	signer, err := util.RecoverTypedDataSigner(typedData, signature)
+	if err != nil || signer != owner {
+		return ErrMetaTxSignature
+	}
+	return ec.CheckMetaTx(owner, nonce, deadline)
+}
+
+// MintNFTWithSignature 提交用户签名的MintRequest，由合约验证签名后铸造给签名者
+func (ec *EthClient) MintNFTWithSignature(to common.Address, uri string, deadline *big.Int, signature string) (string, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return "", fmt.Errorf("签名格式无效: %v", err)
+	}
+	operation := func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		tx, err := ec.MainNFT.MintWithSignature(auth, to, uri, deadline, sig)
+		if err != nil {
+			return nil, fmt.Errorf("铸造NFT失败: %v", err)
+		}
+		return tx, nil
+	}
+
+	return ec.PerformContractOperation(TxKindMint, operation)
+}
+
+// CreateChildNFTWithSignature 提交父NFT持有者签名的CreateChildRequest，由合约验证签名和所有权后创建子NFT
+func (ec *EthClient) CreateChildNFTWithSignature(owner common.Address, parentTokenID *big.Int, receiver common.Address, uri string, deadline *big.Int, signature string) (string, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return "", fmt.Errorf("签名格式无效: %v", err)
+	}
+	operation := func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		tx, err := ec.MainNFT.CreateChildWithSignature(auth, owner, parentTokenID, receiver, uri, deadline, sig)
+		if err != nil {
+			return nil, fmt.Errorf("创建子NFT失败: %v", err)
+		}
+		return tx, nil
+	}
+
+	return ec.PerformContractOperation(TxKindCreateChild, operation)
+}
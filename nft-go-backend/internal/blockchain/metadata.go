@@ -0,0 +1,380 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ABE/nft/nft-go-backend/internal/config"
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+)
+
+// 元数据缓存最多保留的URI和文档数
+const metadataCacheLimit = 4096
+
+// metadataMaxRedirects 下载tokenURI中的https元数据时最多跟随的重定向次数
+const metadataMaxRedirects = 3
+
+// 元数据解析错误
+var (
+	ErrMetadataURIEmpty       = errors.New("tokenURI为空")
+	ErrMetadataUnsupportedURI = errors.New("不支持的元数据URI")
+	ErrMetadataTooLarge       = errors.New("元数据过大")
+	ErrMetadataContentType    = errors.New("元数据的Content-Type不是JSON")
+	ErrMetadataInvalid        = errors.New("元数据不符合ERC-721元数据格式")
+)
+
+// metadataStringFields ERC-721元数据（及常见扩展）中必须为字符串的字段
+var metadataStringFields = []string{"name", "description", "image", "external_url", "animation_url", "image_data", "background_color"}
+
+// metadataURIEntry URI到文档内容哈希的缓存，expiresAt为零表示内容寻址的URI，不过期
+type metadataURIEntry struct {
+	hash      string
+	expiresAt time.Time
+}
+
+// metadataDocument 按内容哈希缓存的校验结果，err不为nil表示文档不符合格式
+type metadataDocument struct {
+	metadata *models.NFTMetadata
+	err      error
+}
+
+// MetadataResolver 解析tokenURI指向的NFT元数据，支持ipfs://、ar://、https://、data:application/json和内联JSON；
+// 下载有超时、大小和Content-Type限制，内容按ERC-721元数据格式校验，结果按URI和内容哈希缓存。
+// tokenURI由用户设置，其中的https地址只通过PublicClient访问公网；ipfs和ar通过运维配置的网关访问，网关可以是本机地址
+type MetadataResolver struct {
+	Client         *http.Client  // 访问配置的IPFS、Arweave网关
+	PublicClient   *http.Client  // 访问tokenURI中的http(s)地址，拒绝内网和本机地址，限制重定向次数
+	IPFSGateway    string        // IPFS网关前缀，如 http://localhost:8080/ipfs/
+	ArweaveGateway string        // Arweave网关前缀，如 https://arweave.net/
+	MaxBytes       int64         // 元数据文档的最大字节数
+	CacheTTL       time.Duration // https元数据的缓存时间，0表示不缓存；ipfs、ar和data URI按内容寻址，始终缓存
+	AllowHTTP      bool          // 是否允许明文http的tokenURI，只在开发模式下开启
+
+	mu     sync.Mutex
+	byURI  map[string]metadataURIEntry
+	byHash map[string]metadataDocument
+}
+
+// NewMetadataResolver 按配置创建元数据解析器
+func NewMetadataResolver(cfg *config.Config) *MetadataResolver {
+	timeout := time.Duration(cfg.MetadataTimeout) * time.Second
+	return &MetadataResolver{
+		Client:         &http.Client{Timeout: timeout},
+		PublicClient:   util.NewPublicHTTPClient(timeout, metadataMaxRedirects),
+		IPFSGateway:    gatewayPrefix(cfg.IPFSGateway),
+		ArweaveGateway: gatewayPrefix(cfg.ArweaveGateway),
+		MaxBytes:       cfg.MetadataMaxBytes,
+		CacheTTL:       time.Duration(cfg.MetadataCacheTTL) * time.Second,
+		AllowHTTP:      cfg.DevMode,
+		byURI:          make(map[string]metadataURIEntry),
+		byHash:         make(map[string]metadataDocument),
+	}
+}
+
+// FetchMetadata 获取NFT元数据，元数据无效时返回错误而不是占位数据
+func (ec *EthClient) FetchMetadata(ctx context.Context, uri string) (*models.NFTMetadata, error) {
+	if ec.Metadata == nil {
+		return nil, fmt.Errorf("未配置元数据解析器")
+	}
+	return ec.Metadata.Resolve(ctx, uri)
+}
+
+// Resolve 解析tokenURI；下载失败不缓存，内容无效的结果按内容哈希缓存
+func (r *MetadataResolver) Resolve(ctx context.Context, uri string) (*models.NFTMetadata, error) {
+	uri = strings.TrimSpace(uri)
+	if uri == "" {
+		return nil, ErrMetadataURIEmpty
+	}
+	if doc, ok := r.cached(uri); ok {
+		return doc.result()
+	}
+
+	raw, immutable, err := r.load(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	hash := hex.EncodeToString(sum[:])
+
+	r.mu.Lock()
+	doc, ok := r.byHash[hash]
+	r.mu.Unlock()
+	if !ok {
+		metadata, err := ValidateMetadata(raw)
+		doc = metadataDocument{metadata: metadata, err: err}
+	}
+	r.store(uri, hash, doc, immutable)
+	return doc.result()
+}
+
+// load 按URI的scheme读取元数据原文，immutable表示内容由URI寻址
+func (r *MetadataResolver) load(ctx context.Context, uri string) ([]byte, bool, error) {
+	// 部分旧token直接把JSON写在tokenURI中
+	if strings.HasPrefix(uri, "{") {
+		if int64(len(uri)) > r.MaxBytes {
+			return nil, false, fmt.Errorf("%w: 超过 %d 字节", ErrMetadataTooLarge, r.MaxBytes)
+		}
+		return []byte(uri), true, nil
+	}
+	if strings.HasPrefix(strings.ToLower(uri), "data:") {
+		raw, err := r.decodeDataURI(uri)
+		return raw, true, err
+	}
+
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		return nil, false, fmt.Errorf("%w: %s", ErrMetadataUnsupportedURI, uri)
+	}
+	switch strings.ToLower(scheme) {
+	case "ipfs":
+		// 兼容 ipfs://ipfs/<CID> 写法
+		path := strings.TrimPrefix(rest, "ipfs/")
+		if path == "" {
+			return nil, false, fmt.Errorf("%w: 缺少CID", ErrMetadataUnsupportedURI)
+		}
+		raw, err := r.fetch(ctx, r.Client, r.IPFSGateway+path, true)
+		return raw, true, err
+	case "ar":
+		if rest == "" {
+			return nil, false, fmt.Errorf("%w: 缺少交易ID", ErrMetadataUnsupportedURI)
+		}
+		raw, err := r.fetch(ctx, r.Client, r.ArweaveGateway+rest, true)
+		return raw, true, err
+	case "https":
+		raw, err := r.fetch(ctx, r.PublicClient, uri, false)
+		return raw, false, err
+	case "http":
+		if !r.AllowHTTP {
+			return nil, false, fmt.Errorf("%w: 只在开发模式下允许http", ErrMetadataUnsupportedURI)
+		}
+		raw, err := r.fetch(ctx, r.PublicClient, uri, false)
+		return raw, false, err
+	}
+	return nil, false, fmt.Errorf("%w: %s", ErrMetadataUnsupportedURI, scheme)
+}
+
+// fetch 下载元数据文档，限制大小与超时，并检查Content-Type；gateway表示从配置的IPFS或Arweave网关下载
+func (r *MetadataResolver) fetch(ctx context.Context, client *http.Client, documentURL string, gateway bool) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, documentURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMetadataUnsupportedURI, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载元数据失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载元数据失败: HTTP %d", resp.StatusCode)
+	}
+	if err := checkMetadataContentType(resp.Header.Get("Content-Type"), gateway); err != nil {
+		return nil, err
+	}
+	if resp.ContentLength > r.MaxBytes {
+		return nil, fmt.Errorf("%w: 超过 %d 字节", ErrMetadataTooLarge, r.MaxBytes)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, r.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取元数据失败: %v", err)
+	}
+	if int64(len(body)) > r.MaxBytes {
+		return nil, fmt.Errorf("%w: 超过 %d 字节", ErrMetadataTooLarge, r.MaxBytes)
+	}
+	return body, nil
+}
+
+// checkMetadataContentType 接受JSON类型。IPFS、Arweave网关按内容嗅探，JSON文件常返回text/plain、application/octet-stream
+// 或不带Content-Type，只有gateway为true（来自配置的网关）时才接受这些类型
+func checkMetadataContentType(contentType string, gateway bool) error {
+	if contentType == "" {
+		if gateway {
+			return nil
+		}
+		return fmt.Errorf("%w: 缺少Content-Type", ErrMetadataContentType)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrMetadataContentType, contentType)
+	}
+	switch {
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		return nil
+	case gateway && (mediaType == "text/plain" || mediaType == "application/octet-stream"):
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrMetadataContentType, mediaType)
+}
+
+// decodeDataURI 解码 data:application/json[;base64],... 形式的URI
+func (r *MetadataResolver) decodeDataURI(uri string) ([]byte, error) {
+	header, data, ok := strings.Cut(uri[len("data:"):], ",")
+	if !ok {
+		return nil, fmt.Errorf("%w: data URI缺少逗号", ErrMetadataUnsupportedURI)
+	}
+	isBase64 := false
+	if trimmed, found := strings.CutSuffix(strings.ToLower(header), ";base64"); found {
+		header, isBase64 = header[:len(trimmed)], true
+	}
+	// 只看媒体类型，忽略 ;charset=utf-8、;utf8 等参数
+	mediaType, _, _ := strings.Cut(header, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return nil, fmt.Errorf("%w: data URI的媒体类型为 %q", ErrMetadataContentType, header)
+	}
+
+	// 解码前先按编码长度粗略判断，避免为过大的内容分配内存
+	if int64(len(data)) > r.MaxBytes*4/3+4 {
+		return nil, fmt.Errorf("%w: 超过 %d 字节", ErrMetadataTooLarge, r.MaxBytes)
+	}
+	var raw []byte
+	var err error
+	if isBase64 {
+		raw, err = base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			raw, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+		}
+	} else {
+		var text string
+		text, err = url.PathUnescape(data)
+		raw = []byte(text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: data URI解码失败: %v", ErrMetadataInvalid, err)
+	}
+	if int64(len(raw)) > r.MaxBytes {
+		return nil, fmt.Errorf("%w: 超过 %d 字节", ErrMetadataTooLarge, r.MaxBytes)
+	}
+	return raw, nil
+}
+
+// ValidateMetadata 按ERC-721元数据JSON格式校验文档：必须是JSON对象，name、description、image等字段为字符串，
+// attributes为对象数组，每项的trait_type为字符串、value为字符串、数字或布尔值；未知字段保留不校验
+func ValidateMetadata(raw []byte) (*models.NFTMetadata, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil || fields == nil {
+		return nil, fmt.Errorf("%w: 不是JSON对象", ErrMetadataInvalid)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("%w: JSON对象后有多余内容", ErrMetadataInvalid)
+	}
+
+	var problems []string
+	for _, field := range metadataStringFields {
+		if value, ok := fields[field]; ok && value != nil {
+			if _, isString := value.(string); !isString {
+				problems = append(problems, field+"必须是字符串")
+			}
+		}
+	}
+	if value, ok := fields["attributes"]; ok && value != nil {
+		attributes, isArray := value.([]interface{})
+		if !isArray {
+			problems = append(problems, "attributes必须是数组")
+		}
+		for i, item := range attributes {
+			attribute, isObject := item.(map[string]interface{})
+			if !isObject {
+				problems = append(problems, fmt.Sprintf("attributes[%d]必须是对象", i))
+				continue
+			}
+			if traitType, ok := attribute["trait_type"]; ok {
+				if _, isString := traitType.(string); !isString {
+					problems = append(problems, fmt.Sprintf("attributes[%d].trait_type必须是字符串", i))
+				}
+			}
+			switch attribute["value"].(type) {
+			case string, json.Number, bool:
+			default:
+				problems = append(problems, fmt.Sprintf("attributes[%d].value必须是字符串、数字或布尔值", i))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMetadataInvalid, strings.Join(problems, "；"))
+	}
+
+	var metadata models.NFTMetadata
+	if err := json.Unmarshal(raw, &metadata); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMetadataInvalid, err)
+	}
+	return &metadata, nil
+}
+
+// cached 返回URI未过期的缓存结果
+func (r *MetadataResolver) cached(uri string) (metadataDocument, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.byURI[uri]
+	if !ok {
+		return metadataDocument{}, false
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		delete(r.byURI, uri)
+		return metadataDocument{}, false
+	}
+	doc, ok := r.byHash[entry.hash]
+	return doc, ok
+}
+
+// store 写入缓存，超出上限时先清理过期条目，仍然超出则清空
+func (r *MetadataResolver) store(uri, hash string, doc metadataDocument, immutable bool) {
+	var expiresAt time.Time
+	if !immutable {
+		if r.CacheTTL <= 0 {
+			return
+		}
+		expiresAt = time.Now().Add(r.CacheTTL)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.byURI) >= metadataCacheLimit || len(r.byHash) >= metadataCacheLimit {
+		now := time.Now()
+		for key, entry := range r.byURI {
+			if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+				delete(r.byURI, key)
+			}
+		}
+		if len(r.byURI) >= metadataCacheLimit || len(r.byHash) >= metadataCacheLimit {
+			r.byURI = make(map[string]metadataURIEntry)
+			r.byHash = make(map[string]metadataDocument)
+		}
+	}
+	r.byURI[uri] = metadataURIEntry{hash: hash, expiresAt: expiresAt}
+	r.byHash[hash] = doc
+}
+
+// result 返回缓存元数据的副本，调用方可以修改
+func (doc metadataDocument) result() (*models.NFTMetadata, error) {
+	if doc.err != nil {
+		return nil, doc.err
+	}
+	metadata := *doc.metadata
+	metadata.Attributes = append([]models.Attribute(nil), doc.metadata.Attributes...)
+	return &metadata, nil
+}
+
+// gatewayPrefix 保证网关前缀以/结尾
+func gatewayPrefix(gateway string) string {
+	if gateway != "" && !strings.HasSuffix(gateway, "/") {
+		gateway += "/"
+	}
+	return gateway
+}
@@ -0,0 +1,155 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ABE/nft/nft-go-backend/internal/config"
+	"github.com/ABE/nft/nft-go-backend/internal/util"
+)
+
+func TestValidateMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		ok   bool
+	}{
+		{"完整元数据", `{"name":"A","description":"B","image":"ipfs://img","attributes":[{"trait_type":"级别","value":3},{"value":true},{"trait_type":"科室","value":"内科"}]}`, true},
+		{"未知字段保留", `{"name":"A","custom":{"nested":[1,2]}}`, true},
+		{"空对象", `{}`, true},
+		{"字段为null", `{"name":null,"attributes":null}`, true},
+		{"不是对象", `["name"]`, false},
+		{"JSON null", `null`, false},
+		{"无效JSON", `{"name":`, false},
+		{"多余内容", `{"name":"A"}{"name":"B"}`, false},
+		{"name不是字符串", `{"name":1}`, false},
+		{"image不是字符串", `{"image":{"url":"x"}}`, false},
+		{"attributes不是数组", `{"attributes":{"trait_type":"a","value":1}}`, false},
+		{"attribute不是对象", `{"attributes":["a"]}`, false},
+		{"trait_type不是字符串", `{"attributes":[{"trait_type":1,"value":"a"}]}`, false},
+		{"value缺失", `{"attributes":[{"trait_type":"a"}]}`, false},
+		{"value是对象", `{"attributes":[{"trait_type":"a","value":{"x":1}}]}`, false},
+	}
+	for _, tt := range tests {
+		metadata, err := ValidateMetadata([]byte(tt.raw))
+		if tt.ok && (err != nil || metadata == nil) {
+			t.Errorf("%s: 应通过，得到 %v", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrMetadataInvalid) {
+			t.Errorf("%s: 应返回ErrMetadataInvalid，得到 %v", tt.name, err)
+		}
+	}
+}
+
+func TestDecodeDataURI(t *testing.T) {
+	resolver := &MetadataResolver{MaxBytes: 64}
+	tests := []struct {
+		uri  string
+		want string
+		err  error
+	}{
+		{`data:application/json,{"name":"A"}`, `{"name":"A"}`, nil},
+		{`data:application/json;charset=utf-8,%7B%22name%22%3A%22A%22%7D`, `{"name":"A"}`, nil},
+		{`data:application/json;base64,eyJuYW1lIjoiQSJ9`, `{"name":"A"}`, nil},
+		{`data:Application/JSON;BASE64,eyJuYW1lIjoiQSJ9`, `{"name":"A"}`, nil},
+		{`data:application/json;base64,eyJuYW1lIjoiQSJ9==`, `{"name":"A"}`, nil},
+		{`data:application/ld+json;base64,eyJuYW1lIjoiQSJ9`, `{"name":"A"}`, nil},
+		{`data:application/json;utf8,{"name":"A"}`, `{"name":"A"}`, nil},
+		{`data:application/json`, "", ErrMetadataUnsupportedURI},
+		{`data:text/html,<script></script>`, "", ErrMetadataContentType},
+		{`data:,{"name":"A"}`, "", ErrMetadataContentType},
+		{`data:application/json;base64,!!!`, "", ErrMetadataInvalid},
+		{`data:application/json,%zz`, "", ErrMetadataInvalid},
+		{`data:application/json,{"description":"` + string(make([]byte, 100)) + `"}`, "", ErrMetadataTooLarge},
+	}
+	for _, tt := range tests {
+		raw, err := resolver.decodeDataURI(tt.uri)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%.60s: 应返回 %v，得到 %v", tt.uri, tt.err, err)
+			}
+			continue
+		}
+		if err != nil || string(raw) != tt.want {
+			t.Errorf("%.60s -> %q, %v，期望 %s", tt.uri, raw, err, tt.want)
+		}
+	}
+}
+
+func TestCheckMetadataContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		gateway     bool
+		ok          bool
+	}{
+		{"application/json", false, true},
+		{"application/json; charset=utf-8", false, true},
+		{"application/ld+json", false, true},
+		{"", false, false},
+		{"text/plain", false, false},
+		{"application/octet-stream", false, false},
+		{"text/html", false, false},
+		{"", true, true},
+		{"text/plain; charset=utf-8", true, true},
+		{"application/octet-stream", true, true},
+		{"text/html", true, false},
+		{"invalid;;", true, false},
+	}
+	for _, tt := range tests {
+		err := checkMetadataContentType(tt.contentType, tt.gateway)
+		if (err == nil) != tt.ok {
+			t.Errorf("%q (gateway=%v): err=%v，期望通过=%v", tt.contentType, tt.gateway, err, tt.ok)
+		}
+	}
+}
+
+func TestMetadataFetchOnlyTrustsGateways(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/ipfs/QmDoc", http.StatusFound)
+			return
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(`{"name":"A"}`))
+	}))
+	defer server.Close()
+
+	resolver := NewMetadataResolver(&config.Config{
+		IPFSGateway:      server.URL + "/ipfs",
+		MetadataTimeout:  5,
+		MetadataMaxBytes: 1024,
+		DevMode:          true,
+	})
+	ctx := context.Background()
+
+	// 配置的网关即使是本机地址、返回text/plain也可以使用
+	if metadata, err := resolver.Resolve(ctx, "ipfs://QmDoc"); err != nil || metadata.Name != "A" {
+		t.Fatalf("应能从网关读取元数据: %+v %v", metadata, err)
+	}
+	// tokenURI中的地址不能指向本机
+	if _, err := resolver.Resolve(ctx, server.URL+"/doc.json"); err == nil || !strings.Contains(err.Error(), util.ErrForbiddenAddress.Error()) {
+		t.Fatalf("应拒绝访问本机地址，得到 %v", err)
+	}
+
+	// 即使能连上，非网关地址返回的text/plain也不接受，重定向次数有上限
+	resolver.PublicClient = server.Client()
+	resolver.PublicClient.CheckRedirect = util.NewPublicHTTPClient(time.Second, metadataMaxRedirects).CheckRedirect
+	if _, err := resolver.Resolve(ctx, server.URL+"/doc.json"); !errors.Is(err, ErrMetadataContentType) {
+		t.Fatalf("非网关地址的text/plain应被拒绝，得到 %v", err)
+	}
+	if _, err := resolver.Resolve(ctx, server.URL+"/redirect"); !errors.Is(err, ErrMetadataContentType) {
+		t.Fatalf("重定向后仍按非网关地址检查，得到 %v", err)
+	}
+	if _, err := resolver.Resolve(ctx, server.URL+"/loop"); err == nil {
+		t.Fatal("循环重定向应失败")
+	}
+}
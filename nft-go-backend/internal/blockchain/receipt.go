@@ -0,0 +1,212 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+	"github.com/ABE/nft/nft-go-backend/pkg/childnft"
+	"github.com/ABE/nft/nft-go-backend/pkg/mainnft"
+)
+
+// receiptPollInterval 轮询交易回执的间隔
+const receiptPollInterval = time.Second
+
+// ErrTransactionReverted 交易已上链但执行失败
+var ErrTransactionReverted = errors.New("交易执行失败（已回滚）")
+
+// isReceiptPending 查询回执的错误是否表示暂时没有回执：交易尚未打包，
+// 或节点仍在建立交易索引（geth此时返回"transaction indexing is in progress"而不是NotFound）
+func isReceiptPending(err error) bool {
+	return errors.Is(err, ethereum.NotFound) || strings.Contains(err.Error(), "transaction indexing is in progress")
+}
+
+// ChildMintResult 从创建子NFT交易回执中解析出的结果
+type ChildMintResult struct {
+	TxHash        common.Hash
+	BlockNumber   uint64
+	ParentTokenID *big.Int
+	ChildTokenID  *big.Int
+	Receiver      common.Address
+}
+
+// WaitForReceipt 轮询直到交易被打包或ctx结束
+func (ec *EthClient) WaitForReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+	for {
+		receipt, err := ec.Client.TransactionReceipt(ctx, txHash)
+		if err == nil {
+			return receipt, nil
+		}
+		if !isReceiptPending(err) {
+			return nil, fmt.Errorf("查询交易回执失败: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForTransaction 等待交易的最终结果：交易管理器发送的交易跟随加价重发后的哈希，
+// 被替换或广播失败时返回ErrTransactionReplaced/ErrTransactionFailed；其他交易直接轮询回执
+func (ec *EthClient) WaitForTransaction(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	_, receipt, err := ec.Tx.Wait(ctx, txHash)
+	if errors.Is(err, ErrTxNotManaged) {
+		return ec.WaitForReceipt(ctx, txHash)
+	}
+	if errors.Is(err, ErrTransactionReverted) {
+		// 回滚的交易同样返回回执，由调用方解析
+		return receipt, nil
+	}
+	return receipt, err
+}
+
+// WaitForChildNFT 等待创建子NFT的交易回执并解析子NFT的tokenID；交易回滚时返回ErrTransactionReverted，
+// 被替换或广播失败时返回带交易哈希的结果和对应错误
+func (ec *EthClient) WaitForChildNFT(ctx context.Context, txHash common.Hash) (*ChildMintResult, error) {
+	receipt, err := ec.WaitForTransaction(ctx, txHash)
+	if errors.Is(err, ErrTransactionReplaced) || errors.Is(err, ErrTransactionFailed) {
+		return &ChildMintResult{TxHash: txHash}, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseChildMintReceipt(receipt, common.HexToAddress(ec.Config.MainNFTAddress), common.HexToAddress(ec.Config.ChildNFTAddress))
+}
+
+// ParseChildMintReceipt 从回执日志中解析ChildNFT的铸造Transfer事件和MainNFT的ChildNFTCreated事件。
+// 两个合约的Transfer事件签名相同，因此先按日志地址区分来源合约
+func ParseChildMintReceipt(receipt *types.Receipt, mainNFTAddress, childNFTAddress common.Address) (*ChildMintResult, error) {
+	result := &ChildMintResult{TxHash: receipt.TxHash}
+	if receipt.BlockNumber != nil {
+		result.BlockNumber = receipt.BlockNumber.Uint64()
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return result, ErrTransactionReverted
+	}
+
+	mainFilterer, err := mainnft.NewMainnftFilterer(mainNFTAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	childFilterer, err := childnft.NewChildnftFilterer(childNFTAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vLog := range receipt.Logs {
+		switch vLog.Address {
+		case childNFTAddress:
+			transfer, err := childFilterer.ParseTransfer(*vLog)
+			if err == nil && transfer.From == (common.Address{}) {
+				result.ChildTokenID = transfer.TokenId
+				result.Receiver = transfer.To
+			}
+		case mainNFTAddress:
+			created, err := mainFilterer.ParseChildNFTCreated(*vLog)
+			if err == nil {
+				result.ParentTokenID = created.TokenId
+				if result.Receiver == (common.Address{}) {
+					result.Receiver = created.Receiver
+				}
+			}
+		}
+	}
+
+	if result.ChildTokenID == nil {
+		return result, fmt.Errorf("交易回执中没有子NFT的铸造事件")
+	}
+	if result.ParentTokenID == nil {
+		return result, fmt.Errorf("交易回执中没有ChildNFTCreated事件")
+	}
+	return result, nil
+}
+
+// DecodeReceiptEvents 用MainNFT和ChildNFT的ABI解码回执中的日志，无法识别的日志跳过
+func DecodeReceiptEvents(receipt *types.Receipt, mainNFTAddress, childNFTAddress common.Address) ([]models.ChainEvent, error) {
+	mainABI, err := mainnft.MainnftMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	childABI, err := childnft.ChildnftMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+
+	events := []models.ChainEvent{}
+	for _, vLog := range receipt.Logs {
+		var contract string
+		var parsed *abi.ABI
+		switch vLog.Address {
+		case mainNFTAddress:
+			contract, parsed = "main", mainABI
+		case childNFTAddress:
+			contract, parsed = "child", childABI
+		default:
+			continue
+		}
+		if len(vLog.Topics) == 0 {
+			continue
+		}
+		event, err := parsed.EventByID(vLog.Topics[0])
+		if err != nil {
+			continue
+		}
+
+		values := make(map[string]interface{})
+		var indexed abi.Arguments
+		for _, input := range event.Inputs {
+			if input.Indexed {
+				indexed = append(indexed, input)
+			}
+		}
+		if err := abi.ParseTopicsIntoMap(values, indexed, vLog.Topics[1:]); err != nil {
+			return nil, fmt.Errorf("解码事件 %s 失败: %v", event.Name, err)
+		}
+		if err := event.Inputs.UnpackIntoMap(values, vLog.Data); err != nil {
+			return nil, fmt.Errorf("解码事件 %s 失败: %v", event.Name, err)
+		}
+
+		args := make(map[string]string, len(values))
+		for name, value := range values {
+			args[name] = formatEventArg(value)
+		}
+		events = append(events, models.ChainEvent{
+			Contract: contract,
+			Address:  vLog.Address.Hex(),
+			Name:     event.Name,
+			LogIndex: vLog.Index,
+			Args:     args,
+		})
+	}
+	return events, nil
+}
+
+// formatEventArg 把事件参数格式化为字符串：地址为校验和格式，整数为十进制，字节为十六进制
+func formatEventArg(value interface{}) string {
+	switch v := value.(type) {
+	case common.Address:
+		return v.Hex()
+	case *big.Int:
+		return v.String()
+	case []byte:
+		return hexutil.Encode(v)
+	case [32]byte:
+		return hexutil.Encode(v[:])
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,106 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/ABE/nft/nft-go-backend/internal/config"
+)
+
+// chainIDTimeout 启动时查询节点链ID的超时
+const chainIDTimeout = 10 * time.Second
+
+// Registry 按部署管理以太坊客户端。同一RPC地址共用一个节点连接，同一条链共用一个交易管理器，
+// 使平台账户在每条链上的nonce只由一个交易管理器分配；所有部署共用平台账户的签名器
+type Registry struct {
+	Clients    []*EthClient // 顺序与配置一致，第一个为默认部署
+	TxManagers []*TxManager // 每条链一个
+
+	byName map[string]*EthClient
+}
+
+// NewRegistry 为配置中的每个部署创建以太坊客户端
+func NewRegistry(cfg *config.Config) (*Registry, error) {
+	// 按配置创建签名器，交易只通过签名器签名
+	signer, err := NewSignerFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	log.Println("使用地址:", signer.Address().Hex(), "签名后端:", cfg.SignerBackend)
+
+	registry := &Registry{byName: make(map[string]*EthClient)}
+	metadata := NewMetadataResolver(cfg)
+	connections := make(map[string]*ethclient.Client)
+	txManagers := make(map[int64]*TxManager)
+	for _, deployment := range cfg.Deployments {
+		// 连接到以太坊节点
+		client, ok := connections[deployment.RPC]
+		if !ok {
+			client, err = ethclient.Dial(deployment.RPC)
+			if err != nil {
+				return nil, fmt.Errorf("部署 %s 无法连接到以太坊客户端: %v", deployment.Name, err)
+			}
+			connections[deployment.RPC] = client
+		}
+		if err := checkChainID(client, deployment); err != nil {
+			return nil, err
+		}
+
+		txManager, ok := txManagers[deployment.ChainID]
+		if !ok {
+			txManager = newTxManager(cfg, client, deployment.ChainID, signer)
+			txManagers[deployment.ChainID] = txManager
+			registry.TxManagers = append(registry.TxManagers, txManager)
+		}
+
+		ethClient, err := newEthClient(cfg, deployment, client, signer, txManager)
+		if err != nil {
+			return nil, fmt.Errorf("部署 %s: %v", deployment.Name, err)
+		}
+		ethClient.Metadata = metadata
+		registry.Clients = append(registry.Clients, ethClient)
+		registry.byName[deployment.Name] = ethClient
+		log.Printf("已加载部署 %s: 链 %d, MainNFT %s, ChildNFT %s", deployment.Name, deployment.ChainID,
+			ethClient.ContractAddress("main"), ethClient.ContractAddress("child"))
+	}
+	return registry, nil
+}
+
+// checkChainID 确认RPC节点所在的链与部署配置的chainId一致，否则交易会用错误的chainId签名、索引记录会归到错误的链
+func checkChainID(client *ethclient.Client, deployment config.Deployment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), chainIDTimeout)
+	defer cancel()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("部署 %s 无法获取链ID: %v", deployment.Name, err)
+	}
+	if !chainID.IsInt64() || chainID.Int64() != deployment.ChainID {
+		return fmt.Errorf("部署 %s 配置的链ID为 %d，但RPC %s 所在的链为 %s", deployment.Name, deployment.ChainID, deployment.RPC, chainID)
+	}
+	return nil
+}
+
+// Default 请求未指定部署时使用的客户端
+func (r *Registry) Default() *EthClient {
+	return r.Clients[0]
+}
+
+// Get 按部署名称查询客户端
+func (r *Registry) Get(name string) (*EthClient, bool) {
+	client, ok := r.byName[name]
+	return client, ok
+}
+
+// ByChainID 返回链上的第一个部署，链上没有部署时返回false
+func (r *Registry) ByChainID(chainID int64) (*EthClient, bool) {
+	for _, client := range r.Clients {
+		if client.Config.ChainID == chainID {
+			return client, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,247 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/ABE/nft/nft-go-backend/internal/config"
+)
+
+// 平台账户签名后端
+const (
+	SignerBackendDev      = "dev"      // 进程内私钥（PRIVATE_KEY），仅用于开发
+	SignerBackendKeystore = "keystore" // go-ethereum加密keystore文件
+	SignerBackendRemote   = "remote"   // Clef或支持eth_signTransaction的远程签名服务
+)
+
+// remoteSignTimeout 远程签名单次请求的超时，Clef需要人工确认时应配置自动批准规则
+const remoteSignTimeout = 30 * time.Second
+
+// ErrDefaultKeyRefused 非开发模式下拒绝使用内置的默认私钥
+var ErrDefaultKeyRefused = errors.New("拒绝使用内置的默认私钥：请配置 SIGNER_BACKEND=keystore/remote 或自己的 PRIVATE_KEY，开发环境可设置 DEV_MODE=true")
+
+// Signer 平台账户的交易签名接口，交易路径只通过它签名，不直接接触私钥
+type Signer interface {
+	// Address 签名账户地址
+	Address() common.Address
+	// SignTx 用chainID对应的签名规则签名交易
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// NewSignerFromConfig 按SIGNER_BACKEND创建签名器
+func NewSignerFromConfig(cfg *config.Config) (Signer, error) {
+	switch cfg.SignerBackend {
+	case SignerBackendDev, "":
+		if isDefaultDevKey(cfg.PrivateKey) && !cfg.DevMode {
+			return nil, ErrDefaultKeyRefused
+		}
+		if !cfg.DevMode {
+			log.Println("警告：正在使用环境变量中的明文私钥签名，生产环境建议使用keystore或远程签名")
+		}
+		return NewKeySigner(cfg.PrivateKey)
+	case SignerBackendKeystore:
+		return NewKeystoreSigner(cfg.KeystoreFile, cfg.KeystorePasswordFile)
+	case SignerBackendRemote:
+		if !common.IsHexAddress(cfg.RemoteSignerAddress) {
+			return nil, fmt.Errorf("无效的远程签名账户地址: %s", cfg.RemoteSignerAddress)
+		}
+		client, err := rpc.Dial(cfg.RemoteSignerURL)
+		if err != nil {
+			return nil, fmt.Errorf("无法连接远程签名服务: %v", err)
+		}
+		return NewRemoteSigner(client, common.HexToAddress(cfg.RemoteSignerAddress), cfg.RemoteSignerMethod), nil
+	}
+	return nil, fmt.Errorf("未知的签名后端: %s", cfg.SignerBackend)
+}
+
+// isDefaultDevKey 判断私钥是否为内置的默认私钥，忽略0x前缀、大小写和首尾空白
+func isDefaultDevKey(hexKey string) bool {
+	key := strings.ToLower(strings.TrimSpace(hexKey))
+	return strings.TrimPrefix(key, "0x") == config.DefaultDevPrivateKey
+}
+
+// NewSignerTransactor 创建由Signer签名的交易选项
+func NewSignerTransactor(signer Signer, chainID *big.Int) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: signer.Address(),
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != signer.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(context.Background(), tx, chainID)
+		},
+		Context: context.Background(),
+	}
+}
+
+// KeySigner 持有内存中私钥的签名器，开发模式和keystore解密后使用
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner 从十六进制私钥创建签名器
+func NewKeySigner(hexKey string) (*KeySigner, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("无法加载私钥: %v", err)
+	}
+	return &KeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}, nil
+}
+
+// NewKeystoreSigner 用密码文件中的密码解密go-ethereum keystore文件
+func NewKeystoreSigner(keystoreFile, passwordFile string) (*KeySigner, error) {
+	keyJSON, err := os.ReadFile(keystoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取keystore文件: %v", err)
+	}
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取keystore密码文件: %v", err)
+	}
+	key, err := keystore.DecryptKey(keyJSON, strings.TrimRight(string(password), "\r\n"))
+	if err != nil {
+		return nil, fmt.Errorf("无法解密keystore: %v", err)
+	}
+	return &KeySigner{key: key.PrivateKey, address: key.Address}, nil
+}
+
+// Address 签名账户地址
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx 用内存中的私钥签名
+func (s *KeySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// RPCCaller 远程签名器使用的JSON-RPC调用接口，*rpc.Client满足该接口，测试时可替换为本地桩
+type RPCCaller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// RemoteSigner 通过JSON-RPC请求远程服务签名：Clef使用account_signTransaction，节点或其他签名服务使用eth_signTransaction
+type RemoteSigner struct {
+	Client  RPCCaller
+	Account common.Address
+	Method  string
+}
+
+// NewRemoteSigner 创建远程签名器，method为空时使用Clef的account_signTransaction
+func NewRemoteSigner(client RPCCaller, account common.Address, method string) *RemoteSigner {
+	if method == "" {
+		method = "account_signTransaction"
+	}
+	return &RemoteSigner{Client: client, Account: account, Method: method}
+}
+
+// remoteSignResult account_signTransaction和eth_signTransaction的返回值
+type remoteSignResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// Address 签名账户地址
+func (s *RemoteSigner) Address() common.Address {
+	return s.Account
+}
+
+// SignTx 把交易字段发送给远程服务签名，并校验返回的交易内容和签名账户
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, remoteSignTimeout)
+	defer cancel()
+
+	args := map[string]interface{}{
+		"from":    s.Account,
+		"gas":     hexutil.Uint64(tx.Gas()),
+		"value":   (*hexutil.Big)(tx.Value()),
+		"nonce":   hexutil.Uint64(tx.Nonce()),
+		"data":    hexutil.Bytes(tx.Data()),
+		"chainId": (*hexutil.Big)(chainID),
+	}
+	if tx.To() != nil {
+		args["to"] = tx.To()
+	}
+	if tx.Type() == types.LegacyTxType {
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+	} else {
+		args["maxFeePerGas"] = (*hexutil.Big)(tx.GasFeeCap())
+		args["maxPriorityFeePerGas"] = (*hexutil.Big)(tx.GasTipCap())
+	}
+
+	var result remoteSignResult
+	if err := s.Client.CallContext(ctx, &result, s.Method, args); err != nil {
+		return nil, fmt.Errorf("远程签名失败: %v", err)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(result.Raw); err != nil {
+		return nil, fmt.Errorf("无法解析远程签名的交易: %v", err)
+	}
+
+	// 远程服务不能改动交易内容，签名账户必须是配置的账户
+	if field := changedField(tx, signed, chainID); field != "" {
+		return nil, fmt.Errorf("远程签名返回的交易与请求不一致: %s", field)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return nil, fmt.Errorf("无法恢复远程签名的账户: %v", err)
+	}
+	if sender != s.Account {
+		return nil, fmt.Errorf("远程签名账户 %s 与配置的 %s 不一致", sender.Hex(), s.Account.Hex())
+	}
+	return signed, nil
+}
+
+// changedField 比较请求签名的交易和远程返回的交易，返回第一个不一致的字段名，全部一致时返回空串
+func changedField(requested, signed *types.Transaction, chainID *big.Int) string {
+	switch {
+	case signed.Type() != requested.Type():
+		return "type"
+	case signed.ChainId().Cmp(chainID) != 0:
+		return "chainId"
+	case signed.Nonce() != requested.Nonce():
+		return "nonce"
+	case signed.Gas() != requested.Gas():
+		return "gas"
+	// 传统交易的GasFeeCap和GasTipCap都返回gasPrice
+	case signed.GasFeeCap().Cmp(requested.GasFeeCap()) != 0:
+		return "maxFeePerGas"
+	case signed.GasTipCap().Cmp(requested.GasTipCap()) != 0:
+		return "maxPriorityFeePerGas"
+	case !equalAddress(signed.To(), requested.To()):
+		return "to"
+	case signed.Value().Cmp(requested.Value()) != 0:
+		return "value"
+	case !bytesEqual(signed.Data(), requested.Data()):
+		return "data"
+	}
+	return ""
+}
+
+// equalAddress 比较可能为nil的地址
+func equalAddress(a, b *common.Address) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// bytesEqual 比较字节切片
+func bytesEqual(a, b []byte) bool {
+	return string(a) == string(b)
+}
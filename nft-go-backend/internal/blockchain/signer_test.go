@@ -0,0 +1,135 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/ABE/nft/nft-go-backend/internal/config"
+)
+
+// stubRemoteSigner 按请求参数构造交易并用本地私钥签名的远程签名服务桩，tamper可在签名前改动交易
+type stubRemoteSigner struct {
+	key    *ecdsa.PrivateKey
+	tamper func(tx *types.DynamicFeeTx)
+	method string
+}
+
+func (s *stubRemoteSigner) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	s.method = method
+	fields := args[0].(map[string]interface{})
+	tx := &types.DynamicFeeTx{
+		ChainID:   fields["chainId"].(*hexutil.Big).ToInt(),
+		Nonce:     uint64(fields["nonce"].(hexutil.Uint64)),
+		GasTipCap: fields["maxPriorityFeePerGas"].(*hexutil.Big).ToInt(),
+		GasFeeCap: fields["maxFeePerGas"].(*hexutil.Big).ToInt(),
+		Gas:       uint64(fields["gas"].(hexutil.Uint64)),
+		Value:     fields["value"].(*hexutil.Big).ToInt(),
+		Data:      fields["data"].(hexutil.Bytes),
+	}
+	if to, ok := fields["to"].(*common.Address); ok {
+		tx.To = to
+	}
+	if s.tamper != nil {
+		s.tamper(tx)
+	}
+	signed, err := types.SignNewTx(s.key, types.LatestSignerForChainID(tx.ChainID), tx)
+	if err != nil {
+		return err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	// 经过JSON编解码，与真实的RPC返回一致
+	response, _ := json.Marshal(map[string]interface{}{"raw": hexutil.Bytes(raw)})
+	return json.Unmarshal(response, result)
+}
+
+func TestRemoteSignerRejectsModifiedTransactions(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(1337)
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	request := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     7,
+		GasTipCap: big.NewInt(2e9),
+		GasFeeCap: big.NewInt(50e9),
+		Gas:       210000,
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      []byte{0xa9, 0x05, 0x9c, 0xbb},
+	})
+
+	stub := &stubRemoteSigner{key: key}
+	signer := NewRemoteSigner(stub, account, "")
+	signed, err := signer.SignTx(context.Background(), request, chainID)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if stub.method != "account_signTransaction" || signed.Hash() == request.Hash() || signed.Nonce() != 7 {
+		t.Fatalf("method=%s nonce=%d", stub.method, signed.Nonce())
+	}
+
+	attacker := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	tests := []struct {
+		field  string
+		tamper func(tx *types.DynamicFeeTx)
+	}{
+		{"chainId", func(tx *types.DynamicFeeTx) { tx.ChainID = big.NewInt(1) }},
+		{"nonce", func(tx *types.DynamicFeeTx) { tx.Nonce++ }},
+		{"gas", func(tx *types.DynamicFeeTx) { tx.Gas = 21000 }},
+		{"maxFeePerGas", func(tx *types.DynamicFeeTx) { tx.GasFeeCap = big.NewInt(500e9) }},
+		{"maxPriorityFeePerGas", func(tx *types.DynamicFeeTx) { tx.GasTipCap = big.NewInt(40e9) }},
+		{"to", func(tx *types.DynamicFeeTx) { tx.To = &attacker }},
+		{"to", func(tx *types.DynamicFeeTx) { tx.To = nil }},
+		{"value", func(tx *types.DynamicFeeTx) { tx.Value = big.NewInt(1) }},
+		{"data", func(tx *types.DynamicFeeTx) { tx.Data = nil }},
+	}
+	for _, tt := range tests {
+		stub.tamper = tt.tamper
+		_, err := signer.SignTx(context.Background(), request, chainID)
+		if err == nil || !strings.HasSuffix(err.Error(), tt.field) {
+			t.Errorf("改动 %s 应被拒绝，得到 %v", tt.field, err)
+		}
+	}
+
+	// 交易内容一致，但签名账户不是配置的账户
+	stub.key, stub.tamper = otherKey, nil
+	if _, err := signer.SignTx(context.Background(), request, chainID); err == nil || !strings.Contains(err.Error(), "不一致") {
+		t.Fatalf("其他账户的签名应被拒绝，得到 %v", err)
+	}
+}
+
+func TestDefaultDevKeyRefusedInAnyFormat(t *testing.T) {
+	tests := []struct {
+		key     string
+		refused bool
+	}{
+		{config.DefaultDevPrivateKey, true},
+		{"0x" + config.DefaultDevPrivateKey, true},
+		{"0X" + strings.ToUpper(config.DefaultDevPrivateKey), true},
+		{" " + config.DefaultDevPrivateKey + "\n", true},
+		{"4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318", false},
+	}
+	for _, tt := range tests {
+		_, err := NewSignerFromConfig(&config.Config{SignerBackend: SignerBackendDev, PrivateKey: tt.key})
+		if refused := errors.Is(err, ErrDefaultKeyRefused); refused != tt.refused {
+			t.Errorf("%q: err=%v，期望拒绝=%v", tt.key, err, tt.refused)
+		}
+	}
+	if _, err := NewSignerFromConfig(&config.Config{SignerBackend: SignerBackendDev, PrivateKey: "0x" + config.DefaultDevPrivateKey, DevMode: true}); err != nil {
+		t.Fatalf("开发模式应允许默认私钥: %v", err)
+	}
+}
@@ -0,0 +1,476 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gorm.io/gorm"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// minBumpPercent 节点接受同nonce替换交易要求的最小加价比例
+const minBumpPercent = 10
+
+// knownTxErrors 重新广播时节点表示交易已收录或nonce已被使用的错误。不同客户端措辞不同，
+// geth还会给错误加上前缀，因此按子串匹配；nonce已被使用时交易的结果由下一次检查确定
+var knownTxErrors = []string{
+	"already known",
+	"known transaction",
+	"already imported",
+	"already exists",
+	"nonce too low",
+}
+
+// 交易管理器错误
+var (
+	ErrUnknownSigner       = errors.New("未登记的交易签名账户")
+	ErrTxNotManaged        = errors.New("交易不是由交易管理器发送的")
+	ErrTransactionReplaced = errors.New("交易已被同nonce的其他交易替换")
+	ErrTransactionFailed   = errors.New("交易广播失败")
+)
+
+// TxBackend 交易管理器需要的节点接口，*ethclient.Client满足该接口
+type TxBackend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// TxBuilder 用给定的交易选项构造已签名的交易，通常是合约绑定的写方法
+type TxBuilder func(opts *bind.TransactOpts) (*types.Transaction, error)
+
+// TxManager 平台账户交易管理器：按签名账户串行分配nonce，按EIP-1559设置费用并估算gas，
+// 广播前持久化交易，长时间未打包时加价重发，并记录最终状态（打包、回滚、被替换）
+type TxManager struct {
+	DB               *gorm.DB
+	Backend          TxBackend
+	ChainID          *big.Int
+	GasMarginPercent uint64        // 在估算的gas上增加的余量
+	MaxFeeCap        *big.Int      // 单位gas费用上限（wei），为nil时不限制
+	BumpAfter        time.Duration // 广播后超过该时间未打包则加价重发，0表示不加价
+	BumpPercent      int64         // 每次加价的比例，不低于10
+
+	mu      sync.Mutex
+	signers map[common.Address]*txSigner
+	checkMu sync.Mutex // 后台任务与等待中的调用方不同时检查同一批交易
+}
+
+// txSigner 单个签名账户的状态，mu保证同一账户的nonce分配与广播串行
+type txSigner struct {
+	mu        sync.Mutex
+	signer    Signer
+	opts      *bind.TransactOpts // 由signer签名的交易选项，用于合约绑定构造交易
+	nextNonce *uint64            // 本地已使用的下一个nonce，避免节点尚未收录待处理交易时重复使用
+}
+
+// txFees 一笔交易的费用设置，feeCap为nil时为旧式交易
+type txFees struct {
+	gasPrice *big.Int
+	feeCap   *big.Int
+	tipCap   *big.Int
+}
+
+// NewTxManager 创建交易管理器
+func NewTxManager(db *gorm.DB, backend TxBackend, chainID *big.Int) *TxManager {
+	return &TxManager{
+		DB:               db,
+		Backend:          backend,
+		ChainID:          chainID,
+		GasMarginPercent: 20,
+		BumpAfter:        time.Minute,
+		BumpPercent:      15,
+		signers:          make(map[common.Address]*txSigner),
+	}
+}
+
+// AddSigner 登记签名账户，之后可以用该账户发送交易，后台加价重发时也用它重新签名
+func (m *TxManager) AddSigner(signer Signer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.signers[signer.Address()] = &txSigner{signer: signer, opts: NewSignerTransactor(signer, m.ChainID)}
+}
+
+// signer 返回已登记的签名账户
+func (m *TxManager) signer(from common.Address) (*txSigner, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	signer, ok := m.signers[from]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSigner, from.Hex())
+	}
+	return signer, nil
+}
+
+// Send 分配nonce、设置费用并估算gas后构造交易，先写入数据库再广播。
+// 构造失败（如估算gas时合约回滚）不占用nonce；广播失败时记录为failed并返回错误
+func (m *TxManager) Send(ctx context.Context, from common.Address, kind string, build TxBuilder) (*models.ManagedTransaction, error) {
+	signer, err := m.signer(from)
+	if err != nil {
+		return nil, err
+	}
+	signer.mu.Lock()
+	defer signer.mu.Unlock()
+
+	nonce, err := m.Backend.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("无法获取nonce: %v", err)
+	}
+	if signer.nextNonce != nil && *signer.nextNonce > nonce {
+		nonce = *signer.nextNonce
+	}
+	fees, err := m.suggestFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := *signer.opts
+	opts.Context = ctx
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+	opts.GasPrice, opts.GasFeeCap, opts.GasTipCap = fees.gasPrice, fees.feeCap, fees.tipCap
+	opts.GasLimit = 0
+	opts.NoSend = true
+	// GasLimit为0时合约绑定会估算gas，再加上余量重新构造
+	tx, err := build(&opts)
+	if err != nil {
+		return nil, err
+	}
+	if m.GasMarginPercent > 0 {
+		opts.GasLimit = tx.Gas() * (100 + m.GasMarginPercent) / 100
+		if tx, err = build(&opts); err != nil {
+			return nil, err
+		}
+	}
+
+	record := &models.ManagedTransaction{
+		ChainID: m.ChainID.Int64(),
+		Signer:  from.Hex(),
+		Nonce:   nonce,
+		Kind:    kind,
+		Status:  models.TxStatusPending,
+	}
+	if err := applyBroadcast(record, tx); err != nil {
+		return nil, err
+	}
+	if err := m.DB.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("保存交易记录失败: %v", err)
+	}
+
+	if err := m.Backend.SendTransaction(ctx, tx); err != nil {
+		// 广播失败时不确定节点状态，下次以节点的待处理nonce为准
+		signer.nextNonce = nil
+		m.DB.Model(record).Updates(map[string]interface{}{"status": models.TxStatusFailed, "error": err.Error()})
+		return nil, fmt.Errorf("%w: %v", ErrTransactionFailed, err)
+	}
+	next := nonce + 1
+	signer.nextNonce = &next
+	return record, nil
+}
+
+// Wait 等待交易得到最终结果，期间按需加价重发；txHash可以是最初或最近一次广播的哈希。
+// 打包的交易返回回执；被替换或广播失败时返回对应错误；ctx结束时返回ctx的错误
+func (m *TxManager) Wait(ctx context.Context, txHash common.Hash) (*models.ManagedTransaction, *types.Receipt, error) {
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+	for {
+		record, err := m.refresh(ctx, txHash)
+		if err != nil {
+			return nil, nil, err
+		}
+		if record.IsFinal() {
+			return m.result(ctx, record)
+		}
+		select {
+		case <-ctx.Done():
+			return record, nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh 读取交易记录，尚未有结果时检查一次；读取与检查在同一把锁内，避免基于过期记录重复加价
+func (m *TxManager) refresh(ctx context.Context, txHash common.Hash) (*models.ManagedTransaction, error) {
+	m.checkMu.Lock()
+	defer m.checkMu.Unlock()
+	record, err := m.Lookup(txHash)
+	if err != nil || record.IsFinal() {
+		return record, err
+	}
+	if err := m.check(ctx, record); err != nil && ctx.Err() == nil {
+		log.Printf("检查交易 %s 失败: %v", record.TxHash, err)
+	}
+	return record, nil
+}
+
+// Lookup 按最初或最近一次广播的哈希查询交易记录
+func (m *TxManager) Lookup(txHash common.Hash) (*models.ManagedTransaction, error) {
+	var record models.ManagedTransaction
+	err := m.DB.Where("tx_hash = ? OR latest_hash = ?", txHash.Hex(), txHash.Hex()).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTxNotManaged
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询交易记录失败: %v", err)
+	}
+	return &record, nil
+}
+
+// result 把最终状态转换为回执和错误
+func (m *TxManager) result(ctx context.Context, record *models.ManagedTransaction) (*models.ManagedTransaction, *types.Receipt, error) {
+	switch record.Status {
+	case models.TxStatusReplaced:
+		return record, nil, ErrTransactionReplaced
+	case models.TxStatusFailed:
+		return record, nil, fmt.Errorf("%w: %s", ErrTransactionFailed, record.Error)
+	}
+	receipt, err := m.Backend.TransactionReceipt(ctx, common.HexToHash(record.MinedHash))
+	if err != nil {
+		return record, nil, fmt.Errorf("查询交易回执失败: %v", err)
+	}
+	if record.Status == models.TxStatusReverted {
+		return record, receipt, ErrTransactionReverted
+	}
+	return record, receipt, nil
+}
+
+// CheckPending 检查本链所有待打包的交易：记录已打包和被替换的交易，对超时未打包的交易加价重发
+func (m *TxManager) CheckPending(ctx context.Context) error {
+	m.checkMu.Lock()
+	defer m.checkMu.Unlock()
+	var pending []models.ManagedTransaction
+	if err := m.DB.Where("chain_id = ? AND status = ?", m.ChainID.Int64(), models.TxStatusPending).Order("signer, nonce").Find(&pending).Error; err != nil {
+		return fmt.Errorf("查询待打包交易失败: %v", err)
+	}
+	for i := range pending {
+		if err := m.check(ctx, &pending[i]); err != nil {
+			log.Printf("检查交易 %s 失败: %v", pending[i].TxHash, err)
+		}
+	}
+	return nil
+}
+
+// Run 定期检查待打包的交易，直到ctx取消
+func (m *TxManager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := m.CheckPending(ctx); err != nil {
+			log.Printf("%v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check 检查一笔待打包交易。先读取账户已确认的nonce再查回执：
+// nonce已越过而所有广播过的交易都没有回执，说明该nonce被其他交易占用
+func (m *TxManager) check(ctx context.Context, record *models.ManagedTransaction) error {
+	confirmedNonce, err := m.Backend.NonceAt(ctx, common.HexToAddress(record.Signer), nil)
+	if err != nil {
+		return fmt.Errorf("无法获取已确认nonce: %v", err)
+	}
+	for i := len(record.Hashes) - 1; i >= 0; i-- {
+		receipt, err := m.Backend.TransactionReceipt(ctx, common.HexToHash(record.Hashes[i]))
+		if err != nil && isReceiptPending(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("查询交易回执失败: %v", err)
+		}
+		record.Status = models.TxStatusMined
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			record.Status = models.TxStatusReverted
+		}
+		record.MinedHash = receipt.TxHash.Hex()
+		record.BlockNumber = receipt.BlockNumber.Uint64()
+		record.GasUsed = receipt.GasUsed
+		return m.finish(record)
+	}
+	if confirmedNonce > record.Nonce {
+		record.Status = models.TxStatusReplaced
+		return m.finish(record)
+	}
+	if m.BumpAfter > 0 && time.Since(record.LastBroadcastAt) > m.BumpAfter {
+		return m.bump(ctx, record)
+	}
+	return nil
+}
+
+// finish 保存最终状态
+func (m *TxManager) finish(record *models.ManagedTransaction) error {
+	if err := m.DB.Model(record).Select("status", "mined_hash", "block_number", "gas_used").Updates(record).Error; err != nil {
+		return fmt.Errorf("更新交易状态失败: %v", err)
+	}
+	return nil
+}
+
+// bump 以相同nonce和调用数据、更高的费用重新签名并广播；已达到费用上限时只重新广播原交易
+func (m *TxManager) bump(ctx context.Context, record *models.ManagedTransaction) error {
+	signer, err := m.signer(common.HexToAddress(record.Signer))
+	if err != nil {
+		return err
+	}
+	previous := new(types.Transaction)
+	if err := previous.UnmarshalBinary(common.FromHex(record.RawTx)); err != nil {
+		return fmt.Errorf("解析已签名交易失败: %v", err)
+	}
+	fees, err := m.suggestFees(ctx)
+	if err != nil {
+		return err
+	}
+
+	var inner types.TxData
+	if previous.Type() == types.LegacyTxType {
+		gasPrice := m.bumpFee(previous.GasPrice(), fees.gasPrice)
+		if gasPrice.Cmp(previous.GasPrice()) <= 0 {
+			return m.rebroadcast(ctx, record, previous)
+		}
+		inner = &types.LegacyTx{
+			Nonce: previous.Nonce(), GasPrice: gasPrice, Gas: previous.Gas(),
+			To: previous.To(), Value: previous.Value(), Data: previous.Data(),
+		}
+	} else {
+		feeCap := m.bumpFee(previous.GasFeeCap(), fees.feeCap)
+		tipCap := m.bumpFee(previous.GasTipCap(), fees.tipCap)
+		if tipCap.Cmp(feeCap) > 0 {
+			tipCap = feeCap
+		}
+		if feeCap.Cmp(previous.GasFeeCap()) <= 0 || tipCap.Cmp(previous.GasTipCap()) <= 0 {
+			return m.rebroadcast(ctx, record, previous)
+		}
+		inner = &types.DynamicFeeTx{
+			ChainID: m.ChainID, Nonce: previous.Nonce(), GasTipCap: tipCap, GasFeeCap: feeCap, Gas: previous.Gas(),
+			To: previous.To(), Value: previous.Value(), Data: previous.Data(),
+		}
+	}
+	replacement, err := signer.signer.SignTx(ctx, types.NewTx(inner), m.ChainID)
+	if err != nil {
+		return fmt.Errorf("重新签名交易失败: %v", err)
+	}
+	if err := m.Backend.SendTransaction(ctx, replacement); err != nil {
+		return fmt.Errorf("广播加价交易失败: %v", err)
+	}
+	log.Printf("交易 %s 超过 %s 未打包，已加价重发为 %s", record.TxHash, m.BumpAfter, replacement.Hash().Hex())
+
+	if err := applyBroadcast(record, replacement); err != nil {
+		return err
+	}
+	record.Bumps++
+	return m.DB.Model(record).Select("latest_hash", "hashes", "raw_tx", "gas_price", "gas_fee_cap", "gas_tip_cap", "bumps", "last_broadcast_at").
+		Updates(record).Error
+}
+
+// rebroadcast 重新广播原交易，应对节点丢弃交易池的情况
+func (m *TxManager) rebroadcast(ctx context.Context, record *models.ManagedTransaction, tx *types.Transaction) error {
+	if err := m.Backend.SendTransaction(ctx, tx); err != nil && !isKnownTxError(err) {
+		return fmt.Errorf("重新广播交易失败: %v", err)
+	}
+	return m.DB.Model(record).Update("last_broadcast_at", time.Now()).Error
+}
+
+// isKnownTxError 节点是否因交易已收录或nonce已被使用而拒绝广播
+func isKnownTxError(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, known := range knownTxErrors {
+		if strings.Contains(message, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// bumpFee 在原费用上按BumpPercent加价，且不低于当前建议值，不超过MaxFeeCap
+func (m *TxManager) bumpFee(previous, suggested *big.Int) *big.Int {
+	percent := m.BumpPercent
+	if percent < minBumpPercent {
+		percent = minBumpPercent
+	}
+	bumped := new(big.Int).Mul(previous, big.NewInt(100+percent))
+	bumped.Div(bumped, big.NewInt(100))
+	bumped.Add(bumped, big.NewInt(1))
+	if suggested != nil && suggested.Cmp(bumped) > 0 {
+		bumped = new(big.Int).Set(suggested)
+	}
+	if m.MaxFeeCap != nil && bumped.Cmp(m.MaxFeeCap) > 0 {
+		bumped = new(big.Int).Set(m.MaxFeeCap)
+	}
+	return bumped
+}
+
+// suggestFees 支持EIP-1559的链使用 2*baseFee+tip 作为费用上限，否则使用建议的gasPrice；均不超过MaxFeeCap
+func (m *TxManager) suggestFees(ctx context.Context) (*txFees, error) {
+	head, err := m.Backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("无法获取最新区块: %v", err)
+	}
+	if head.BaseFee == nil {
+		gasPrice, err := m.Backend.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("无法获取gas价格: %v", err)
+		}
+		return &txFees{gasPrice: m.capFee(gasPrice)}, nil
+	}
+	tipCap, err := m.Backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("无法获取小费建议: %v", err)
+	}
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tipCap)
+	feeCap = m.capFee(feeCap)
+	if tipCap.Cmp(feeCap) > 0 {
+		tipCap = feeCap
+	}
+	return &txFees{feeCap: feeCap, tipCap: tipCap}, nil
+}
+
+// capFee 把费用限制在MaxFeeCap以内
+func (m *TxManager) capFee(fee *big.Int) *big.Int {
+	if m.MaxFeeCap != nil && fee.Cmp(m.MaxFeeCap) > 0 {
+		return new(big.Int).Set(m.MaxFeeCap)
+	}
+	return fee
+}
+
+// applyBroadcast 把即将广播的交易写入记录
+func applyBroadcast(record *models.ManagedTransaction, tx *types.Transaction) error {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("编码交易失败: %v", err)
+	}
+	hash := tx.Hash().Hex()
+	if record.TxHash == "" {
+		record.TxHash = hash
+	}
+	record.LatestHash = hash
+	record.Hashes = append(record.Hashes, hash)
+	record.RawTx = hexutil.Encode(raw)
+	if tx.To() != nil {
+		record.To = tx.To().Hex()
+	}
+	record.GasLimit = tx.Gas()
+	record.GasPrice, record.GasFeeCap, record.GasTipCap = "", "", ""
+	if tx.Type() == types.LegacyTxType {
+		record.GasPrice = tx.GasPrice().String()
+	} else {
+		record.GasFeeCap = tx.GasFeeCap().String()
+		record.GasTipCap = tx.GasTipCap().String()
+	}
+	record.LastBroadcastAt = time.Now()
+	return nil
+}
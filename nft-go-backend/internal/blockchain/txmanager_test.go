@@ -0,0 +1,266 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/ABE/nft/nft-go-backend/internal/models"
+)
+
+// txTestChainID 模拟链的chainID
+var txTestChainID = big.NewInt(1337)
+
+// txTestRecipient 测试交易的收款地址
+var txTestRecipient = common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+// txTestChain 交易管理器使用的模拟链，由测试手动出块
+type txTestChain struct {
+	backend *simulated.Backend
+	client  simulated.Client
+	key     *ecdsa.PrivateKey
+	from    common.Address
+	manager *TxManager
+}
+
+func newTxTestChain(t *testing.T) *txTestChain {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	chain := &txTestChain{key: key, from: crypto.PubkeyToAddress(key.PublicKey)}
+	chain.backend = simulated.NewBackend(types.GenesisAlloc{chain.from: {Balance: balance}})
+	t.Cleanup(func() { chain.backend.Close() })
+	chain.client = chain.backend.Client()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	// 内存数据库每个连接相互独立，只保留一个连接
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&models.ManagedTransaction{}); err != nil {
+		t.Fatalf("迁移测试表失败: %v", err)
+	}
+
+	signer, err := NewKeySigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.manager = NewTxManager(db, chain.client, txTestChainID)
+	chain.manager.AddSigner(signer)
+	return chain
+}
+
+// transfer 按交易选项构造一笔转账，GasLimit为0时使用转账的固定gas，与合约绑定估算gas的行为一致
+func transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	gas := opts.GasLimit
+	if gas == 0 {
+		gas = 21000
+	}
+	return opts.Signer(opts.From, types.NewTx(&types.DynamicFeeTx{
+		ChainID:   txTestChainID,
+		Nonce:     opts.Nonce.Uint64(),
+		GasTipCap: opts.GasTipCap,
+		GasFeeCap: opts.GasFeeCap,
+		Gas:       gas,
+		To:        &txTestRecipient,
+		Value:     big.NewInt(1),
+	}))
+}
+
+// record 重新读取交易记录
+func (c *txTestChain) record(t *testing.T, hash string) *models.ManagedTransaction {
+	t.Helper()
+	record, err := c.manager.Lookup(common.HexToHash(hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+func TestTxManagerConcurrentSendUsesSequentialNonces(t *testing.T) {
+	chain := newTxTestChain(t)
+	ctx := context.Background()
+
+	// 节点的待处理nonce在广播之后才增加，并发发送必须由管理器串行分配
+	const count = 8
+	var wg sync.WaitGroup
+	records := make([]*models.ManagedTransaction, count)
+	errs := make([]error, count)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			records[i], errs[i] = chain.manager.Send(ctx, chain.from, "transfer", transfer)
+		}(i)
+	}
+	wg.Wait()
+
+	nonces := make([]int, 0, count)
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("第 %d 笔交易发送失败: %v", i, err)
+		}
+		nonces = append(nonces, int(records[i].Nonce))
+	}
+	sort.Ints(nonces)
+	for i, nonce := range nonces {
+		if nonce != i {
+			t.Fatalf("nonce应为0到%d且互不相同，得到 %v", count-1, nonces)
+		}
+	}
+	if pending, err := chain.client.PendingNonceAt(ctx, chain.from); err != nil || pending != count {
+		t.Fatalf("交易池应收录全部交易，待处理nonce %d: %v", pending, err)
+	}
+
+	chain.backend.Commit()
+	if err := chain.manager.CheckPending(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if got := chain.record(t, record.TxHash); got.Status != models.TxStatusMined || got.MinedHash != record.TxHash {
+			t.Fatalf("nonce %d 的交易应已打包: %+v", record.Nonce, got)
+		}
+	}
+}
+
+func TestTxManagerBumpsStuckTransaction(t *testing.T) {
+	chain := newTxTestChain(t)
+	ctx := context.Background()
+
+	record, err := chain.manager.Send(ctx, chain.from, "transfer", transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 未出块，超过BumpAfter后应以相同nonce、更高费用重新签名
+	chain.manager.BumpAfter = time.Nanosecond
+	if err := chain.manager.CheckPending(ctx); err != nil {
+		t.Fatal(err)
+	}
+	chain.manager.BumpAfter = 0
+
+	bumped := chain.record(t, record.TxHash)
+	if bumped.Bumps != 1 || bumped.LatestHash == record.TxHash || len(bumped.Hashes) != 2 {
+		t.Fatalf("应已加价重发一次: %+v", bumped)
+	}
+	replacement := new(types.Transaction)
+	if err := replacement.UnmarshalBinary(common.FromHex(bumped.RawTx)); err != nil {
+		t.Fatal(err)
+	}
+	previousFeeCap, _ := new(big.Int).SetString(record.GasFeeCap, 10)
+	previousTipCap, _ := new(big.Int).SetString(record.GasTipCap, 10)
+	if replacement.Nonce() != record.Nonce || replacement.GasFeeCap().Cmp(previousFeeCap) <= 0 || replacement.GasTipCap().Cmp(previousTipCap) <= 0 {
+		t.Fatalf("加价交易应使用相同nonce和更高费用: nonce=%d feeCap=%s tipCap=%s", replacement.Nonce(), replacement.GasFeeCap(), replacement.GasTipCap())
+	}
+	if sender, err := types.Sender(types.LatestSignerForChainID(txTestChainID), replacement); err != nil || sender != chain.from {
+		t.Fatalf("加价交易应由原账户重新签名: %s %v", sender.Hex(), err)
+	}
+
+	// 打包的是加价后的交易，用最初的哈希也能等到结果
+	chain.backend.Commit()
+	mined, receipt, err := chain.manager.Wait(ctx, common.HexToHash(record.TxHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mined.Status != models.TxStatusMined || mined.MinedHash != bumped.LatestHash || receipt.TxHash.Hex() != bumped.LatestHash {
+		t.Fatalf("应记录加价后的交易已打包: %+v", mined)
+	}
+}
+
+func TestTxManagerRebroadcastsAtFeeCap(t *testing.T) {
+	chain := newTxTestChain(t)
+	ctx := context.Background()
+
+	record, err := chain.manager.Send(ctx, chain.from, "transfer", transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 费用已达上限时只重新广播原交易，节点返回的already known不是失败
+	chain.manager.MaxFeeCap, _ = new(big.Int).SetString(record.GasFeeCap, 10)
+	chain.manager.BumpAfter = time.Nanosecond
+	broadcastAt := record.LastBroadcastAt
+	if err := chain.manager.check(ctx, record); err != nil {
+		t.Fatalf("重新广播已收录的交易不应失败: %v", err)
+	}
+	if got := chain.record(t, record.TxHash); got.Bumps != 0 || got.LatestHash != record.TxHash || !got.LastBroadcastAt.After(broadcastAt) {
+		t.Fatalf("应只更新广播时间: %+v", got)
+	}
+}
+
+func TestTxManagerDetectsReplacedTransaction(t *testing.T) {
+	chain := newTxTestChain(t)
+	ctx := context.Background()
+
+	record, err := chain.manager.Send(ctx, chain.from, "transfer", transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同一账户在管理器之外用相同nonce和更高费用发送了另一笔交易
+	feeCap, _ := new(big.Int).SetString(record.GasFeeCap, 10)
+	tipCap, _ := new(big.Int).SetString(record.GasTipCap, 10)
+	other := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	tx, err := types.SignNewTx(chain.key, types.LatestSignerForChainID(txTestChainID), &types.DynamicFeeTx{
+		ChainID:   txTestChainID,
+		Nonce:     record.Nonce,
+		GasTipCap: new(big.Int).Mul(tipCap, big.NewInt(2)),
+		GasFeeCap: new(big.Int).Mul(feeCap, big.NewInt(2)),
+		Gas:       21000,
+		To:        &other,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.client.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	chain.backend.Commit()
+
+	got, _, err := chain.manager.Wait(ctx, common.HexToHash(record.TxHash))
+	if !errors.Is(err, ErrTransactionReplaced) || got.Status != models.TxStatusReplaced {
+		t.Fatalf("应记录为被替换: %+v %v", got, err)
+	}
+}
+
+func TestIsKnownTxError(t *testing.T) {
+	tests := []struct {
+		message string
+		known   bool
+	}{
+		{"already known", true},
+		{"AlreadyKnown: already known", true},
+		{"Known transaction: 5e9ad5ee", true},
+		{"transaction already imported", true},
+		{"nonce too low: address 0x1111, tx: 0 state: 1", true},
+		{"insufficient funds for gas * price + value", false},
+		{"replacement transaction underpriced", false},
+		{"context deadline exceeded", false},
+	}
+	for _, tt := range tests {
+		if got := isKnownTxError(errors.New(tt.message)); got != tt.known {
+			t.Errorf("%q: %v，期望 %v", tt.message, got, tt.known)
+		}
+	}
+}
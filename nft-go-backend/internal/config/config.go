@@ -5,8 +5,12 @@ import (
 	"strconv"
 	"fmt"
 	"github.com/joho/godotenv"
+	"strings"
 )
 
+// DefaultDevPrivateKey 内置的开发私钥（本地Ganache账户），只允许在DEV_MODE下使用
+const DefaultDevPrivateKey = "63435add31c605dfa2ee262dfb1dd019c985c881196309c4d194d3574a0c3fc1"
+
 // Config 结构体
 type Config struct {
 	EthereumRPC      string
@@ -25,6 +29,79 @@ type Config struct {
 
 	//ipfs
 	AcccessKey string
+
+	// 平台管理员钱包地址（逗号分隔），用于审核医院入驻
+	PlatformAdminAddresses []string
+
+	// 链上锚定（可选）：DID注册表与凭证Merkle根锚定合约
+	AnchoringEnabled        bool
+	DIDRegistryAddress      string
+	CredentialAnchorAddress string
+	AnchorArtifactsDir      string // Hardhat编译产物目录，未配置合约地址时用于部署
+	AnchorBatchInterval     int64  // 凭证批量锚定间隔（秒）
+	AnchorBatchSize         int64  // 每批最多锚定的记录数
+
+	// DID解析
+	DIDResolverCacheTTL int64  // 外部DID解析结果缓存时间（秒），0表示不缓存
+	DIDWebStubDir       string // did:web本地桩目录，设置后不访问网络
+
+	// 子NFT申请
+	ChildRequestTTL            int64 // 待审批申请的有效期（秒），0表示永不过期
+	ChildRequestExpiryInterval int64 // 过期申请清理间隔（秒）
+	ChildMintReceiptTimeout    int64 // 批准后等待创建子NFT交易回执的时间（秒），超时后由后台任务继续确认
+
+	// 事件通知Webhook
+	WebhookTimeout      int64 // 单次投递的HTTP超时（秒）
+	WebhookMaxAttempts  int64 // 最多投递次数，用尽后转入死信表
+	WebhookRetryBase    int64 // 第一次重试的等待时间（秒），之后每次翻倍
+	WebhookPollInterval int64 // 检查到期重试任务的间隔（秒）
+
+	// 合约事件索引
+	IndexerEnabled       bool
+	IndexerStartBlock    int64 // 首次启动时开始回填的区块
+	IndexerBatchSize     int64 // 每次FilterLogs查询的最大区块数
+	IndexerPollInterval  int64 // 检查新区块的间隔（秒）
+	IndexerConfirmations int64 // 区块达到多少确认后才写入数据库
+	IndexerReorgWindow   int64 // 可回滚的最大重组深度（区块数）
+
+	// 平台账户交易管理
+	TxMaxFeeGwei       int64 // 单位gas费用上限（gwei），0表示不限制
+	TxGasMarginPercent int64 // 在估算的gas上增加的余量（百分比）
+	TxBumpAfter        int64 // 交易超过该时间（秒）未打包则加价重发，0表示不加价
+	TxBumpPercent      int64 // 每次加价的比例（百分比），不低于10
+	TxPollInterval     int64 // 检查待打包交易的间隔（秒）
+	TxWaitTimeout      int64 // 接口等待交易最终状态的时间（秒），超时返回pending
+
+	// 平台账户签名
+	DevMode              bool   // 开发模式，允许使用内置的默认私钥
+	SignerBackend        string // dev、keystore或remote
+	KeystoreFile         string // 加密的keystore文件
+	KeystorePasswordFile string // keystore密码文件
+	RemoteSignerURL      string // 远程签名服务（Clef）的RPC地址
+	RemoteSignerAddress  string // 远程签名使用的账户
+	RemoteSignerMethod   string // 签名方法，account_signTransaction或eth_signTransaction
+
+	// 用户签名、平台代为提交的链上操作
+	MetaTxTTL int64 // 签名请求的有效期（秒），用于计算下发的deadline
+
+	// 多链、多合约部署
+	DeploymentsFile string       // 部署配置文件（JSON），未配置时只有由上面单部署字段组成的default部署
+	Deployments     []Deployment // 第一个为默认部署
+
+	// 链上读取
+	ChainReadBatchSize int64  // 每个JSON-RPC批量请求或Multicall3调用包含的最大调用数
+	Multicall3Address  string // Multicall3合约地址，为空时使用JSON-RPC批量请求
+	NFTReadConsistency string // 列表接口默认的一致性模式：db、chain或db-verified
+
+	// NFT元数据
+	IPFSGateway      string // 解析ipfs://元数据使用的网关
+	ArweaveGateway   string // 解析ar://元数据使用的网关
+	MetadataTimeout  int64  // 下载元数据的超时（秒）
+	MetadataMaxBytes int64  // 元数据文档的最大字节数
+	MetadataCacheTTL int64  // https元数据的缓存时间（秒），0表示不缓存
+
+	// 签名授权的写操作
+	ActionChallengeTTL int64 // 操作挑战的有效期（秒）
 }
 
 // LoadConfig 加载配置
@@ -33,11 +110,11 @@ func LoadConfig() (*Config, error) {
 	godotenv.Load()
 
 
-	return &Config{
+	cfg := &Config{
 		EthereumRPC:     getEnv("ETHEREUM_RPC", "http://localhost:7545"),
 		MainNFTAddress:  getEnv("MAIN_NFT_ADDRESS", "0x3b5a6b78d0625d6eb6333e0DA27b75A12Fc5F27D"),
 		ChildNFTAddress: getEnv("CHILD_NFT_ADDRESS", "0x38C5f113b716e21C57cc24bDEE237cEd28bA866F"),
-		PrivateKey:      getEnv("PRIVATE_KEY", "63435add31c605dfa2ee262dfb1dd019c985c881196309c4d194d3574a0c3fc1"),
+		PrivateKey:      getEnv("PRIVATE_KEY", DefaultDevPrivateKey),
 		ChainID:         getEnvAsInt64("CHAIN_ID", 1337),
 		Port:            getEnv("PORT", "8080"),
 		// 数据库配置
@@ -48,9 +125,85 @@ func LoadConfig() (*Config, error) {
 		DBName:     getEnv("DB_NAME", "nft_db"),
 
 		//ipfs
+
+		PlatformAdminAddresses: getEnvAsList("PLATFORM_ADMIN_ADDRESSES"),
+
+		// 链上锚定
+		AnchoringEnabled:        getEnvAsBool("ANCHORING_ENABLED", false),
+		DIDRegistryAddress:      getEnv("DID_REGISTRY_ADDRESS", ""),
+		CredentialAnchorAddress: getEnv("CREDENTIAL_ANCHOR_ADDRESS", ""),
+		AnchorArtifactsDir:      getEnv("ANCHOR_ARTIFACTS_DIR", "../artifacts/contracts"),
+		AnchorBatchInterval:     getEnvAsInt64("ANCHOR_BATCH_INTERVAL", 60),
+		AnchorBatchSize:         getEnvAsInt64("ANCHOR_BATCH_SIZE", 256),
+
+		// DID解析
+		DIDResolverCacheTTL: getEnvAsInt64("DID_RESOLVER_CACHE_TTL", 300),
+		DIDWebStubDir:       getEnv("DID_WEB_STUB_DIR", ""),
+
+		// 子NFT申请
+		ChildRequestTTL:            getEnvAsInt64("CHILD_REQUEST_TTL", 7*24*3600),
+		ChildMintReceiptTimeout:    getEnvAsInt64("CHILD_MINT_RECEIPT_TIMEOUT", 60),
+		ChildRequestExpiryInterval: getEnvAsInt64("CHILD_REQUEST_EXPIRY_INTERVAL", 300),
+
+		// 事件通知Webhook
+		WebhookTimeout:      getEnvAsInt64("WEBHOOK_TIMEOUT", 10),
+		WebhookMaxAttempts:  getEnvAsInt64("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookRetryBase:    getEnvAsInt64("WEBHOOK_RETRY_BASE", 30),
+		WebhookPollInterval: getEnvAsInt64("WEBHOOK_POLL_INTERVAL", 5),
+
+		// 合约事件索引
+		IndexerEnabled:       getEnvAsBool("INDEXER_ENABLED", true),
+		IndexerStartBlock:    getEnvAsInt64("INDEXER_START_BLOCK", 0),
+		IndexerBatchSize:     getEnvAsInt64("INDEXER_BATCH_SIZE", 2000),
+		IndexerPollInterval:  getEnvAsInt64("INDEXER_POLL_INTERVAL", 5),
+		IndexerConfirmations: getEnvAsInt64("INDEXER_CONFIRMATIONS", 6),
+		IndexerReorgWindow:   getEnvAsInt64("INDEXER_REORG_WINDOW", 128),
+
+		// 平台账户交易管理
+		TxMaxFeeGwei:       getEnvAsInt64("TX_MAX_FEE_GWEI", 0),
+		TxGasMarginPercent: getEnvAsInt64("TX_GAS_MARGIN_PERCENT", 20),
+		TxBumpAfter:        getEnvAsInt64("TX_BUMP_AFTER", 60),
+		TxBumpPercent:      getEnvAsInt64("TX_BUMP_PERCENT", 15),
+		TxPollInterval:     getEnvAsInt64("TX_POLL_INTERVAL", 5),
+		TxWaitTimeout:      getEnvAsInt64("TX_WAIT_TIMEOUT", 30),
+
+		// 平台账户签名
+		DevMode:              getEnvAsBool("DEV_MODE", false),
+		SignerBackend:        getEnv("SIGNER_BACKEND", "dev"),
+		KeystoreFile:         getEnv("KEYSTORE_FILE", ""),
+		KeystorePasswordFile: getEnv("KEYSTORE_PASSWORD_FILE", ""),
+		RemoteSignerURL:      getEnv("REMOTE_SIGNER_URL", "http://localhost:8550"),
+		RemoteSignerAddress:  getEnv("REMOTE_SIGNER_ADDRESS", ""),
+		RemoteSignerMethod:   getEnv("REMOTE_SIGNER_METHOD", "account_signTransaction"),
+
+		// 用户签名、平台代为提交的链上操作
+		MetaTxTTL: getEnvAsInt64("META_TX_TTL", 600),
 		AcccessKey: getEnv("IPFS_ACCESS_KEY", "NDU5RDlCQUU0NTg5NkYzRDA5Njc6dWdMSll1enZvaTBCWGNOVjZtRnNBcEY3YzVGM2FkZ3R1aWVUVUFTdTphYmUtbmZ0"),
-		
-	}, nil
+
+		// 多链、多合约部署
+		DeploymentsFile: getEnv("DEPLOYMENTS_FILE", ""),
+
+		// 链上读取
+		ChainReadBatchSize: getEnvAsInt64("CHAIN_READ_BATCH_SIZE", 100),
+		Multicall3Address:  getEnv("MULTICALL3_ADDRESS", ""),
+		NFTReadConsistency: getEnv("NFT_READ_CONSISTENCY", "db"),
+
+		// NFT元数据
+		IPFSGateway:      getEnv("IPFS_GATEWAY", "http://localhost:8080/ipfs/"),
+		ArweaveGateway:   getEnv("ARWEAVE_GATEWAY", "https://arweave.net/"),
+		MetadataTimeout:  getEnvAsInt64("METADATA_TIMEOUT", 10),
+		MetadataMaxBytes: getEnvAsInt64("METADATA_MAX_BYTES", 1<<20),
+		MetadataCacheTTL: getEnvAsInt64("METADATA_CACHE_TTL", 300),
+
+		// 签名授权的写操作
+		ActionChallengeTTL: getEnvAsInt64("ACTION_CHALLENGE_TTL", 300),
+	}
+
+	// 读取部署列表，未配置部署文件时由单部署字段组成默认部署
+	if err := cfg.loadDeployments(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
 }
 
 // getEnv 获取环境变量，如果不存在则返回默认值
@@ -77,6 +230,26 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 	return intValue
 } 
 
+// getEnvAsBool 获取环境变量并转换为bool
+func getEnvAsBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvAsList 获取以逗号分隔的环境变量列表
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
 // GetDSN 返回数据库连接字符串
 func (c *Config) GetDSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
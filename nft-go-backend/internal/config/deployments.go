@@ -0,0 +1,119 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultDeploymentName 未配置部署文件时，由ETHEREUM_RPC等环境变量组成的部署名称
+const DefaultDeploymentName = "default"
+
+// Deployment 一组部署在同一条链上的MainNFT和ChildNFT合约，接口通过collection参数选择
+type Deployment struct {
+	Name            string `json:"name"` // 集合名称，即接口的collection参数
+	ChainID         int64  `json:"chainId"`
+	RPC             string `json:"rpc"`
+	MainNFTAddress  string `json:"mainNftAddress"`
+	ChildNFTAddress string `json:"childNftAddress"`
+	StartBlock      int64  `json:"startBlock"`    // 索引器首次启动时开始回填的区块
+	Confirmations   int64  `json:"confirmations"` // 区块达到多少确认后才写入数据库
+
+	Multicall3Address string `json:"multicall3Address,omitempty"` // 链上的Multicall3合约，为空时使用MULTICALL3_ADDRESS
+}
+
+// deploymentsFile 部署配置文件的结构，default为空时第一个部署为默认部署
+type deploymentsFile struct {
+	Default     string       `json:"default"`
+	Deployments []Deployment `json:"deployments"`
+}
+
+// loadDeployments 从DEPLOYMENTS_FILE读取部署列表并把默认部署排在第一位；未配置文件时用旧的单部署环境变量
+func (c *Config) loadDeployments() error {
+	if c.DeploymentsFile == "" {
+		c.Deployments = []Deployment{{
+			Name:            DefaultDeploymentName,
+			ChainID:         c.ChainID,
+			RPC:             c.EthereumRPC,
+			MainNFTAddress:  c.MainNFTAddress,
+			ChildNFTAddress: c.ChildNFTAddress,
+			StartBlock:      c.IndexerStartBlock,
+			Confirmations:   c.IndexerConfirmations,
+		}}
+		return nil
+	}
+
+	raw, err := os.ReadFile(c.DeploymentsFile)
+	if err != nil {
+		return fmt.Errorf("读取部署配置文件失败: %v", err)
+	}
+	var file deploymentsFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return fmt.Errorf("解析部署配置文件失败: %v", err)
+	}
+	if len(file.Deployments) == 0 {
+		return fmt.Errorf("部署配置文件 %s 中没有部署", c.DeploymentsFile)
+	}
+
+	seen := make(map[string]bool)
+	defaultIndex := -1
+	for i := range file.Deployments {
+		d := &file.Deployments[i]
+		d.Name = strings.TrimSpace(d.Name)
+		if d.Name == "" {
+			return fmt.Errorf("第 %d 个部署缺少name", i+1)
+		}
+		if seen[d.Name] {
+			return fmt.Errorf("部署名称 %s 重复", d.Name)
+		}
+		seen[d.Name] = true
+		if d.ChainID <= 0 || d.RPC == "" {
+			return fmt.Errorf("部署 %s 缺少chainId或rpc", d.Name)
+		}
+		if !common.IsHexAddress(d.MainNFTAddress) || !common.IsHexAddress(d.ChildNFTAddress) {
+			return fmt.Errorf("部署 %s 的合约地址无效", d.Name)
+		}
+		if d.Multicall3Address != "" && !common.IsHexAddress(d.Multicall3Address) {
+			return fmt.Errorf("部署 %s 的multicall3Address无效", d.Name)
+		}
+		if d.StartBlock < 0 || d.Confirmations < 0 {
+			return fmt.Errorf("部署 %s 的startBlock和confirmations不能为负数", d.Name)
+		}
+		if d.Name == file.Default {
+			defaultIndex = i
+		}
+	}
+	switch {
+	case file.Default == "":
+		defaultIndex = 0
+	case defaultIndex < 0:
+		return fmt.Errorf("默认部署 %s 不存在", file.Default)
+	}
+
+	c.Deployments = append([]Deployment{file.Deployments[defaultIndex]}, file.Deployments[:defaultIndex]...)
+	c.Deployments = append(c.Deployments, file.Deployments[defaultIndex+1:]...)
+	return nil
+}
+
+// DefaultDeployment 请求未指定collection时使用的部署
+func (c *Config) DefaultDeployment() Deployment {
+	return c.Deployments[0]
+}
+
+// ForDeployment 返回以部署的链、RPC和合约地址替换单部署字段后的配置副本，其他配置共用
+func (c *Config) ForDeployment(d Deployment) *Config {
+	copied := *c
+	copied.ChainID = d.ChainID
+	copied.EthereumRPC = d.RPC
+	copied.MainNFTAddress = d.MainNFTAddress
+	copied.ChildNFTAddress = d.ChildNFTAddress
+	copied.IndexerStartBlock = d.StartBlock
+	copied.IndexerConfirmations = d.Confirmations
+	if d.Multicall3Address != "" {
+		copied.Multicall3Address = d.Multicall3Address
+	}
+	return &copied
+}
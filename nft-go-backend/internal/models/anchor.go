@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CredentialAnchorEntry 待锚定或已锚定的一条凭证颁发/撤销记录（Merkle叶子）
+type CredentialAnchorEntry struct {
+	gorm.Model
+	CredentialID string `json:"credentialId" gorm:"column:credential_id;size:255;not null;index"` // 凭证ID（通用凭证或主体凭证）
+	Event        string `json:"event" gorm:"column:event;size:32;not null"`                       // 事件：issued, revoked
+	ContentHash  string `json:"contentHash" gorm:"column:content_hash;size:66;not null"`          // 凭证内容的keccak256
+	LeafHash     string `json:"leafHash" gorm:"column:leaf_hash;size:66;not null;uniqueIndex"`    // Merkle叶子哈希
+	BatchID      *uint  `json:"batchId" gorm:"column:batch_id;index"`                             // 所属批次，未锚定时为空
+	LeafIndex    int    `json:"leafIndex" gorm:"column:leaf_index"`                               // 在批次中的位置
+}
+
+// TableName 指定表名
+func (CredentialAnchorEntry) TableName() string {
+	return "credential_anchor_entries"
+}
+
+// CredentialAnchorBatch 一次写入链上的Merkle根
+type CredentialAnchorBatch struct {
+	gorm.Model
+	MerkleRoot    string     `json:"merkleRoot" gorm:"column:merkle_root;size:66;not null;uniqueIndex"` // Merkle根
+	LeafCount     int        `json:"leafCount" gorm:"column:leaf_count"`                                // 叶子数量
+	Status        string     `json:"status" gorm:"column:status;size:32;not null;default:'pending'"`    // 状态：pending, anchored, failed
+	TxHash        string     `json:"txHash" gorm:"column:tx_hash;size:66"`                              // 锚定交易哈希
+	BlockNumber   uint64     `json:"blockNumber" gorm:"column:block_number"`                            // 所在区块
+	OnChainID     uint64     `json:"onChainId" gorm:"column:on_chain_id"`                               // 合约中的批次ID
+	AnchoredAt    *time.Time `json:"anchoredAt" gorm:"column:anchored_at"`                              // 上链时间
+	FailureReason string     `json:"failureReason" gorm:"column:failure_reason;type:text"`              // 失败原因
+}
+
+// TableName 指定表名
+func (CredentialAnchorBatch) TableName() string {
+	return "credential_anchor_batches"
+}
+
+// DIDAnchorRecord DID文档变更写入链上注册表的记录
+type DIDAnchorRecord struct {
+	gorm.Model
+	DIDString     string   `json:"did" gorm:"column:did_string;size:255;not null;index"`           // DID
+	VersionID     uint     `json:"versionId" gorm:"column:version_id"`                             // 对应的文档版本
+	Operation     string   `json:"operation" gorm:"column:operation;size:64"`                      // DID操作
+	TxHashes      []string `json:"txHashes" gorm:"column:tx_hashes;type:text;serializer:json"`     // 注册表交易哈希
+	Status        string   `json:"status" gorm:"column:status;size:32;not null;default:'pending'"` // 状态：pending, anchored, skipped, failed
+	FailureReason string   `json:"failureReason" gorm:"column:failure_reason;type:text"`           // 失败或跳过原因
+}
+
+// TableName 指定表名
+func (DIDAnchorRecord) TableName() string {
+	return "did_anchor_records"
+}
+
+// CredentialAnchorProof 凭证记录的Merkle包含证明
+type CredentialAnchorProof struct {
+	CredentialID    string   `json:"credentialId"`    // 凭证ID
+	Event           string   `json:"event"`           // 事件：issued, revoked
+	ContentHash     string   `json:"contentHash"`     // 凭证内容哈希
+	LeafHash        string   `json:"leafHash"`        // 叶子哈希
+	Status          string   `json:"status"`          // pending（等待批量锚定）或批次状态
+	MerkleRoot      string   `json:"merkleRoot"`      // Merkle根
+	Proof           []string `json:"proof"`           // 自下而上的兄弟节点哈希
+	LeafIndex       int      `json:"leafIndex"`       // 叶子位置
+	TxHash          string   `json:"txHash"`          // 锚定交易哈希
+	BlockNumber     uint64   `json:"blockNumber"`     // 所在区块
+	ProofValid      bool     `json:"proofValid"`      // 本地校验证明是否成立
+	OnChainVerified bool     `json:"onChainVerified"` // 合约verifyInclusion的结果
+}
+
+// CredentialAnchorStatus 凭证验证时附带的链上锚定核对结果
+type CredentialAnchorStatus struct {
+	Anchored        bool   `json:"anchored"`             // 颁发记录是否已锚定
+	ContentMatches  bool   `json:"contentMatches"`       // 当前内容与锚定时是否一致
+	OnChainVerified bool   `json:"onChainVerified"`      // 链上包含证明是否通过
+	RevokedOnChain  bool   `json:"revokedOnChain"`       // 是否有已锚定的撤销记录
+	MerkleRoot      string `json:"merkleRoot,omitempty"` // 颁发记录所在批次的Merkle根
+	TxHash          string `json:"txHash,omitempty"`     // 锚定交易哈希
+}
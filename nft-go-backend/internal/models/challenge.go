@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ActionChallenge 签名授权写操作时下发的一次性挑战，签名消息绑定操作、参数、nonce和过期时间
+type ActionChallenge struct {
+	gorm.Model
+	WalletAddress string     `json:"walletAddress" gorm:"column:wallet_address;size:255;index;not null"` // 签名者钱包地址（小写）
+	Action        string     `json:"action" gorm:"column:action;size:64;not null"`                       // 操作名称
+	Nonce         string     `json:"nonce" gorm:"column:nonce;uniqueIndex;size:64;not null"`             // 一次性挑战值
+	ExpiresAt     time.Time  `json:"expiresAt" gorm:"column:expires_at;not null"`                        // 过期时间（精确到秒）
+	UsedAt        *time.Time `json:"usedAt" gorm:"column:used_at"`                                       // 使用时间
+}
+
+// TableName 指定表名
+func (ActionChallenge) TableName() string {
+	return "action_challenges"
+}
+
+// ActionChallengeRequest 申请操作挑战的请求
+type ActionChallengeRequest struct {
+	Address string            `json:"address" binding:"required"` // 签名者钱包地址
+	Action  string            `json:"action" binding:"required"`  // 操作名称
+	Params  map[string]string `json:"params"`                     // 操作参数，只用于生成待签名消息，提交操作时按请求内容重新构造
+}
+
+// ActionChallengeResponse 操作挑战的响应
+type ActionChallengeResponse struct {
+	Action    string `json:"action"`    // 操作名称
+	Nonce     string `json:"nonce"`     // 挑战值
+	Message   string `json:"message"`   // 钱包需要签名的消息
+	ExpiresAt int64  `json:"expiresAt"` // 过期时间（Unix秒）
+}
+
+// ActionProof 请求中携带的操作签名：对操作挑战消息的personal_sign签名
+type ActionProof struct {
+	Address   string `json:"address" form:"address"`     // 签名者钱包地址
+	Nonce     string `json:"nonce" form:"nonce"`         // 操作挑战的nonce
+	Signature string `json:"signature" form:"signature"` // 签名
+}
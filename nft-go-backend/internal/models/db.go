@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -12,8 +13,19 @@ import (
 // DB 全局数据库连接
 var DB *gorm.DB
 
-// InitDB 初始化数据库连接
-func InitDB(dsn string) error {
+// LegacyDeployment 支持多部署之前唯一的合约部署，迁移时没有链、合约或部署标识的旧记录归入该部署
+type LegacyDeployment struct {
+	Name          string
+	ChainID       int64
+	MainContract  string // 校验和格式
+	ChildContract string
+}
+
+// legacyIndexerName 支持多部署之前索引器使用的名称
+const legacyIndexerName = "nft"
+
+// InitDB 初始化数据库连接，legacy为旧记录所属的部署
+func InitDB(dsn string, legacy LegacyDeployment) error {
 	var err error
 	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
@@ -24,7 +36,7 @@ func InitDB(dsn string) error {
 	}
 
 	// 执行安全的数据库迁移
-	if err := safeMigration(); err != nil {
+	if err := safeMigration(legacy); err != nil {
 		return fmt.Errorf("数据库迁移失败: %w", err)
 	}
 
@@ -33,7 +45,7 @@ func InitDB(dsn string) error {
 }
 
 // safeMigration 安全的数据库迁移
-func safeMigration() error {
+func safeMigration(legacy LegacyDeployment) error {
 	log.Println("开始安全数据库迁移...")
 
 	// 强制重建NFT元数据表以修复表结构问题
@@ -48,23 +60,57 @@ func safeMigration() error {
 	}
 	log.Println("DID表迁移完成")
 
-	if err := migrateDoctorTable(); err != nil {
-		return fmt.Errorf("迁移Doctor表失败: %w", err)
+	// 一次性数据迁移的完成记录
+	if err := DB.AutoMigrate(&DataMigration{}); err != nil {
+		return fmt.Errorf("迁移数据迁移记录表失败: %w", err)
+	}
+	doctorsMigrated, err := dataMigrationDone(doctorsToSubjectsMigration)
+	if err != nil {
+		return err
+	}
+
+	// 旧的doctors和doctor_vcs表只在复制到主体表之前需要补齐列，复制完成后不再读写
+	if !doctorsMigrated {
+		if err := migrateDoctorTable(); err != nil {
+			return fmt.Errorf("迁移Doctor表失败: %w", err)
+		}
+		log.Println("Doctor表迁移完成")
+
+		// 迁移DoctorVC表
+		if err := migrateDoctorVCTable(); err != nil {
+			return fmt.Errorf("迁移DoctorVC表失败: %w", err)
+		}
+		log.Println("DoctorVC表迁移完成")
+	}
+
+	// 子NFT与主NFT的tokenID各自从1开始，NFT表改为按合约类型+tokenID唯一
+	if err := migrateNFTTokenIndex(); err != nil {
+		return fmt.Errorf("迁移NFT唯一索引失败: %w", err)
 	}
-	log.Println("Doctor表迁移完成")
 
-	// 迁移DoctorVC表
-	if err := migrateDoctorVCTable(); err != nil {
-		return fmt.Errorf("迁移DoctorVC表失败: %w", err)
+	// 支持多部署：NFT表改为按链+合约+tokenID唯一，申请、规则和交易记录增加部署标识，旧记录归入legacy部署
+	if err := migrateDeploymentKeys(legacy); err != nil {
+		return fmt.Errorf("迁移部署标识失败: %w", err)
 	}
-	log.Println("DoctorVC表迁移完成")
 
 	// 自动迁移其他表 - 这些都是安全的
-	err := DB.AutoMigrate(
+	err = DB.AutoMigrate(
 		// NFT相关模型
 		&NFT{},
 		&ChildNFTRequest{},
+		&ChildNFTRequestTransition{},
+		&ChildIssuanceRule{},
+		&WebhookEndpoint{},
+		&WebhookDelivery{},
+		&WebhookDeadLetter{},
+		&IndexerCheckpoint{},
+		&IndexedBlock{},
+		&IndexerJournal{},
+		&ManagedTransaction{},
+		&ChainTransaction{},
 		&NFTMetadataDB{},
+		// 签名授权的写操作
+		&ActionChallenge{},
 		// ABE相关模型
 		&ABESystemKey{},
 		&ABEUserKey{},
@@ -73,13 +119,31 @@ func safeMigration() error {
 		// DID/VC相关模型
 		&VerifiableCredential{},
 		&VerifiablePresentation{},
+		&PresentationRequest{},
+		&DIDChallenge{},
+		&DIDDocumentVersion{},
 		&CredentialSchema{},
 		&CredentialDefinition{},
+		&Hospital{},
+		&IssuerAuthorization{},
+		&SubjectProfile{},
+		&SubjectCredential{},
+		// 链上锚定相关模型
+		&CredentialAnchorEntry{},
+		&CredentialAnchorBatch{},
+		&DIDAnchorRecord{},
 	)
 	if err != nil {
 		return fmt.Errorf("自动迁移其他表失败: %w", err)
 	}
 
+	// 将旧的医生及医生凭证数据迁移到通用的主体档案和主体凭证，完成后记录，之后启动不再执行
+	if !doctorsMigrated {
+		if err := migrateDoctorsToSubjects(); err != nil {
+			return fmt.Errorf("迁移医生数据失败: %w", err)
+		}
+	}
+
 	log.Println("所有表迁移完成")
 	return nil
 }
@@ -120,10 +184,9 @@ func migrateDIDTable() error {
 
 // migrateDoctorTable 迁移Doctor表
 func migrateDoctorTable() error {
-	// 检查表是否存在
+	// 旧表不存在时无需迁移，医生数据已改存主体表
 	if !DB.Migrator().HasTable(&Doctor{}) {
-		// 表不存在，直接创建
-		return DB.AutoMigrate(&Doctor{})
+		return nil
 	}
 
 	// 表存在，检查列是否存在并且正确
@@ -169,10 +232,9 @@ func migrateDoctorTable() error {
 
 // migrateDoctorVCTable 迁移DoctorVC表
 func migrateDoctorVCTable() error {
-	// 检查表是否存在
+	// 旧表不存在时无需迁移，医生凭证已改存主体凭证表
 	if !DB.Migrator().HasTable(&DoctorVC{}) {
-		// 表不存在，直接创建
-		return DB.AutoMigrate(&DoctorVC{})
+		return nil
 	}
 
 	// 表存在，检查列是否存在并且正确
@@ -216,6 +278,249 @@ func migrateDoctorVCTable() error {
 	return DB.AutoMigrate(&DoctorVC{})
 }
 
+// 一次性数据迁移的名称
+const doctorsToSubjectsMigration = "doctors_to_subjects"
+
+// 医生数据迁移每批处理的记录数
+const doctorMigrationBatchSize = 500
+
+// DataMigration 已完成的一次性数据迁移
+type DataMigration struct {
+	Name        string    `gorm:"column:name;primaryKey;size:64"` // 迁移名称
+	CompletedAt time.Time `gorm:"column:completed_at;not null"`   // 完成时间
+}
+
+// TableName 指定表名
+func (DataMigration) TableName() string {
+	return "data_migrations"
+}
+
+// dataMigrationDone 检查一次性数据迁移是否已完成
+func dataMigrationDone(name string) (bool, error) {
+	var count int64
+	if err := DB.Model(&DataMigration{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询数据迁移记录失败: %w", err)
+	}
+	return count > 0, nil
+}
+
+// migrateDoctorsToSubjects 将doctors和doctor_vcs中的数据分批复制为医生角色的主体档案和凭证，
+// 全部完成后写入迁移记录；中途失败时下次启动重新执行，已复制的记录会被跳过
+func migrateDoctorsToSubjects() error {
+	var profileCount, credentialCount int
+
+	if DB.Migrator().HasTable(&Doctor{}) {
+		var batch []Doctor
+		err := DB.FindInBatches(&batch, doctorMigrationBatchSize, func(*gorm.DB, int) error {
+			dids := make([]string, 0, len(batch))
+			for _, doctor := range batch {
+				dids = append(dids, doctor.DIDString)
+			}
+			var existing []string
+			if err := DB.Model(&SubjectProfile{}).Where("did_string IN ? AND role = ?", dids, "doctor").Pluck("did_string", &existing).Error; err != nil {
+				return err
+			}
+			migrated := make(map[string]bool, len(existing))
+			for _, did := range existing {
+				migrated[did] = true
+			}
+
+			var profiles []SubjectProfile
+			for _, doctor := range batch {
+				if migrated[doctor.DIDString] {
+					continue
+				}
+				migrated[doctor.DIDString] = true
+				profiles = append(profiles, SubjectProfile{
+					Model:           gorm.Model{CreatedAt: doctor.CreatedAt, UpdatedAt: doctor.UpdatedAt},
+					DIDString:       doctor.DIDString,
+					WalletAddress:   doctor.WalletAddress,
+					Role:            "doctor",
+					Name:            doctor.Name,
+					Attributes:      map[string]interface{}{"licenseNumber": doctor.LicenseNumber},
+					OrganizationDID: doctor.HospitalDID,
+					Status:          doctor.Status,
+				})
+			}
+			if len(profiles) == 0 {
+				return nil
+			}
+			if err := DB.Create(&profiles).Error; err != nil {
+				return fmt.Errorf("迁移医生档案失败: %w", err)
+			}
+			profileCount += len(profiles)
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if DB.Migrator().HasTable(&DoctorVC{}) {
+		var batch []DoctorVC
+		err := DB.FindInBatches(&batch, doctorMigrationBatchSize, func(*gorm.DB, int) error {
+			vcIDs := make([]string, 0, len(batch))
+			for _, vc := range batch {
+				vcIDs = append(vcIDs, vc.VCID)
+			}
+			var existing []string
+			if err := DB.Model(&SubjectCredential{}).Where("vcid IN ?", vcIDs).Pluck("vcid", &existing).Error; err != nil {
+				return err
+			}
+			migrated := make(map[string]bool, len(existing))
+			for _, vcID := range existing {
+				migrated[vcID] = true
+			}
+
+			var credentials []SubjectCredential
+			for _, vc := range batch {
+				if migrated[vc.VCID] {
+					continue
+				}
+				migrated[vc.VCID] = true
+				credentials = append(credentials, SubjectCredential{
+					Model:          gorm.Model{CreatedAt: vc.CreatedAt, UpdatedAt: vc.UpdatedAt},
+					VCID:           vc.VCID,
+					SubjectDID:     vc.DoctorDID,
+					Role:           "doctor",
+					IssuerDID:      vc.IssuerDID,
+					Type:           vc.Type,
+					Content:        vc.Content,
+					IssuedAt:       vc.IssuedAt,
+					ExpiresAt:      vc.ExpiresAt,
+					Status:         vc.Status,
+					RevocationDate: vc.RevocationDate,
+					SchemaID:       vc.SchemaID,
+					SuspendedAt:    vc.SuspendedAt,
+					StatusReason:   vc.StatusReason,
+					ReplacesVCID:   vc.ReplacesVCID,
+					SupersededBy:   vc.SupersededBy,
+				})
+			}
+			if len(credentials) == 0 {
+				return nil
+			}
+			if err := DB.Create(&credentials).Error; err != nil {
+				return fmt.Errorf("迁移医生凭证失败: %w", err)
+			}
+			credentialCount += len(credentials)
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	marker := DataMigration{Name: doctorsToSubjectsMigration, CompletedAt: time.Now()}
+	if err := DB.Create(&marker).Error; err != nil {
+		return fmt.Errorf("记录数据迁移失败: %w", err)
+	}
+	log.Printf("医生数据迁移完成: %d 个档案, %d 份凭证", profileCount, credentialCount)
+	return nil
+}
+
+// migrateNFTTokenIndex 删除旧的token_id单列唯一索引，新的组合唯一索引由AutoMigrate创建
+func migrateNFTTokenIndex() error {
+	if !DB.Migrator().HasTable(&NFT{}) || !DB.Migrator().HasIndex(&NFT{}, "token_id") {
+		return nil
+	}
+	log.Println("删除NFT表旧的token_id唯一索引...")
+	return DB.Migrator().DropIndex(&NFT{}, "token_id")
+}
+
+// migrateDeploymentKeys 为已有的表补充链、合约或部署列并回填旧记录，删除不含部署的旧唯一索引，
+// 新的组合唯一索引由AutoMigrate创建。可重复执行，只更新标识为空的记录
+func migrateDeploymentKeys(legacy LegacyDeployment) error {
+	columns := []struct {
+		model interface{}
+		field string
+	}{
+		{&NFT{}, "ChainID"},
+		{&NFT{}, "Contract"},
+		{&ChildNFTRequest{}, "Collection"},
+		{&ChildIssuanceRule{}, "Collection"},
+		{&ChainTransaction{}, "Collection"},
+		{&ManagedTransaction{}, "ChainID"},
+	}
+	for _, column := range columns {
+		if !DB.Migrator().HasTable(column.model) || DB.Migrator().HasColumn(column.model, column.field) {
+			continue
+		}
+		if err := DB.Migrator().AddColumn(column.model, column.field); err != nil {
+			return err
+		}
+	}
+
+	for model, index := range map[interface{}]string{
+		&NFT{}:               "idx_nft_contract_token",
+		&ChildIssuanceRule{}: "idx_child_issuance_rules_parent_token_id",
+	} {
+		if DB.Migrator().HasTable(model) && DB.Migrator().HasIndex(model, index) {
+			log.Printf("删除不含部署的旧唯一索引 %s...", index)
+			if err := DB.Migrator().DropIndex(model, index); err != nil {
+				return err
+			}
+		}
+	}
+
+	backfills := []struct {
+		model   interface{}
+		where   string
+		args    []interface{}
+		updates map[string]interface{}
+	}{
+		{&NFT{}, "contract = '' AND contract_type = ?", []interface{}{"child"},
+			map[string]interface{}{"chain_id": legacy.ChainID, "contract": legacy.ChildContract}},
+		{&NFT{}, "contract = ''", nil,
+			map[string]interface{}{"chain_id": legacy.ChainID, "contract": legacy.MainContract}},
+		{&ChildNFTRequest{}, "collection = ''", nil, map[string]interface{}{"collection": legacy.Name}},
+		{&ChildIssuanceRule{}, "collection = ''", nil, map[string]interface{}{"collection": legacy.Name}},
+		{&ChainTransaction{}, "collection = ''", nil, map[string]interface{}{"collection": legacy.Name}},
+		{&ManagedTransaction{}, "chain_id = 0", nil, map[string]interface{}{"chain_id": legacy.ChainID}},
+	}
+	for _, backfill := range backfills {
+		if !DB.Migrator().HasTable(backfill.model) {
+			continue
+		}
+		result := DB.Unscoped().Model(backfill.model).Where(backfill.where, backfill.args...).Updates(backfill.updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("已将 %d 条旧记录归入部署 %s", result.RowsAffected, legacy.Name)
+		}
+	}
+
+	return migrateLegacyIndexer(legacy.Name)
+}
+
+// migrateLegacyIndexer 把旧索引器的进度、区块哈希和修改日志改为以部署名称标识，避免重新回填
+func migrateLegacyIndexer(name string) error {
+	if name == legacyIndexerName || !DB.Migrator().HasTable(&IndexerCheckpoint{}) {
+		return nil
+	}
+	var count int64
+	if err := DB.Model(&IndexerCheckpoint{}).Where("name = ?", name).Count(&count).Error; err != nil || count > 0 {
+		return err
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&IndexerCheckpoint{}).Where("name = ?", legacyIndexerName).Update("name", name).Error; err != nil {
+			return err
+		}
+		if tx.Migrator().HasTable(&IndexedBlock{}) {
+			if err := tx.Model(&IndexedBlock{}).Where("indexer = ?", legacyIndexerName).Update("indexer", name).Error; err != nil {
+				return err
+			}
+		}
+		if tx.Migrator().HasTable(&IndexerJournal{}) {
+			if err := tx.Model(&IndexerJournal{}).Where("indexer = ?", legacyIndexerName).Update("indexer", name).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // fixNFTMetadataTable 修复NFT元数据表结构
 func fixNFTMetadataTable() error {
 	log.Println("开始修复NFT元数据表...")
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,9 +10,12 @@ import (
 // DID 表示去中心化身份标识符的数据库模型
 type DID struct {
 	gorm.Model
-	DIDString     string `json:"didString" gorm:"column:did_string;unique;not null"`     // 完整的DID字符串
-	WalletAddress string `json:"walletAddress" gorm:"column:wallet_address;unique"`      // 关联的钱包地址
-	Status        string `json:"status" gorm:"column:status;not null;default:'active'"` // DID状态：active, revoked
+	DIDString     string     `json:"didString" gorm:"column:did_string;unique;not null"`    // 完整的DID字符串
+	WalletAddress string     `json:"walletAddress" gorm:"column:wallet_address;unique"`     // 关联的钱包地址
+	Status        string     `json:"status" gorm:"column:status;not null;default:'active'"` // DID状态：active, revoked
+	PublicKeyHex  string     `json:"publicKeyHex" gorm:"column:public_key_hex;size:66"`     // 从钱包签名恢复的压缩公钥
+	VersionID     uint       `json:"versionId" gorm:"column:version_id;not null;default:1"` // DID文档版本号，每次变更递增
+	DeactivatedAt *time.Time `json:"deactivatedAt" gorm:"column:deactivated_at"`            // 停用时间
 }
 
 // TableName 指定表名
@@ -19,7 +23,7 @@ func (DID) TableName() string {
 	return "dids"
 }
 
-// Doctor 医生身份表示数据库模型
+// Doctor 医生身份表示数据库模型（旧表，数据已迁移到SubjectProfile，仅用于迁移和医生接口的响应格式）
 type Doctor struct {
 	gorm.Model
 	DIDString     string `json:"didString" gorm:"column:did_string;unique;not null"`                 // 医生DID
@@ -35,7 +39,7 @@ func (Doctor) TableName() string {
 	return "doctors"
 }
 
-// DoctorVC 医生可验证凭证数据库模型
+// DoctorVC 医生可验证凭证数据库模型（旧表，数据已迁移到SubjectCredential，仅用于迁移和医生接口的响应格式）
 type DoctorVC struct {
 	gorm.Model
 	VCID           string     `json:"vcId" gorm:"column:vcid;unique;not null"`                 // 凭证ID
@@ -45,8 +49,13 @@ type DoctorVC struct {
 	Content        string     `json:"content" gorm:"column:content;type:text"`                 // 凭证内容
 	IssuedAt       time.Time  `json:"issuedAt" gorm:"column:issued_at;not null"`               // 颁发时间
 	ExpiresAt      time.Time  `json:"expiresAt" gorm:"column:expires_at;not null"`             // 过期时间
-	Status         string     `json:"status" gorm:"column:status;not null;default:'active'"`   // 状态：active, revoked
+	Status         string     `json:"status" gorm:"column:status;not null;default:'active'"`   // 状态：active, suspended, superseded, revoked
 	RevocationDate *time.Time `json:"revocationDate" gorm:"column:revocation_date"`            // 撤销日期
+	SchemaID       string     `json:"schemaId" gorm:"column:schema_id"`                      // 凭证模式ID
+	SuspendedAt    *time.Time `json:"suspendedAt" gorm:"column:suspended_at"`                // 暂停时间
+	StatusReason   string     `json:"statusReason" gorm:"column:status_reason"`              // 暂停、撤销或取代的原因
+	ReplacesVCID   string     `json:"replacesVcId" gorm:"column:replaces_vcid"`              // 本凭证续期或取代的旧凭证ID
+	SupersededBy   string     `json:"supersededBy" gorm:"column:superseded_by"`              // 取代本凭证的新凭证ID
 }
 
 // TableName 指定表名
@@ -54,6 +63,36 @@ func (DoctorVC) TableName() string {
 	return "doctor_vcs"
 }
 
+// Hospital 受信任的凭证颁发机构（医院）数据库模型
+type Hospital struct {
+	gorm.Model
+	DIDString        string     `json:"didString" gorm:"column:did_string;unique;not null"`         // 医院DID
+	WalletAddress    string     `json:"walletAddress" gorm:"column:wallet_address;unique;not null"` // 医院钱包地址
+	Name             string     `json:"name" gorm:"column:name;not null"`                           // 医院名称
+	Status           string     `json:"status" gorm:"column:status;not null;default:'active'"`      // 状态：active, suspended
+	OnboardedBy      string     `json:"onboardedBy" gorm:"column:onboarded_by"`                     // 审核入驻的平台管理员地址
+	SuspendedAt      *time.Time `json:"suspendedAt" gorm:"column:suspended_at"`                     // 暂停时间
+	SuspensionReason string     `json:"suspensionReason" gorm:"column:suspension_reason"`           // 暂停原因
+}
+
+// TableName 指定表名
+func (Hospital) TableName() string {
+	return "hospitals"
+}
+
+// IssuerAuthorization 颁发机构可颁发的凭证类型授权
+type IssuerAuthorization struct {
+	gorm.Model
+	IssuerDID      string `json:"issuerDid" gorm:"column:issuer_did;size:255;not null;uniqueIndex:idx_issuer_credential_type"`           // 颁发机构DID
+	CredentialType string `json:"credentialType" gorm:"column:credential_type;size:255;not null;uniqueIndex:idx_issuer_credential_type"` // 凭证类型
+	GrantedBy      string `json:"grantedBy" gorm:"column:granted_by"`                                                                    // 授权的平台管理员地址
+}
+
+// TableName 指定表名
+func (IssuerAuthorization) TableName() string {
+	return "issuer_authorizations"
+}
+
 // DIDDocument 表示DID文档的结构
 type DIDDocument struct {
 	Context              []string             `json:"@context"`
@@ -72,12 +111,14 @@ type DIDDocument struct {
 
 // VerificationMethod 表示DID文档中的验证方法
 type VerificationMethod struct {
-	ID              string                 `json:"id"`
-	Type            string                 `json:"type"`
-	Controller      string                 `json:"controller"`
-	PublicKeyJwk    map[string]interface{} `json:"publicKeyJwk,omitempty"`
-	PublicKeyBase58 string                 `json:"publicKeyBase58,omitempty"`
-	PublicKeyHex    string                 `json:"publicKeyHex,omitempty"`
+	ID                  string                 `json:"id"`
+	Type                string                 `json:"type"`
+	Controller          string                 `json:"controller"`
+	PublicKeyJwk        map[string]interface{} `json:"publicKeyJwk,omitempty"`
+	PublicKeyBase58     string                 `json:"publicKeyBase58,omitempty"`
+	PublicKeyMultibase  string                 `json:"publicKeyMultibase,omitempty"`
+	PublicKeyHex        string                 `json:"publicKeyHex,omitempty"`
+	BlockchainAccountID string                 `json:"blockchainAccountId,omitempty"` // CAIP-10账户标识，如 eip155:1:0xab...
 }
 
 // Service 表示DID文档中的服务端点
@@ -127,6 +168,35 @@ func (CredentialSchema) TableName() string {
 	return "credential_schemas"
 }
 
+// CreateSchemaRequest 表示注册凭证模式的请求
+type CreateSchemaRequest struct {
+	Name        string          `json:"name" binding:"required"`    // 模式名称，通常与凭证类型一致
+	Description string          `json:"description"`                // 模式描述
+	Version     string          `json:"version" binding:"required"` // 模式版本
+	Author      string          `json:"author"`                     // 作者DID
+	Schema      json.RawMessage `json:"schema" binding:"required"`  // JSON Schema定义
+	Proof       ActionProof     `json:"proof"`                      // 平台管理员对CreateSchema操作的签名
+}
+
+// UpdateSchemaRequest 表示更新凭证模式的请求
+type UpdateSchemaRequest struct {
+	Description string          `json:"description"` // 模式描述
+	Schema      json.RawMessage `json:"schema"`      // JSON Schema定义（为空则不修改）
+	Proof       ActionProof     `json:"proof"`       // 平台管理员对UpdateSchema操作的签名
+}
+
+// SchemaResponse 表示凭证模式的响应
+type SchemaResponse struct {
+	SchemaID    string          `json:"schemaId"`    // 模式ID
+	Name        string          `json:"name"`        // 模式名称
+	Description string          `json:"description"` // 模式描述
+	Version     string          `json:"version"`     // 模式版本
+	Author      string          `json:"author"`      // 作者
+	Schema      json.RawMessage `json:"schema"`      // JSON Schema定义
+	Attributes  []string        `json:"attributes"`  // 可用于访问策略的属性名
+	CreatedAt   string          `json:"createdAt"`   // 创建时间
+}
+
 // CredentialDefinition 表示凭证定义的数据库模型
 type CredentialDefinition struct {
 	gorm.Model
@@ -152,7 +222,8 @@ type VerifiablePresentation struct {
 	VerifierDID      string     `json:"verifierDid"`                           // 验证者DID
 	CredentialIDs    []string   `json:"credentialIds" gorm:"serializer:json"`  // 包含的凭证ID列表
 	Purpose          string     `json:"purpose"`                               // 展示目的
-	Challenge        string     `json:"challenge"`                             // 挑战值
+	Challenge        string     `json:"challenge"`                             // 挑战值（验证者下发的nonce）
+	Domain           string     `json:"domain"`                                // 验证者指定的域
 	PresentationDate time.Time  `json:"presentationDate"`                      // 展示日期
 	LastVerified     *time.Time `json:"lastVerified"`                          // 最后验证时间
 	Status           string     `json:"status" gorm:"default:active"`          // 状态
@@ -164,15 +235,83 @@ func (VerifiablePresentation) TableName() string {
 	return "verifiable_presentations"
 }
 
+// PresentationRequest 表示验证者发起的展示请求（挑战-应答）
+type PresentationRequest struct {
+	gorm.Model
+	Nonce           string     `json:"nonce" gorm:"uniqueIndex;size:64;not null"` // 一次性挑战值
+	VerifierDID     string     `json:"verifierDid"`                               // 验证者DID
+	Domain          string     `json:"domain" gorm:"not null"`                    // 验证者域，防止跨站重放
+	CredentialTypes []string   `json:"credentialTypes" gorm:"serializer:json"`    // 要求出示的凭证类型
+	ExpiresAt       time.Time  `json:"expiresAt" gorm:"index;not null"`           // 过期时间
+	Status          string     `json:"status" gorm:"index;default:pending"`       // 状态：pending, used, expired
+	PresentationID  string     `json:"presentationId"`                            // 绑定的展示ID
+	UsedAt          *time.Time `json:"usedAt"`                                    // 使用时间
+}
+
+// TableName 指定表名
+func (PresentationRequest) TableName() string {
+	return "presentation_requests"
+}
+
 // DIDResolutionRequest 表示DID解析请求
 type DIDResolutionRequest struct {
 	DID string `json:"did" binding:"required"` // 要解析的DID
 }
 
-// DIDResolutionResponse 表示DID解析响应
+// DIDResolutionResponse 表示DID解析结果（DID Resolution规范）
 type DIDResolutionResponse struct {
-	DIDDocument DIDDocument `json:"didDocument"` // DID文档
-	Status      string      `json:"status"`      // 解析状态
+	Context               string                `json:"@context"`              // 解析结果上下文
+	DIDDocument           *DIDDocument          `json:"didDocument"`           // DID文档，解析失败时为null
+	DIDResolutionMetadata DIDResolutionMetadata `json:"didResolutionMetadata"` // 解析过程元数据
+	DIDDocumentMetadata   DIDDocumentMetadata   `json:"didDocumentMetadata"`   // 文档元数据
+}
+
+// DIDResolutionMetadata 表示解析过程元数据
+type DIDResolutionMetadata struct {
+	ContentType string `json:"contentType,omitempty"` // 文档表示类型
+	Error       string `json:"error,omitempty"`       // 错误码：invalidDid, notFound, methodNotSupported, invalidDidDocument
+	Retrieved   string `json:"retrieved"`             // 解析时间
+	Cached      bool   `json:"cached,omitempty"`      // 是否来自解析缓存
+}
+
+// DIDDocumentMetadata 表示DID文档元数据
+type DIDDocumentMetadata struct {
+	Created       string `json:"created,omitempty"`       // 创建时间
+	Updated       string `json:"updated,omitempty"`       // 最后更新时间
+	Deactivated   bool   `json:"deactivated"`             // 是否已停用
+	VersionID     string `json:"versionId,omitempty"`     // 当前版本号
+	NextUpdate    string `json:"nextUpdate,omitempty"`    // 按历史时间解析时，下一次变更的时间
+	NextVersionID string `json:"nextVersionId,omitempty"` // 按历史时间解析时，下一个版本号
+}
+
+// DIDChallenge 创建DID时下发给钱包签名的一次性挑战
+type DIDChallenge struct {
+	gorm.Model
+	WalletAddress string     `json:"walletAddress" gorm:"column:wallet_address;size:255;index;not null"` // 钱包地址
+	Nonce         string     `json:"nonce" gorm:"column:nonce;uniqueIndex;size:64;not null"`             // 一次性挑战值
+	ExpiresAt     time.Time  `json:"expiresAt" gorm:"column:expires_at;not null"`                        // 过期时间
+	UsedAt        *time.Time `json:"usedAt" gorm:"column:used_at"`                                       // 使用时间
+}
+
+// TableName 指定表名
+func (DIDChallenge) TableName() string {
+	return "did_challenges"
+}
+
+// DIDDocumentVersion DID文档的一个历史版本，每次签名变更追加一条
+type DIDDocumentVersion struct {
+	gorm.Model
+	DIDString   string      `json:"did" gorm:"column:did_string;size:255;not null;uniqueIndex:idx_did_version"` // DID
+	VersionID   uint        `json:"versionId" gorm:"column:version_id;not null;uniqueIndex:idx_did_version"`    // 版本号
+	Document    DIDDocument `json:"didDocument" gorm:"column:document;type:text;serializer:json"`               // 该版本的DID文档
+	Deactivated bool        `json:"deactivated" gorm:"column:deactivated;not null;default:false"`               // 该版本是否已停用
+	Operation   string      `json:"operation" gorm:"column:operation;size:64"`                                  // 产生该版本的操作
+	SignedBy    string      `json:"signedBy" gorm:"column:signed_by"`                                           // 授权该操作的控制者地址
+}
+
+// TableName 指定表名
+func (DIDDocumentVersion) TableName() string {
+	return "did_document_versions"
 }
 
 // CreateDIDRequest 表示创建DID的请求
@@ -188,37 +327,41 @@ type CreateDIDResponse struct {
 	DIDDocument DIDDocument `json:"didDocument"` // DID文档
 }
 
-// UpdateDIDRequest 表示更新DID的请求
-type UpdateDIDRequest struct {
-	DID               string `json:"did" binding:"required"` // 要更新的DID
-	ControllerAddress string `json:"controllerAddress"`      // 新的控制者地址
-	PublicKey         string `json:"publicKey"`              // 新的公钥
-	ServiceEndpoints  string `json:"serviceEndpoints"`       // 新的服务端点
+// DIDOperationRequest 表示经控制者签名的DID文档变更请求
+type DIDOperationRequest struct {
+	DID                string              `json:"did" binding:"required"`       // 要变更的DID
+	Operation          string              `json:"operation" binding:"required"` // 操作：addVerificationMethod, removeVerificationMethod, addService, removeService, setControllers, deactivate
+	VerificationMethod *VerificationMethod `json:"verificationMethod"`           // 新增的验证方法
+	Relationships      []string            `json:"relationships"`                // 新验证方法的用途，默认authentication
+	Service            *Service            `json:"service"`                      // 新增的服务端点
+	TargetID           string              `json:"targetId"`                     // 要移除的验证方法或服务ID
+	Controllers        []string            `json:"controllers"`                  // 新的控制者DID列表，为空表示由自身控制
+	Signature          string              `json:"signature" binding:"required"` // 当前控制者密钥对操作消息的签名
 }
 
-// UpdateDIDResponse 表示更新DID的响应
-type UpdateDIDResponse struct {
-	DID         string      `json:"did"`         // 更新的DID
-	DIDDocument DIDDocument `json:"didDocument"` // 更新后的DID文档
+// DIDOperationResponse 表示DID文档变更的响应
+type DIDOperationResponse struct {
+	DID         string       `json:"did"`         // DID
+	Operation   string       `json:"operation"`   // 执行的操作
+	VersionID   string       `json:"versionId"`   // 变更后的版本号
+	Deactivated bool         `json:"deactivated"` // 是否已停用
+	DIDDocument *DIDDocument `json:"didDocument"` // 变更后的DID文档
 }
 
-// RevokeDIDRequest 表示撤销DID的请求
+// RevokeDIDRequest 表示停用DID的请求
 type RevokeDIDRequest struct {
-	DID               string `json:"did" binding:"required"`               // 要撤销的DID
-	ControllerAddress string `json:"controllerAddress" binding:"required"` // 控制者地址
-}
-
-// RevokeDIDResponse 表示撤销DID的响应
-type RevokeDIDResponse struct {
-	DID    string `json:"did"`    // 撤销的DID
-	Status string `json:"status"` // 撤销状态
+	DID       string `json:"did" binding:"required"`       // 要停用的DID
+	Signature string `json:"signature" binding:"required"` // 当前控制者密钥对停用消息的签名
 }
 
 // IssueCredentialRequest 表示颁发凭证的请求
 type IssueCredentialRequest struct {
-	IssuerDID      string `json:"issuerDid" binding:"required"`      // 颁发者DID
-	SubjectDID     string `json:"subjectDid" binding:"required"`     // 主体DID
-	CredentialType string `json:"credentialType" binding:"required"` // 凭证类型
+	IssuerDID      string          `json:"issuerDid" binding:"required"`      // 颁发者DID
+	SubjectDID     string          `json:"subjectDid" binding:"required"`     // 主体DID
+	CredentialType string          `json:"credentialType" binding:"required"` // 凭证类型
+	SchemaID       string          `json:"schemaId" binding:"required"`       // 已注册的凭证模式ID
+	Claims         json.RawMessage `json:"claims"`                            // 凭证声明（JSON对象），需符合模式
+	Proof          ActionProof     `json:"proof"`                             // 颁发者的签名
 }
 
 // IssueCredentialResponse 表示颁发凭证的响应
@@ -238,6 +381,7 @@ type VerifiableCredentialResponse struct {
 	ExpirationDate    string                 `json:"expirationDate,omitempty"`
 	CredentialSubject map[string]interface{} `json:"credentialSubject,omitempty"`
 	CredentialStatus  map[string]interface{} `json:"credentialStatus,omitempty"`
+	CredentialSchema  map[string]interface{} `json:"credentialSchema,omitempty"`
 	Proof             Proof                  `json:"proof,omitempty"`
 }
 
@@ -248,13 +392,14 @@ type VerifyCredentialRequest struct {
 
 // VerifyCredentialResponse 表示验证凭证的响应
 type VerifyCredentialResponse struct {
-	Valid       bool     `json:"valid"`                 // 是否有效
-	Reason      string   `json:"reason"`                // 无效原因（如果有）
-	IssuerDID   string   `json:"issuerDid,omitempty"`   // 颁发者DID
-	SubjectDID  string   `json:"subjectDid,omitempty"`  // 主体DID
-	IssuedAt    string   `json:"issuedAt,omitempty"`    // 颁发时间
-	ExpiresAt   string   `json:"expiresAt,omitempty"`   // 过期时间
-	Credentials []string `json:"credentials,omitempty"` // 凭证类型列表
+	Valid       bool                    `json:"valid"`                 // 是否有效
+	Reason      string                  `json:"reason"`                // 无效原因（如果有）
+	IssuerDID   string                  `json:"issuerDid,omitempty"`   // 颁发者DID
+	SubjectDID  string                  `json:"subjectDid,omitempty"`  // 主体DID
+	IssuedAt    string                  `json:"issuedAt,omitempty"`    // 颁发时间
+	ExpiresAt   string                  `json:"expiresAt,omitempty"`   // 过期时间
+	Credentials []string                `json:"credentials,omitempty"` // 凭证类型列表
+	Anchor      *CredentialAnchorStatus `json:"anchor,omitempty"`      // 链上锚定核对结果（启用锚定时）
 }
 
 // RevokeCredentialRequest 表示撤销凭证的请求
@@ -272,12 +417,31 @@ type RevokeCredentialResponse struct {
 	Reason       string `json:"reason"`       // 撤销原因
 }
 
+// RequestPresentationRequest 表示验证者申请展示挑战的请求
+type RequestPresentationRequest struct {
+	VerifierDID     string   `json:"verifierDid"`               // 验证者DID
+	Domain          string   `json:"domain" binding:"required"` // 验证者域
+	CredentialTypes []string `json:"credentialTypes"`           // 要求出示的凭证类型
+	TTLSeconds      int      `json:"ttlSeconds"`                // 有效期（秒），默认300
+}
+
+// RequestPresentationResponse 表示展示挑战的响应
+type RequestPresentationResponse struct {
+	Nonce           string   `json:"nonce"`           // 一次性挑战值
+	Domain          string   `json:"domain"`          // 验证者域
+	VerifierDID     string   `json:"verifierDid"`     // 验证者DID
+	CredentialTypes []string `json:"credentialTypes"` // 要求出示的凭证类型
+	ExpiresAt       string   `json:"expiresAt"`       // 过期时间
+}
+
 // CreatePresentationRequest 表示创建表示的请求
 type CreatePresentationRequest struct {
 	HolderDID     string   `json:"holderDid" binding:"required"`     // 持有者DID
 	VerifierDID   string   `json:"verifierDid"`                      // 验证者DID
 	CredentialIDs []string `json:"credentialIds" binding:"required"` // 要包含的凭证ID列表
 	Purpose       string   `json:"purpose" binding:"required"`       // 展示目的
+	Nonce         string   `json:"nonce" binding:"required"`         // 验证者下发的挑战值
+	Signature     string   `json:"signature" binding:"required"`     // 持有者钱包对展示消息的签名
 }
 
 // CreatePresentationResponse 表示创建表示的响应
@@ -298,6 +462,7 @@ type VerifiablePresentationResponse struct {
 // VerifyPresentationRequest 表示验证表示的请求
 type VerifyPresentationRequest struct {
 	PresentationID string `json:"presentationId" binding:"required"` // 要验证的表示ID
+	Domain         string `json:"domain"`                            // 期望的验证者域（可选）
 }
 
 // VerifyPresentationResponse 表示验证表示的响应
@@ -307,18 +472,31 @@ type VerifyPresentationResponse struct {
 	HolderDID     string   `json:"holderDid,omitempty"`     // 持有者DID
 	PresentedAt   string   `json:"presentedAt,omitempty"`   // 展示时间
 	CredentialIDs []string `json:"credentialIds,omitempty"` // 包含的凭证ID
+	Nonce         string   `json:"nonce,omitempty"`         // 已消费的挑战值
+	Domain        string   `json:"domain,omitempty"`        // 验证者域
 }
 
-// CreateDIDFromWalletRequest 表示从钱包创建DID的请求
+// CreateDIDFromWalletRequest 表示从钱包创建DID的请求，钱包需对挑战消息签名
 type CreateDIDFromWalletRequest struct {
-	WalletAddress string `json:"walletAddress" binding:"required"` // 钱包地址
+	Nonce     string `json:"nonce"`     // 挑战值
+	Signature string `json:"signature"` // 钱包对挑战消息的签名
 }
 
 // CreateDIDFromWalletResponse 表示从钱包创建DID的响应
 type CreateDIDFromWalletResponse struct {
-	DID           string `json:"did"`           // 创建的DID
+	DID           string `json:"did"`                    // 创建的DID
+	WalletAddress string `json:"walletAddress"`          // 钱包地址
+	Exists        bool   `json:"exists"`                 // 是否已存在
+	PublicKeyHex  string `json:"publicKeyHex,omitempty"` // 从签名恢复的公钥
+}
+
+// DIDChallengeResponse 表示创建DID挑战的响应
+type DIDChallengeResponse struct {
+	DID           string `json:"did"`           // 将要创建的DID
 	WalletAddress string `json:"walletAddress"` // 钱包地址
-	Exists        bool   `json:"exists"`        // 是否已存在
+	Nonce         string `json:"nonce"`         // 挑战值
+	Message       string `json:"message"`       // 钱包需要签名的消息
+	ExpiresAt     string `json:"expiresAt"`     // 过期时间
 }
 
 // Proof 表示凭证或表示的证明
@@ -328,6 +506,7 @@ type Proof struct {
 	VerificationMethod string `json:"verificationMethod"`
 	ProofPurpose       string `json:"proofPurpose"`
 	Challenge          string `json:"challenge,omitempty"`
+	Domain             string `json:"domain,omitempty"`
 	ProofValue         string `json:"proofValue"`
 }
 
@@ -348,10 +527,12 @@ type CreateDoctorDIDResponse struct {
 
 // IssueDoctorVCRequest 颁发医生凭证的请求
 type IssueDoctorVCRequest struct {
-	IssuerDID string `json:"issuerDid" binding:"required"` // 颁发者DID (医院)
-	DoctorDID string `json:"doctorDid" binding:"required"` // 医生DID
-	VCType    string `json:"vcType" binding:"required"`    // 凭证类型
-	VCContent string `json:"vcContent" binding:"required"` // 凭证内容
+	IssuerDID string      `json:"issuerDid" binding:"required"` // 颁发者DID (医院)
+	DoctorDID string      `json:"doctorDid" binding:"required"` // 医生DID
+	VCType    string      `json:"vcType" binding:"required"`    // 凭证类型
+	VCContent string      `json:"vcContent" binding:"required"` // 凭证内容（JSON，需符合模式）
+	SchemaID  string      `json:"schemaId"`                     // 凭证模式ID，为空时按凭证类型查找
+	Proof     ActionProof `json:"proof"`                        // 颁发医院登记钱包对IssueSubjectCredential操作的签名
 }
 
 // IssueDoctorVCResponse 颁发医生凭证的响应
@@ -369,13 +550,44 @@ type VerifyDoctorVCRequest struct {
 	VCID string `json:"vcId" binding:"required"` // 凭证ID
 }
 
+// OnboardHospitalRequest 医院入驻请求（需平台管理员签名）
+type OnboardHospitalRequest struct {
+	Proof           ActionProof `json:"proof"`                              // 平台管理员对OnboardHospital操作的签名
+	WalletAddress   string      `json:"walletAddress" binding:"required"`   // 医院钱包地址
+	Name            string      `json:"name" binding:"required"`            // 医院名称
+	DIDString       string      `json:"didString"`                          // 医院DID，为空时由钱包地址生成
+	CredentialTypes []string    `json:"credentialTypes" binding:"required"` // 授权颁发的凭证类型
+}
+
+// HospitalStatusRequest 暂停或恢复医院的请求（需平台管理员签名）
+type HospitalStatusRequest struct {
+	Proof       ActionProof `json:"proof"`                          // 平台管理员对SuspendHospital或ReinstateHospital操作的签名
+	HospitalDID string      `json:"hospitalDid" binding:"required"` // 医院DID
+	Reason      string      `json:"reason"`                         // 原因
+}
+
+// IssuerAuthorizationRequest 授予或撤销凭证类型授权的请求（需平台管理员签名）
+type IssuerAuthorizationRequest struct {
+	Proof          ActionProof `json:"proof"`                             // 平台管理员对GrantIssuerAuthorization或RevokeIssuerAuthorization操作的签名
+	HospitalDID    string      `json:"hospitalDid" binding:"required"`    // 医院DID
+	CredentialType string      `json:"credentialType" binding:"required"` // 凭证类型
+}
+
+// HospitalResponse 医院信息响应
+type HospitalResponse struct {
+	Hospital
+	CredentialTypes []string `json:"credentialTypes"` // 已授权的凭证类型
+}
+
 // VerifyDoctorVCResponse 验证医生凭证的响应
 type VerifyDoctorVCResponse struct {
-	Valid     bool   `json:"valid"`               // 是否有效
-	DoctorDID string `json:"doctorDid,omitempty"` // 医生DID
-	IssuerDID string `json:"issuerDid,omitempty"` // 颁发者DID
-	VCType    string `json:"vcType,omitempty"`    // 凭证类型
-	Reason    string `json:"reason,omitempty"`    // 无效原因
+	Valid        bool   `json:"valid"`                  // 是否有效
+	DoctorDID    string `json:"doctorDid,omitempty"`    // 医生DID
+	IssuerDID    string `json:"issuerDid,omitempty"`    // 颁发者DID
+	VCType       string `json:"vcType,omitempty"`       // 凭证类型
+	Status       string `json:"status,omitempty"`       // 凭证状态
+	SupersededBy string `json:"supersededBy,omitempty"` // 取代本凭证的新凭证ID
+	Reason       string `json:"reason,omitempty"`       // 无效原因
 }
 
 // GetDoctorVCsRequest 获取医生凭证列表请求
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IndexerCheckpoint 链上事件索引器的进度，记录已处理的最后一个区块
+type IndexerCheckpoint struct {
+	gorm.Model
+	Name        string `json:"name" gorm:"column:name;size:128;not null;uniqueIndex"` // 索引器名称，区分不同的合约部署
+	BlockNumber uint64 `json:"blockNumber" gorm:"column:block_number"`
+	BlockHash   string `json:"blockHash" gorm:"column:block_hash;size:66"` // 用于下次同步前检测重组
+}
+
+// TableName 指定表名
+func (IndexerCheckpoint) TableName() string {
+	return "indexer_checkpoints"
+}
+
+// IndexedBlock 索引器在重组窗口内处理过的区块哈希，检测到重组时用于查找共同祖先
+type IndexedBlock struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Indexer   string    `json:"indexer" gorm:"column:indexer;size:128;not null;uniqueIndex:idx_indexed_block"`
+	Number    uint64    `json:"number" gorm:"column:number;not null;uniqueIndex:idx_indexed_block"`
+	Hash      string    `json:"hash" gorm:"column:hash;size:66;not null"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TableName 指定表名
+func (IndexedBlock) TableName() string {
+	return "indexed_blocks"
+}
+
+// IndexerJournal 索引器修改nfts表之前的行快照，重组时按相反顺序恢复
+type IndexerJournal struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Indexer       string    `json:"indexer" gorm:"column:indexer;size:128;not null;index:idx_indexer_journal"`
+	BlockNumber   uint64    `json:"blockNumber" gorm:"column:block_number;not null;index:idx_indexer_journal"` // 引起修改的事件所在区块
+	ContractType  string    `json:"contractType" gorm:"column:contract_type;size:16;not null"`
+	TokenID       string    `json:"tokenId" gorm:"column:token_id;size:78;not null"`
+	Existed       bool      `json:"existed" gorm:"column:existed"` // 修改前记录是否存在，不存在时回滚即删除
+	Owner         string    `json:"owner" gorm:"column:owner"`
+	URI           string    `json:"uri" gorm:"column:uri;type:text"`
+	IsChildNFT    bool      `json:"isChildNft" gorm:"column:is_child_nft"`
+	ParentTokenID string    `json:"parentTokenId" gorm:"column:parent_token_id"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// TableName 指定表名
+func (IndexerJournal) TableName() string {
+	return "indexer_journals"
+}
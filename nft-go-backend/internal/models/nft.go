@@ -2,22 +2,36 @@ package models
 
 import (
 	"encoding/json"
+	"time"
 
 	"gorm.io/gorm"
 )
 
-// NFT 表示NFT实体的数据库模型
+// NFT 表示NFT实体的数据库模型，按链、合约地址和TokenID唯一
 type NFT struct {
 	gorm.Model
-	TokenID       string `json:"tokenId" gorm:"unique"`
-	Owner         string `json:"owner"`
+	ChainID       int64  `json:"chainId" gorm:"not null;default:0;uniqueIndex:idx_nft_chain_contract_token,priority:1"`
+	Contract      string `json:"contract" gorm:"size:42;not null;default:'';uniqueIndex:idx_nft_chain_contract_token,priority:2"` // 合约地址（校验和格式）
+	TokenID       string `json:"tokenId" gorm:"uniqueIndex:idx_nft_chain_contract_token,priority:3;size:78"`
+	Owner         string `json:"owner" gorm:"index;size:42"`
 	URI           string `json:"uri"`
 	TotalSupply   string `json:"totalSupply,omitempty"`
 	IsChildNFT    bool   `json:"isChildNft" gorm:"default:false"`  // 标识是否为子NFT
-	ParentTokenID string `json:"parentTokenId,omitempty"`          // 父NFT的TokenID（仅子NFT有效）
+	ParentTokenID string `json:"parentTokenId,omitempty"`          // 父NFT的TokenID（仅子NFT有效，父NFT在同一部署的主合约中）
 	ContractType  string `json:"contractType" gorm:"default:main"` // 合约类型：main或child
 }
 
+// NFTContractKey 标识一条链上的一个NFT合约，与TokenID共同确定nfts表中的一条记录
+type NFTContractKey struct {
+	ChainID int64
+	Address string // 校验和格式
+}
+
+// Scope 把查询限定为该合约的NFT，用于db.Scopes
+func (k NFTContractKey) Scope(db *gorm.DB) *gorm.DB {
+	return db.Where("chain_id = ? AND contract = ?", k.ChainID, k.Address)
+}
+
 // SignedRequest 表示签名请求的基础结构
 type SignedRequest struct {
 	Address   string `json:"address" binding:"required"`   // 钱包地址
@@ -27,6 +41,8 @@ type SignedRequest struct {
 
 // NFTResponse 表示NFT信息的响应结构
 type NFTResponse struct {
+	ChainID       int64  `json:"chainId,omitempty"`
+	Contract      string `json:"contract,omitempty"`
 	TokenID       string `json:"tokenId"`
 	Owner         string `json:"owner"`
 	URI           string `json:"uri"`
@@ -36,15 +52,31 @@ type NFTResponse struct {
 	ContractType  string `json:"contractType"`
 }
 
-// MintRequest 表示铸造NFT的请求结构
+// TypedDataSignedRequest 表示EIP-712签名请求的基础结构：用户对操作参数、nonce和截止时间签名，平台代为提交，合约验证签名
+type TypedDataSignedRequest struct {
+	Address   string `json:"address" binding:"required"`   // 钱包地址，即签名者
+	Signature string `json:"signature" binding:"required"` // eth_signTypedData_v4签名
+	Nonce     string `json:"nonce" binding:"required"`     // 主合约nonces(address)的当前值
+	Deadline  string `json:"deadline" binding:"required"`  // 签名截止时间（Unix秒）
+}
+
+// ChildMintAuthorization 父NFT持有者批准申请时对CreateChildRequest的EIP-712签名（receiver为申请者，uri为申请的URI），
+// 平台代为提交到合约的createChildWithSignature
+type ChildMintAuthorization struct {
+	Signature string `json:"signature" binding:"required"` // eth_signTypedData_v4签名
+	Nonce     string `json:"nonce" binding:"required"`     // 签名中的主合约nonce
+	Deadline  string `json:"deadline" binding:"required"`  // 签名截止时间（Unix秒）
+}
+
+// MintRequest 表示铸造NFT的请求结构，签名的类型为MintRequest
 type MintRequest struct {
-	SignedRequest
+	TypedDataSignedRequest
 	URI string `json:"uri" binding:"required"`
 }
 
-// CreateChildRequest 表示创建子NFT的请求结构
+// CreateChildRequest 表示创建子NFT的请求结构，签名的类型为CreateChildRequest
 type CreateChildRequest struct {
-	SignedRequest
+	TypedDataSignedRequest
 	ParentTokenID string `json:"parentTokenId" binding:"required"`
 	Recipient     string `json:"recipient" binding:"required"`
 	URI           string `json:"uri" binding:"required"`
@@ -53,15 +85,49 @@ type CreateChildRequest struct {
 // ChildNFTRequest 表示申请子NFT的数据库记录结构
 type ChildNFTRequest struct {
 	gorm.Model
-	RequestId        string `json:"requestId" gorm:"-"` // 虚拟字段，用于API响应
+	RequestId        string `json:"requestId" gorm:"-"`                                  // 虚拟字段，用于API响应
+	Collection       string `json:"collection" gorm:"size:64;not null;default:'';index"` // 父NFT所在的部署
 	ChildTokenID     string `json:"childTokenId"`
-	ParentTokenId    string `json:"parentTokenId"`
-	ApplicantAddress string `json:"applicantAddress"`
+	ParentTokenId    string `json:"parentTokenId" gorm:"size:78;index:idx_child_request_parent_status,priority:1"`
+	ApplicantAddress string `json:"applicantAddress" gorm:"size:42;index:idx_child_request_applicant_status,priority:1"`
 	URI              string `json:"uri"`
-	Status           string `json:"status" gorm:"default:pending"`     // pending, approved, rejected, auto_approved
-	VCCredentials    string `json:"vcCredentials"`                     // 提交的VC凭证（JSON）
-	AutoApproved     bool   `json:"autoApproved" gorm:"default:false"` // 是否自动审核通过
-	PolicyResult     string `json:"policyResult"`                      // 策略验证结果（JSON）
+	// 见ChildRequestStatus*常量；与父token、申请者组成复合索引，供申请列表按状态过滤
+	Status        string `json:"status" gorm:"default:pending;index;index:idx_child_request_parent_status,priority:2;index:idx_child_request_applicant_status,priority:2"`
+	VCCredentials string `json:"vcCredentials"`                     // 提交的VC凭证（JSON）
+	AutoApproved  bool   `json:"autoApproved" gorm:"default:false"` // 是否自动审核通过
+	PolicyResult  string `json:"policyResult"`                      // 策略验证结果（JSON）
+
+	// 状态机相关字段
+	Version      uint       `json:"version" gorm:"not null;default:1"` // 乐观锁版本号，每次状态迁移加一
+	StatusReason string     `json:"statusReason,omitempty"`            // 最近一次状态迁移的原因
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty" gorm:"index"`  // 待审批申请的过期时间
+
+	// 子NFT铸造交易
+	TxHash      string `json:"txHash,omitempty" gorm:"index"` // 创建子NFT的交易哈希
+	BlockNumber uint64 `json:"blockNumber,omitempty"`         // 交易所在区块
+}
+
+// 子NFT申请状态
+const (
+	ChildRequestStatusPending      = "pending"       // 等待父NFT持有者审批
+	ChildRequestStatusMinting      = "minting"       // 已批准，创建子NFT的交易等待回执
+	ChildRequestStatusApproved     = "approved"      // 持有者已批准，子NFT已上链
+	ChildRequestStatusAutoApproved = "auto_approved" // 凭证满足策略自动批准，子NFT已上链
+	ChildRequestStatusFailed       = "failed"        // 创建子NFT的交易执行失败
+	ChildRequestStatusRejected     = "rejected"      // 持有者已拒绝
+	ChildRequestStatusCancelled    = "cancelled"     // 申请者主动撤回
+	ChildRequestStatusExpired      = "expired"       // 超过有效期未处理
+)
+
+// ChildNFTRequestTransition 子NFT申请的状态迁移历史
+type ChildNFTRequestTransition struct {
+	gorm.Model
+	RequestID  uint   `json:"requestId" gorm:"index;not null"`
+	FromStatus string `json:"fromStatus"` // 新建申请时为空
+	ToStatus   string `json:"toStatus" gorm:"not null"`
+	Version    uint   `json:"version"` // 迁移后的版本号
+	Actor      string `json:"actor"`   // 操作者钱包地址，系统操作为system
+	Reason     string `json:"reason"`
 }
 
 // MarshalJSON 自定义JSON序列化，确保ID字段被正确包含
@@ -82,22 +148,98 @@ type RequestChildNFTRequest struct {
 	ParentTokenId    string `json:"parentTokenId" binding:"required"`
 	ApplicantAddress string `json:"applicantAddress" binding:"required"`
 	URI              string `json:"uri" binding:"required"`
-	VCCredentials    string `json:"vcCredentials,omitempty"` // VC凭证JSON字符串（可选）
-	AutoApprove      bool   `json:"autoApprove,omitempty"`   // 是否尝试自动审核
+	VCCredentials    string `json:"vcCredentials,omitempty"`  // VC凭证JSON字符串（可选）
+	AutoApprove      bool   `json:"autoApprove,omitempty"`    // 是否尝试自动审核
+	PresentationID   string `json:"presentationId,omitempty"` // 基于挑战签名的可验证展示ID（自动审核必需）
+}
+
+// CancelChildNFTRequest 表示申请者撤回子NFT申请的请求结构
+type CancelChildNFTRequest struct {
+	SignedRequest
+	RequestID uint   `json:"requestId" binding:"required"`
+	Version   uint   `json:"version,omitempty"` // 可选，客户端看到的申请版本号
+	Reason    string `json:"reason,omitempty"`
+}
+
+// BatchProcessItem 表示批量处理中的单个申请操作
+type BatchProcessItem struct {
+	RequestID uint   `json:"requestId" binding:"required"`
+	Action    string `json:"action" binding:"required,oneof=approve reject"`
+	Version   uint   `json:"version,omitempty"` // 可选，客户端看到的申请版本号
+	Reason    string `json:"reason,omitempty"`
+	// 批准时必需：父NFT持有者对该申请的CreateChildRequest签名，批次中第k个批准项的nonce为当前值加k
+	Authorization *ChildMintAuthorization `json:"authorization,omitempty"`
+}
+
+// BatchProcessRequestsRequest 表示批量处理子NFT申请的请求结构，签名消息须包含全部申请ID和操作
+type BatchProcessRequestsRequest struct {
+	SignedRequest
+	Nonce string             `json:"nonce" binding:"required"` // 签名消息对应的一次性挑战值
+	Items []BatchProcessItem `json:"items" binding:"required,min=1,dive"`
+}
+
+// BatchProcessResult 表示批量处理中单个申请的结果
+type BatchProcessResult struct {
+	RequestID    uint   `json:"requestId"`
+	Action       string `json:"action"`
+	Success      bool   `json:"success"`
+	Status       string `json:"status,omitempty"` // 处理后的申请状态
+	Version      uint   `json:"version,omitempty"`
+	TxHash       string `json:"txHash,omitempty"`
+	BlockNumber  uint64 `json:"blockNumber,omitempty"`
+	ChildTokenID string `json:"childTokenId,omitempty"`
+	Error        string `json:"error,omitempty"`
+	Rule         string `json:"rule,omitempty"` // 被发行规则拦截时的规则代码
+}
+
+// ChildIssuanceRule 父NFT持有者为子NFT发行设置的规则，零值字段表示不限制
+type ChildIssuanceRule struct {
+	gorm.Model
+	Collection         string     `json:"collection" gorm:"uniqueIndex:idx_issuance_rule_parent,priority:1;size:64;not null;default:''"` // 父NFT所在的部署
+	ParentTokenID      string     `json:"parentTokenId" gorm:"uniqueIndex:idx_issuance_rule_parent,priority:2;size:78;not null"`
+	MaxSupply          uint       `json:"maxSupply"`                                       // 子NFT总量上限（含铸造中）
+	PerApplicantLimit  uint       `json:"perApplicantLimit"`                               // 每个申请者的有效申请与已发行数量上限
+	StartsAt           *time.Time `json:"startsAt,omitempty"`                              // 开放申请时间
+	EndsAt             *time.Time `json:"endsAt,omitempty"`                                // 截止申请时间
+	AllowList          []string   `json:"allowList" gorm:"serializer:json;type:text"`      // 允许的钱包地址或DID，非空时只有名单内可申请
+	DenyList           []string   `json:"denyList" gorm:"serializer:json;type:text"`       // 禁止的钱包地址或DID
+	RequiredVCType     string     `json:"requiredVcType,omitempty"`                        // 申请者必须持有的有效凭证类型
+	RequiredVCIssuer   string     `json:"requiredVcIssuer,omitempty"`                      // 上述凭证必须由该DID颁发
+	AutoApproveAllowed bool       `json:"autoApproveAllowed" gorm:"not null;default:true"` // 是否允许凭证满足策略时自动批准
+	UpdatedBy          string     `json:"updatedBy"`                                       // 最近一次修改规则的持有者地址
+}
+
+// SetIssuanceRulesRequest 表示父NFT持有者设置子NFT发行规则的请求结构
+type SetIssuanceRulesRequest struct {
+	SignedRequest
+	ParentTokenID      string     `json:"parentTokenId" binding:"required"`
+	MaxSupply          uint       `json:"maxSupply"`
+	PerApplicantLimit  uint       `json:"perApplicantLimit"`
+	StartsAt           *time.Time `json:"startsAt,omitempty"`
+	EndsAt             *time.Time `json:"endsAt,omitempty"`
+	AllowList          []string   `json:"allowList,omitempty"`
+	DenyList           []string   `json:"denyList,omitempty"`
+	RequiredVCType     string     `json:"requiredVcType,omitempty"`
+	RequiredVCIssuer   string     `json:"requiredVcIssuer,omitempty"`
+	AutoApproveAllowed *bool      `json:"autoApproveAllowed,omitempty"` // 缺省为允许
 }
 
 // TransactionResponse 表示交易响应的结构
 type TransactionResponse struct {
 	TransactionHash string `json:"transactionHash"`
+	Status          string `json:"status,omitempty"`  // 交易最终状态，等待超时为pending
+	TokenID         string `json:"tokenId,omitempty"` // 铸造或创建的NFT tokenID，交易确认后才有
 	Message         string `json:"message"`
 }
 
 // NFTMetadata 表示NFT元数据的结构
 type NFTMetadata struct {
-	Name        string      `json:"name"`
-	Description string      `json:"description"`
-	Image       string      `json:"image"`
-	Attributes  []Attribute `json:"attributes"`
+	Name         string      `json:"name"`
+	Description  string      `json:"description"`
+	Image        string      `json:"image"`
+	ExternalURL  string      `json:"external_url,omitempty"`
+	AnimationURL string      `json:"animation_url,omitempty"`
+	Attributes   []Attribute `json:"attributes"`
 }
 
 // Attribute 表示NFT属性的结构
@@ -117,6 +259,8 @@ type NFTMetadataRequest struct {
 type NFTResponseWithMetadata struct {
 	NFTResponse
 	Metadata *NFTMetadata `json:"metadata,omitempty"`
+	// MetadataError 元数据无法获取或不符合ERC-721元数据格式时的原因，此时不返回metadata
+	MetadataError string `json:"metadataError,omitempty"`
 }
 
 // NFTMetadataDB 表示NFT元数据的数据库模型
@@ -164,7 +308,9 @@ type UpdateURIRequest struct {
 
 // GetAllRequestsResponse 表示获取所有申请记录的响应结构
 type GetAllRequestsResponse struct {
-	Requests []ChildNFTRequestWithParentInfo `json:"requests"`
+	Requests   []ChildNFTRequestWithParentInfo `json:"requests"`
+	NextCursor string                          `json:"nextCursor,omitempty"` // 下一页游标，没有更多记录时为空
+	HasMore    bool                            `json:"hasMore"`
 }
 
 // ChildNFTRequestWithParentInfo 表示包含父NFT信息的子NFT申请记录
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SubjectProfile 与角色无关的身份主体档案（医生、患者、研究人员、药房等）
+type SubjectProfile struct {
+	gorm.Model
+	DIDString       string                 `json:"didString" gorm:"column:did_string;size:255;not null;uniqueIndex:idx_subject_did_role"`                         // 主体DID
+	WalletAddress   string                 `json:"walletAddress" gorm:"column:wallet_address;size:255;not null;uniqueIndex:idx_subject_wallet_role"`              // 钱包地址
+	Role            string                 `json:"role" gorm:"column:role;size:64;not null;uniqueIndex:idx_subject_did_role;uniqueIndex:idx_subject_wallet_role"` // 角色：doctor, patient, researcher, pharmacy
+	Name            string                 `json:"name" gorm:"column:name;not null"`                                                                              // 名称
+	Attributes      map[string]interface{} `json:"attributes" gorm:"column:attributes;type:text;serializer:json"`                                                 // 按角色模板校验的属性
+	OrganizationDID string                 `json:"organizationDid" gorm:"column:organization_did;default:''"`                                                     // 所属机构DID
+	Status          string                 `json:"status" gorm:"column:status;not null;default:'active'"`                                                         // 状态
+}
+
+// TableName 指定表名
+func (SubjectProfile) TableName() string {
+	return "subject_profiles"
+}
+
+// ToDoctor 将医生角色的档案转换为旧的医生结构，供医生接口保持原有响应格式
+func (p SubjectProfile) ToDoctor() Doctor {
+	licenseNumber, _ := p.Attributes["licenseNumber"].(string)
+	return Doctor{
+		Model:         p.Model,
+		DIDString:     p.DIDString,
+		WalletAddress: p.WalletAddress,
+		Name:          p.Name,
+		LicenseNumber: licenseNumber,
+		Status:        p.Status,
+		HospitalDID:   p.OrganizationDID,
+	}
+}
+
+// SubjectCredential 颁发给身份主体的可验证凭证
+type SubjectCredential struct {
+	gorm.Model
+	VCID           string     `json:"vcId" gorm:"column:vcid;size:255;unique;not null"`             // 凭证ID
+	SubjectDID     string     `json:"subjectDid" gorm:"column:subject_did;size:255;not null;index"` // 主体DID
+	Role           string     `json:"role" gorm:"column:role;size:64;not null"`                     // 主体角色
+	IssuerDID      string     `json:"issuerDid" gorm:"column:issuer_did;not null"`                  // 颁发者DID
+	Type           string     `json:"type" gorm:"column:type"`                                      // 凭证类型，如"执业资格"
+	Content        string     `json:"content" gorm:"column:content;type:text"`                      // 凭证内容
+	IssuedAt       time.Time  `json:"issuedAt" gorm:"column:issued_at;not null"`                    // 颁发时间
+	ExpiresAt      time.Time  `json:"expiresAt" gorm:"column:expires_at;not null"`                  // 过期时间
+	Status         string     `json:"status" gorm:"column:status;not null;default:'active'"`        // 状态：active, suspended, superseded, revoked
+	RevocationDate *time.Time `json:"revocationDate" gorm:"column:revocation_date"`                 // 撤销日期
+	SchemaID       string     `json:"schemaId" gorm:"column:schema_id"`                             // 凭证模式ID
+	SuspendedAt    *time.Time `json:"suspendedAt" gorm:"column:suspended_at"`                       // 暂停时间
+	StatusReason   string     `json:"statusReason" gorm:"column:status_reason"`                     // 暂停、撤销或取代的原因
+	ReplacesVCID   string     `json:"replacesVcId" gorm:"column:replaces_vcid"`                     // 本凭证续期或取代的旧凭证ID
+	SupersededBy   string     `json:"supersededBy" gorm:"column:superseded_by"`                     // 取代本凭证的新凭证ID
+}
+
+// TableName 指定表名
+func (SubjectCredential) TableName() string {
+	return "subject_credentials"
+}
+
+// ToDoctorVC 转换为旧的医生凭证结构，供医生接口保持原有响应格式
+func (c SubjectCredential) ToDoctorVC() DoctorVC {
+	return DoctorVC{
+		Model:          c.Model,
+		VCID:           c.VCID,
+		DoctorDID:      c.SubjectDID,
+		IssuerDID:      c.IssuerDID,
+		Type:           c.Type,
+		Content:        c.Content,
+		IssuedAt:       c.IssuedAt,
+		ExpiresAt:      c.ExpiresAt,
+		Status:         c.Status,
+		RevocationDate: c.RevocationDate,
+		SchemaID:       c.SchemaID,
+		SuspendedAt:    c.SuspendedAt,
+		StatusReason:   c.StatusReason,
+		ReplacesVCID:   c.ReplacesVCID,
+		SupersededBy:   c.SupersededBy,
+	}
+}
+
+// CreateSubjectProfileRequest 创建身份主体档案的请求
+type CreateSubjectProfileRequest struct {
+	WalletAddress   string                 `json:"walletAddress" binding:"required"` // 钱包地址
+	Role            string                 `json:"role" binding:"required"`          // 角色
+	Name            string                 `json:"name" binding:"required"`          // 名称
+	OrganizationDID string                 `json:"organizationDid"`                  // 所属机构DID
+	Attributes      map[string]interface{} `json:"attributes"`                       // 角色属性
+}
+
+// IssueSubjectCredentialRequest 颁发主体凭证的请求
+type IssueSubjectCredentialRequest struct {
+	IssuerDID  string      `json:"issuerDid" binding:"required"`  // 颁发者DID
+	SubjectDID string      `json:"subjectDid" binding:"required"` // 主体DID
+	Role       string      `json:"role" binding:"required"`       // 主体角色
+	VCType     string      `json:"vcType" binding:"required"`     // 凭证类型
+	VCContent  string      `json:"vcContent"`                     // 凭证内容（JSON对象，模板会从档案补全属性）
+	SchemaID   string      `json:"schemaId"`                      // 凭证模式ID，为空时按凭证类型查找
+	Proof      ActionProof `json:"proof"`                         // 颁发医院登记钱包对IssueSubjectCredential操作的签名
+}
+
+// SubjectCredentialStatusRequest 颁发者撤销、暂停、恢复或续期凭证的请求
+type SubjectCredentialStatusRequest struct {
+	VCID      string      `json:"vcId" binding:"required"`      // 凭证ID
+	IssuerDID string      `json:"issuerDid" binding:"required"` // 颁发者DID
+	Reason    string      `json:"reason"`                       // 原因
+	Proof     ActionProof `json:"proof"`                        // 颁发医院登记钱包对该生命周期操作的签名
+}
+
+// SupersedeSubjectCredentialRequest 颁发者以新内容取代凭证的请求
+type SupersedeSubjectCredentialRequest struct {
+	VCID      string      `json:"vcId" binding:"required"`      // 被取代的凭证ID
+	IssuerDID string      `json:"issuerDid" binding:"required"` // 颁发者DID
+	VCContent string      `json:"vcContent" binding:"required"` // 新的凭证内容（JSON，需符合模式）
+	SchemaID  string      `json:"schemaId"`                     // 凭证模式ID，为空时按凭证类型查找
+	Proof     ActionProof `json:"proof"`                        // 颁发医院登记钱包对SupersedeSubjectCredential操作的签名
+}
+
+// VerifySubjectCredentialResponse 验证主体凭证的响应
+type VerifySubjectCredentialResponse struct {
+	Valid        bool                    `json:"valid"`                  // 是否有效
+	SubjectDID   string                  `json:"subjectDid,omitempty"`   // 主体DID
+	Role         string                  `json:"role,omitempty"`         // 主体角色
+	IssuerDID    string                  `json:"issuerDid,omitempty"`    // 颁发者DID
+	VCType       string                  `json:"vcType,omitempty"`       // 凭证类型
+	Status       string                  `json:"status,omitempty"`       // 凭证状态
+	SupersededBy string                  `json:"supersededBy,omitempty"` // 取代本凭证的新凭证ID
+	Reason       string                  `json:"reason,omitempty"`       // 无效原因
+	Anchor       *CredentialAnchorStatus `json:"anchor,omitempty"`       // 链上锚定核对结果（启用锚定时）
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 平台账户交易状态
+const (
+	TxStatusPending  = "pending"  // 已广播，等待打包
+	TxStatusMined    = "mined"    // 已打包且执行成功
+	TxStatusReverted = "reverted" // 已打包但执行失败
+	TxStatusReplaced = "replaced" // 同一nonce被其他交易占用
+	TxStatusFailed   = "failed"   // 广播失败，nonce未被使用
+)
+
+// ManagedTransaction 交易管理器发送的平台账户交易。广播前写入，重启后继续跟踪；
+// 加价重发时保留最初的交易哈希作为标识，LatestHash为最近一次广播的交易
+type ManagedTransaction struct {
+	gorm.Model
+	ChainID         int64     `json:"chainId" gorm:"column:chain_id;not null;default:0;index"` // 交易所在的链，每条链由各自的交易管理器跟踪
+	Signer          string    `json:"signer" gorm:"column:signer;size:42;not null;index:idx_managed_tx_signer_nonce,priority:1"`
+	Nonce           uint64    `json:"nonce" gorm:"column:nonce;not null;index:idx_managed_tx_signer_nonce,priority:2"`
+	Kind            string    `json:"kind" gorm:"column:kind;size:64"` // 操作类型，如mint、createChild
+	TxHash          string    `json:"txHash" gorm:"column:tx_hash;size:66;not null;uniqueIndex"`
+	LatestHash      string    `json:"latestHash" gorm:"column:latest_hash;size:66;index"`
+	Hashes          []string  `json:"hashes" gorm:"column:hashes;serializer:json;type:text"` // 所有广播过的交易哈希
+	RawTx           string    `json:"-" gorm:"column:raw_tx;type:mediumtext"`                // 最近一次广播的已签名交易，加价重发时复用其内容
+	To              string    `json:"to" gorm:"column:to_address;size:42"`
+	GasLimit        uint64    `json:"gasLimit" gorm:"column:gas_limit"`
+	GasPrice        string    `json:"gasPrice,omitempty" gorm:"column:gas_price;size:78"` // 旧式交易
+	GasFeeCap       string    `json:"gasFeeCap,omitempty" gorm:"column:gas_fee_cap;size:78"`
+	GasTipCap       string    `json:"gasTipCap,omitempty" gorm:"column:gas_tip_cap;size:78"`
+	Bumps           int       `json:"bumps" gorm:"column:bumps"`
+	LastBroadcastAt time.Time `json:"lastBroadcastAt" gorm:"column:last_broadcast_at"`
+	Status          string    `json:"status" gorm:"column:status;size:16;not null;index"`
+	MinedHash       string    `json:"minedHash,omitempty" gorm:"column:mined_hash;size:66"` // 实际被打包的交易
+	BlockNumber     uint64    `json:"blockNumber,omitempty" gorm:"column:block_number"`
+	GasUsed         uint64    `json:"gasUsed,omitempty" gorm:"column:gas_used"`
+	Error           string    `json:"error,omitempty" gorm:"column:error;type:text"`
+}
+
+// TableName 指定表名
+func (ManagedTransaction) TableName() string {
+	return "managed_transactions"
+}
+
+// IsFinal 交易是否已有最终结果
+func (t *ManagedTransaction) IsFinal() bool {
+	return t.Status != TxStatusPending
+}
+
+// ChainTransaction 链上操作账本：记录接口发起的交易、发起钱包和请求体哈希，
+// 交易有结果后补充回执数据和解码后的事件，并据此写入NFT记录和申请状态
+type ChainTransaction struct {
+	gorm.Model
+	Collection        string            `json:"collection" gorm:"column:collection;size:64;not null;default:'';index"` // 发起交易的部署
+	Operation         string            `json:"operation" gorm:"column:operation;size:64;not null;index"`              // 操作类型，如mint、createChild
+	Wallet            string            `json:"wallet" gorm:"column:wallet;size:42;index"`                             // 发起操作的钱包地址
+	PayloadHash       string            `json:"payloadHash" gorm:"column:payload_hash;size:66"`                        // 请求体的keccak256
+	TxHash            string            `json:"txHash" gorm:"column:tx_hash;size:66;not null;uniqueIndex"`
+	Params            map[string]string `json:"params,omitempty" gorm:"column:params;serializer:json;type:text"` // 后续写入需要的参数，如uri、requestId
+	Status            string            `json:"status" gorm:"column:status;size:16;not null;index"`              // 取值同ManagedTransaction.Status
+	MinedHash         string            `json:"minedHash,omitempty" gorm:"column:mined_hash;size:66"`            // 加价重发后实际打包的交易
+	BlockNumber       uint64            `json:"blockNumber,omitempty" gorm:"column:block_number"`
+	BlockHash         string            `json:"blockHash,omitempty" gorm:"column:block_hash;size:66"`
+	GasUsed           uint64            `json:"gasUsed,omitempty" gorm:"column:gas_used"`
+	EffectiveGasPrice string            `json:"effectiveGasPrice,omitempty" gorm:"column:effective_gas_price;size:78"`
+	Events            []ChainEvent      `json:"events,omitempty" gorm:"column:events;serializer:json;type:mediumtext"`
+	TokenID           string            `json:"tokenId,omitempty" gorm:"column:token_id;size:78"` // 铸造或创建的NFT tokenID
+	Error             string            `json:"error,omitempty" gorm:"column:error;type:text"`
+	ResolvedAt        *time.Time        `json:"resolvedAt,omitempty" gorm:"column:resolved_at"`
+}
+
+// TableName 指定表名
+func (ChainTransaction) TableName() string {
+	return "chain_transactions"
+}
+
+// ChainEvent 从交易回执中解码的合约事件
+type ChainEvent struct {
+	Contract string            `json:"contract"` // main或child
+	Address  string            `json:"address"`
+	Name     string            `json:"name"`
+	LogIndex uint              `json:"logIndex"`
+	Args     map[string]string `json:"args"`
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ChildRequestEvent 子NFT申请事件，通过Webhook和SSE推送
+type ChildRequestEvent struct {
+	ID            string    `json:"id"`
+	Type          string    `json:"type"` // 见service.Event*常量
+	RequestID     uint      `json:"requestId"`
+	Collection    string    `json:"collection"` // 父NFT所在的部署
+	ParentTokenID string    `json:"parentTokenId"`
+	ParentOwner   string    `json:"parentOwner,omitempty"`
+	Applicant     string    `json:"applicantAddress"`
+	Status        string    `json:"status"`
+	Version       uint      `json:"version"`
+	Actor         string    `json:"actor,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	TxHash        string    `json:"txHash,omitempty"`
+	BlockNumber   uint64    `json:"blockNumber,omitempty"`
+	ChildTokenID  string    `json:"childTokenId,omitempty"`
+	OccurredAt    time.Time `json:"occurredAt"`
+}
+
+// WebhookEndpoint 钱包持有者登记的Webhook接收地址
+type WebhookEndpoint struct {
+	gorm.Model
+	OwnerAddress string   `json:"ownerAddress" gorm:"column:owner_address;size:42;not null;index"` // 登记者钱包地址（小写）
+	URL          string   `json:"url" gorm:"column:url;type:text;not null"`
+	Secret       string   `json:"-" gorm:"column:secret;size:128;not null"`              // HMAC-SHA256签名密钥，不在响应中返回
+	Events       []string `json:"events" gorm:"column:events;serializer:json;type:text"` // 订阅的事件类型，为空表示全部
+	Active       bool     `json:"active" gorm:"column:active;not null;default:true"`
+	Description  string   `json:"description" gorm:"column:description;size:255"`
+}
+
+// TableName 指定表名
+func (WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// WebhookDelivery 一次事件投递及其重试状态
+type WebhookDelivery struct {
+	gorm.Model
+	EndpointID    uint       `json:"endpointId" gorm:"column:endpoint_id;not null;index"`
+	EventID       string     `json:"eventId" gorm:"column:event_id;size:64;not null"`
+	EventType     string     `json:"eventType" gorm:"column:event_type;size:64;not null"`
+	Payload       string     `json:"payload" gorm:"column:payload;type:text"`
+	Status        string     `json:"status" gorm:"column:status;size:16;not null;default:'pending';index"` // pending, delivered, dead
+	Attempts      int        `json:"attempts" gorm:"column:attempts"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" gorm:"column:next_attempt_at;index"`
+	LastError     string     `json:"lastError" gorm:"column:last_error;type:text"`
+	ResponseCode  int        `json:"responseCode" gorm:"column:response_code"`
+	DeliveredAt   *time.Time `json:"deliveredAt" gorm:"column:delivered_at"`
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookDeadLetter 重试次数用尽仍未送达的投递，可由登记者手动重新投递
+type WebhookDeadLetter struct {
+	gorm.Model
+	DeliveryID    uint       `json:"deliveryId" gorm:"column:delivery_id;not null;uniqueIndex"`
+	EndpointID    uint       `json:"endpointId" gorm:"column:endpoint_id;not null;index"`
+	OwnerAddress  string     `json:"ownerAddress" gorm:"column:owner_address;size:42;not null;index"`
+	EventID       string     `json:"eventId" gorm:"column:event_id;size:64;not null"`
+	EventType     string     `json:"eventType" gorm:"column:event_type;size:64;not null"`
+	Payload       string     `json:"payload" gorm:"column:payload;type:text"`
+	Attempts      int        `json:"attempts" gorm:"column:attempts"`
+	LastError     string     `json:"lastError" gorm:"column:last_error;type:text"`
+	RedeliveredAt *time.Time `json:"redeliveredAt" gorm:"column:redelivered_at"`
+}
+
+// TableName 指定表名
+func (WebhookDeadLetter) TableName() string {
+	return "webhook_dead_letters"
+}
+
+// RegisterWebhookRequest 登记Webhook的请求结构
+type RegisterWebhookRequest struct {
+	Proof       ActionProof `json:"proof"` // 钱包对RegisterWebhook操作的签名
+	URL         string      `json:"url" binding:"required"`
+	Secret      string      `json:"secret,omitempty"` // 为空时由服务端生成并仅在本次响应中返回
+	Events      []string    `json:"events,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+// WebhookActionRequest 删除Webhook或重新投递死信的请求结构
+type WebhookActionRequest struct {
+	Proof ActionProof `json:"proof"` // 钱包对DeleteWebhook或RedeliverWebhookDeadLetter操作的签名
+	ID    uint        `json:"id" binding:"required"`
+}
@@ -0,0 +1,59 @@
+package util
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// base58btc字母表（比特币）
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var base58Radix = big.NewInt(58)
+
+// Base58Encode 按base58btc编码字节
+func Base58Encode(data []byte) string {
+	num := new(big.Int).SetBytes(data)
+	mod := new(big.Int)
+	var out []byte
+	for num.Sign() > 0 {
+		num.DivMod(num, base58Radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	// 前导零字节编码为'1'
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// Base58Decode 解码base58btc字符串
+func Base58Decode(s string) ([]byte, error) {
+	num := new(big.Int)
+	for i := 0; i < len(s); i++ {
+		index := -1
+		for j := 0; j < len(base58Alphabet); j++ {
+			if base58Alphabet[j] == s[i] {
+				index = j
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("无效的base58字符: %q", s[i])
+		}
+		num.Mul(num, base58Radix)
+		num.Add(num, big.NewInt(int64(index)))
+	}
+
+	decoded := num.Bytes()
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), decoded...), nil
+}
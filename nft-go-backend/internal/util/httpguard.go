@@ -0,0 +1,87 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress 目标地址属于回环、内网、链路本地等不允许服务端访问的范围
+var ErrForbiddenAddress = errors.New("不允许访问内网或本机地址")
+
+// 标准库未单独判断的保留网段
+var forbiddenNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",     // 本网络
+		"100.64.0.0/10", // 运营商级NAT
+		"192.0.0.0/24",  // IETF协议分配
+		"198.18.0.0/15", // 基准测试
+		"240.0.0.0/4",   // 保留
+		"64:ff9b::/96",  // NAT64，可映射到任意IPv4
+		"2002::/16",     // 6to4，可映射到任意IPv4
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// IsPublicIP 判断IP是否为可访问的公网地址：排除回环、私有、链路本地（含云元数据地址169.254.169.254）、组播、未指定及保留网段
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range forbiddenNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// publicOnlyControl 在建立连接前检查解析后的地址，域名解析到内网地址（包括DNS重绑定）时拒绝连接
+func publicOnlyControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !IsPublicIP(net.ParseIP(host)) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
+
+// NewPublicHTTPClient 创建只访问公网地址的HTTP客户端，用于按用户提供的地址发起请求：
+// 拨号时校验每个解析出的IP，不使用环境代理；maxRedirects为0时不跟随重定向（返回3xx响应本身），
+// 否则最多跟随maxRedirects次，且只跟随到http/https地址
+func NewPublicHTTPClient(timeout time.Duration, maxRedirects int) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: publicOnlyControl}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if maxRedirects <= 0 {
+				return http.ErrUseLastResponse
+			}
+			if len(via) > maxRedirects {
+				return fmt.Errorf("重定向次数超过 %d 次", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("不支持重定向到 %s 地址", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,245 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JSONSchema 凭证模式所支持的JSON Schema子集
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+}
+
+// 支持的类型关键字
+var supportedSchemaTypes = map[string]bool{
+	"": true, "object": true, "array": true, "string": true,
+	"number": true, "integer": true, "boolean": true, "null": true,
+}
+
+// uuidPattern UUID的文本格式
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// didPattern DID语法：did:<method>:<method-specific-id>
+var didPattern = regexp.MustCompile(`^did:[a-z0-9]+:[A-Za-z0-9._:%-]*[A-Za-z0-9._%-]$`)
+
+// supportedSchemaFormats 支持的format关键字及其校验函数，使用其他format的模式会被拒绝
+var supportedSchemaFormats = map[string]func(string) bool{
+	"date-time": func(v string) bool {
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	},
+	"date": func(v string) bool {
+		_, err := time.Parse("2006-01-02", v)
+		return err == nil
+	},
+	"email": func(v string) bool {
+		addr, err := mail.ParseAddress(v)
+		return err == nil && addr.Address == v
+	},
+	"uri": func(v string) bool {
+		u, err := url.Parse(v)
+		return err == nil && u.Scheme != ""
+	},
+	"uuid": uuidPattern.MatchString,
+	"did":  didPattern.MatchString,
+}
+
+// ParseJSONSchema 解析并检查JSON Schema定义
+func ParseJSONSchema(schemaJSON string) (*JSONSchema, error) {
+	var schema JSONSchema
+	if err := json.Unmarshal([]byte(schemaJSON), &schema); err != nil {
+		return nil, fmt.Errorf("解析JSON Schema失败: %v", err)
+	}
+	if schema.Type != "object" {
+		return nil, fmt.Errorf("凭证模式的根类型必须为object")
+	}
+	if err := schema.check("$"); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// check 递归检查模式定义本身是否合法
+func (s *JSONSchema) check(path string) error {
+	if !supportedSchemaTypes[s.Type] {
+		return fmt.Errorf("%s: 不支持的类型 %s", path, s.Type)
+	}
+	if s.Pattern != "" {
+		if _, err := regexp.Compile(s.Pattern); err != nil {
+			return fmt.Errorf("%s: 无效的pattern: %v", path, err)
+		}
+	}
+	if s.Format != "" && supportedSchemaFormats[s.Format] == nil {
+		return fmt.Errorf("%s: 不支持的format %s", path, s.Format)
+	}
+	for _, name := range s.Required {
+		if s.Properties == nil || s.Properties[name] == nil {
+			return fmt.Errorf("%s: required中的属性 %s 未在properties中定义", path, name)
+		}
+	}
+	for name, prop := range s.Properties {
+		if prop == nil {
+			return fmt.Errorf("%s.%s: 属性定义为空", path, name)
+		}
+		if err := prop.check(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.check(path + "[]")
+	}
+	return nil
+}
+
+// AttributeNames 返回模式顶层定义的属性名（已排序）
+func (s *JSONSchema) AttributeNames() []string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate 按模式校验数据，返回所有不满足的约束
+func (s *JSONSchema) Validate(data interface{}) []string {
+	var errs []string
+	s.validate("$", data, &errs)
+	return errs
+}
+
+// validate 递归校验
+func (s *JSONSchema) validate(path string, data interface{}, errs *[]string) {
+	if !s.matchType(data) {
+		*errs = append(*errs, fmt.Sprintf("%s: 类型应为%s", path, s.Type))
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		matched := false
+		for _, candidate := range s.Enum {
+			if fmt.Sprint(candidate) == fmt.Sprint(data) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			*errs = append(*errs, fmt.Sprintf("%s: 取值不在允许范围内", path))
+		}
+	}
+
+	switch v := data.(type) {
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			*errs = append(*errs, fmt.Sprintf("%s: 长度不能小于%d", path, *s.MinLength))
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			*errs = append(*errs, fmt.Sprintf("%s: 长度不能大于%d", path, *s.MaxLength))
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(v) {
+				*errs = append(*errs, fmt.Sprintf("%s: 不匹配格式 %s", path, s.Pattern))
+			}
+		}
+		if valid := supportedSchemaFormats[s.Format]; valid != nil && !valid(v) {
+			*errs = append(*errs, fmt.Sprintf("%s: 不是有效的%s", path, s.Format))
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			*errs = append(*errs, fmt.Sprintf("%s: 不能小于%v", path, *s.Minimum))
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			*errs = append(*errs, fmt.Sprintf("%s: 不能大于%v", path, *s.Maximum))
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			*errs = append(*errs, fmt.Sprintf("%s: 元素个数不能少于%d", path, *s.MinItems))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			*errs = append(*errs, fmt.Sprintf("%s: 元素个数不能多于%d", path, *s.MaxItems))
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s: 缺少必需属性 %s", path, name))
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop, defined := s.Properties[key]
+			if !defined {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					*errs = append(*errs, fmt.Sprintf("%s: 不允许的属性 %s", path, key))
+				}
+				continue
+			}
+			prop.validate(path+"."+key, v[key], errs)
+		}
+	}
+}
+
+// matchType 检查数据是否符合声明的类型
+func (s *JSONSchema) matchType(data interface{}) bool {
+	switch s.Type {
+	case "":
+		return true
+	case "object":
+		_, ok := data.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := data.([]interface{})
+		return ok
+	case "string":
+		_, ok := data.(string)
+		return ok
+	case "number":
+		_, ok := data.(float64)
+		return ok
+	case "integer":
+		f, ok := data.(float64)
+		return ok && f == float64(int64(f))
+	case "boolean":
+		_, ok := data.(bool)
+		return ok
+	case "null":
+		return data == nil
+	}
+	return false
+}
+
+// FormatSchemaErrors 将校验错误拼接为一条可读信息
+func FormatSchemaErrors(errs []string) string {
+	return strings.Join(errs, "；")
+}
@@ -0,0 +1,81 @@
+package util
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MerkleTree 采用有序对哈希的Merkle树，与CredentialAnchor合约的verifyInclusion一致：
+// parent = keccak256(min(a,b) ++ max(a,b))，奇数个节点时最后一个节点直接提升到上一层
+type MerkleTree struct {
+	levels [][][32]byte
+}
+
+// NewMerkleTree 由叶子哈希构建Merkle树
+func NewMerkleTree(leaves [][32]byte) *MerkleTree {
+	tree := &MerkleTree{}
+	if len(leaves) == 0 {
+		return tree
+	}
+
+	level := append([][32]byte(nil), leaves...)
+	tree.levels = append(tree.levels, level)
+	for len(level) > 1 {
+		next := make([][32]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, hashMerklePair(level[i], level[i+1]))
+		}
+		tree.levels = append(tree.levels, next)
+		level = next
+	}
+	return tree
+}
+
+// Root 返回Merkle根，空树返回零值
+func (t *MerkleTree) Root() [32]byte {
+	if len(t.levels) == 0 {
+		return [32]byte{}
+	}
+	return t.levels[len(t.levels)-1][0]
+}
+
+// Proof 返回指定叶子自下而上的兄弟节点哈希
+func (t *MerkleTree) Proof(index int) [][32]byte {
+	var proof [][32]byte
+	if len(t.levels) == 0 || index < 0 || index >= len(t.levels[0]) {
+		return proof
+	}
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			proof = append(proof, level[sibling])
+		}
+		index /= 2
+	}
+	return proof
+}
+
+// VerifyMerkleProof 校验叶子是否属于给定的Merkle根
+func VerifyMerkleProof(leaf [32]byte, proof [][32]byte, root [32]byte) bool {
+	computed := leaf
+	for _, sibling := range proof {
+		computed = hashMerklePair(computed, sibling)
+	}
+	return computed == root
+}
+
+// hashMerklePair 按字节序排序后哈希两个节点
+func hashMerklePair(a, b [32]byte) [32]byte {
+	var out [32]byte
+	if bytes.Compare(a[:], b[:]) < 0 {
+		copy(out[:], crypto.Keccak256(a[:], b[:]))
+	} else {
+		copy(out[:], crypto.Keccak256(b[:], a[:]))
+	}
+	return out
+}
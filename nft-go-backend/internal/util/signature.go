@@ -0,0 +1,79 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// PersonalSignHash 将消息转换为以太坊personal_sign签名格式的哈希
+func PersonalSignHash(data []byte) []byte {
+	msg := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(data), data)
+	return crypto.Keccak256([]byte(msg))
+}
+
+// RecoverPersonalSignPublicKey 从personal_sign签名中恢复签名者公钥
+func RecoverPersonalSignPublicKey(signature, message string) (*ecdsa.PublicKey, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return nil, fmt.Errorf("签名格式无效: %v", err)
+	}
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("签名长度不正确")
+	}
+
+	// 钱包返回的v值为27/28，Ecrecover需要0/1
+	if sig[64] > 1 {
+		sig[64] -= 27
+	}
+
+	pubKey, err := crypto.SigToPub(PersonalSignHash([]byte(message)), sig)
+	if err != nil {
+		return nil, fmt.Errorf("公钥恢复失败: %v", err)
+	}
+	return pubKey, nil
+}
+
+// VerifyPersonalSignature 验证personal_sign签名是否由指定地址签出
+func VerifyPersonalSignature(address, signature, message string) bool {
+	if !common.IsHexAddress(address) {
+		return false
+	}
+
+	pubKey, err := RecoverPersonalSignPublicKey(signature, message)
+	if err != nil {
+		return false
+	}
+
+	recovered := crypto.PubkeyToAddress(*pubKey)
+	return strings.EqualFold(recovered.Hex(), common.HexToAddress(address).Hex())
+}
+
+// RecoverTypedDataSigner 从EIP-712（eth_signTypedData_v4）签名中恢复签名者地址
+func RecoverTypedDataSigner(typedData apitypes.TypedData, signature string) (common.Address, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("签名格式无效: %v", err)
+	}
+	if len(sig) != 65 {
+		return common.Address{}, fmt.Errorf("签名长度不正确")
+	}
+	if sig[64] > 1 {
+		sig[64] -= 27
+	}
+
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("类型化数据无效: %v", err)
+	}
+	pubKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("公钥恢复失败: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
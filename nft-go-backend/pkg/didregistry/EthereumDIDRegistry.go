@@ -0,0 +1,1402 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package didregistry
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// DidregistryMetaData contains all meta data concerning the Didregistry contract.
+var DidregistryMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"name\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"bytes\",\"name\":\"value\",\"type\":\"bytes\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"validTo\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"previousChange\",\"type\":\"uint256\"}],\"name\":\"DIDAttributeChanged\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"delegateType\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"delegate\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"validTo\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"previousChange\",\"type\":\"uint256\"}],\"name\":\"DIDDelegateChanged\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"previousChange\",\"type\":\"uint256\"}],\"name\":\"DIDOwnerChanged\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"registrar\",\"type\":\"address\"}],\"name\":\"RegistrarChanged\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bool\",\"name\":\"selfManaged\",\"type\":\"bool\"}],\"name\":\"SelfManagementChanged\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"internalType\":\"bytes32\",\"name\":\"delegateType\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"delegate\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"validity\",\"type\":\"uint256\"}],\"name\":\"addDelegate\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"internalType\":\"uint8\",\"name\":\"sigV\",\"type\":\"uint8\"},{\"internalType\":\"bytes32\",\"name\":\"sigR\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"sigS\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"delegateType\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"delegate\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"validity\",\"type\":\"uint256\"}],\"name\":\"addDelegateSigned\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"changeOwner\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"internalType\":\"uint8\",\"name\":\"sigV\",\"type\":\"uint8\"},{\"internalType\":\"bytes32\",\"name\":\"sigR\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"sigS\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"changeOwnerSigned\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"changed\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"delegates\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"}],\"name\":\"identityOwner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"nonce\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"owners\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"registrar\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"internalType\":\"bytes32\",\"name\":\"name\",\"type\":\"bytes32\"},{\"internalType\":\"bytes\",\"name\":\"value\",\"type\":\"bytes\"}],\"name\":\"revokeAttribute\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"internalType\":\"uint8\",\"name\":\"sigV\",\"type\":\"uint8\"},{\"internalType\":\"bytes32\",\"name\":\"sigR\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"sigS\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"name\",\"type\":\"bytes32\"},{\"internalType\":\"bytes\",\"name\":\"value\",\"type\":\"bytes\"}],\"name\":\"revokeAttributeSigned\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"internalType\":\"bytes32\",\"name\":\"delegateType\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"delegate\",\"type\":\"address\"}],\"name\":\"revokeDelegate\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"internalType\":\"uint8\",\"name\":\"sigV\",\"type\":\"uint8\"},{\"internalType\":\"bytes32\",\"name\":\"sigR\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"sigS\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"delegateType\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"delegate\",\"type\":\"address\"}],\"name\":\"revokeDelegateSigned\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"selfManaged\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"internalType\":\"bytes32\",\"name\":\"name\",\"type\":\"bytes32\"},{\"internalType\":\"bytes\",\"name\":\"value\",\"type\":\"bytes\"},{\"internalType\":\"uint256\",\"name\":\"validity\",\"type\":\"uint256\"}],\"name\":\"setAttribute\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"internalType\":\"uint8\",\"name\":\"sigV\",\"type\":\"uint8\"},{\"internalType\":\"bytes32\",\"name\":\"sigR\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"sigS\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"name\",\"type\":\"bytes32\"},{\"internalType\":\"bytes\",\"name\":\"value\",\"type\":\"bytes\"},{\"internalType\":\"uint256\",\"name\":\"validity\",\"type\":\"uint256\"}],\"name\":\"setAttributeSigned\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newRegistrar\",\"type\":\"address\"}],\"name\":\"setRegistrar\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"internalType\":\"bool\",\"name\":\"value\",\"type\":\"bool\"}],\"name\":\"setSelfManaged\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"identity\",\"type\":\"address\"},{\"internalType\":\"bytes32\",\"name\":\"delegateType\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"delegate\",\"type\":\"address\"}],\"name\":\"validDelegate\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]",
+}
+
+// DidregistryABI is the input ABI used to generate the binding from.
+// Deprecated: Use DidregistryMetaData.ABI instead.
+var DidregistryABI = DidregistryMetaData.ABI
+
+// Didregistry is an auto generated Go binding around an Ethereum contract.
+type Didregistry struct {
+	DidregistryCaller     // Read-only binding to the contract
+	DidregistryTransactor // Write-only binding to the contract
+	DidregistryFilterer   // Log filterer for contract events
+}
+
+// DidregistryCaller is an auto generated read-only Go binding around an Ethereum contract.
+type DidregistryCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DidregistryTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type DidregistryTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DidregistryFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type DidregistryFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DidregistrySession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type DidregistrySession struct {
+	Contract     *Didregistry      // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// DidregistryCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type DidregistryCallerSession struct {
+	Contract *DidregistryCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts      // Call options to use throughout this session
+}
+
+// DidregistryTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type DidregistryTransactorSession struct {
+	Contract     *DidregistryTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts      // Transaction auth options to use throughout this session
+}
+
+// DidregistryRaw is an auto generated low-level Go binding around an Ethereum contract.
+type DidregistryRaw struct {
+	Contract *Didregistry // Generic contract binding to access the raw methods on
+}
+
+// DidregistryCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type DidregistryCallerRaw struct {
+	Contract *DidregistryCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// DidregistryTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type DidregistryTransactorRaw struct {
+	Contract *DidregistryTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewDidregistry creates a new instance of Didregistry, bound to a specific deployed contract.
+func NewDidregistry(address common.Address, backend bind.ContractBackend) (*Didregistry, error) {
+	contract, err := bindDidregistry(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Didregistry{DidregistryCaller: DidregistryCaller{contract: contract}, DidregistryTransactor: DidregistryTransactor{contract: contract}, DidregistryFilterer: DidregistryFilterer{contract: contract}}, nil
+}
+
+// NewDidregistryCaller creates a new read-only instance of Didregistry, bound to a specific deployed contract.
+func NewDidregistryCaller(address common.Address, caller bind.ContractCaller) (*DidregistryCaller, error) {
+	contract, err := bindDidregistry(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DidregistryCaller{contract: contract}, nil
+}
+
+// NewDidregistryTransactor creates a new write-only instance of Didregistry, bound to a specific deployed contract.
+func NewDidregistryTransactor(address common.Address, transactor bind.ContractTransactor) (*DidregistryTransactor, error) {
+	contract, err := bindDidregistry(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DidregistryTransactor{contract: contract}, nil
+}
+
+// NewDidregistryFilterer creates a new log filterer instance of Didregistry, bound to a specific deployed contract.
+func NewDidregistryFilterer(address common.Address, filterer bind.ContractFilterer) (*DidregistryFilterer, error) {
+	contract, err := bindDidregistry(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &DidregistryFilterer{contract: contract}, nil
+}
+
+// bindDidregistry binds a generic wrapper to an already deployed contract.
+func bindDidregistry(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := DidregistryMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_Didregistry *DidregistryRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _Didregistry.Contract.DidregistryCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_Didregistry *DidregistryRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _Didregistry.Contract.DidregistryTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_Didregistry *DidregistryRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _Didregistry.Contract.DidregistryTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_Didregistry *DidregistryCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _Didregistry.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_Didregistry *DidregistryTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _Didregistry.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_Didregistry *DidregistryTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _Didregistry.Contract.contract.Transact(opts, method, params...)
+}
+
+// Changed is a free data retrieval call binding the contract method 0xf96d0f9f.
+//
+// Solidity: function changed(address ) view returns(uint256)
+func (_Didregistry *DidregistryCaller) Changed(opts *bind.CallOpts, arg0 common.Address) (*big.Int, error) {
+	var out []interface{}
+	err := _Didregistry.contract.Call(opts, &out, "changed", arg0)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// Changed is a free data retrieval call binding the contract method 0xf96d0f9f.
+//
+// Solidity: function changed(address ) view returns(uint256)
+func (_Didregistry *DidregistrySession) Changed(arg0 common.Address) (*big.Int, error) {
+	return _Didregistry.Contract.Changed(&_Didregistry.CallOpts, arg0)
+}
+
+// Changed is a free data retrieval call binding the contract method 0xf96d0f9f.
+//
+// Solidity: function changed(address ) view returns(uint256)
+func (_Didregistry *DidregistryCallerSession) Changed(arg0 common.Address) (*big.Int, error) {
+	return _Didregistry.Contract.Changed(&_Didregistry.CallOpts, arg0)
+}
+
+// Delegates is a free data retrieval call binding the contract method 0x0d44625b.
+//
+// Solidity: function delegates(address , bytes32 , address ) view returns(uint256)
+func (_Didregistry *DidregistryCaller) Delegates(opts *bind.CallOpts, arg0 common.Address, arg1 [32]byte, arg2 common.Address) (*big.Int, error) {
+	var out []interface{}
+	err := _Didregistry.contract.Call(opts, &out, "delegates", arg0, arg1, arg2)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// Delegates is a free data retrieval call binding the contract method 0x0d44625b.
+//
+// Solidity: function delegates(address , bytes32 , address ) view returns(uint256)
+func (_Didregistry *DidregistrySession) Delegates(arg0 common.Address, arg1 [32]byte, arg2 common.Address) (*big.Int, error) {
+	return _Didregistry.Contract.Delegates(&_Didregistry.CallOpts, arg0, arg1, arg2)
+}
+
+// Delegates is a free data retrieval call binding the contract method 0x0d44625b.
+//
+// Solidity: function delegates(address , bytes32 , address ) view returns(uint256)
+func (_Didregistry *DidregistryCallerSession) Delegates(arg0 common.Address, arg1 [32]byte, arg2 common.Address) (*big.Int, error) {
+	return _Didregistry.Contract.Delegates(&_Didregistry.CallOpts, arg0, arg1, arg2)
+}
+
+// IdentityOwner is a free data retrieval call binding the contract method 0x8733d4e8.
+//
+// Solidity: function identityOwner(address identity) view returns(address)
+func (_Didregistry *DidregistryCaller) IdentityOwner(opts *bind.CallOpts, identity common.Address) (common.Address, error) {
+	var out []interface{}
+	err := _Didregistry.contract.Call(opts, &out, "identityOwner", identity)
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// IdentityOwner is a free data retrieval call binding the contract method 0x8733d4e8.
+//
+// Solidity: function identityOwner(address identity) view returns(address)
+func (_Didregistry *DidregistrySession) IdentityOwner(identity common.Address) (common.Address, error) {
+	return _Didregistry.Contract.IdentityOwner(&_Didregistry.CallOpts, identity)
+}
+
+// IdentityOwner is a free data retrieval call binding the contract method 0x8733d4e8.
+//
+// Solidity: function identityOwner(address identity) view returns(address)
+func (_Didregistry *DidregistryCallerSession) IdentityOwner(identity common.Address) (common.Address, error) {
+	return _Didregistry.Contract.IdentityOwner(&_Didregistry.CallOpts, identity)
+}
+
+// Nonce is a free data retrieval call binding the contract method 0x70ae92d2.
+//
+// Solidity: function nonce(address ) view returns(uint256)
+func (_Didregistry *DidregistryCaller) Nonce(opts *bind.CallOpts, arg0 common.Address) (*big.Int, error) {
+	var out []interface{}
+	err := _Didregistry.contract.Call(opts, &out, "nonce", arg0)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// Nonce is a free data retrieval call binding the contract method 0x70ae92d2.
+//
+// Solidity: function nonce(address ) view returns(uint256)
+func (_Didregistry *DidregistrySession) Nonce(arg0 common.Address) (*big.Int, error) {
+	return _Didregistry.Contract.Nonce(&_Didregistry.CallOpts, arg0)
+}
+
+// Nonce is a free data retrieval call binding the contract method 0x70ae92d2.
+//
+// Solidity: function nonce(address ) view returns(uint256)
+func (_Didregistry *DidregistryCallerSession) Nonce(arg0 common.Address) (*big.Int, error) {
+	return _Didregistry.Contract.Nonce(&_Didregistry.CallOpts, arg0)
+}
+
+// Owners is a free data retrieval call binding the contract method 0x022914a7.
+//
+// Solidity: function owners(address ) view returns(address)
+func (_Didregistry *DidregistryCaller) Owners(opts *bind.CallOpts, arg0 common.Address) (common.Address, error) {
+	var out []interface{}
+	err := _Didregistry.contract.Call(opts, &out, "owners", arg0)
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Owners is a free data retrieval call binding the contract method 0x022914a7.
+//
+// Solidity: function owners(address ) view returns(address)
+func (_Didregistry *DidregistrySession) Owners(arg0 common.Address) (common.Address, error) {
+	return _Didregistry.Contract.Owners(&_Didregistry.CallOpts, arg0)
+}
+
+// Owners is a free data retrieval call binding the contract method 0x022914a7.
+//
+// Solidity: function owners(address ) view returns(address)
+func (_Didregistry *DidregistryCallerSession) Owners(arg0 common.Address) (common.Address, error) {
+	return _Didregistry.Contract.Owners(&_Didregistry.CallOpts, arg0)
+}
+
+// Registrar is a free data retrieval call binding the contract method 0x2b20e397.
+//
+// Solidity: function registrar() view returns(address)
+func (_Didregistry *DidregistryCaller) Registrar(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _Didregistry.contract.Call(opts, &out, "registrar")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Registrar is a free data retrieval call binding the contract method 0x2b20e397.
+//
+// Solidity: function registrar() view returns(address)
+func (_Didregistry *DidregistrySession) Registrar() (common.Address, error) {
+	return _Didregistry.Contract.Registrar(&_Didregistry.CallOpts)
+}
+
+// Registrar is a free data retrieval call binding the contract method 0x2b20e397.
+//
+// Solidity: function registrar() view returns(address)
+func (_Didregistry *DidregistryCallerSession) Registrar() (common.Address, error) {
+	return _Didregistry.Contract.Registrar(&_Didregistry.CallOpts)
+}
+
+// SelfManaged is a free data retrieval call binding the contract method 0x5f12357f.
+//
+// Solidity: function selfManaged(address ) view returns(bool)
+func (_Didregistry *DidregistryCaller) SelfManaged(opts *bind.CallOpts, arg0 common.Address) (bool, error) {
+	var out []interface{}
+	err := _Didregistry.contract.Call(opts, &out, "selfManaged", arg0)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// SelfManaged is a free data retrieval call binding the contract method 0x5f12357f.
+//
+// Solidity: function selfManaged(address ) view returns(bool)
+func (_Didregistry *DidregistrySession) SelfManaged(arg0 common.Address) (bool, error) {
+	return _Didregistry.Contract.SelfManaged(&_Didregistry.CallOpts, arg0)
+}
+
+// SelfManaged is a free data retrieval call binding the contract method 0x5f12357f.
+//
+// Solidity: function selfManaged(address ) view returns(bool)
+func (_Didregistry *DidregistryCallerSession) SelfManaged(arg0 common.Address) (bool, error) {
+	return _Didregistry.Contract.SelfManaged(&_Didregistry.CallOpts, arg0)
+}
+
+// ValidDelegate is a free data retrieval call binding the contract method 0x622b2a3c.
+//
+// Solidity: function validDelegate(address identity, bytes32 delegateType, address delegate) view returns(bool)
+func (_Didregistry *DidregistryCaller) ValidDelegate(opts *bind.CallOpts, identity common.Address, delegateType [32]byte, delegate common.Address) (bool, error) {
+	var out []interface{}
+	err := _Didregistry.contract.Call(opts, &out, "validDelegate", identity, delegateType, delegate)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// ValidDelegate is a free data retrieval call binding the contract method 0x622b2a3c.
+//
+// Solidity: function validDelegate(address identity, bytes32 delegateType, address delegate) view returns(bool)
+func (_Didregistry *DidregistrySession) ValidDelegate(identity common.Address, delegateType [32]byte, delegate common.Address) (bool, error) {
+	return _Didregistry.Contract.ValidDelegate(&_Didregistry.CallOpts, identity, delegateType, delegate)
+}
+
+// ValidDelegate is a free data retrieval call binding the contract method 0x622b2a3c.
+//
+// Solidity: function validDelegate(address identity, bytes32 delegateType, address delegate) view returns(bool)
+func (_Didregistry *DidregistryCallerSession) ValidDelegate(identity common.Address, delegateType [32]byte, delegate common.Address) (bool, error) {
+	return _Didregistry.Contract.ValidDelegate(&_Didregistry.CallOpts, identity, delegateType, delegate)
+}
+
+// AddDelegate is a paid mutator transaction binding the contract method 0xa7068d66.
+//
+// Solidity: function addDelegate(address identity, bytes32 delegateType, address delegate, uint256 validity) returns()
+func (_Didregistry *DidregistryTransactor) AddDelegate(opts *bind.TransactOpts, identity common.Address, delegateType [32]byte, delegate common.Address, validity *big.Int) (*types.Transaction, error) {
+	return _Didregistry.contract.Transact(opts, "addDelegate", identity, delegateType, delegate, validity)
+}
+
+// AddDelegate is a paid mutator transaction binding the contract method 0xa7068d66.
+//
+// Solidity: function addDelegate(address identity, bytes32 delegateType, address delegate, uint256 validity) returns()
+func (_Didregistry *DidregistrySession) AddDelegate(identity common.Address, delegateType [32]byte, delegate common.Address, validity *big.Int) (*types.Transaction, error) {
+	return _Didregistry.Contract.AddDelegate(&_Didregistry.TransactOpts, identity, delegateType, delegate, validity)
+}
+
+// AddDelegate is a paid mutator transaction binding the contract method 0xa7068d66.
+//
+// Solidity: function addDelegate(address identity, bytes32 delegateType, address delegate, uint256 validity) returns()
+func (_Didregistry *DidregistryTransactorSession) AddDelegate(identity common.Address, delegateType [32]byte, delegate common.Address, validity *big.Int) (*types.Transaction, error) {
+	return _Didregistry.Contract.AddDelegate(&_Didregistry.TransactOpts, identity, delegateType, delegate, validity)
+}
+
+// AddDelegateSigned is a paid mutator transaction binding the contract method 0x9c2c1b2b.
+//
+// Solidity: function addDelegateSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 delegateType, address delegate, uint256 validity) returns()
+func (_Didregistry *DidregistryTransactor) AddDelegateSigned(opts *bind.TransactOpts, identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, delegateType [32]byte, delegate common.Address, validity *big.Int) (*types.Transaction, error) {
+	return _Didregistry.contract.Transact(opts, "addDelegateSigned", identity, sigV, sigR, sigS, delegateType, delegate, validity)
+}
+
+// AddDelegateSigned is a paid mutator transaction binding the contract method 0x9c2c1b2b.
+//
+// Solidity: function addDelegateSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 delegateType, address delegate, uint256 validity) returns()
+func (_Didregistry *DidregistrySession) AddDelegateSigned(identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, delegateType [32]byte, delegate common.Address, validity *big.Int) (*types.Transaction, error) {
+	return _Didregistry.Contract.AddDelegateSigned(&_Didregistry.TransactOpts, identity, sigV, sigR, sigS, delegateType, delegate, validity)
+}
+
+// AddDelegateSigned is a paid mutator transaction binding the contract method 0x9c2c1b2b.
+//
+// Solidity: function addDelegateSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 delegateType, address delegate, uint256 validity) returns()
+func (_Didregistry *DidregistryTransactorSession) AddDelegateSigned(identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, delegateType [32]byte, delegate common.Address, validity *big.Int) (*types.Transaction, error) {
+	return _Didregistry.Contract.AddDelegateSigned(&_Didregistry.TransactOpts, identity, sigV, sigR, sigS, delegateType, delegate, validity)
+}
+
+// ChangeOwner is a paid mutator transaction binding the contract method 0xf00d4b5d.
+//
+// Solidity: function changeOwner(address identity, address newOwner) returns()
+func (_Didregistry *DidregistryTransactor) ChangeOwner(opts *bind.TransactOpts, identity common.Address, newOwner common.Address) (*types.Transaction, error) {
+	return _Didregistry.contract.Transact(opts, "changeOwner", identity, newOwner)
+}
+
+// ChangeOwner is a paid mutator transaction binding the contract method 0xf00d4b5d.
+//
+// Solidity: function changeOwner(address identity, address newOwner) returns()
+func (_Didregistry *DidregistrySession) ChangeOwner(identity common.Address, newOwner common.Address) (*types.Transaction, error) {
+	return _Didregistry.Contract.ChangeOwner(&_Didregistry.TransactOpts, identity, newOwner)
+}
+
+// ChangeOwner is a paid mutator transaction binding the contract method 0xf00d4b5d.
+//
+// Solidity: function changeOwner(address identity, address newOwner) returns()
+func (_Didregistry *DidregistryTransactorSession) ChangeOwner(identity common.Address, newOwner common.Address) (*types.Transaction, error) {
+	return _Didregistry.Contract.ChangeOwner(&_Didregistry.TransactOpts, identity, newOwner)
+}
+
+// ChangeOwnerSigned is a paid mutator transaction binding the contract method 0x240cf1fa.
+//
+// Solidity: function changeOwnerSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, address newOwner) returns()
+func (_Didregistry *DidregistryTransactor) ChangeOwnerSigned(opts *bind.TransactOpts, identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, newOwner common.Address) (*types.Transaction, error) {
+	return _Didregistry.contract.Transact(opts, "changeOwnerSigned", identity, sigV, sigR, sigS, newOwner)
+}
+
+// ChangeOwnerSigned is a paid mutator transaction binding the contract method 0x240cf1fa.
+//
+// Solidity: function changeOwnerSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, address newOwner) returns()
+func (_Didregistry *DidregistrySession) ChangeOwnerSigned(identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, newOwner common.Address) (*types.Transaction, error) {
+	return _Didregistry.Contract.ChangeOwnerSigned(&_Didregistry.TransactOpts, identity, sigV, sigR, sigS, newOwner)
+}
+
+// ChangeOwnerSigned is a paid mutator transaction binding the contract method 0x240cf1fa.
+//
+// Solidity: function changeOwnerSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, address newOwner) returns()
+func (_Didregistry *DidregistryTransactorSession) ChangeOwnerSigned(identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, newOwner common.Address) (*types.Transaction, error) {
+	return _Didregistry.Contract.ChangeOwnerSigned(&_Didregistry.TransactOpts, identity, sigV, sigR, sigS, newOwner)
+}
+
+// RevokeAttribute is a paid mutator transaction binding the contract method 0x00c023da.
+//
+// Solidity: function revokeAttribute(address identity, bytes32 name, bytes value) returns()
+func (_Didregistry *DidregistryTransactor) RevokeAttribute(opts *bind.TransactOpts, identity common.Address, name [32]byte, value []byte) (*types.Transaction, error) {
+	return _Didregistry.contract.Transact(opts, "revokeAttribute", identity, name, value)
+}
+
+// RevokeAttribute is a paid mutator transaction binding the contract method 0x00c023da.
+//
+// Solidity: function revokeAttribute(address identity, bytes32 name, bytes value) returns()
+func (_Didregistry *DidregistrySession) RevokeAttribute(identity common.Address, name [32]byte, value []byte) (*types.Transaction, error) {
+	return _Didregistry.Contract.RevokeAttribute(&_Didregistry.TransactOpts, identity, name, value)
+}
+
+// RevokeAttribute is a paid mutator transaction binding the contract method 0x00c023da.
+//
+// Solidity: function revokeAttribute(address identity, bytes32 name, bytes value) returns()
+func (_Didregistry *DidregistryTransactorSession) RevokeAttribute(identity common.Address, name [32]byte, value []byte) (*types.Transaction, error) {
+	return _Didregistry.Contract.RevokeAttribute(&_Didregistry.TransactOpts, identity, name, value)
+}
+
+// RevokeAttributeSigned is a paid mutator transaction binding the contract method 0xe476af5c.
+//
+// Solidity: function revokeAttributeSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 name, bytes value) returns()
+func (_Didregistry *DidregistryTransactor) RevokeAttributeSigned(opts *bind.TransactOpts, identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, name [32]byte, value []byte) (*types.Transaction, error) {
+	return _Didregistry.contract.Transact(opts, "revokeAttributeSigned", identity, sigV, sigR, sigS, name, value)
+}
+
+// RevokeAttributeSigned is a paid mutator transaction binding the contract method 0xe476af5c.
+//
+// Solidity: function revokeAttributeSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 name, bytes value) returns()
+func (_Didregistry *DidregistrySession) RevokeAttributeSigned(identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, name [32]byte, value []byte) (*types.Transaction, error) {
+	return _Didregistry.Contract.RevokeAttributeSigned(&_Didregistry.TransactOpts, identity, sigV, sigR, sigS, name, value)
+}
+
+// RevokeAttributeSigned is a paid mutator transaction binding the contract method 0xe476af5c.
+//
+// Solidity: function revokeAttributeSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 name, bytes value) returns()
+func (_Didregistry *DidregistryTransactorSession) RevokeAttributeSigned(identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, name [32]byte, value []byte) (*types.Transaction, error) {
+	return _Didregistry.Contract.RevokeAttributeSigned(&_Didregistry.TransactOpts, identity, sigV, sigR, sigS, name, value)
+}
+
+// RevokeDelegate is a paid mutator transaction binding the contract method 0x80b29f7c.
+//
+// Solidity: function revokeDelegate(address identity, bytes32 delegateType, address delegate) returns()
+func (_Didregistry *DidregistryTransactor) RevokeDelegate(opts *bind.TransactOpts, identity common.Address, delegateType [32]byte, delegate common.Address) (*types.Transaction, error) {
+	return _Didregistry.contract.Transact(opts, "revokeDelegate", identity, delegateType, delegate)
+}
+
+// RevokeDelegate is a paid mutator transaction binding the contract method 0x80b29f7c.
+//
+// Solidity: function revokeDelegate(address identity, bytes32 delegateType, address delegate) returns()
+func (_Didregistry *DidregistrySession) RevokeDelegate(identity common.Address, delegateType [32]byte, delegate common.Address) (*types.Transaction, error) {
+	return _Didregistry.Contract.RevokeDelegate(&_Didregistry.TransactOpts, identity, delegateType, delegate)
+}
+
+// RevokeDelegate is a paid mutator transaction binding the contract method 0x80b29f7c.
+//
+// Solidity: function revokeDelegate(address identity, bytes32 delegateType, address delegate) returns()
+func (_Didregistry *DidregistryTransactorSession) RevokeDelegate(identity common.Address, delegateType [32]byte, delegate common.Address) (*types.Transaction, error) {
+	return _Didregistry.Contract.RevokeDelegate(&_Didregistry.TransactOpts, identity, delegateType, delegate)
+}
+
+// RevokeDelegateSigned is a paid mutator transaction binding the contract method 0x93072684.
+//
+// Solidity: function revokeDelegateSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 delegateType, address delegate) returns()
+func (_Didregistry *DidregistryTransactor) RevokeDelegateSigned(opts *bind.TransactOpts, identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, delegateType [32]byte, delegate common.Address) (*types.Transaction, error) {
+	return _Didregistry.contract.Transact(opts, "revokeDelegateSigned", identity, sigV, sigR, sigS, delegateType, delegate)
+}
+
+// RevokeDelegateSigned is a paid mutator transaction binding the contract method 0x93072684.
+//
+// Solidity: function revokeDelegateSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 delegateType, address delegate) returns()
+func (_Didregistry *DidregistrySession) RevokeDelegateSigned(identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, delegateType [32]byte, delegate common.Address) (*types.Transaction, error) {
+	return _Didregistry.Contract.RevokeDelegateSigned(&_Didregistry.TransactOpts, identity, sigV, sigR, sigS, delegateType, delegate)
+}
+
+// RevokeDelegateSigned is a paid mutator transaction binding the contract method 0x93072684.
+//
+// Solidity: function revokeDelegateSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 delegateType, address delegate) returns()
+func (_Didregistry *DidregistryTransactorSession) RevokeDelegateSigned(identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, delegateType [32]byte, delegate common.Address) (*types.Transaction, error) {
+	return _Didregistry.Contract.RevokeDelegateSigned(&_Didregistry.TransactOpts, identity, sigV, sigR, sigS, delegateType, delegate)
+}
+
+// SetAttribute is a paid mutator transaction binding the contract method 0x7ad4b0a4.
+//
+// Solidity: function setAttribute(address identity, bytes32 name, bytes value, uint256 validity) returns()
+func (_Didregistry *DidregistryTransactor) SetAttribute(opts *bind.TransactOpts, identity common.Address, name [32]byte, value []byte, validity *big.Int) (*types.Transaction, error) {
+	return _Didregistry.contract.Transact(opts, "setAttribute", identity, name, value, validity)
+}
+
+// SetAttribute is a paid mutator transaction binding the contract method 0x7ad4b0a4.
+//
+// Solidity: function setAttribute(address identity, bytes32 name, bytes value, uint256 validity) returns()
+func (_Didregistry *DidregistrySession) SetAttribute(identity common.Address, name [32]byte, value []byte, validity *big.Int) (*types.Transaction, error) {
+	return _Didregistry.Contract.SetAttribute(&_Didregistry.TransactOpts, identity, name, value, validity)
+}
+
+// SetAttribute is a paid mutator transaction binding the contract method 0x7ad4b0a4.
+//
+// Solidity: function setAttribute(address identity, bytes32 name, bytes value, uint256 validity) returns()
+func (_Didregistry *DidregistryTransactorSession) SetAttribute(identity common.Address, name [32]byte, value []byte, validity *big.Int) (*types.Transaction, error) {
+	return _Didregistry.Contract.SetAttribute(&_Didregistry.TransactOpts, identity, name, value, validity)
+}
+
+// SetAttributeSigned is a paid mutator transaction binding the contract method 0x123b5e98.
+//
+// Solidity: function setAttributeSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 name, bytes value, uint256 validity) returns()
+func (_Didregistry *DidregistryTransactor) SetAttributeSigned(opts *bind.TransactOpts, identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, name [32]byte, value []byte, validity *big.Int) (*types.Transaction, error) {
+	return _Didregistry.contract.Transact(opts, "setAttributeSigned", identity, sigV, sigR, sigS, name, value, validity)
+}
+
+// SetAttributeSigned is a paid mutator transaction binding the contract method 0x123b5e98.
+//
+// Solidity: function setAttributeSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 name, bytes value, uint256 validity) returns()
+func (_Didregistry *DidregistrySession) SetAttributeSigned(identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, name [32]byte, value []byte, validity *big.Int) (*types.Transaction, error) {
+	return _Didregistry.Contract.SetAttributeSigned(&_Didregistry.TransactOpts, identity, sigV, sigR, sigS, name, value, validity)
+}
+
+// SetAttributeSigned is a paid mutator transaction binding the contract method 0x123b5e98.
+//
+// Solidity: function setAttributeSigned(address identity, uint8 sigV, bytes32 sigR, bytes32 sigS, bytes32 name, bytes value, uint256 validity) returns()
+func (_Didregistry *DidregistryTransactorSession) SetAttributeSigned(identity common.Address, sigV uint8, sigR [32]byte, sigS [32]byte, name [32]byte, value []byte, validity *big.Int) (*types.Transaction, error) {
+	return _Didregistry.Contract.SetAttributeSigned(&_Didregistry.TransactOpts, identity, sigV, sigR, sigS, name, value, validity)
+}
+
+// SetRegistrar is a paid mutator transaction binding the contract method 0xfaab9d39.
+//
+// Solidity: function setRegistrar(address newRegistrar) returns()
+func (_Didregistry *DidregistryTransactor) SetRegistrar(opts *bind.TransactOpts, newRegistrar common.Address) (*types.Transaction, error) {
+	return _Didregistry.contract.Transact(opts, "setRegistrar", newRegistrar)
+}
+
+// SetRegistrar is a paid mutator transaction binding the contract method 0xfaab9d39.
+//
+// Solidity: function setRegistrar(address newRegistrar) returns()
+func (_Didregistry *DidregistrySession) SetRegistrar(newRegistrar common.Address) (*types.Transaction, error) {
+	return _Didregistry.Contract.SetRegistrar(&_Didregistry.TransactOpts, newRegistrar)
+}
+
+// SetRegistrar is a paid mutator transaction binding the contract method 0xfaab9d39.
+//
+// Solidity: function setRegistrar(address newRegistrar) returns()
+func (_Didregistry *DidregistryTransactorSession) SetRegistrar(newRegistrar common.Address) (*types.Transaction, error) {
+	return _Didregistry.Contract.SetRegistrar(&_Didregistry.TransactOpts, newRegistrar)
+}
+
+// SetSelfManaged is a paid mutator transaction binding the contract method 0xf186964d.
+//
+// Solidity: function setSelfManaged(address identity, bool value) returns()
+func (_Didregistry *DidregistryTransactor) SetSelfManaged(opts *bind.TransactOpts, identity common.Address, value bool) (*types.Transaction, error) {
+	return _Didregistry.contract.Transact(opts, "setSelfManaged", identity, value)
+}
+
+// SetSelfManaged is a paid mutator transaction binding the contract method 0xf186964d.
+//
+// Solidity: function setSelfManaged(address identity, bool value) returns()
+func (_Didregistry *DidregistrySession) SetSelfManaged(identity common.Address, value bool) (*types.Transaction, error) {
+	return _Didregistry.Contract.SetSelfManaged(&_Didregistry.TransactOpts, identity, value)
+}
+
+// SetSelfManaged is a paid mutator transaction binding the contract method 0xf186964d.
+//
+// Solidity: function setSelfManaged(address identity, bool value) returns()
+func (_Didregistry *DidregistryTransactorSession) SetSelfManaged(identity common.Address, value bool) (*types.Transaction, error) {
+	return _Didregistry.Contract.SetSelfManaged(&_Didregistry.TransactOpts, identity, value)
+}
+
+// DidregistryDIDAttributeChangedIterator is returned from FilterDIDAttributeChanged and is used to iterate over the raw logs and unpacked data for DIDAttributeChanged events raised by the Didregistry contract.
+type DidregistryDIDAttributeChangedIterator struct {
+	Event *DidregistryDIDAttributeChanged // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *DidregistryDIDAttributeChangedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(DidregistryDIDAttributeChanged)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(DidregistryDIDAttributeChanged)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *DidregistryDIDAttributeChangedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *DidregistryDIDAttributeChangedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// DidregistryDIDAttributeChanged represents a DIDAttributeChanged event raised by the Didregistry contract.
+type DidregistryDIDAttributeChanged struct {
+	Identity       common.Address
+	Name           [32]byte
+	Value          []byte
+	ValidTo        *big.Int
+	PreviousChange *big.Int
+	Raw            types.Log // Blockchain specific contextual infos
+}
+
+// FilterDIDAttributeChanged is a free log retrieval operation binding the contract event 0x18ab6b2ae3d64306c00ce663125f2bd680e441a098de1635bd7ad8b0d44965e4.
+//
+// Solidity: event DIDAttributeChanged(address indexed identity, bytes32 name, bytes value, uint256 validTo, uint256 previousChange)
+func (_Didregistry *DidregistryFilterer) FilterDIDAttributeChanged(opts *bind.FilterOpts, identity []common.Address) (*DidregistryDIDAttributeChangedIterator, error) {
+
+	var identityRule []interface{}
+	for _, identityItem := range identity {
+		identityRule = append(identityRule, identityItem)
+	}
+
+	logs, sub, err := _Didregistry.contract.FilterLogs(opts, "DIDAttributeChanged", identityRule)
+	if err != nil {
+		return nil, err
+	}
+	return &DidregistryDIDAttributeChangedIterator{contract: _Didregistry.contract, event: "DIDAttributeChanged", logs: logs, sub: sub}, nil
+}
+
+// WatchDIDAttributeChanged is a free log subscription operation binding the contract event 0x18ab6b2ae3d64306c00ce663125f2bd680e441a098de1635bd7ad8b0d44965e4.
+//
+// Solidity: event DIDAttributeChanged(address indexed identity, bytes32 name, bytes value, uint256 validTo, uint256 previousChange)
+func (_Didregistry *DidregistryFilterer) WatchDIDAttributeChanged(opts *bind.WatchOpts, sink chan<- *DidregistryDIDAttributeChanged, identity []common.Address) (event.Subscription, error) {
+
+	var identityRule []interface{}
+	for _, identityItem := range identity {
+		identityRule = append(identityRule, identityItem)
+	}
+
+	logs, sub, err := _Didregistry.contract.WatchLogs(opts, "DIDAttributeChanged", identityRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(DidregistryDIDAttributeChanged)
+				if err := _Didregistry.contract.UnpackLog(event, "DIDAttributeChanged", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseDIDAttributeChanged is a log parse operation binding the contract event 0x18ab6b2ae3d64306c00ce663125f2bd680e441a098de1635bd7ad8b0d44965e4.
+//
+// Solidity: event DIDAttributeChanged(address indexed identity, bytes32 name, bytes value, uint256 validTo, uint256 previousChange)
+func (_Didregistry *DidregistryFilterer) ParseDIDAttributeChanged(log types.Log) (*DidregistryDIDAttributeChanged, error) {
+	event := new(DidregistryDIDAttributeChanged)
+	if err := _Didregistry.contract.UnpackLog(event, "DIDAttributeChanged", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// DidregistryDIDDelegateChangedIterator is returned from FilterDIDDelegateChanged and is used to iterate over the raw logs and unpacked data for DIDDelegateChanged events raised by the Didregistry contract.
+type DidregistryDIDDelegateChangedIterator struct {
+	Event *DidregistryDIDDelegateChanged // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *DidregistryDIDDelegateChangedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(DidregistryDIDDelegateChanged)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(DidregistryDIDDelegateChanged)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *DidregistryDIDDelegateChangedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *DidregistryDIDDelegateChangedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// DidregistryDIDDelegateChanged represents a DIDDelegateChanged event raised by the Didregistry contract.
+type DidregistryDIDDelegateChanged struct {
+	Identity       common.Address
+	DelegateType   [32]byte
+	Delegate       common.Address
+	ValidTo        *big.Int
+	PreviousChange *big.Int
+	Raw            types.Log // Blockchain specific contextual infos
+}
+
+// FilterDIDDelegateChanged is a free log retrieval operation binding the contract event 0x5a5084339536bcab65f20799fcc58724588145ca054bd2be626174b27ba156f7.
+//
+// Solidity: event DIDDelegateChanged(address indexed identity, bytes32 delegateType, address delegate, uint256 validTo, uint256 previousChange)
+func (_Didregistry *DidregistryFilterer) FilterDIDDelegateChanged(opts *bind.FilterOpts, identity []common.Address) (*DidregistryDIDDelegateChangedIterator, error) {
+
+	var identityRule []interface{}
+	for _, identityItem := range identity {
+		identityRule = append(identityRule, identityItem)
+	}
+
+	logs, sub, err := _Didregistry.contract.FilterLogs(opts, "DIDDelegateChanged", identityRule)
+	if err != nil {
+		return nil, err
+	}
+	return &DidregistryDIDDelegateChangedIterator{contract: _Didregistry.contract, event: "DIDDelegateChanged", logs: logs, sub: sub}, nil
+}
+
+// WatchDIDDelegateChanged is a free log subscription operation binding the contract event 0x5a5084339536bcab65f20799fcc58724588145ca054bd2be626174b27ba156f7.
+//
+// Solidity: event DIDDelegateChanged(address indexed identity, bytes32 delegateType, address delegate, uint256 validTo, uint256 previousChange)
+func (_Didregistry *DidregistryFilterer) WatchDIDDelegateChanged(opts *bind.WatchOpts, sink chan<- *DidregistryDIDDelegateChanged, identity []common.Address) (event.Subscription, error) {
+
+	var identityRule []interface{}
+	for _, identityItem := range identity {
+		identityRule = append(identityRule, identityItem)
+	}
+
+	logs, sub, err := _Didregistry.contract.WatchLogs(opts, "DIDDelegateChanged", identityRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(DidregistryDIDDelegateChanged)
+				if err := _Didregistry.contract.UnpackLog(event, "DIDDelegateChanged", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseDIDDelegateChanged is a log parse operation binding the contract event 0x5a5084339536bcab65f20799fcc58724588145ca054bd2be626174b27ba156f7.
+//
+// Solidity: event DIDDelegateChanged(address indexed identity, bytes32 delegateType, address delegate, uint256 validTo, uint256 previousChange)
+func (_Didregistry *DidregistryFilterer) ParseDIDDelegateChanged(log types.Log) (*DidregistryDIDDelegateChanged, error) {
+	event := new(DidregistryDIDDelegateChanged)
+	if err := _Didregistry.contract.UnpackLog(event, "DIDDelegateChanged", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// DidregistryDIDOwnerChangedIterator is returned from FilterDIDOwnerChanged and is used to iterate over the raw logs and unpacked data for DIDOwnerChanged events raised by the Didregistry contract.
+type DidregistryDIDOwnerChangedIterator struct {
+	Event *DidregistryDIDOwnerChanged // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *DidregistryDIDOwnerChangedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(DidregistryDIDOwnerChanged)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(DidregistryDIDOwnerChanged)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *DidregistryDIDOwnerChangedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *DidregistryDIDOwnerChangedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// DidregistryDIDOwnerChanged represents a DIDOwnerChanged event raised by the Didregistry contract.
+type DidregistryDIDOwnerChanged struct {
+	Identity       common.Address
+	Owner          common.Address
+	PreviousChange *big.Int
+	Raw            types.Log // Blockchain specific contextual infos
+}
+
+// FilterDIDOwnerChanged is a free log retrieval operation binding the contract event 0x38a5a6e68f30ed1ab45860a4afb34bcb2fc00f22ca462d249b8a8d40cda6f7a3.
+//
+// Solidity: event DIDOwnerChanged(address indexed identity, address owner, uint256 previousChange)
+func (_Didregistry *DidregistryFilterer) FilterDIDOwnerChanged(opts *bind.FilterOpts, identity []common.Address) (*DidregistryDIDOwnerChangedIterator, error) {
+
+	var identityRule []interface{}
+	for _, identityItem := range identity {
+		identityRule = append(identityRule, identityItem)
+	}
+
+	logs, sub, err := _Didregistry.contract.FilterLogs(opts, "DIDOwnerChanged", identityRule)
+	if err != nil {
+		return nil, err
+	}
+	return &DidregistryDIDOwnerChangedIterator{contract: _Didregistry.contract, event: "DIDOwnerChanged", logs: logs, sub: sub}, nil
+}
+
+// WatchDIDOwnerChanged is a free log subscription operation binding the contract event 0x38a5a6e68f30ed1ab45860a4afb34bcb2fc00f22ca462d249b8a8d40cda6f7a3.
+//
+// Solidity: event DIDOwnerChanged(address indexed identity, address owner, uint256 previousChange)
+func (_Didregistry *DidregistryFilterer) WatchDIDOwnerChanged(opts *bind.WatchOpts, sink chan<- *DidregistryDIDOwnerChanged, identity []common.Address) (event.Subscription, error) {
+
+	var identityRule []interface{}
+	for _, identityItem := range identity {
+		identityRule = append(identityRule, identityItem)
+	}
+
+	logs, sub, err := _Didregistry.contract.WatchLogs(opts, "DIDOwnerChanged", identityRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(DidregistryDIDOwnerChanged)
+				if err := _Didregistry.contract.UnpackLog(event, "DIDOwnerChanged", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseDIDOwnerChanged is a log parse operation binding the contract event 0x38a5a6e68f30ed1ab45860a4afb34bcb2fc00f22ca462d249b8a8d40cda6f7a3.
+//
+// Solidity: event DIDOwnerChanged(address indexed identity, address owner, uint256 previousChange)
+func (_Didregistry *DidregistryFilterer) ParseDIDOwnerChanged(log types.Log) (*DidregistryDIDOwnerChanged, error) {
+	event := new(DidregistryDIDOwnerChanged)
+	if err := _Didregistry.contract.UnpackLog(event, "DIDOwnerChanged", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// DidregistryRegistrarChangedIterator is returned from FilterRegistrarChanged and is used to iterate over the raw logs and unpacked data for RegistrarChanged events raised by the Didregistry contract.
+type DidregistryRegistrarChangedIterator struct {
+	Event *DidregistryRegistrarChanged // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *DidregistryRegistrarChangedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(DidregistryRegistrarChanged)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(DidregistryRegistrarChanged)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *DidregistryRegistrarChangedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *DidregistryRegistrarChangedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// DidregistryRegistrarChanged represents a RegistrarChanged event raised by the Didregistry contract.
+type DidregistryRegistrarChanged struct {
+	Registrar common.Address
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterRegistrarChanged is a free log retrieval operation binding the contract event 0x6e4c52923c1e70447ecc72995ce26bf2400d9c26b1a7c21ab433e7caff8d40a2.
+//
+// Solidity: event RegistrarChanged(address registrar)
+func (_Didregistry *DidregistryFilterer) FilterRegistrarChanged(opts *bind.FilterOpts) (*DidregistryRegistrarChangedIterator, error) {
+
+	logs, sub, err := _Didregistry.contract.FilterLogs(opts, "RegistrarChanged")
+	if err != nil {
+		return nil, err
+	}
+	return &DidregistryRegistrarChangedIterator{contract: _Didregistry.contract, event: "RegistrarChanged", logs: logs, sub: sub}, nil
+}
+
+// WatchRegistrarChanged is a free log subscription operation binding the contract event 0x6e4c52923c1e70447ecc72995ce26bf2400d9c26b1a7c21ab433e7caff8d40a2.
+//
+// Solidity: event RegistrarChanged(address registrar)
+func (_Didregistry *DidregistryFilterer) WatchRegistrarChanged(opts *bind.WatchOpts, sink chan<- *DidregistryRegistrarChanged) (event.Subscription, error) {
+
+	logs, sub, err := _Didregistry.contract.WatchLogs(opts, "RegistrarChanged")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(DidregistryRegistrarChanged)
+				if err := _Didregistry.contract.UnpackLog(event, "RegistrarChanged", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseRegistrarChanged is a log parse operation binding the contract event 0x6e4c52923c1e70447ecc72995ce26bf2400d9c26b1a7c21ab433e7caff8d40a2.
+//
+// Solidity: event RegistrarChanged(address registrar)
+func (_Didregistry *DidregistryFilterer) ParseRegistrarChanged(log types.Log) (*DidregistryRegistrarChanged, error) {
+	event := new(DidregistryRegistrarChanged)
+	if err := _Didregistry.contract.UnpackLog(event, "RegistrarChanged", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// DidregistrySelfManagementChangedIterator is returned from FilterSelfManagementChanged and is used to iterate over the raw logs and unpacked data for SelfManagementChanged events raised by the Didregistry contract.
+type DidregistrySelfManagementChangedIterator struct {
+	Event *DidregistrySelfManagementChanged // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *DidregistrySelfManagementChangedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(DidregistrySelfManagementChanged)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(DidregistrySelfManagementChanged)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *DidregistrySelfManagementChangedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *DidregistrySelfManagementChangedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// DidregistrySelfManagementChanged represents a SelfManagementChanged event raised by the Didregistry contract.
+type DidregistrySelfManagementChanged struct {
+	Identity    common.Address
+	SelfManaged bool
+	Raw         types.Log // Blockchain specific contextual infos
+}
+
+// FilterSelfManagementChanged is a free log retrieval operation binding the contract event 0x8e92e2dad8db0102c2bea5d02dbeebcf5b7f178d07890dfa0ef9caf14167b6ea.
+//
+// Solidity: event SelfManagementChanged(address indexed identity, bool selfManaged)
+func (_Didregistry *DidregistryFilterer) FilterSelfManagementChanged(opts *bind.FilterOpts, identity []common.Address) (*DidregistrySelfManagementChangedIterator, error) {
+
+	var identityRule []interface{}
+	for _, identityItem := range identity {
+		identityRule = append(identityRule, identityItem)
+	}
+
+	logs, sub, err := _Didregistry.contract.FilterLogs(opts, "SelfManagementChanged", identityRule)
+	if err != nil {
+		return nil, err
+	}
+	return &DidregistrySelfManagementChangedIterator{contract: _Didregistry.contract, event: "SelfManagementChanged", logs: logs, sub: sub}, nil
+}
+
+// WatchSelfManagementChanged is a free log subscription operation binding the contract event 0x8e92e2dad8db0102c2bea5d02dbeebcf5b7f178d07890dfa0ef9caf14167b6ea.
+//
+// Solidity: event SelfManagementChanged(address indexed identity, bool selfManaged)
+func (_Didregistry *DidregistryFilterer) WatchSelfManagementChanged(opts *bind.WatchOpts, sink chan<- *DidregistrySelfManagementChanged, identity []common.Address) (event.Subscription, error) {
+
+	var identityRule []interface{}
+	for _, identityItem := range identity {
+		identityRule = append(identityRule, identityItem)
+	}
+
+	logs, sub, err := _Didregistry.contract.WatchLogs(opts, "SelfManagementChanged", identityRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(DidregistrySelfManagementChanged)
+				if err := _Didregistry.contract.UnpackLog(event, "SelfManagementChanged", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseSelfManagementChanged is a log parse operation binding the contract event 0x8e92e2dad8db0102c2bea5d02dbeebcf5b7f178d07890dfa0ef9caf14167b6ea.
+//
+// Solidity: event SelfManagementChanged(address indexed identity, bool selfManaged)
+func (_Didregistry *DidregistryFilterer) ParseSelfManagementChanged(log types.Log) (*DidregistrySelfManagementChanged, error) {
+	event := new(DidregistrySelfManagementChanged)
+	if err := _Didregistry.contract.UnpackLog(event, "SelfManagementChanged", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
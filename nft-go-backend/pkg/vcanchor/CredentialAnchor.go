@@ -0,0 +1,666 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package vcanchor
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// VcanchorMetaData contains all meta data concerning the Vcanchor contract.
+var VcanchorMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"registrar\",\"type\":\"address\"}],\"name\":\"RegistrarChanged\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"batchId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"root\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"leafCount\",\"type\":\"uint256\"}],\"name\":\"RootAnchored\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"root\",\"type\":\"bytes32\"},{\"internalType\":\"uint256\",\"name\":\"leafCount\",\"type\":\"uint256\"}],\"name\":\"anchorRoot\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"batchId\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"name\":\"anchoredAt\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"batchCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"batchRoots\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"registrar\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newRegistrar\",\"type\":\"address\"}],\"name\":\"setRegistrar\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32[]\",\"name\":\"proof\",\"type\":\"bytes32[]\"},{\"internalType\":\"bytes32\",\"name\":\"root\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"leaf\",\"type\":\"bytes32\"}],\"name\":\"verifyInclusion\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]",
+}
+
+// VcanchorABI is the input ABI used to generate the binding from.
+// Deprecated: Use VcanchorMetaData.ABI instead.
+var VcanchorABI = VcanchorMetaData.ABI
+
+// Vcanchor is an auto generated Go binding around an Ethereum contract.
+type Vcanchor struct {
+	VcanchorCaller     // Read-only binding to the contract
+	VcanchorTransactor // Write-only binding to the contract
+	VcanchorFilterer   // Log filterer for contract events
+}
+
+// VcanchorCaller is an auto generated read-only Go binding around an Ethereum contract.
+type VcanchorCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// VcanchorTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type VcanchorTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// VcanchorFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type VcanchorFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// VcanchorSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type VcanchorSession struct {
+	Contract     *Vcanchor         // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// VcanchorCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type VcanchorCallerSession struct {
+	Contract *VcanchorCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts   // Call options to use throughout this session
+}
+
+// VcanchorTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type VcanchorTransactorSession struct {
+	Contract     *VcanchorTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts   // Transaction auth options to use throughout this session
+}
+
+// VcanchorRaw is an auto generated low-level Go binding around an Ethereum contract.
+type VcanchorRaw struct {
+	Contract *Vcanchor // Generic contract binding to access the raw methods on
+}
+
+// VcanchorCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type VcanchorCallerRaw struct {
+	Contract *VcanchorCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// VcanchorTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type VcanchorTransactorRaw struct {
+	Contract *VcanchorTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewVcanchor creates a new instance of Vcanchor, bound to a specific deployed contract.
+func NewVcanchor(address common.Address, backend bind.ContractBackend) (*Vcanchor, error) {
+	contract, err := bindVcanchor(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Vcanchor{VcanchorCaller: VcanchorCaller{contract: contract}, VcanchorTransactor: VcanchorTransactor{contract: contract}, VcanchorFilterer: VcanchorFilterer{contract: contract}}, nil
+}
+
+// NewVcanchorCaller creates a new read-only instance of Vcanchor, bound to a specific deployed contract.
+func NewVcanchorCaller(address common.Address, caller bind.ContractCaller) (*VcanchorCaller, error) {
+	contract, err := bindVcanchor(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &VcanchorCaller{contract: contract}, nil
+}
+
+// NewVcanchorTransactor creates a new write-only instance of Vcanchor, bound to a specific deployed contract.
+func NewVcanchorTransactor(address common.Address, transactor bind.ContractTransactor) (*VcanchorTransactor, error) {
+	contract, err := bindVcanchor(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &VcanchorTransactor{contract: contract}, nil
+}
+
+// NewVcanchorFilterer creates a new log filterer instance of Vcanchor, bound to a specific deployed contract.
+func NewVcanchorFilterer(address common.Address, filterer bind.ContractFilterer) (*VcanchorFilterer, error) {
+	contract, err := bindVcanchor(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &VcanchorFilterer{contract: contract}, nil
+}
+
+// bindVcanchor binds a generic wrapper to an already deployed contract.
+func bindVcanchor(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := VcanchorMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_Vcanchor *VcanchorRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _Vcanchor.Contract.VcanchorCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_Vcanchor *VcanchorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _Vcanchor.Contract.VcanchorTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_Vcanchor *VcanchorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _Vcanchor.Contract.VcanchorTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_Vcanchor *VcanchorCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _Vcanchor.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_Vcanchor *VcanchorTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _Vcanchor.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_Vcanchor *VcanchorTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _Vcanchor.Contract.contract.Transact(opts, method, params...)
+}
+
+// AnchoredAt is a free data retrieval call binding the contract method 0x9591a610.
+//
+// Solidity: function anchoredAt(bytes32 ) view returns(uint256)
+func (_Vcanchor *VcanchorCaller) AnchoredAt(opts *bind.CallOpts, arg0 [32]byte) (*big.Int, error) {
+	var out []interface{}
+	err := _Vcanchor.contract.Call(opts, &out, "anchoredAt", arg0)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// AnchoredAt is a free data retrieval call binding the contract method 0x9591a610.
+//
+// Solidity: function anchoredAt(bytes32 ) view returns(uint256)
+func (_Vcanchor *VcanchorSession) AnchoredAt(arg0 [32]byte) (*big.Int, error) {
+	return _Vcanchor.Contract.AnchoredAt(&_Vcanchor.CallOpts, arg0)
+}
+
+// AnchoredAt is a free data retrieval call binding the contract method 0x9591a610.
+//
+// Solidity: function anchoredAt(bytes32 ) view returns(uint256)
+func (_Vcanchor *VcanchorCallerSession) AnchoredAt(arg0 [32]byte) (*big.Int, error) {
+	return _Vcanchor.Contract.AnchoredAt(&_Vcanchor.CallOpts, arg0)
+}
+
+// BatchCount is a free data retrieval call binding the contract method 0x06f13056.
+//
+// Solidity: function batchCount() view returns(uint256)
+func (_Vcanchor *VcanchorCaller) BatchCount(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _Vcanchor.contract.Call(opts, &out, "batchCount")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// BatchCount is a free data retrieval call binding the contract method 0x06f13056.
+//
+// Solidity: function batchCount() view returns(uint256)
+func (_Vcanchor *VcanchorSession) BatchCount() (*big.Int, error) {
+	return _Vcanchor.Contract.BatchCount(&_Vcanchor.CallOpts)
+}
+
+// BatchCount is a free data retrieval call binding the contract method 0x06f13056.
+//
+// Solidity: function batchCount() view returns(uint256)
+func (_Vcanchor *VcanchorCallerSession) BatchCount() (*big.Int, error) {
+	return _Vcanchor.Contract.BatchCount(&_Vcanchor.CallOpts)
+}
+
+// BatchRoots is a free data retrieval call binding the contract method 0x68e2f979.
+//
+// Solidity: function batchRoots(uint256 ) view returns(bytes32)
+func (_Vcanchor *VcanchorCaller) BatchRoots(opts *bind.CallOpts, arg0 *big.Int) ([32]byte, error) {
+	var out []interface{}
+	err := _Vcanchor.contract.Call(opts, &out, "batchRoots", arg0)
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// BatchRoots is a free data retrieval call binding the contract method 0x68e2f979.
+//
+// Solidity: function batchRoots(uint256 ) view returns(bytes32)
+func (_Vcanchor *VcanchorSession) BatchRoots(arg0 *big.Int) ([32]byte, error) {
+	return _Vcanchor.Contract.BatchRoots(&_Vcanchor.CallOpts, arg0)
+}
+
+// BatchRoots is a free data retrieval call binding the contract method 0x68e2f979.
+//
+// Solidity: function batchRoots(uint256 ) view returns(bytes32)
+func (_Vcanchor *VcanchorCallerSession) BatchRoots(arg0 *big.Int) ([32]byte, error) {
+	return _Vcanchor.Contract.BatchRoots(&_Vcanchor.CallOpts, arg0)
+}
+
+// Registrar is a free data retrieval call binding the contract method 0x2b20e397.
+//
+// Solidity: function registrar() view returns(address)
+func (_Vcanchor *VcanchorCaller) Registrar(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _Vcanchor.contract.Call(opts, &out, "registrar")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Registrar is a free data retrieval call binding the contract method 0x2b20e397.
+//
+// Solidity: function registrar() view returns(address)
+func (_Vcanchor *VcanchorSession) Registrar() (common.Address, error) {
+	return _Vcanchor.Contract.Registrar(&_Vcanchor.CallOpts)
+}
+
+// Registrar is a free data retrieval call binding the contract method 0x2b20e397.
+//
+// Solidity: function registrar() view returns(address)
+func (_Vcanchor *VcanchorCallerSession) Registrar() (common.Address, error) {
+	return _Vcanchor.Contract.Registrar(&_Vcanchor.CallOpts)
+}
+
+// VerifyInclusion is a free data retrieval call binding the contract method 0x49a359f7.
+//
+// Solidity: function verifyInclusion(bytes32[] proof, bytes32 root, bytes32 leaf) view returns(bool)
+func (_Vcanchor *VcanchorCaller) VerifyInclusion(opts *bind.CallOpts, proof [][32]byte, root [32]byte, leaf [32]byte) (bool, error) {
+	var out []interface{}
+	err := _Vcanchor.contract.Call(opts, &out, "verifyInclusion", proof, root, leaf)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// VerifyInclusion is a free data retrieval call binding the contract method 0x49a359f7.
+//
+// Solidity: function verifyInclusion(bytes32[] proof, bytes32 root, bytes32 leaf) view returns(bool)
+func (_Vcanchor *VcanchorSession) VerifyInclusion(proof [][32]byte, root [32]byte, leaf [32]byte) (bool, error) {
+	return _Vcanchor.Contract.VerifyInclusion(&_Vcanchor.CallOpts, proof, root, leaf)
+}
+
+// VerifyInclusion is a free data retrieval call binding the contract method 0x49a359f7.
+//
+// Solidity: function verifyInclusion(bytes32[] proof, bytes32 root, bytes32 leaf) view returns(bool)
+func (_Vcanchor *VcanchorCallerSession) VerifyInclusion(proof [][32]byte, root [32]byte, leaf [32]byte) (bool, error) {
+	return _Vcanchor.Contract.VerifyInclusion(&_Vcanchor.CallOpts, proof, root, leaf)
+}
+
+// AnchorRoot is a paid mutator transaction binding the contract method 0xb4e6bbf2.
+//
+// Solidity: function anchorRoot(bytes32 root, uint256 leafCount) returns(uint256 batchId)
+func (_Vcanchor *VcanchorTransactor) AnchorRoot(opts *bind.TransactOpts, root [32]byte, leafCount *big.Int) (*types.Transaction, error) {
+	return _Vcanchor.contract.Transact(opts, "anchorRoot", root, leafCount)
+}
+
+// AnchorRoot is a paid mutator transaction binding the contract method 0xb4e6bbf2.
+//
+// Solidity: function anchorRoot(bytes32 root, uint256 leafCount) returns(uint256 batchId)
+func (_Vcanchor *VcanchorSession) AnchorRoot(root [32]byte, leafCount *big.Int) (*types.Transaction, error) {
+	return _Vcanchor.Contract.AnchorRoot(&_Vcanchor.TransactOpts, root, leafCount)
+}
+
+// AnchorRoot is a paid mutator transaction binding the contract method 0xb4e6bbf2.
+//
+// Solidity: function anchorRoot(bytes32 root, uint256 leafCount) returns(uint256 batchId)
+func (_Vcanchor *VcanchorTransactorSession) AnchorRoot(root [32]byte, leafCount *big.Int) (*types.Transaction, error) {
+	return _Vcanchor.Contract.AnchorRoot(&_Vcanchor.TransactOpts, root, leafCount)
+}
+
+// SetRegistrar is a paid mutator transaction binding the contract method 0xfaab9d39.
+//
+// Solidity: function setRegistrar(address newRegistrar) returns()
+func (_Vcanchor *VcanchorTransactor) SetRegistrar(opts *bind.TransactOpts, newRegistrar common.Address) (*types.Transaction, error) {
+	return _Vcanchor.contract.Transact(opts, "setRegistrar", newRegistrar)
+}
+
+// SetRegistrar is a paid mutator transaction binding the contract method 0xfaab9d39.
+//
+// Solidity: function setRegistrar(address newRegistrar) returns()
+func (_Vcanchor *VcanchorSession) SetRegistrar(newRegistrar common.Address) (*types.Transaction, error) {
+	return _Vcanchor.Contract.SetRegistrar(&_Vcanchor.TransactOpts, newRegistrar)
+}
+
+// SetRegistrar is a paid mutator transaction binding the contract method 0xfaab9d39.
+//
+// Solidity: function setRegistrar(address newRegistrar) returns()
+func (_Vcanchor *VcanchorTransactorSession) SetRegistrar(newRegistrar common.Address) (*types.Transaction, error) {
+	return _Vcanchor.Contract.SetRegistrar(&_Vcanchor.TransactOpts, newRegistrar)
+}
+
+// VcanchorRegistrarChangedIterator is returned from FilterRegistrarChanged and is used to iterate over the raw logs and unpacked data for RegistrarChanged events raised by the Vcanchor contract.
+type VcanchorRegistrarChangedIterator struct {
+	Event *VcanchorRegistrarChanged // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *VcanchorRegistrarChangedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(VcanchorRegistrarChanged)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(VcanchorRegistrarChanged)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *VcanchorRegistrarChangedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *VcanchorRegistrarChangedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// VcanchorRegistrarChanged represents a RegistrarChanged event raised by the Vcanchor contract.
+type VcanchorRegistrarChanged struct {
+	Registrar common.Address
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterRegistrarChanged is a free log retrieval operation binding the contract event 0x6e4c52923c1e70447ecc72995ce26bf2400d9c26b1a7c21ab433e7caff8d40a2.
+//
+// Solidity: event RegistrarChanged(address registrar)
+func (_Vcanchor *VcanchorFilterer) FilterRegistrarChanged(opts *bind.FilterOpts) (*VcanchorRegistrarChangedIterator, error) {
+
+	logs, sub, err := _Vcanchor.contract.FilterLogs(opts, "RegistrarChanged")
+	if err != nil {
+		return nil, err
+	}
+	return &VcanchorRegistrarChangedIterator{contract: _Vcanchor.contract, event: "RegistrarChanged", logs: logs, sub: sub}, nil
+}
+
+// WatchRegistrarChanged is a free log subscription operation binding the contract event 0x6e4c52923c1e70447ecc72995ce26bf2400d9c26b1a7c21ab433e7caff8d40a2.
+//
+// Solidity: event RegistrarChanged(address registrar)
+func (_Vcanchor *VcanchorFilterer) WatchRegistrarChanged(opts *bind.WatchOpts, sink chan<- *VcanchorRegistrarChanged) (event.Subscription, error) {
+
+	logs, sub, err := _Vcanchor.contract.WatchLogs(opts, "RegistrarChanged")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(VcanchorRegistrarChanged)
+				if err := _Vcanchor.contract.UnpackLog(event, "RegistrarChanged", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseRegistrarChanged is a log parse operation binding the contract event 0x6e4c52923c1e70447ecc72995ce26bf2400d9c26b1a7c21ab433e7caff8d40a2.
+//
+// Solidity: event RegistrarChanged(address registrar)
+func (_Vcanchor *VcanchorFilterer) ParseRegistrarChanged(log types.Log) (*VcanchorRegistrarChanged, error) {
+	event := new(VcanchorRegistrarChanged)
+	if err := _Vcanchor.contract.UnpackLog(event, "RegistrarChanged", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// VcanchorRootAnchoredIterator is returned from FilterRootAnchored and is used to iterate over the raw logs and unpacked data for RootAnchored events raised by the Vcanchor contract.
+type VcanchorRootAnchoredIterator struct {
+	Event *VcanchorRootAnchored // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *VcanchorRootAnchoredIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(VcanchorRootAnchored)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(VcanchorRootAnchored)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *VcanchorRootAnchoredIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *VcanchorRootAnchoredIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// VcanchorRootAnchored represents a RootAnchored event raised by the Vcanchor contract.
+type VcanchorRootAnchored struct {
+	BatchId   *big.Int
+	Root      [32]byte
+	LeafCount *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterRootAnchored is a free log retrieval operation binding the contract event 0x9695251415fcf6cbef6106d4863ec9e296b92c740fd3611865bb4683750dc6ae.
+//
+// Solidity: event RootAnchored(uint256 indexed batchId, bytes32 indexed root, uint256 leafCount)
+func (_Vcanchor *VcanchorFilterer) FilterRootAnchored(opts *bind.FilterOpts, batchId []*big.Int, root [][32]byte) (*VcanchorRootAnchoredIterator, error) {
+
+	var batchIdRule []interface{}
+	for _, batchIdItem := range batchId {
+		batchIdRule = append(batchIdRule, batchIdItem)
+	}
+	var rootRule []interface{}
+	for _, rootItem := range root {
+		rootRule = append(rootRule, rootItem)
+	}
+
+	logs, sub, err := _Vcanchor.contract.FilterLogs(opts, "RootAnchored", batchIdRule, rootRule)
+	if err != nil {
+		return nil, err
+	}
+	return &VcanchorRootAnchoredIterator{contract: _Vcanchor.contract, event: "RootAnchored", logs: logs, sub: sub}, nil
+}
+
+// WatchRootAnchored is a free log subscription operation binding the contract event 0x9695251415fcf6cbef6106d4863ec9e296b92c740fd3611865bb4683750dc6ae.
+//
+// Solidity: event RootAnchored(uint256 indexed batchId, bytes32 indexed root, uint256 leafCount)
+func (_Vcanchor *VcanchorFilterer) WatchRootAnchored(opts *bind.WatchOpts, sink chan<- *VcanchorRootAnchored, batchId []*big.Int, root [][32]byte) (event.Subscription, error) {
+
+	var batchIdRule []interface{}
+	for _, batchIdItem := range batchId {
+		batchIdRule = append(batchIdRule, batchIdItem)
+	}
+	var rootRule []interface{}
+	for _, rootItem := range root {
+		rootRule = append(rootRule, rootItem)
+	}
+
+	logs, sub, err := _Vcanchor.contract.WatchLogs(opts, "RootAnchored", batchIdRule, rootRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(VcanchorRootAnchored)
+				if err := _Vcanchor.contract.UnpackLog(event, "RootAnchored", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseRootAnchored is a log parse operation binding the contract event 0x9695251415fcf6cbef6106d4863ec9e296b92c740fd3611865bb4683750dc6ae.
+//
+// Solidity: event RootAnchored(uint256 indexed batchId, bytes32 indexed root, uint256 leafCount)
+func (_Vcanchor *VcanchorFilterer) ParseRootAnchored(log types.Log) (*VcanchorRootAnchored, error) {
+	event := new(VcanchorRootAnchored)
+	if err := _Vcanchor.contract.UnpackLog(event, "RootAnchored", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
@@ -10,6 +10,7 @@ set MAIN_NFT_ADDRESS=0x3b5a6b78d0625d6eb6333e0DA27b75A12Fc5F27D
 set CHILD_NFT_ADDRESS=0x38C5f113b716e21C57cc24bDEE237cEd28bA866F
 set PRIVATE_KEY=63435add31c605dfa2ee262dfb1dd019c985c881196309c4d194d3574a0c3fc1
 set CHAIN_ID=1337
+set DEV_MODE=true
 set DB_USER=root
 set DB_PASSWORD=123456
 set DB_HOST=localhost
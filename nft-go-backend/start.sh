@@ -11,6 +11,7 @@ export MAIN_NFT_ADDRESS=0x3b5a6b78d0625d6eb6333e0DA27b75A12Fc5F27D
 export CHILD_NFT_ADDRESS=0x38C5f113b716e21C57cc24bDEE237cEd28bA866F
 export PRIVATE_KEY=63435add31c605dfa2ee262dfb1dd019c985c881196309c4d194d3574a0c3fc1
 export CHAIN_ID=1337
+export DEV_MODE=true
 export DB_USER=root
 export DB_PASSWORD=123456
 export DB_HOST=localhost
@@ -264,11 +264,29 @@ async function createDIDFromWallet() {
     if (!walletAddress) return;
 
     try {
+        // 获取挑战并用钱包签名，证明对该地址的控制权
+        const challengeResponse = await fetch(`/api/did/wallet/${walletAddress}/challenge`, {
+            method: 'POST'
+        });
+        const challenge = await challengeResponse.json();
+        if (!challengeResponse.ok) {
+            alert("获取DID挑战失败: " + (challenge.error || "未知错误"));
+            return;
+        }
+        const signature = await window.ethereum.request({
+            method: 'personal_sign',
+            params: [challenge.message, walletAddress]
+        });
+
         const response = await fetch(`/api/did/wallet/${walletAddress}`, {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json'
-            }
+            },
+            body: JSON.stringify({
+                nonce: challenge.nonce,
+                signature: signature
+            })
         });
 
         const data = await response.json();
@@ -436,17 +454,12 @@ async function handleMintFormSubmit(event) {
 
     showLoading(true);
     try {
-        // 创建要签名的消息
-        const message = JSON.stringify({
-            action: 'mint_nft',
-            uri: uri,
-            timestamp: Date.now()
-        });
-
-        // 获取签名
-        const signature = await signMessage(message);
+        // 获取并签名EIP-712铸造请求
+        const params = new URLSearchParams({ address: currentAccount, uri: uri });
+        const typedData = await fetchTypedData(`/nft/typed-data/mint?${params}`);
+        const signature = await signTypedData(typedData);
 
-        // 发送铸造请求
+        // 发送铸造请求，由平台代为提交
         const response = await fetch(`${API_BASE_URL}/nft/mint`, {
             method: 'POST',
             headers: {
@@ -455,7 +468,8 @@ async function handleMintFormSubmit(event) {
             body: JSON.stringify({
                 address: currentAccount,
                 signature: signature,
-                message: message,
+                nonce: typedData.message.nonce,
+                deadline: typedData.message.deadline,
                 uri: uri
             })
         });
@@ -885,19 +899,31 @@ async function processRequest(requestId, action) {
         // 获取签名
         const signature = await signMessage(message);
 
+        const body = {
+            address: currentAccount,
+            signature: signature,
+            message: message,
+            requestId: requestId,
+            action: action
+        };
+        // 批准时签名该申请的EIP-712创建请求，由合约验证后创建子NFT
+        if (action === 'approve') {
+            const params = new URLSearchParams({ requestId: requestId });
+            const typedData = await fetchTypedData(`/nft/typed-data/approve-request?${params}`);
+            body.authorization = {
+                signature: await signTypedData(typedData),
+                nonce: typedData.message.nonce,
+                deadline: typedData.message.deadline
+            };
+        }
+
         // 发送处理申请的请求
         const response = await fetch(`${API_BASE_URL}/nft/process-request`, {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json'
             },
-            body: JSON.stringify({
-                address: currentAccount,
-                signature: signature,
-                message: message,
-                requestId: requestId,
-                action: action
-            })
+            body: JSON.stringify(body)
         });
 
         const result = await response.json();
@@ -932,6 +958,29 @@ async function signMessage(message) {
     }
 }
 
+// 获取需要签名的EIP-712数据
+async function fetchTypedData(path) {
+    const response = await fetch(`${API_BASE_URL}${path}`);
+    const result = await response.json();
+    if (!response.ok) {
+        throw new Error(result.error || '获取签名数据失败');
+    }
+    return result.typedData;
+}
+
+// 签名EIP-712类型化数据
+async function signTypedData(typedData) {
+    try {
+        return await window.ethereum.request({
+            method: 'eth_signTypedData_v4',
+            params: [currentAccount, JSON.stringify(typedData)]
+        });
+    } catch (error) {
+        console.error('签名类型化数据失败:', error);
+        throw new Error('签名类型化数据失败: ' + error.message);
+    }
+}
+
 // 显示成功消息
 function showSuccess(message, txHash = null) {
     const modal = new bootstrap.Modal(document.getElementById('result-modal'));
@@ -1153,19 +1202,12 @@ async function handleCreateChildFormSubmit(event) {
 
     showLoading(true);
     try {
-        // 创建要签名的消息
-        const message = JSON.stringify({
-            action: 'create_child_nft',
-            parentTokenId: parentTokenId,
-            recipient: recipient,
-            uri: uri,
-            timestamp: Date.now()
-        });
-
-        // 获取签名
-        const signature = await signMessage(message);
+        // 获取并签名EIP-712创建子NFT请求
+        const params = new URLSearchParams({ address: currentAccount, parentTokenId: parentTokenId, recipient: recipient, uri: uri });
+        const typedData = await fetchTypedData(`/nft/typed-data/create-child?${params}`);
+        const signature = await signTypedData(typedData);
 
-        // 发送创建请求
+        // 发送创建请求，由平台代为提交
         const response = await fetch(`${API_BASE_URL}/nft/createChild`, {
             method: 'POST',
             headers: {
@@ -1174,7 +1216,8 @@ async function handleCreateChildFormSubmit(event) {
             body: JSON.stringify({
                 address: currentAccount,
                 signature: signature,
-                message: message,
+                nonce: typedData.message.nonce,
+                deadline: typedData.message.deadline,
                 parentTokenId: parentTokenId,
                 recipient: recipient,
                 uri: uri
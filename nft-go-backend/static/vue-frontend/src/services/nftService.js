@@ -19,6 +19,24 @@ const nftService = {
     return response.data;
   },
 
+  // 获取铸造NFT需要签名的EIP-712数据
+  getMintTypedData: async (address, uri) => {
+    const response = await api.get('/nft/typed-data/mint', { params: { address, uri } });
+    return response.data.typedData;
+  },
+
+  // 获取创建子NFT需要签名的EIP-712数据
+  getCreateChildTypedData: async (address, parentTokenId, recipient, uri) => {
+    const response = await api.get('/nft/typed-data/create-child', { params: { address, parentTokenId, recipient, uri } });
+    return response.data.typedData;
+  },
+
+  // 获取批准子NFT申请需要签名的EIP-712数据，offset为同一批次中排在前面的批准数量
+  getApproveRequestTypedData: async (requestId, offset = 0) => {
+    const response = await api.get('/nft/typed-data/approve-request', { params: { requestId, offset } });
+    return response.data.typedData;
+  },
+
   // 铸造NFT
   mintNFT: async (data) => {
     const response = await api.post('/nft/mint', data);
@@ -224,20 +224,18 @@ export default {
                     throw new Error('钱包未连接')
                 }
 
-                // 创建要签名的消息
-                const message = createSignMessage('mint_nft', {
-                    uri: uri,
-                    timestamp: Date.now()
-                })
+                // 获取需要签名的EIP-712铸造请求（含主合约nonce和截止时间）
+                const typedData = await nftService.getMintTypedData(address, uri)
 
-                // 获取签名 - 使用dispatch调用wallet模块的signMessage action
-                const signature = await dispatch('wallet/signMessage', message, { root: true })
+                // 获取签名 - 使用dispatch调用wallet模块的signTypedData action
+                const signature = await dispatch('wallet/signTypedData', typedData, { root: true })
 
-                // 构建请求数据
+                // 构建请求数据，由平台代为提交
                 const mintData = {
                     address: address,
                     signature: signature,
-                    message: message,
+                    nonce: typedData.message.nonce,
+                    deadline: typedData.message.deadline,
                     uri: uri
                 }
 
@@ -361,6 +359,16 @@ export default {
                     action: action
                 }
 
+                // 批准时签名该申请的EIP-712创建请求，由合约验证后创建子NFT
+                if (action === 'approve') {
+                    const typedData = await nftService.getApproveRequestTypedData(requestId)
+                    requestData.authorization = {
+                        signature: await dispatch('wallet/signTypedData', typedData, { root: true }),
+                        nonce: typedData.message.nonce,
+                        deadline: typedData.message.deadline
+                    }
+                }
+
                 console.log('发送请求数据:', requestData);
 
                 // 使用nftService处理请求
@@ -450,10 +458,28 @@ export default {
         },
 
         // 创建子NFT
-        async createChildNFT({ commit }, childData) {
+        async createChildNFT({ commit, rootState, dispatch }, childData) {
             commit('SET_LOADING', true)
             try {
-                const result = await nftService.createChildNFT(childData)
+                const address = rootState.wallet.account
+                if (!address) {
+                    throw new Error('钱包未连接')
+                }
+
+                // 父NFT持有者签名EIP-712创建子NFT请求，由平台代为提交
+                const { parentTokenId, recipient, uri } = childData
+                const typedData = await nftService.getCreateChildTypedData(address, parentTokenId, recipient, uri)
+                const signature = await dispatch('wallet/signTypedData', typedData, { root: true })
+
+                const result = await nftService.createChildNFT({
+                    address,
+                    signature,
+                    nonce: typedData.message.nonce,
+                    deadline: typedData.message.deadline,
+                    parentTokenId,
+                    recipient,
+                    uri
+                })
                 return result
             } catch (error) {
                 commit('SET_ERROR', error.message || '创建子NFT失败')
@@ -165,12 +165,25 @@ export default {
                     }
                 }
 
-                // 如果没有找到DID，则创建新DID
+                // 如果没有找到DID，先获取挑战并用钱包签名，再创建新DID
+                const challengeResponse = await fetch(`/api/did/wallet/${walletAddress}/challenge`, {
+                    method: 'POST'
+                })
+                if (!challengeResponse.ok) {
+                    throw new Error('获取DID挑战失败')
+                }
+                const challenge = await challengeResponse.json()
+                const signature = await dispatch('signMessage', challenge.message)
+
                 const createResponse = await fetch(`/api/did/wallet/${walletAddress}`, {
                     method: 'POST',
                     headers: {
                         'Content-Type': 'application/json'
-                    }
+                    },
+                    body: JSON.stringify({
+                        nonce: challenge.nonce,
+                        signature
+                    })
                 })
 
                 if (createResponse.ok) {
@@ -208,6 +221,24 @@ export default {
                 dispatch('app/showError', '签名消息失败: ' + error.message, { root: true })
                 throw error
             }
+        },
+
+        // 签名EIP-712类型化数据
+        async signTypedData({ state, dispatch }, typedData) {
+            try {
+                if (!state.isConnected || !state.account) {
+                    throw new Error('钱包未连接')
+                }
+
+                return await window.ethereum.request({
+                    method: 'eth_signTypedData_v4',
+                    params: [state.account, JSON.stringify(typedData)]
+                })
+            } catch (error) {
+                console.error('签名类型化数据失败:', error)
+                dispatch('app/showError', '签名类型化数据失败: ' + error.message, { root: true })
+                throw error
+            }
         }
     },
 
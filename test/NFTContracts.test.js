@@ -138,6 +138,124 @@ describe("NFT Contracts", function () {
         });
     });
 
+    describe("Signed Requests (EIP-712)", function () {
+        let domain;
+        const mintTypes = {
+            MintRequest: [
+                { name: "to", type: "address" },
+                { name: "uri", type: "string" },
+                { name: "nonce", type: "uint256" },
+                { name: "deadline", type: "uint256" },
+            ],
+        };
+        const childTypes = {
+            CreateChildRequest: [
+                { name: "owner", type: "address" },
+                { name: "parentTokenId", type: "uint256" },
+                { name: "receiver", type: "address" },
+                { name: "uri", type: "string" },
+                { name: "nonce", type: "uint256" },
+                { name: "deadline", type: "uint256" },
+            ],
+        };
+
+        beforeEach(async function () {
+            const { chainId } = await ethers.provider.getNetwork();
+            domain = {
+                name: "MainABE",
+                version: "1",
+                chainId,
+                verifyingContract: await mainNFT.getAddress(),
+            };
+        });
+
+        async function deadlineIn(seconds) {
+            const block = await ethers.provider.getBlock("latest");
+            return block.timestamp + seconds;
+        }
+
+        it("Should mint with the user's signature relayed by another account", async function () {
+            const uri = "ipfs://QmSignedMint";
+            const deadline = await deadlineIn(3600);
+            const signature = await addr1.signTypedData(domain, mintTypes, { to: addr1.address, uri, nonce: 0, deadline });
+
+            await mainNFT.connect(owner).mintWithSignature(addr1.address, uri, deadline, signature);
+
+            expect(await mainNFT.ownerOf(0)).to.equal(addr1.address);
+            expect(await mainNFT.tokenURI(0)).to.equal(uri);
+            expect(await mainNFT.nonces(addr1.address)).to.equal(1);
+        });
+
+        it("Should reject a replayed, expired or foreign mint signature", async function () {
+            const uri = "ipfs://QmSignedMint";
+            const deadline = await deadlineIn(3600);
+            const signature = await addr1.signTypedData(domain, mintTypes, { to: addr1.address, uri, nonce: 0, deadline });
+            await mainNFT.mintWithSignature(addr1.address, uri, deadline, signature);
+
+            await expect(
+                mainNFT.mintWithSignature(addr1.address, uri, deadline, signature)
+            ).to.be.revertedWith("Invalid signature");
+
+            const forged = await addr2.signTypedData(domain, mintTypes, { to: addr1.address, uri, nonce: 1, deadline });
+            await expect(
+                mainNFT.mintWithSignature(addr1.address, uri, deadline, forged)
+            ).to.be.revertedWith("Invalid signature");
+
+            const expired = await deadlineIn(-1);
+            const late = await addr1.signTypedData(domain, mintTypes, { to: addr1.address, uri, nonce: 1, deadline: expired });
+            await expect(
+                mainNFT.mintWithSignature(addr1.address, uri, expired, late)
+            ).to.be.revertedWith("Signature expired");
+        });
+
+        it("Should only let the platform mint for an arbitrary address", async function () {
+            await expect(
+                mainNFT.connect(addr1).mintTo(addr2.address, "ipfs://QmTest")
+            ).to.be.revertedWithCustomError(mainNFT, "OwnableUnauthorizedAccount");
+        });
+
+        it("Should create a child NFT from the parent owner's signature", async function () {
+            await mainNFT.mintTo(addr1.address, "ipfs://QmMainNFT");
+            const uri = "ipfs://QmChildNFT";
+            const deadline = await deadlineIn(3600);
+            const request = { owner: addr1.address, parentTokenId: 0, receiver: addr2.address, uri, nonce: 0, deadline };
+            const signature = await addr1.signTypedData(domain, childTypes, request);
+
+            await mainNFT.createChildWithSignature(addr1.address, 0, addr2.address, uri, deadline, signature);
+
+            expect(await childNFT.ownerOf(0)).to.equal(addr2.address);
+            expect(await childNFT.getChildCreator(0)).to.equal(addr1.address);
+        });
+
+        it("Should not accept a child request signed by someone other than the parent owner", async function () {
+            await mainNFT.mintTo(addr1.address, "ipfs://QmMainNFT");
+            const uri = "ipfs://QmChildNFT";
+            const deadline = await deadlineIn(3600);
+            const request = { owner: addr2.address, parentTokenId: 0, receiver: addr2.address, uri, nonce: 0, deadline };
+            const signature = await addr2.signTypedData(domain, childTypes, request);
+
+            await expect(
+                mainNFT.createChildWithSignature(addr2.address, 0, addr2.address, uri, deadline, signature)
+            ).to.be.revertedWith("You must own the token");
+            await expect(
+                mainNFT.connect(addr2).createAutoApprovedChild(0, addr2.address, uri)
+            ).to.be.revertedWithCustomError(mainNFT, "OwnableUnauthorizedAccount");
+        });
+
+        it("Should record the current parent owner as creator of an auto-approved child", async function () {
+            await mainNFT.mintTo(addr1.address, "ipfs://QmMainNFT");
+            await mainNFT.connect(addr1).transferFrom(addr1.address, addr2.address, 0);
+
+            await mainNFT.createAutoApprovedChild(0, addr1.address, "ipfs://QmChildNFT");
+
+            expect(await childNFT.ownerOf(0)).to.equal(addr1.address);
+            expect(await childNFT.getChildCreator(0)).to.equal(addr2.address);
+            await expect(
+                mainNFT.createAutoApprovedChild(999, addr1.address, "ipfs://QmChildNFT")
+            ).to.be.revertedWith("Token does not exist");
+        });
+    });
+
     describe("Token URI Management", function () {
         beforeEach(async function () {
             await mainNFT.connect(addr1).mint("ipfs://QmMainNFT");